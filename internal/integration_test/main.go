@@ -266,8 +266,8 @@ func convertModelEntry(usecase *minteractor.Vrm2PmxUsecase, config batchConfig,
 	}
 	// UI の変換ボタンと同様に、保存直前に出力先パスをモデルへ再設定する。
 	converted.Model.SetPath(converted.OutputPath)
-	if err := usecase.SaveModel(nil, converted.OutputPath, converted.Model, minteractor.SaveOptions{}); err != nil {
-		result.Err = fmt.Errorf("SaveModelに失敗しました: %w", err)
+	if err := usecase.SaveConvertResult(nil, converted, minteractor.SaveOptions{}); err != nil {
+		result.Err = fmt.Errorf("SaveConvertResultに失敗しました: %w", err)
 		return result
 	}
 
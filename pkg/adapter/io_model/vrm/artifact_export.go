@@ -44,14 +44,24 @@ type artifactImage struct {
 	MimeType   string `json:"mimeType"`
 }
 
-// ExportArtifacts はVRMから glTF とテクスチャ補助出力を生成する。
+// ExportArtifacts はVRM/glTFから glTF とテクスチャ補助出力を生成する。
 func ExportArtifacts(vrmPath string, gltfDir string, textureDir string) (*ArtifactExportResult, error) {
+	return ExportArtifactsWithOptions(vrmPath, gltfDir, textureDir, TextureExportOptions{})
+}
+
+// ExportArtifactsWithOptions はテクスチャの縮小・形式変換設定を指定して補助出力を生成する。
+func ExportArtifactsWithOptions(
+	vrmPath string,
+	gltfDir string,
+	textureDir string,
+	textureOptions TextureExportOptions,
+) (*ArtifactExportResult, error) {
 	trimmedVrmPath := strings.TrimSpace(vrmPath)
 	if trimmedVrmPath == "" {
 		return nil, fmt.Errorf("VRMパスが未指定です")
 	}
-	if !strings.EqualFold(filepath.Ext(trimmedVrmPath), ".vrm") {
-		return nil, fmt.Errorf("VRM拡張子ではありません: %s", trimmedVrmPath)
+	if !isSupportedGltfSourcePath(trimmedVrmPath) {
+		return nil, fmt.Errorf("VRM/glTF拡張子ではありません: %s", trimmedVrmPath)
 	}
 	if strings.TrimSpace(gltfDir) == "" {
 		return nil, fmt.Errorf("glTF出力先ディレクトリが未指定です")
@@ -70,7 +80,7 @@ func ExportArtifacts(vrmPath string, gltfDir string, textureDir string) (*Artifa
 	if err != nil {
 		return nil, fmt.Errorf("VRMファイルの読み取りに失敗しました: %w", err)
 	}
-	jsonChunk, binChunk, err := parseGLBChunks(sourceBytes)
+	jsonChunk, binChunk, err := readGltfSourceChunks(trimmedVrmPath, sourceBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +108,7 @@ func ExportArtifacts(vrmPath string, gltfDir string, textureDir string) (*Artifa
 	if err := json.Unmarshal(jsonChunk, &doc); err != nil {
 		return nil, fmt.Errorf("glTF JSON の解析に失敗しました: %w", err)
 	}
-	textureNames, err := exportTexturesFromDocument(&doc, binChunk, trimmedVrmPath, textureDir, baseName)
+	textureNames, err := exportTexturesFromDocument(&doc, binChunk, trimmedVrmPath, textureDir, baseName, textureOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -158,6 +168,7 @@ func exportTexturesFromDocument(
 	vrmPath string,
 	textureDir string,
 	baseName string,
+	textureOptions TextureExportOptions,
 ) ([]string, error) {
 	if doc == nil || len(doc.Images) == 0 {
 		return []string{}, nil
@@ -175,6 +186,10 @@ func exportTexturesFromDocument(
 		if ext == "" {
 			ext = ".bin"
 		}
+		imageBytes, ext, err := convertExportTextureImage(imageBytes, ext, textureOptions)
+		if err != nil {
+			return nil, err
+		}
 		nameBase := chooseTextureBaseName(image, imageIndex, baseName)
 		fileName := buildUniqueTextureFileName(nameBase, ext, used)
 		savePath := filepath.Join(textureDir, fileName)
@@ -206,11 +221,7 @@ func resolveImageData(
 			return data, ext, true
 		}
 
-		sourcePath := uri
-		if !filepath.IsAbs(sourcePath) {
-			sourcePath = filepath.Join(filepath.Dir(vrmPath), filepath.FromSlash(sourcePath))
-		}
-		data, err := os.ReadFile(sourcePath)
+		data, err := os.ReadFile(resolveGltfRelativeURIPath(vrmPath, uri))
 		if err != nil {
 			return nil, "", false
 		}
@@ -0,0 +1,232 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"github.com/ftrvxmtrx/tga"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// TextureExportFormat はテクスチャ抽出時の出力形式を表す。
+type TextureExportFormat string
+
+const (
+	// TextureExportFormatOriginal は埋め込み画像の形式を維持する。
+	TextureExportFormatOriginal TextureExportFormat = ""
+	// TextureExportFormatPng はPNGへ変換する。
+	TextureExportFormatPng TextureExportFormat = "png"
+	// TextureExportFormatTga はTGAへ変換する。
+	TextureExportFormatTga TextureExportFormat = "tga"
+	// TextureExportFormatBmp はBMPへ変換する。
+	TextureExportFormatBmp TextureExportFormat = "bmp"
+)
+
+const (
+	textureExportJpegQuality = 95
+)
+
+// TextureExportOptions はテクスチャ抽出時の縮小・形式変換設定を表す。
+// 対象は埋め込み画像の抽出テクスチャのみで、変換時に生成する toon・スフィア・MASK二値化・頂点カラー焼き込み・アトラスは
+// 常にPNGで出力する。抽出テクスチャから作る生成テクスチャは縮小後の解像度を引き継ぐ。
+type TextureExportOptions struct {
+	// MaxDimension は出力画像の最大辺長(px)を表す。0以下の場合は縮小しない。
+	MaxDimension int
+	// Format は出力形式を表す。空文字の場合は埋め込み形式を維持する。
+	Format TextureExportFormat
+	// FlattenOpaque はアルファを使わない画像をアルファなしで出力するかを表す。
+	FlattenOpaque bool
+}
+
+// ParseTextureExportFormat は指定文字列をテクスチャ出力形式へ変換する。
+func ParseTextureExportFormat(value string) (TextureExportFormat, error) {
+	normalized := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), ".")
+	switch TextureExportFormat(normalized) {
+	case TextureExportFormatOriginal, TextureExportFormatPng, TextureExportFormatTga, TextureExportFormatBmp:
+		return TextureExportFormat(normalized), nil
+	default:
+		return TextureExportFormatOriginal, fmt.Errorf("未対応のテクスチャ出力形式です: %s", value)
+	}
+}
+
+// isActive は画像の再エンコードが必要な設定かを判定する。
+func (o TextureExportOptions) isActive() bool {
+	return o.MaxDimension > 0 || o.Format != TextureExportFormatOriginal || o.FlattenOpaque
+}
+
+// convertExportTextureImage は設定に従って画像を縮小・形式変換し、出力バイト列と拡張子を返す。
+// 変更が不要な場合とデコードできない場合は入力をそのまま返す。
+func convertExportTextureImage(imageBytes []byte, ext string, options TextureExportOptions) ([]byte, string, error) {
+	if !options.isActive() {
+		return imageBytes, ext, nil
+	}
+	sourceImage, err := decodeExportTextureImage(imageBytes, ext)
+	if err != nil {
+		logVrmWarn("テクスチャ変換をスキップしました: ext=%s err=%s", ext, err.Error())
+		return imageBytes, ext, nil
+	}
+
+	outputExt := resolveTextureExportExt(ext, options.Format)
+	convertedImage := sourceImage
+	changed := outputExt != strings.ToLower(ext)
+	if resized, ok := resizeExportTextureImage(convertedImage, options.MaxDimension); ok {
+		convertedImage = resized
+		changed = true
+	}
+	if options.FlattenOpaque && hasExportTextureAlphaChannel(convertedImage) && isOpaqueExportTextureImage(convertedImage) {
+		convertedImage = flattenExportTextureImage(convertedImage)
+		changed = true
+	}
+	if !changed {
+		return imageBytes, ext, nil
+	}
+
+	encoded, err := encodeExportTextureImage(convertedImage, outputExt)
+	if err != nil {
+		return nil, "", fmt.Errorf("テクスチャの再エンコードに失敗しました: %w", err)
+	}
+	return encoded, outputExt, nil
+}
+
+// decodeExportTextureImage は拡張子優先で画像をデコードし、失敗時はシグネチャから再判定する。
+func decodeExportTextureImage(imageBytes []byte, ext string) (image.Image, error) {
+	decoded, err := decodeExportTextureImageByExt(imageBytes, ext)
+	if err == nil {
+		return decoded, nil
+	}
+	detectedExt := detectImageExt(imageBytes)
+	if detectedExt == "" || detectedExt == strings.ToLower(ext) {
+		return nil, err
+	}
+	return decodeExportTextureImageByExt(imageBytes, detectedExt)
+}
+
+// decodeExportTextureImageByExt は拡張子指定で画像をデコードする。
+func decodeExportTextureImageByExt(imageBytes []byte, ext string) (image.Image, error) {
+	reader := bytes.NewReader(imageBytes)
+	switch strings.ToLower(strings.TrimSpace(ext)) {
+	case ".png":
+		return png.Decode(reader)
+	case ".jpg", ".jpeg":
+		return jpeg.Decode(reader)
+	case ".gif":
+		return gif.Decode(reader)
+	case ".bmp":
+		return bmp.Decode(reader)
+	case ".webp":
+		return webp.Decode(reader)
+	case ".tga":
+		return tga.Decode(reader)
+	default:
+		return nil, fmt.Errorf("未対応画像拡張子です: %s", ext)
+	}
+}
+
+// resolveTextureExportExt は出力拡張子を決定する。形式維持でもエンコード不可の形式はPNGへ変換する。
+func resolveTextureExportExt(ext string, format TextureExportFormat) string {
+	if format != TextureExportFormatOriginal {
+		return "." + string(format)
+	}
+	switch normalized := strings.ToLower(strings.TrimSpace(ext)); normalized {
+	case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".tga":
+		return normalized
+	default:
+		return ".png"
+	}
+}
+
+// resizeExportTextureImage は長辺が最大辺長を超える画像を縦横比を保って縮小する。
+func resizeExportTextureImage(sourceImage image.Image, maxDimension int) (image.Image, bool) {
+	if maxDimension <= 0 {
+		return sourceImage, false
+	}
+	bounds := sourceImage.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	if width <= maxDimension && height <= maxDimension {
+		return sourceImage, false
+	}
+	targetWidth := maxDimension
+	targetHeight := maxDimension
+	if width >= height {
+		targetHeight = max(1, height*maxDimension/width)
+	} else {
+		targetWidth = max(1, width*maxDimension/height)
+	}
+	resized := image.NewNRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+	draw.CatmullRom.Scale(resized, resized.Rect, sourceImage, bounds, draw.Src, nil)
+	return resized, true
+}
+
+// hasExportTextureAlphaChannel は画像がアルファチャンネル付きの画素形式かを判定する。
+func hasExportTextureAlphaChannel(sourceImage image.Image) bool {
+	switch sourceImage.(type) {
+	case *image.NRGBA, *image.NRGBA64, *image.Paletted:
+		return true
+	default:
+		return false
+	}
+}
+
+// isOpaqueExportTextureImage は全画素が不透明かを判定する。
+func isOpaqueExportTextureImage(sourceImage image.Image) bool {
+	if opaqueImage, ok := sourceImage.(interface{ Opaque() bool }); ok {
+		return opaqueImage.Opaque()
+	}
+	bounds := sourceImage.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, alpha := sourceImage.At(x, y).RGBA(); alpha != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// flattenExportTextureImage は不透明画像をアルファなしで書き出せる RGBA 画像へ変換する。
+func flattenExportTextureImage(sourceImage image.Image) *image.RGBA {
+	bounds := sourceImage.Bounds()
+	flattened := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixel := color.RGBAModel.Convert(sourceImage.At(x, y)).(color.RGBA)
+			pixel.A = 0xff
+			flattened.SetRGBA(x-bounds.Min.X, y-bounds.Min.Y, pixel)
+		}
+	}
+	return flattened
+}
+
+// encodeExportTextureImage は拡張子に応じて画像をエンコードする。
+func encodeExportTextureImage(sourceImage image.Image, ext string) ([]byte, error) {
+	var out bytes.Buffer
+	var err error
+	switch ext {
+	case ".png":
+		err = png.Encode(&out, sourceImage)
+	case ".jpg", ".jpeg":
+		err = jpeg.Encode(&out, sourceImage, &jpeg.Options{Quality: textureExportJpegQuality})
+	case ".gif":
+		err = gif.Encode(&out, sourceImage, nil)
+	case ".bmp":
+		err = bmp.Encode(&out, sourceImage)
+	case ".tga":
+		err = tga.Encode(&out, sourceImage)
+	default:
+		err = fmt.Errorf("未対応の出力拡張子です: %s", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
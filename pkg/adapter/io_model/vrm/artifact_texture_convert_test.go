@@ -0,0 +1,118 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/ftrvxmtrx/tga"
+)
+
+func TestConvertExportTextureImageResizesAndConvertsFormat(t *testing.T) {
+	sourceImage := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			sourceImage.SetNRGBA(x, y, color.NRGBA{R: 0x40, G: 0x80, B: 0xc0, A: 0xff})
+		}
+	}
+	var sourceBytes bytes.Buffer
+	if err := png.Encode(&sourceBytes, sourceImage); err != nil {
+		t.Fatalf("failed to encode source png: %v", err)
+	}
+
+	unchanged, unchangedExt, err := convertExportTextureImage(sourceBytes.Bytes(), ".png", TextureExportOptions{})
+	if err != nil || unchangedExt != ".png" || !bytes.Equal(unchanged, sourceBytes.Bytes()) {
+		t.Fatalf("inactive options should keep source bytes: ext=%s err=%v", unchangedExt, err)
+	}
+	withinLimit, withinLimitExt, err := convertExportTextureImage(sourceBytes.Bytes(), ".png", TextureExportOptions{MaxDimension: 8})
+	if err != nil || withinLimitExt != ".png" || !bytes.Equal(withinLimit, sourceBytes.Bytes()) {
+		t.Fatalf("image within max dimension should keep source bytes: ext=%s err=%v", withinLimitExt, err)
+	}
+
+	converted, ext, err := convertExportTextureImage(sourceBytes.Bytes(), ".png", TextureExportOptions{
+		MaxDimension:  4,
+		Format:        TextureExportFormatTga,
+		FlattenOpaque: true,
+	})
+	if err != nil {
+		t.Fatalf("convert failed: %v", err)
+	}
+	if ext != ".tga" {
+		t.Fatalf("ext mismatch: got=%s", ext)
+	}
+	decoded, err := tga.Decode(bytes.NewReader(converted))
+	if err != nil {
+		t.Fatalf("converted tga decode failed: %v", err)
+	}
+	if decoded.Bounds().Dx() != 4 || decoded.Bounds().Dy() != 2 {
+		t.Fatalf("converted size mismatch: %v", decoded.Bounds())
+	}
+	pixel := color.NRGBAModel.Convert(decoded.At(1, 1)).(color.NRGBA)
+	if absDiffUint8(pixel.R, 0x40) > 1 || absDiffUint8(pixel.G, 0x80) > 1 || absDiffUint8(pixel.B, 0xc0) > 1 || pixel.A != 0xff {
+		t.Fatalf("converted color mismatch: %+v", pixel)
+	}
+}
+
+func TestConvertExportTextureImageFlattensOnlyOpaqueImages(t *testing.T) {
+	opaqueImage := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	translucentImage := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			opaqueImage.SetNRGBA(x, y, color.NRGBA{R: 0xff, A: 0xff})
+			translucentImage.SetNRGBA(x, y, color.NRGBA{R: 0xff, A: 0x80})
+		}
+	}
+	for _, testCase := range []struct {
+		name        string
+		sourceImage image.Image
+		expectFlat  bool
+	}{
+		{name: "opaque", sourceImage: opaqueImage, expectFlat: true},
+		{name: "translucent", sourceImage: translucentImage, expectFlat: false},
+	} {
+		var sourceBytes bytes.Buffer
+		if err := png.Encode(&sourceBytes, testCase.sourceImage); err != nil {
+			t.Fatalf("%s: failed to encode source png: %v", testCase.name, err)
+		}
+		converted, ext, err := convertExportTextureImage(sourceBytes.Bytes(), ".png", TextureExportOptions{FlattenOpaque: true})
+		if err != nil || ext != ".png" {
+			t.Fatalf("%s: convert failed: ext=%s err=%v", testCase.name, ext, err)
+		}
+		decoded, err := png.Decode(bytes.NewReader(converted))
+		if err != nil {
+			t.Fatalf("%s: decode failed: %v", testCase.name, err)
+		}
+		_, hasAlphaChannel := decoded.(*image.NRGBA)
+		if hasAlphaChannel == testCase.expectFlat {
+			t.Fatalf("%s: alpha channel mismatch: type=%T", testCase.name, decoded)
+		}
+	}
+}
+
+func TestParseTextureExportFormat(t *testing.T) {
+	for input, expected := range map[string]TextureExportFormat{
+		"":     TextureExportFormatOriginal,
+		"PNG":  TextureExportFormatPng,
+		".tga": TextureExportFormatTga,
+		"bmp":  TextureExportFormatBmp,
+	} {
+		got, err := ParseTextureExportFormat(input)
+		if err != nil || got != expected {
+			t.Fatalf("format mismatch: input=%s got=%s err=%v", input, got, err)
+		}
+	}
+	if _, err := ParseTextureExportFormat("webp"); err == nil {
+		t.Fatalf("unsupported format should fail")
+	}
+}
+
+// absDiffUint8 は2値の差の絶対値を返す。
+func absDiffUint8(left uint8, right uint8) uint8 {
+	if left > right {
+		return left - right
+	}
+	return right - left
+}
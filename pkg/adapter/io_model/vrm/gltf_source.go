@@ -0,0 +1,201 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	gltfSourceExtVrm  = ".vrm"
+	gltfSourceExtGlb  = ".glb"
+	gltfSourceExtGltf = ".gltf"
+
+	gltfBufferAlignment = 4
+)
+
+// gltfSourceBufferDocument は外部バッファ統合に必要な glTF 要素を表す。
+type gltfSourceBufferDocument struct {
+	Buffers     []gltfSourceBuffer     `json:"buffers"`
+	BufferViews []gltfSourceBufferView `json:"bufferViews"`
+}
+
+// gltfSourceBuffer は glTF buffer 要素のURI情報を表す。
+type gltfSourceBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+// gltfSourceBufferView は glTF bufferView 要素の参照先バッファを表す。
+type gltfSourceBufferView struct {
+	Buffer int `json:"buffer"`
+}
+
+// isSupportedGltfSourcePath は入力パスが読み込み対象拡張子かを判定する。
+func isSupportedGltfSourcePath(path string) bool {
+	switch strings.ToLower(filepath.Ext(strings.TrimSpace(path))) {
+	case gltfSourceExtVrm, gltfSourceExtGlb, gltfSourceExtGltf:
+		return true
+	default:
+		return false
+	}
+}
+
+// isGltfJSONSourcePath は入力パスがJSON形式の .gltf かを判定する。
+func isGltfJSONSourcePath(path string) bool {
+	return strings.EqualFold(filepath.Ext(strings.TrimSpace(path)), gltfSourceExtGltf)
+}
+
+// readGltfSourceChunks は入力ファイルからJSONと単一BINバッファを取得する。
+// 外部URIバッファは入力ファイル基準で解決し、buffer 0 へ連結した構成へ正規化する。
+func readGltfSourceChunks(path string, sourceBytes []byte) ([]byte, []byte, error) {
+	jsonChunk := sourceBytes
+	var binChunk []byte
+	if !isGltfJSONSourcePath(path) {
+		parsedJSON, parsedBin, err := parseGLBChunks(sourceBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		jsonChunk = parsedJSON
+		binChunk = parsedBin
+	}
+	return mergeGltfExternalBuffers(path, jsonChunk, binChunk)
+}
+
+// mergeGltfExternalBuffers は複数/外部バッファを buffer 0 へ連結し、bufferView のoffsetを付け替える。
+func mergeGltfExternalBuffers(path string, jsonChunk []byte, binChunk []byte) ([]byte, []byte, error) {
+	doc := gltfSourceBufferDocument{}
+	if err := json.Unmarshal(jsonChunk, &doc); err != nil {
+		return nil, nil, fmt.Errorf("glTF JSON の解析に失敗しました: %w", err)
+	}
+	if !requiresGltfBufferMerge(doc.Buffers) {
+		return jsonChunk, binChunk, nil
+	}
+
+	merged := make([]byte, 0, len(binChunk))
+	bufferOffsets := make([]int, len(doc.Buffers))
+	for bufferIndex, buffer := range doc.Buffers {
+		data, err := resolveGltfBufferBytes(path, bufferIndex, buffer, binChunk)
+		if err != nil {
+			return nil, nil, err
+		}
+		if padSize := (gltfBufferAlignment - len(merged)%gltfBufferAlignment) % gltfBufferAlignment; padSize > 0 {
+			merged = append(merged, make([]byte, padSize)...)
+		}
+		bufferOffsets[bufferIndex] = len(merged)
+		merged = append(merged, data...)
+	}
+
+	rewritten, err := rewriteGltfBufferReferences(jsonChunk, doc.BufferViews, bufferOffsets, len(merged))
+	if err != nil {
+		return nil, nil, err
+	}
+	return rewritten, merged, nil
+}
+
+// requiresGltfBufferMerge は buffer 0 のGLB埋め込み構成以外かを判定する。
+func requiresGltfBufferMerge(buffers []gltfSourceBuffer) bool {
+	if len(buffers) > 1 {
+		return true
+	}
+	return len(buffers) == 1 && strings.TrimSpace(buffers[0].URI) != ""
+}
+
+// resolveGltfBufferBytes は buffer 要素のバイト列を解決する。
+func resolveGltfBufferBytes(path string, bufferIndex int, buffer gltfSourceBuffer, binChunk []byte) ([]byte, error) {
+	uri := strings.TrimSpace(buffer.URI)
+	var data []byte
+	switch {
+	case uri == "":
+		if bufferIndex != 0 || len(binChunk) == 0 {
+			return nil, fmt.Errorf("glTF buffer の参照先が見つかりません: buffer=%d", bufferIndex)
+		}
+		data = binChunk
+	case strings.HasPrefix(uri, "data:"):
+		decoded, _, err := decodeDataURI(uri)
+		if err != nil {
+			return nil, fmt.Errorf("glTF buffer のdata URI解析に失敗しました: buffer=%d: %w", bufferIndex, err)
+		}
+		data = decoded
+	default:
+		decoded, err := os.ReadFile(resolveGltfRelativeURIPath(path, uri))
+		if err != nil {
+			return nil, fmt.Errorf("glTF 外部バッファの読み取りに失敗しました: buffer=%d uri=%s: %w", bufferIndex, uri, err)
+		}
+		data = decoded
+	}
+	if buffer.ByteLength > len(data) {
+		return nil, fmt.Errorf(
+			"glTF buffer のbyteLengthが実データを超えています: buffer=%d byteLength=%d actual=%d",
+			bufferIndex,
+			buffer.ByteLength,
+			len(data),
+		)
+	}
+	if buffer.ByteLength > 0 {
+		data = data[:buffer.ByteLength]
+	}
+	return data, nil
+}
+
+// rewriteGltfBufferReferences は統合後の単一バッファ構成へJSONを書き換える。
+func rewriteGltfBufferReferences(
+	jsonChunk []byte,
+	bufferViews []gltfSourceBufferView,
+	bufferOffsets []int,
+	mergedLength int,
+) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(jsonChunk))
+	decoder.UseNumber()
+	root := map[string]any{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("glTF JSON の解析に失敗しました: %w", err)
+	}
+
+	rawViews, _ := root["bufferViews"].([]any)
+	for viewIndex, rawView := range rawViews {
+		view, ok := rawView.(map[string]any)
+		if !ok || viewIndex >= len(bufferViews) {
+			continue
+		}
+		bufferIndex := bufferViews[viewIndex].Buffer
+		if bufferIndex < 0 || bufferIndex >= len(bufferOffsets) {
+			return nil, fmt.Errorf("bufferView.buffer が不正です: bufferView=%d buffer=%d", viewIndex, bufferIndex)
+		}
+		byteOffset := 0
+		if rawOffset, exists := view["byteOffset"].(json.Number); exists {
+			parsed, err := rawOffset.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("bufferView.byteOffset が不正です: bufferView=%d: %w", viewIndex, err)
+			}
+			byteOffset = int(parsed)
+		}
+		view["buffer"] = 0
+		view["byteOffset"] = byteOffset + bufferOffsets[bufferIndex]
+	}
+	root["buffers"] = []any{map[string]any{"byteLength": mergedLength}}
+
+	rewritten, err := json.Marshal(root)
+	if err != nil {
+		return nil, fmt.Errorf("glTF JSON の再構築に失敗しました: %w", err)
+	}
+	return rewritten, nil
+}
+
+// resolveGltfRelativeURIPath は glTF の相対URIを入力ファイル基準のパスへ変換する。
+func resolveGltfRelativeURIPath(basePath string, uri string) string {
+	decoded := strings.TrimSpace(uri)
+	if unescaped, err := url.PathUnescape(decoded); err == nil {
+		decoded = unescaped
+	}
+	sourcePath := filepath.FromSlash(decoded)
+	if filepath.IsAbs(sourcePath) {
+		return sourcePath
+	}
+	return filepath.Join(filepath.Dir(basePath), sourcePath)
+}
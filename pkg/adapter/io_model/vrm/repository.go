@@ -69,9 +69,84 @@ type vrmProfileInference struct {
 	Reasons       []string       `json:"reasons"`
 }
 
+// WeightReductionMode はBDEF4上限を超える頂点ウェイトの縮約方法を表す。
+type WeightReductionMode string
+
+const (
+	// WeightReductionModeDropSmallest は小さいウェイトを切り捨てて再正規化する。
+	WeightReductionModeDropSmallest WeightReductionMode = "drop_smallest"
+	// WeightReductionModeRedistributeToAncestor は切り捨て分を最寄りの残存祖先ボーンへ寄せる。
+	WeightReductionModeRedistributeToAncestor WeightReductionMode = "redistribute_to_ancestor"
+)
+
+// ParseWeightReductionMode は文字列からウェイト縮約方法を解決する。空文字は drop_smallest として扱う。
+func ParseWeightReductionMode(value string) (WeightReductionMode, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	switch WeightReductionMode(normalized) {
+	case "":
+		return WeightReductionModeDropSmallest, nil
+	case WeightReductionModeDropSmallest, WeightReductionModeRedistributeToAncestor:
+		return WeightReductionMode(normalized), nil
+	default:
+		return WeightReductionModeDropSmallest, fmt.Errorf("未対応のウェイト縮約方法です: %s", value)
+	}
+}
+
+// VertexColorMode は COLOR_0 頂点カラーの変換方法を表す。
+type VertexColorMode string
+
+const (
+	// VertexColorModeNone は頂点カラーを読み捨てる。
+	VertexColorModeNone VertexColorMode = "none"
+	// VertexColorModeTexture は頂点カラーを材質別に生成するテクスチャへ乗算して焼き込む。
+	VertexColorModeTexture VertexColorMode = "texture"
+	// VertexColorModeExtendedUv は頂点カラーを拡張UV2へRGBAとして格納する。
+	VertexColorModeExtendedUv VertexColorMode = "extended_uv"
+)
+
+// ParseVertexColorMode は文字列から頂点カラーの変換方法を解決する。空文字は none として扱う。
+func ParseVertexColorMode(value string) (VertexColorMode, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	switch VertexColorMode(normalized) {
+	case "":
+		return VertexColorModeNone, nil
+	case VertexColorModeNone, VertexColorModeTexture, VertexColorModeExtendedUv:
+		return VertexColorMode(normalized), nil
+	default:
+		return VertexColorModeNone, fmt.Errorf("未対応の頂点カラー変換方法です: %s", value)
+	}
+}
+
+// VrmLoadOptions はVRM読込時に適用する変換オプションを表す。
+type VrmLoadOptions struct {
+	// WeightReductionMode はBDEF4上限超過ウェイトの縮約方法を表す。
+	WeightReductionMode WeightReductionMode
+	// VertexColorMode は COLOR_0 頂点カラーの変換方法を表す。
+	VertexColorMode VertexColorMode
+	// ExtendedUvTexcoord1 は TEXCOORD_1 を拡張UV3へ出力するかを表す。
+	ExtendedUvTexcoord1 bool
+	// ExtendedUvTangent は TANGENT を拡張UV4へ出力するかを表す。
+	ExtendedUvTangent bool
+	// JawLipSyncAngles は顎口パク補完の母音別開口角度(度)を表す。nil の場合は既定値を使う。
+	JawLipSyncAngles *JawLipSyncAngles
+	// MorphRuleFilePath は組み込みモーフ規則へ追加・上書きする規則ファイルのパスを表す。空文字時は組み込み規則のみ使う。
+	MorphRuleFilePath string
+}
+
+// IsDefault は全項目が既定値で、読込結果へ影響しないかを返す。
+func (o VrmLoadOptions) IsDefault() bool {
+	return (o.WeightReductionMode == "" || o.WeightReductionMode == WeightReductionModeDropSmallest) &&
+		(o.VertexColorMode == "" || o.VertexColorMode == VertexColorModeNone) &&
+		!o.ExtendedUvTexcoord1 &&
+		!o.ExtendedUvTangent &&
+		o.JawLipSyncAngles == nil &&
+		strings.TrimSpace(o.MorphRuleFilePath) == ""
+}
+
 // VrmRepository はVRM入力の読み込み契約を表す。
 type VrmRepository struct {
 	loadProgressReporter func(LoadProgressEvent)
+	meshOptions          vrmMeshOptions
 }
 
 // NewVrmRepository はVrmRepositoryを生成する。
@@ -87,9 +162,80 @@ func (r *VrmRepository) SetLoadProgressReporter(reporter func(LoadProgressEvent)
 	r.loadProgressReporter = reporter
 }
 
+// SetWeightReductionMode はBDEF4上限超過ウェイトの縮約方法を設定する。
+func (r *VrmRepository) SetWeightReductionMode(mode WeightReductionMode) {
+	if r == nil {
+		return
+	}
+	r.meshOptions.WeightReductionMode = mode
+}
+
+// ApplyLoadOptions は読込オプションをまとめて設定する。未指定の項目は既定値へ戻す。
+// モーフ規則ファイルが不正な場合は設定を変更せずにエラーを返す。
+func (r *VrmRepository) ApplyLoadOptions(options VrmLoadOptions) error {
+	if r == nil {
+		return nil
+	}
+	if err := r.SetMorphRuleFile(options.MorphRuleFilePath); err != nil {
+		return err
+	}
+	r.SetWeightReductionMode(options.WeightReductionMode)
+	r.SetVertexColorMode(options.VertexColorMode)
+	r.SetExtendedUvSources(options.ExtendedUvTexcoord1, options.ExtendedUvTangent)
+	if options.JawLipSyncAngles != nil {
+		r.SetJawLipSyncAngles(*options.JawLipSyncAngles)
+	} else {
+		r.meshOptions.JawLipSyncAngles = nil
+	}
+	return nil
+}
+
+// SetVertexColorMode は COLOR_0 頂点カラーの変換方法を設定する。
+func (r *VrmRepository) SetVertexColorMode(mode VertexColorMode) {
+	if r == nil {
+		return
+	}
+	r.meshOptions.VertexColorMode = mode
+}
+
+// SetExtendedUvSources は TEXCOORD_1(UV3) と TANGENT(UV4) を拡張UVへ出力するかを設定する。
+func (r *VrmRepository) SetExtendedUvSources(texcoord1 bool, tangent bool) {
+	if r == nil {
+		return
+	}
+	r.meshOptions.ExtendedUvTexcoord1 = texcoord1
+	r.meshOptions.ExtendedUvTangent = tangent
+}
+
+// SetMorphRuleFile は組み込みモーフ規則へ追加・上書きする規則ファイルを読み込んで検証し、以降の読込へ適用する。
+// 空文字を指定した場合は組み込み規則のみを使う。
+func (r *VrmRepository) SetMorphRuleFile(path string) error {
+	if r == nil {
+		return nil
+	}
+	if strings.TrimSpace(path) == "" {
+		r.meshOptions.MorphRules = nil
+		return nil
+	}
+	ruleSet, err := loadMorphRuleSetWithOverride(path)
+	if err != nil {
+		return err
+	}
+	r.meshOptions.MorphRules = ruleSet
+	return nil
+}
+
+// SetJawLipSyncAngles は口形状の表情が無いモデルへ補完する顎口パクモーフの母音別開口角度(度)を設定する。
+func (r *VrmRepository) SetJawLipSyncAngles(angles JawLipSyncAngles) {
+	if r == nil {
+		return
+	}
+	r.meshOptions.JawLipSyncAngles = &angles
+}
+
 // CanLoad は拡張子に応じて読み込み可否を判定する。
 func (r *VrmRepository) CanLoad(path string) bool {
-	return strings.EqualFold(filepath.Ext(path), ".vrm")
+	return isSupportedGltfSourcePath(path)
 }
 
 // InferName はパスから表示名を推定する。
@@ -124,11 +270,11 @@ func (r *VrmRepository) Load(path string) (hashable.IHashable, error) {
 	})
 	logVrmInfo("VRM読込ステップ: ファイル読み取り完了 bytes=%d", len(b))
 
-	jsonChunk, binChunk, err := parseGLBChunks(b)
+	jsonChunk, binChunk, err := readGltfSourceChunks(path, b)
 	if err != nil {
-		return nil, io_common.NewIoParseFailed("VRM GLBチャンクの解析に失敗しました", err)
+		return nil, io_common.NewIoParseFailed("VRM GLB/glTFデータの解析に失敗しました", err)
 	}
-	logVrmInfo("VRM読込ステップ: GLBチャンク解析完了 jsonBytes=%d binBytes=%d", len(jsonChunk), len(binChunk))
+	logVrmInfo("VRM読込ステップ: GLB/glTFデータ解析完了 jsonBytes=%d binBytes=%d", len(jsonChunk), len(binChunk))
 
 	doc := gltfDocument{}
 	if err := json.Unmarshal(jsonChunk, &doc); err != nil {
@@ -187,6 +333,7 @@ func (r *VrmRepository) Load(path string) (hashable.IHashable, error) {
 		parentIndexes,
 		vrmData,
 		r.InferName(path),
+		r.meshOptions,
 		r.reportLoadProgress,
 	)
 	if err != nil {
@@ -316,6 +463,8 @@ type gltfNode struct {
 	Translation []float64 `json:"translation"`
 	Rotation    []float64 `json:"rotation"`
 	Scale       []float64 `json:"scale"`
+	// Extensions は VRMC_node_constraint などノード単位の拡張を保持する。
+	Extensions map[string]json.RawMessage `json:"extensions"`
 }
 
 // gltfBuffer はglTF buffer要素を表す。
@@ -333,12 +482,27 @@ type gltfBufferView struct {
 
 // gltfAccessor はglTF accessor要素を表す。
 type gltfAccessor struct {
-	BufferView    *int   `json:"bufferView"`
-	ByteOffset    int    `json:"byteOffset"`
-	ComponentType int    `json:"componentType"`
-	Count         int    `json:"count"`
-	Type          string `json:"type"`
-	Normalized    bool   `json:"normalized"`
+	BufferView    *int                `json:"bufferView"`
+	ByteOffset    int                 `json:"byteOffset"`
+	ComponentType int                 `json:"componentType"`
+	Count         int                 `json:"count"`
+	Type          string              `json:"type"`
+	Normalized    bool                `json:"normalized"`
+	Sparse        *gltfAccessorSparse `json:"sparse"`
+}
+
+// gltfAccessorSparse はglTF accessor.sparse要素を表す。
+type gltfAccessorSparse struct {
+	Count   int `json:"count"`
+	Indices struct {
+		BufferView    int `json:"bufferView"`
+		ByteOffset    int `json:"byteOffset"`
+		ComponentType int `json:"componentType"`
+	} `json:"indices"`
+	Values struct {
+		BufferView int `json:"bufferView"`
+		ByteOffset int `json:"byteOffset"`
+	} `json:"values"`
 }
 
 // gltfMesh はglTF mesh要素を表す。
@@ -371,10 +535,12 @@ type gltfSkin struct {
 type gltfMaterial struct {
 	Name                 string                     `json:"name"`
 	AlphaMode            string                     `json:"alphaMode"`
+	AlphaCutoff          *float64                   `json:"alphaCutoff"`
 	DoubleSided          bool                       `json:"doubleSided"`
 	PbrMetallicRoughness gltfPbrMetallicRoughness   `json:"pbrMetallicRoughness"`
 	EmissiveFactor       []float64                  `json:"emissiveFactor"`
 	EmissiveTexture      *gltfTextureRef            `json:"emissiveTexture"`
+	NormalTexture        *gltfNormalTextureRef      `json:"normalTexture"`
 	Extensions           map[string]json.RawMessage `json:"extensions"`
 }
 
@@ -386,7 +552,9 @@ type gltfPbrMetallicRoughness struct {
 
 // gltfTextureRef は材質から参照されるテクスチャ参照を表す。
 type gltfTextureRef struct {
-	Index int `json:"index"`
+	Index      int                        `json:"index"`
+	TexCoord   int                        `json:"texCoord"`
+	Extensions map[string]json.RawMessage `json:"extensions"`
 }
 
 // gltfTexture はglTF texture要素を表す。
@@ -608,8 +776,10 @@ func parseQuaternion(values []float64) (mmath.Quaternion, error) {
 // buildVrmData はglTF文書からVrmDataを構築する。
 func buildVrmData(doc *gltfDocument, parents []int) (*vrm.VrmData, error) {
 	version := detectVrmVersion(doc)
-	if version == "" {
-		return nil, io_common.NewIoFormatNotSupported("VRM拡張が見つかりません", nil)
+	isHeuristicHumanoid := version == ""
+	if isHeuristicHumanoid {
+		// VRM拡張を持たないglTFはノード名から推定したHumanoidをVRM1相当として扱う。
+		version = vrm.VRM_VERSION_1
 	}
 
 	vrmData := vrm.NewVrmData()
@@ -633,7 +803,9 @@ func buildVrmData(doc *gltfDocument, parents []int) (*vrm.VrmData, error) {
 	}
 
 	exporterVersion := ""
-	if version == vrm.VRM_VERSION_1 {
+	if isHeuristicHumanoid {
+		vrmData.Vrm1 = buildHeuristicVrm1Data(doc)
+	} else if version == vrm.VRM_VERSION_1 {
 		ext, err := parseVRM1Extension(doc.Extensions)
 		if err != nil {
 			return nil, err
@@ -659,6 +831,7 @@ func buildVrmData(doc *gltfDocument, parents []int) (*vrm.VrmData, error) {
 	profileInference := detectProfile(doc, vrmData, exporterVersion)
 	vrmData.Profile = profileInference.Profile
 	storeVrmProfileInference(vrmData, profileInference)
+	storeVrmLookAtDefinition(doc, vrmData)
 	return vrmData, nil
 }
 
@@ -1098,6 +1271,7 @@ func buildPmxModel(
 	parentIndexes []int,
 	vrmData *vrm.VrmData,
 	inferredName string,
+	meshOptions vrmMeshOptions,
 	progressReporter func(LoadProgressEvent),
 ) (*model.PmxModel, error) {
 	conversion := buildVrmConversion(vrmData)
@@ -1168,12 +1342,15 @@ func buildPmxModel(
 		binChunk,
 		nodeToBoneIndex,
 		conversion,
+		meshOptions,
 		progressReporter,
 	)
 	if err != nil {
 		return nil, err
 	}
-	appendExpressionMorphsFromVrmDefinition(modelData, doc, targetMorphRegistry)
+	applyNodeConstraints(modelData, doc, parentIndexes, nodeToBoneIndex, conversion)
+	appendExpressionMorphsFromVrmDefinition(modelData, doc, targetMorphRegistry, nodeToBoneIndex, meshOptions)
+	appendSpringBonePhysics(modelData, doc, vrmData, nodeToBoneIndex, conversion)
 
 	return modelData, nil
 }
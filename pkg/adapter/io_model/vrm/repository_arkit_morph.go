@@ -0,0 +1,129 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+)
+
+// arkitBlendShapeMorphRule は ARKit 52 ブレンドシェイプの重み付き合成で MMD モーフを生成する規則を表す。
+// 規則はモーフ規則ファイルの arkit 区分で定義し、左右対のモーフは右/左を個別に生成して親モーフは表情連動規則で補完する。
+type arkitBlendShapeMorphRule struct {
+	Name   string
+	Panel  model.MorphPanel
+	Binds  []string
+	Ratios []float64
+}
+
+// arkitBlendShapeMorphStats は ARKit 合成モーフ生成の集計情報を表す。
+type arkitBlendShapeMorphStats struct {
+	RuleCount       int
+	Generated       int
+	SkippedExisting int
+	SkippedNoSource int
+}
+
+// appendArkitBlendShapeMorphs は ARKit ターゲットの重み付き合成で未生成の MMD モーフを補完する。
+func appendArkitBlendShapeMorphs(modelData *model.PmxModel, rules []arkitBlendShapeMorphRule) {
+	if modelData == nil || modelData.Morphs == nil || len(rules) == 0 {
+		return
+	}
+	sourcesByName := collectArkitBlendShapeSourceMorphs(modelData, rules)
+	if len(sourcesByName) == 0 {
+		return
+	}
+	stats := arkitBlendShapeMorphStats{RuleCount: len(rules)}
+	for _, rule := range rules {
+		if existing, err := modelData.Morphs.GetByName(rule.Name); err == nil && existing != nil && len(existing.Offsets) > 0 {
+			stats.SkippedExisting++
+			logVrmDebug("ARKit合成モーフ生成スキップ: name=%s reason=already_exists", rule.Name)
+			continue
+		}
+		offsets := buildArkitBlendShapeRuleOffsets(rule, sourcesByName)
+		if len(offsets) == 0 {
+			stats.SkippedNoSource++
+			logVrmDebug("ARKit合成モーフ生成スキップ: name=%s reason=source_not_found", rule.Name)
+			continue
+		}
+		upsertTypedExpressionMorph(
+			modelData,
+			rule.Name,
+			rule.Panel,
+			model.MORPH_TYPE_VERTEX,
+			offsets,
+			false,
+		)
+		stats.Generated++
+		logVrmDebug("ARKit合成モーフ生成: name=%s offsets=%d", rule.Name, len(offsets))
+	}
+	logVrmInfo(
+		"ARKit合成モーフ生成完了: rules=%d generated=%d skippedExisting=%d skippedNoSource=%d",
+		stats.RuleCount,
+		stats.Generated,
+		stats.SkippedExisting,
+		stats.SkippedNoSource,
+	)
+}
+
+// collectArkitBlendShapeSourceMorphs は ARKit 名(小文字)ごとの合成元頂点モーフを返す。
+// 同名の表情定義モーフがあればそれを優先し、無ければ全メッシュの内部ターゲット頂点モーフを束ねる。
+func collectArkitBlendShapeSourceMorphs(
+	modelData *model.PmxModel,
+	rules []arkitBlendShapeMorphRule,
+) map[string][]*model.Morph {
+	arkitNames := map[string]struct{}{}
+	for _, rule := range rules {
+		for _, bindName := range rule.Binds {
+			arkitNames[strings.ToLower(bindName)] = struct{}{}
+		}
+	}
+	expressionSources := map[string][]*model.Morph{}
+	targetSources := map[string][]*model.Morph{}
+	for _, morphData := range modelData.Morphs.Values() {
+		if morphData == nil || morphData.MorphType != model.MORPH_TYPE_VERTEX || len(morphData.Offsets) == 0 {
+			continue
+		}
+		morphName := strings.TrimSpace(morphData.Name())
+		isTarget := strings.HasPrefix(morphName, "__vrm_target_")
+		key := strings.ToLower(strings.TrimSpace(stripPrimitiveTargetMorphPrefix(morphName)))
+		if _, exists := arkitNames[key]; !exists {
+			continue
+		}
+		if isTarget {
+			targetSources[key] = append(targetSources[key], morphData)
+			continue
+		}
+		expressionSources[key] = append(expressionSources[key], morphData)
+	}
+	sourcesByName := map[string][]*model.Morph{}
+	for key := range arkitNames {
+		if sources := expressionSources[key]; len(sources) > 0 {
+			sourcesByName[key] = sources
+			continue
+		}
+		if sources := targetSources[key]; len(sources) > 0 {
+			sourcesByName[key] = sources
+		}
+	}
+	return sourcesByName
+}
+
+// buildArkitBlendShapeRuleOffsets は規則の重みで合成元頂点オフセットを統合する。
+func buildArkitBlendShapeRuleOffsets(
+	rule arkitBlendShapeMorphRule,
+	sourcesByName map[string][]*model.Morph,
+) []model.IMorphOffset {
+	offsetsByVertex := map[int]mmath.Vec3{}
+	for bindIndex, bindName := range rule.Binds {
+		ratio := 1.0
+		if bindIndex < len(rule.Ratios) {
+			ratio = rule.Ratios[bindIndex]
+		}
+		for _, sourceMorph := range sourcesByName[strings.ToLower(bindName)] {
+			appendWeightedVertexOffsets(offsetsByVertex, sourceMorph.Offsets, ratio)
+		}
+	}
+	return buildMergedVertexOffsets(offsetsByVertex)
+}
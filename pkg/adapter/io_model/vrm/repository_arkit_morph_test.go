@@ -0,0 +1,178 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestAppendArkitBlendShapeMorphsSynthesizesWeightedMorphs(t *testing.T) {
+	modelData := model.NewPmxModel()
+	appendArkitTestVertexMorph(modelData, "__vrm_target_m000_t000_eyeBlinkLeft", 0, -1.0)
+	appendArkitTestVertexMorph(modelData, "__vrm_target_m001_t000_eyeBlinkLeft", 2, -1.0)
+	appendArkitTestVertexMorph(modelData, "__vrm_target_m000_t001_eyeBlinkRight", 1, -1.0)
+	appendArkitTestVertexMorph(modelData, "__vrm_target_m000_t002_jawOpen", 4, -1.0)
+	appendArkitTestVertexMorph(modelData, "jawOpen", 3, -1.0)
+	appendArkitTestVertexMorph(modelData, "ウィンク２", 5, -0.5)
+
+	appendArkitBlendShapeMorphs(modelData, defaultMorphRuleSet.Arkit)
+
+	blink, err := modelData.Morphs.GetByName("まばたき")
+	if err != nil || blink == nil {
+		t.Fatalf("まばたき should be generated: err=%v", err)
+	}
+	blinkOffsets := collectVertexOffsetByIndex(blink.Offsets)
+	if len(blinkOffsets) != 3 || blink.Panel != model.MORPH_PANEL_EYE_UPPER_LEFT {
+		t.Fatalf("まばたき offsets mismatch: offsets=%d panel=%d", len(blinkOffsets), blink.Panel)
+	}
+	for _, vertexIndex := range []int{0, 1, 2} {
+		if offset, exists := blinkOffsets[vertexIndex]; !exists || math.Abs(offset.Position.Y+1.0) > 1e-9 {
+			t.Fatalf("まばたき offset mismatch: vertex=%d offset=%v", vertexIndex, offset)
+		}
+	}
+
+	rightWink, err := modelData.Morphs.GetByName("ｳｨﾝｸ２右")
+	if err != nil || rightWink == nil || len(rightWink.Offsets) != 1 {
+		t.Fatalf("ｳｨﾝｸ２右 should be generated from eyeBlinkRight: err=%v", err)
+	}
+	leftWink, _ := modelData.Morphs.GetByName("ウィンク２")
+	if leftWink == nil || len(leftWink.Offsets) != 1 {
+		t.Fatalf("existing ウィンク２ should be kept")
+	}
+	if offset := collectVertexOffsetByIndex(leftWink.Offsets)[5]; offset == nil || offset.Position.Y != -0.5 {
+		t.Fatalf("existing ウィンク２ offsets should not be replaced: %v", offset)
+	}
+
+	// 表情定義の jawOpen を内部ターゲットより優先し、重み 0.6 で合成する。
+	mouthA, err := modelData.Morphs.GetByName("あ頂点")
+	if err != nil || mouthA == nil {
+		t.Fatalf("あ頂点 should be generated: err=%v", err)
+	}
+	mouthAOffsets := collectVertexOffsetByIndex(mouthA.Offsets)
+	if len(mouthAOffsets) != 1 || mouthAOffsets[3] == nil || math.Abs(mouthAOffsets[3].Position.Y+0.6) > 1e-9 {
+		t.Fatalf("あ頂点 offsets mismatch: %v", mouthAOffsets)
+	}
+	if _, err := modelData.Morphs.GetByName("にやり"); err == nil {
+		t.Fatalf("にやり should not be generated without mouthSmile sources")
+	}
+}
+
+func TestVrmRepositoryLoadSynthesizesArkitMorphsForGlbWithoutVrmExtension(t *testing.T) {
+	positions := []float32{
+		0.0, 1.5, 0.0,
+		0.1, 1.5, 0.0,
+		0.0, 1.6, 0.0,
+	}
+	normals := []float32{
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+	}
+	uvs := []float32{
+		0.0, 0.0,
+		1.0, 0.0,
+		0.0, 1.0,
+	}
+	indices := []uint16{0, 1, 2}
+	buf := bytes.NewBuffer(buildInterleavedBinForMeshTest(t, positions, normals, uvs, indices))
+	buf.Write([]byte{0x00, 0x00})
+	targetOffset := buf.Len()
+	for _, value := range []float32{0, -0.01, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, -0.01, 0, 0, 0, 0} {
+		if err := binary.Write(buf, binary.LittleEndian, value); err != nil {
+			t.Fatalf("write target position failed: %v", err)
+		}
+	}
+	binChunk := buf.Bytes()
+	doc := map[string]any{
+		"asset": map[string]any{"version": "2.0", "generator": "Blender glTF exporter"},
+		"nodes": []any{
+			map[string]any{"name": "Hips", "children": []int{1}},
+			map[string]any{"name": "Face", "mesh": 0},
+		},
+		"meshes": []any{
+			map[string]any{
+				"name": "Face",
+				"primitives": []any{
+					map[string]any{
+						"attributes": map[string]any{"POSITION": 0, "NORMAL": 1, "TEXCOORD_0": 2},
+						"indices":    3,
+						"mode":       4,
+						"targets": []any{
+							map[string]any{"POSITION": 4},
+							map[string]any{"POSITION": 5},
+						},
+						"extras": map[string]any{"targetNames": []string{"eyeBlinkLeft", "eyeBlinkRight"}},
+					},
+				},
+			},
+		},
+		"buffers": []any{map[string]any{"byteLength": len(binChunk)}},
+		"bufferViews": []any{
+			map[string]any{"buffer": 0, "byteOffset": 0, "byteLength": len(positions) * 4},
+			map[string]any{"buffer": 0, "byteOffset": len(positions) * 4, "byteLength": len(normals) * 4},
+			map[string]any{"buffer": 0, "byteOffset": (len(positions) + len(normals)) * 4, "byteLength": len(uvs) * 4},
+			map[string]any{"buffer": 0, "byteOffset": (len(positions) + len(normals) + len(uvs)) * 4, "byteLength": len(indices) * 2},
+			map[string]any{"buffer": 0, "byteOffset": targetOffset, "byteLength": 36},
+			map[string]any{"buffer": 0, "byteOffset": targetOffset + 36, "byteLength": 36},
+		},
+		"accessors": []any{
+			map[string]any{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]any{"bufferView": 1, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]any{"bufferView": 2, "componentType": 5126, "count": 3, "type": "VEC2"},
+			map[string]any{"bufferView": 3, "componentType": 5123, "count": 3, "type": "SCALAR"},
+			map[string]any{"bufferView": 4, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]any{"bufferView": 5, "componentType": 5126, "count": 3, "type": "VEC3"},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "perfect_sync.glb")
+	writeGLBFileForUsecaseMeshTest(t, path, doc, binChunk)
+
+	hashableModel, err := NewVrmRepository().Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	pmxModel, ok := hashableModel.(*model.PmxModel)
+	if !ok {
+		t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+	}
+	blink, err := pmxModel.Morphs.GetByName("まばたき")
+	if err != nil || blink == nil || len(blink.Offsets) != 2 {
+		t.Fatalf("まばたき should be synthesized without VRM extension: morph=%v err=%v", blink, err)
+	}
+	if rightWink, err := pmxModel.Morphs.GetByName("ｳｨﾝｸ２右"); err != nil || rightWink == nil {
+		t.Fatalf("ｳｨﾝｸ２右 should be synthesized without VRM extension: err=%v", err)
+	}
+	sources := map[string]string{}
+	if err := json.Unmarshal(pmxModel.VrmData.RawExtensions[warningid.VrmMorphSourceRawExtensionKey], &sources); err != nil {
+		t.Fatalf("morph source should be stored without VRM extension: %v", err)
+	}
+	if source := sources[strconv.Itoa(blink.Index())]; source != MorphSourceArkitSynthesis {
+		t.Fatalf("まばたき source mismatch: %s", source)
+	}
+}
+
+// appendArkitTestVertexMorph は1頂点分の頂点モーフを追加する。
+func appendArkitTestVertexMorph(modelData *model.PmxModel, name string, vertexIndex int, deltaY float64) {
+	morphData := &model.Morph{
+		Panel:     model.MORPH_PANEL_SYSTEM,
+		MorphType: model.MORPH_TYPE_VERTEX,
+		Offsets: []model.IMorphOffset{
+			&model.VertexMorphOffset{
+				VertexIndex: vertexIndex,
+				Position:    mmath.Vec3{Vec: r3.Vec{Y: deltaY}},
+			},
+		},
+	}
+	morphData.SetName(name)
+	modelData.Morphs.AppendRaw(morphData)
+}
@@ -0,0 +1,130 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// 拡張UVスロットは用途ごとに固定し、未使用の手前スロットはゼロ埋めする。
+// UV1 は ensureVertexExtendedUv1 による UVモーフ用に常に空けておく。
+const (
+	extendedUvSlotUvMorph     = 0
+	extendedUvSlotVertexColor = 1
+	extendedUvSlotTexcoord1   = 2
+	extendedUvSlotTangent     = 3
+)
+
+// ExtendedUvSlotTangent は TANGENT を格納する拡張UVスロット(UV4)の index を表す。
+// xyz はPMX座標系の接線方向、w は従接線の向きを格納する。
+const ExtendedUvSlotTangent = extendedUvSlotTangent
+
+// hasExtendedUvOutput は拡張UVへ頂点属性を書き出す設定か判定する。
+func (o vrmMeshOptions) hasExtendedUvOutput() bool {
+	return o.VertexColorMode == VertexColorModeExtendedUv || o.ExtendedUvTexcoord1 || o.ExtendedUvTangent
+}
+
+// applyPrimitiveExtendedUvAttributes は TEXCOORD_1/TANGENT を設定に応じて固定の拡張UVスロットへ格納する。
+func applyPrimitiveExtendedUvAttributes(
+	modelData *model.PmxModel,
+	doc *gltfDocument,
+	primitive gltfPrimitive,
+	primitiveName string,
+	binChunk []byte,
+	cache *accessorValueCache,
+	conversion vrmConversion,
+	meshOptions vrmMeshOptions,
+	vertexStart int,
+	vertexCount int,
+) {
+	if modelData == nil || modelData.Vertices == nil {
+		return
+	}
+	var texcoords [][]float64
+	if meshOptions.ExtendedUvTexcoord1 {
+		values, err := readOptionalFloatAttribute(doc, primitive.Attributes, "TEXCOORD_1", binChunk, cache)
+		if err != nil {
+			logVrmWarn("VRM TEXCOORD_1 の読み取りに失敗したためゼロで継続します: primitive=%s err=%s", primitiveName, err.Error())
+		}
+		texcoords = values
+	}
+	var tangents [][]float64
+	if meshOptions.ExtendedUvTangent {
+		values, err := readOptionalFloatAttribute(doc, primitive.Attributes, "TANGENT", binChunk, cache)
+		if err != nil {
+			logVrmWarn("VRM TANGENT の読み取りに失敗したためゼロで継続します: primitive=%s err=%s", primitiveName, err.Error())
+		}
+		tangents = values
+	}
+	// 軸反転数が奇数の場合は従接線の向きが反転するため、w(handedness)も反転する。
+	handedness := conversion.Axis.X * conversion.Axis.Y * conversion.Axis.Z
+	for vertexOffset := 0; vertexOffset < vertexCount; vertexOffset++ {
+		vertex, err := modelData.Vertices.Get(vertexStart + vertexOffset)
+		if err != nil || vertex == nil {
+			continue
+		}
+		if meshOptions.ExtendedUvTexcoord1 {
+			texcoord := mmath.ZERO_VEC4
+			if vertexOffset < len(texcoords) {
+				uv := toVec2(texcoords[vertexOffset], mmath.ZERO_VEC2)
+				texcoord = mmath.Vec4{X: uv.X, Y: uv.Y}
+			}
+			setVertexExtendedUvSlot(vertex, extendedUvSlotTexcoord1, texcoord)
+		}
+		if meshOptions.ExtendedUvTangent {
+			tangent := mmath.ZERO_VEC4
+			if vertexOffset < len(tangents) && len(tangents[vertexOffset]) >= 3 {
+				values := tangents[vertexOffset]
+				direction := convertVrmNormalToPmx(
+					mmath.Vec3{Vec: r3.Vec{X: values[0], Y: values[1], Z: values[2]}},
+					conversion,
+				)
+				w := 1.0
+				if len(values) >= 4 && values[3] < 0 {
+					w = -1.0
+				}
+				if handedness < 0 {
+					w = -w
+				}
+				tangent = mmath.Vec4{X: direction.X, Y: direction.Y, Z: direction.Z, W: w}
+			}
+			setVertexExtendedUvSlot(vertex, extendedUvSlotTangent, tangent)
+		}
+	}
+}
+
+// setVertexExtendedUvSlot は拡張UVスロットを不足分ゼロ埋めで確保し、値を設定する。
+func setVertexExtendedUvSlot(vertex *model.Vertex, slot int, value mmath.Vec4) {
+	if vertex == nil || slot < 0 {
+		return
+	}
+	for len(vertex.ExtendedUvs) <= slot {
+		vertex.ExtendedUvs = append(vertex.ExtendedUvs, mmath.ZERO_VEC4)
+	}
+	vertex.ExtendedUvs[slot] = value
+}
+
+// padVertexExtendedUvs は全頂点の拡張UV数を最大数へ揃える。
+func padVertexExtendedUvs(modelData *model.PmxModel) {
+	if modelData == nil || modelData.Vertices == nil {
+		return
+	}
+	maxCount := 0
+	for _, vertex := range modelData.Vertices.Values() {
+		if vertex != nil && len(vertex.ExtendedUvs) > maxCount {
+			maxCount = len(vertex.ExtendedUvs)
+		}
+	}
+	if maxCount == 0 {
+		return
+	}
+	for _, vertex := range modelData.Vertices.Values() {
+		if vertex == nil {
+			continue
+		}
+		for len(vertex.ExtendedUvs) < maxCount {
+			vertex.ExtendedUvs = append(vertex.ExtendedUvs, mmath.ZERO_VEC4)
+		}
+	}
+}
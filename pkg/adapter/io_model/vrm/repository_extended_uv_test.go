@@ -0,0 +1,160 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+)
+
+func TestVrmRepositoryLoadWritesTexcoord1AndTangentToFixedExtendedUvSlots(t *testing.T) {
+	positions := []float32{
+		0.0, 0.0, 0.0,
+		0.0, 1.0, 0.0,
+		1.0, 0.0, 0.0,
+	}
+	normals := []float32{
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+	}
+	uvs := []float32{
+		0.0, 0.0,
+		0.0, 1.0,
+		1.0, 0.0,
+	}
+	indices := []uint16{0, 1, 2}
+	texcoord1 := []float32{
+		0.25, 0.75,
+		0.5, 0.5,
+		0.75, 0.25,
+	}
+	tangents := []float32{
+		1.0, 0.0, 0.0, 1.0,
+		1.0, 0.0, 0.0, 1.0,
+		0.0, 1.0, 0.0, -1.0,
+	}
+	binChunk := buildInterleavedBinForMeshTest(t, positions, normals, uvs, indices)
+	binChunk = append(binChunk, 0, 0)
+	var extraBuf bytes.Buffer
+	for _, value := range append(append([]float32{}, texcoord1...), tangents...) {
+		if err := binary.Write(&extraBuf, binary.LittleEndian, value); err != nil {
+			t.Fatalf("write extra attribute failed: %v", err)
+		}
+	}
+	texcoord1Offset := len(binChunk)
+	tangentOffset := texcoord1Offset + len(texcoord1)*4
+	binChunk = append(binChunk, extraBuf.Bytes()...)
+
+	doc := map[string]any{
+		"asset": map[string]any{
+			"version":   "2.0",
+			"generator": "VRM Test",
+		},
+		"extensionsUsed": []string{"VRMC_vrm"},
+		"nodes": []any{
+			map[string]any{"name": "hips_node"},
+			map[string]any{"name": "mesh_node", "mesh": 0, "skin": 0},
+		},
+		"skins": []any{
+			map[string]any{"joints": []int{0}},
+		},
+		"meshes": []any{
+			map[string]any{
+				"name": "mesh0",
+				"primitives": []any{
+					map[string]any{
+						"attributes": map[string]any{
+							"POSITION":   0,
+							"NORMAL":     1,
+							"TEXCOORD_0": 2,
+							"TEXCOORD_1": 4,
+							"TANGENT":    5,
+						},
+						"indices":  3,
+						"material": 0,
+						"mode":     4,
+					},
+				},
+			},
+		},
+		"materials": []any{
+			map[string]any{"name": "detail"},
+		},
+		"buffers": []any{
+			map[string]any{"byteLength": len(binChunk)},
+		},
+		"bufferViews": []any{
+			map[string]any{"buffer": 0, "byteOffset": 0, "byteLength": len(positions) * 4},
+			map[string]any{"buffer": 0, "byteOffset": len(positions) * 4, "byteLength": len(normals) * 4},
+			map[string]any{"buffer": 0, "byteOffset": (len(positions) + len(normals)) * 4, "byteLength": len(uvs) * 4},
+			map[string]any{"buffer": 0, "byteOffset": (len(positions) + len(normals) + len(uvs)) * 4, "byteLength": len(indices) * 2},
+			map[string]any{"buffer": 0, "byteOffset": texcoord1Offset, "byteLength": len(texcoord1) * 4},
+			map[string]any{"buffer": 0, "byteOffset": tangentOffset, "byteLength": len(tangents) * 4},
+		},
+		"accessors": []any{
+			map[string]any{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]any{"bufferView": 1, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]any{"bufferView": 2, "componentType": 5126, "count": 3, "type": "VEC2"},
+			map[string]any{"bufferView": 3, "componentType": 5123, "count": 3, "type": "SCALAR"},
+			map[string]any{"bufferView": 4, "componentType": 5126, "count": 3, "type": "VEC2"},
+			map[string]any{"bufferView": 5, "componentType": 5126, "count": 3, "type": "VEC4"},
+		},
+		"extensions": map[string]any{
+			"VRMC_vrm": map[string]any{
+				"specVersion": "1.0",
+				"humanoid": map[string]any{
+					"humanBones": map[string]any{
+						"hips": map[string]any{"node": 0},
+					},
+				},
+			},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "extended_uv.vrm")
+	writeGLBFileForTestWithBin(t, path, doc, binChunk)
+
+	repository := NewVrmRepository()
+	repository.SetExtendedUvSources(true, true)
+	hashableModel, err := repository.Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	pmxModel, ok := hashableModel.(*model.PmxModel)
+	if !ok {
+		t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+	}
+
+	for vertexIndex := 0; vertexIndex < pmxModel.Vertices.Len(); vertexIndex++ {
+		vertex, getErr := pmxModel.Vertices.Get(vertexIndex)
+		if getErr != nil || vertex == nil {
+			t.Fatalf("vertex not found: index=%d err=%v", vertexIndex, getErr)
+		}
+		if len(vertex.ExtendedUvs) != 4 {
+			t.Fatalf("extended uv count mismatch: index=%d got=%d", vertexIndex, len(vertex.ExtendedUvs))
+		}
+		uvMorphSlot := vertex.ExtendedUvs[extendedUvSlotUvMorph]
+		if uvMorphSlot.X != 0 || uvMorphSlot.Y != 0 || uvMorphSlot.Z != 0 || uvMorphSlot.W != 0 {
+			t.Fatalf("uv morph slot should stay zero: index=%d got=%v", vertexIndex, uvMorphSlot)
+		}
+	}
+
+	firstVertex, _ := pmxModel.Vertices.Get(0)
+	texcoord := firstVertex.ExtendedUvs[extendedUvSlotTexcoord1]
+	if math.Abs(texcoord.X-0.25) > 1e-6 || math.Abs(texcoord.Y-0.75) > 1e-6 {
+		t.Fatalf("texcoord1 mismatch: got=%v", texcoord)
+	}
+	// 標準VRMはX反転で取り込むため、接線Xと handedness が反転する。
+	tangent := firstVertex.ExtendedUvs[extendedUvSlotTangent]
+	if math.Abs(tangent.X+1.0) > 1e-6 || math.Abs(tangent.Y) > 1e-6 || tangent.W != -1.0 {
+		t.Fatalf("tangent mismatch: got=%v", tangent)
+	}
+	lastVertex, _ := pmxModel.Vertices.Get(2)
+	if lastTangent := lastVertex.ExtendedUvs[extendedUvSlotTangent]; math.Abs(lastTangent.Y-1.0) > 1e-6 || lastTangent.W != 1.0 {
+		t.Fatalf("tangent handedness mismatch: got=%v", lastTangent)
+	}
+}
@@ -0,0 +1,132 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+)
+
+const (
+	vrmFirstPersonMemoKey = "firstPerson"
+
+	vrmFirstPersonTypeAuto            = "auto"
+	vrmFirstPersonTypeBoth            = "both"
+	vrmFirstPersonTypeThirdPersonOnly = "thirdPersonOnly"
+	vrmFirstPersonTypeFirstPersonOnly = "firstPersonOnly"
+)
+
+// vrmFirstPersonAnnotations はノード/メッシュ単位の firstPerson 注釈を表す。
+type vrmFirstPersonAnnotations struct {
+	ByNode map[int]string
+	ByMesh map[int]string
+}
+
+// vrm1FirstPersonExtensionSource は VRMC_vrm.firstPerson の最小構造を表す。
+type vrm1FirstPersonExtensionSource struct {
+	FirstPerson *struct {
+		MeshAnnotations []struct {
+			Node *int   `json:"node"`
+			Type string `json:"type"`
+		} `json:"meshAnnotations"`
+	} `json:"firstPerson"`
+}
+
+// vrm0FirstPersonExtensionSource は VRM.firstPerson の meshAnnotations を表す。
+type vrm0FirstPersonExtensionSource struct {
+	FirstPerson *struct {
+		MeshAnnotations []struct {
+			Mesh            *int   `json:"mesh"`
+			FirstPersonFlag string `json:"firstPersonFlag"`
+		} `json:"meshAnnotations"`
+	} `json:"firstPerson"`
+}
+
+// resolveVrmFirstPersonAnnotations は VRM0/VRM1 の firstPerson 注釈を解決する。
+func resolveVrmFirstPersonAnnotations(doc *gltfDocument) vrmFirstPersonAnnotations {
+	annotations := vrmFirstPersonAnnotations{
+		ByNode: map[int]string{},
+		ByMesh: map[int]string{},
+	}
+	if doc == nil || doc.Extensions == nil {
+		return annotations
+	}
+	if raw, exists := doc.Extensions["VRMC_vrm"]; exists {
+		source := vrm1FirstPersonExtensionSource{}
+		if err := json.Unmarshal(raw, &source); err == nil && source.FirstPerson != nil {
+			for _, annotation := range source.FirstPerson.MeshAnnotations {
+				annotationType, ok := normalizeVrmFirstPersonType(annotation.Type)
+				if annotation.Node == nil || !ok {
+					continue
+				}
+				annotations.ByNode[*annotation.Node] = annotationType
+			}
+		}
+		return annotations
+	}
+	if raw, exists := doc.Extensions["VRM"]; exists {
+		source := vrm0FirstPersonExtensionSource{}
+		if err := json.Unmarshal(raw, &source); err == nil && source.FirstPerson != nil {
+			for _, annotation := range source.FirstPerson.MeshAnnotations {
+				annotationType, ok := normalizeVrmFirstPersonType(annotation.FirstPersonFlag)
+				if annotation.Mesh == nil || !ok {
+					continue
+				}
+				annotations.ByMesh[*annotation.Mesh] = annotationType
+			}
+		}
+	}
+	return annotations
+}
+
+// resolve はノード/メッシュに対応する firstPerson 注釈を返す。
+func (a vrmFirstPersonAnnotations) resolve(nodeIndex int, meshIndex int) (string, bool) {
+	if annotationType, exists := a.ByNode[nodeIndex]; exists {
+		return annotationType, true
+	}
+	if annotationType, exists := a.ByMesh[meshIndex]; exists {
+		return annotationType, true
+	}
+	return "", false
+}
+
+// count は登録済み注釈数を返す。
+func (a vrmFirstPersonAnnotations) count() int {
+	return len(a.ByNode) + len(a.ByMesh)
+}
+
+// normalizeVrmFirstPersonType は VRM0/VRM1 の注釈種別を VRM1 表記へ正規化する。
+func normalizeVrmFirstPersonType(value string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "auto":
+		return vrmFirstPersonTypeAuto, true
+	case "both":
+		return vrmFirstPersonTypeBoth, true
+	case "thirdpersononly":
+		return vrmFirstPersonTypeThirdPersonOnly, true
+	case "firstpersononly":
+		return vrmFirstPersonTypeFirstPersonOnly, true
+	default:
+		return "", false
+	}
+}
+
+// appendFirstPersonMaterialMemo は指定index以降の材質メモへ firstPerson 注釈を追記する。
+func appendFirstPersonMaterialMemo(modelData *model.PmxModel, startMaterialIndex int, annotationType string) {
+	if modelData == nil || modelData.Materials == nil || strings.TrimSpace(annotationType) == "" {
+		return
+	}
+	token := fmt.Sprintf("%s=%s", vrmFirstPersonMemoKey, annotationType)
+	for materialIndex := startMaterialIndex; materialIndex < modelData.Materials.Len(); materialIndex++ {
+		materialData, err := modelData.Materials.Get(materialIndex)
+		if err != nil || materialData == nil {
+			continue
+		}
+		if strings.Contains(materialData.Memo, vrmFirstPersonMemoKey+"=") {
+			continue
+		}
+		materialData.Memo = strings.TrimSpace(materialData.Memo + " " + token)
+	}
+}
@@ -0,0 +1,223 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+)
+
+const (
+	heuristicHumanoidSideNone  = ""
+	heuristicHumanoidSideLeft  = "left"
+	heuristicHumanoidSideRight = "right"
+)
+
+// heuristicHumanoidNamePrefixes はノード名推定前に除去するリグ固有接頭辞を表す。
+var heuristicHumanoidNamePrefixes = []string{
+	"mixamorig",
+	"j_bip_",
+	"bip001",
+	"bip01",
+	"def-",
+	"def_",
+}
+
+// heuristicHumanoidCenterAliases は左右なしノード名の中核語とVRM1 humanBone名の対応を表す。
+var heuristicHumanoidCenterAliases = map[string]string{
+	"hips":       "hips",
+	"hip":        "hips",
+	"pelvis":     "hips",
+	"spine":      "spine",
+	"spine1":     "chest",
+	"chest":      "chest",
+	"spine2":     "upperChest",
+	"upperchest": "upperChest",
+	"neck":       "neck",
+	"head":       "head",
+	"jaw":        "jaw",
+}
+
+// heuristicHumanoidSideAliases は左右付きノード名の中核語とVRM1 humanBone名(左右接頭辞なし)の対応を表す。
+var heuristicHumanoidSideAliases = map[string]string{
+	"shoulder": "Shoulder",
+	"clavicle": "Shoulder",
+	"upperarm": "UpperArm",
+	"uparm":    "UpperArm",
+	"arm":      "UpperArm",
+	"lowerarm": "LowerArm",
+	"forearm":  "LowerArm",
+	"elbow":    "LowerArm",
+	"hand":     "Hand",
+	"wrist":    "Hand",
+	"upperleg": "UpperLeg",
+	"upleg":    "UpperLeg",
+	"thigh":    "UpperLeg",
+	"hip":      "UpperLeg",
+	"lowerleg": "LowerLeg",
+	"leg":      "LowerLeg",
+	"calf":     "LowerLeg",
+	"shin":     "LowerLeg",
+	"knee":     "LowerLeg",
+	"foot":     "Foot",
+	"ankle":    "Foot",
+	"toes":     "Toes",
+	"toe":      "Toes",
+	"toebase":  "Toes",
+	"eye":      "Eye",
+	"eyeball":  "Eye",
+}
+
+// heuristicHumanoidFingerNames は指ノード名の中核語とVRM1指名の対応を表す。
+var heuristicHumanoidFingerNames = []struct {
+	Alias string
+	Name  string
+}{
+	{Alias: "thumb", Name: "Thumb"},
+	{Alias: "index", Name: "Index"},
+	{Alias: "middle", Name: "Middle"},
+	{Alias: "ring", Name: "Ring"},
+	{Alias: "pinky", Name: "Little"},
+	{Alias: "little", Name: "Little"},
+}
+
+// buildHeuristicVrm1Data はVRM拡張を持たないglTFのノード名からVRM1相当のHumanoid定義を推定する。
+func buildHeuristicVrm1Data(doc *gltfDocument) *vrm.Vrm1Data {
+	data := vrm.NewVrm1Data()
+	data.Meta = &vrm.Vrm1Meta{}
+	data.Humanoid = &vrm.Vrm1Humanoid{
+		HumanBones: map[string]vrm.Vrm1HumanBone{},
+	}
+	if doc == nil {
+		return data
+	}
+	for nodeIndex, node := range doc.Nodes {
+		humanBoneName, ok := resolveHeuristicHumanoidBoneName(node.Name)
+		if !ok {
+			continue
+		}
+		// 同名候補はノード順で先に現れた親側を採用する。
+		if _, exists := data.Humanoid.HumanBones[humanBoneName]; exists {
+			continue
+		}
+		data.Humanoid.HumanBones[humanBoneName] = vrm.Vrm1HumanBone{Node: nodeIndex}
+	}
+	if _, exists := data.Humanoid.HumanBones["hips"]; !exists {
+		logVrmWarn("ノード名からhipsを推定できませんでした: humanBones=%d", len(data.Humanoid.HumanBones))
+	}
+	logVrmInfo("ノード名からHumanoid定義を推定しました: humanBones=%d", len(data.Humanoid.HumanBones))
+	return data
+}
+
+// resolveHeuristicHumanoidBoneName はノード名からVRM1 humanBone名を推定する。
+func resolveHeuristicHumanoidBoneName(nodeName string) (string, bool) {
+	side, core := splitHeuristicHumanoidNodeName(nodeName)
+	if core == "" {
+		return "", false
+	}
+	if side == heuristicHumanoidSideNone {
+		humanBoneName, exists := heuristicHumanoidCenterAliases[core]
+		return humanBoneName, exists
+	}
+	if fingerName, ok := resolveHeuristicHumanoidFingerName(core); ok {
+		return side + fingerName, true
+	}
+	humanBoneName, exists := heuristicHumanoidSideAliases[core]
+	if !exists || humanBoneName == "" {
+		return "", false
+	}
+	return side + humanBoneName, true
+}
+
+// splitHeuristicHumanoidNodeName はノード名を左右区分と区切り文字を除いた中核語へ分解する。
+func splitHeuristicHumanoidNodeName(nodeName string) (string, string) {
+	name := strings.ToLower(strings.TrimSpace(nodeName))
+	if index := strings.LastIndexAny(name, ":|"); index >= 0 {
+		name = name[index+1:]
+	}
+	for _, prefix := range heuristicHumanoidNamePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+	tokens := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '.' || r == '-' || r == ' '
+	})
+	if len(tokens) == 0 {
+		return heuristicHumanoidSideNone, ""
+	}
+
+	side := heuristicHumanoidSideNone
+	switch tokens[0] {
+	case "l", "left":
+		side = heuristicHumanoidSideLeft
+		tokens = tokens[1:]
+	case "r", "right":
+		side = heuristicHumanoidSideRight
+		tokens = tokens[1:]
+	case "c":
+		tokens = tokens[1:]
+	}
+	if side == heuristicHumanoidSideNone && len(tokens) > 1 {
+		switch tokens[len(tokens)-1] {
+		case "l", "left":
+			side = heuristicHumanoidSideLeft
+			tokens = tokens[:len(tokens)-1]
+		case "r", "right":
+			side = heuristicHumanoidSideRight
+			tokens = tokens[:len(tokens)-1]
+		}
+	}
+	core := strings.Join(tokens, "")
+	if side == heuristicHumanoidSideNone {
+		switch {
+		case strings.HasPrefix(core, heuristicHumanoidSideLeft):
+			side = heuristicHumanoidSideLeft
+			core = strings.TrimPrefix(core, heuristicHumanoidSideLeft)
+		case strings.HasPrefix(core, heuristicHumanoidSideRight):
+			side = heuristicHumanoidSideRight
+			core = strings.TrimPrefix(core, heuristicHumanoidSideRight)
+		}
+	}
+	return side, core
+}
+
+// resolveHeuristicHumanoidFingerName は指ノードの中核語からVRM1指名(左右接頭辞なし)を返す。
+func resolveHeuristicHumanoidFingerName(core string) (string, bool) {
+	trimmed := strings.TrimPrefix(core, "hand")
+	for _, finger := range heuristicHumanoidFingerNames {
+		if !strings.HasPrefix(trimmed, finger.Alias) {
+			continue
+		}
+		segment := strings.TrimLeft(strings.TrimPrefix(trimmed, finger.Alias), "0")
+		isThumb := finger.Name == "Thumb"
+		switch segment {
+		case "1":
+			if isThumb {
+				return finger.Name + "Metacarpal", true
+			}
+			return finger.Name + "Proximal", true
+		case "2":
+			if isThumb {
+				return finger.Name + "Proximal", true
+			}
+			return finger.Name + "Intermediate", true
+		case "3":
+			return finger.Name + "Distal", true
+		case "metacarpal":
+			if isThumb {
+				return finger.Name + "Metacarpal", true
+			}
+		case "proximal", "distal":
+			return finger.Name + strings.ToUpper(segment[:1]) + segment[1:], true
+		case "intermediate":
+			if isThumb {
+				return finger.Name + "Proximal", true
+			}
+			return finger.Name + "Intermediate", true
+		}
+		return "", false
+	}
+	return "", false
+}
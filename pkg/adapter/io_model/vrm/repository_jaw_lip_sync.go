@@ -0,0 +1,295 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"math"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+const (
+	// jawLipSyncOffsetEpsilon は顎領域頂点モーフで出力を省く微小移動量を表す。
+	jawLipSyncOffsetEpsilon = 1e-6
+)
+
+// JawLipSyncAngles は顎フォールバック口パクモーフの母音別開口角度(度)を表す。
+type JawLipSyncAngles struct {
+	A float64
+	I float64
+	U float64
+	E float64
+	O float64
+}
+
+// DefaultJawLipSyncAngles は顎フォールバック口パクモーフの既定開口角度を返す。
+func DefaultJawLipSyncAngles() JawLipSyncAngles {
+	return JawLipSyncAngles{
+		A: 20.0,
+		I: 6.0,
+		U: 8.0,
+		E: 12.0,
+		O: 16.0,
+	}
+}
+
+// jawLipSyncVowel は顎フォールバックで生成する母音1件分の出力先を表す。
+type jawLipSyncVowel struct {
+	VertexMorphName string
+	BoneMorphName   string
+	Angle           float64
+}
+
+// jawLipSyncVowels は母音別の出力先モーフ名と開口角度を返す。
+func (angles JawLipSyncAngles) jawLipSyncVowels() []jawLipSyncVowel {
+	return []jawLipSyncVowel{
+		{VertexMorphName: "あ頂点", BoneMorphName: "あボーン", Angle: angles.A},
+		{VertexMorphName: "い頂点", BoneMorphName: "いボーン", Angle: angles.I},
+		{VertexMorphName: "う頂点", BoneMorphName: "うボーン", Angle: angles.U},
+		{VertexMorphName: "え頂点", BoneMorphName: "えボーン", Angle: angles.E},
+		{VertexMorphName: "お頂点", BoneMorphName: "おボーン", Angle: angles.O},
+	}
+}
+
+// jawLipSyncVertexRegion は顎の代替として回転させる顔下部頂点領域を表す。
+type jawLipSyncVertexRegion struct {
+	VertexIndexes []int
+	Pivot         mmath.Vec3
+	MinY          float64
+}
+
+// appendJawLipSyncFallbackMorphs は口形状の表情やターゲットが無い場合に、顎ボーンまたは顔下部頂点の回転で口パクモーフを補完する。
+// 顎ボーンがあれば母音ボーンモーフへ顎回転を追加し、無ければ顔下部領域を顎とみなした母音頂点モーフを生成する。
+func appendJawLipSyncFallbackMorphs(
+	modelData *model.PmxModel,
+	nodeToBoneIndex map[int]int,
+	angles *JawLipSyncAngles,
+) {
+	if modelData == nil || modelData.Morphs == nil {
+		return
+	}
+	if hasLipSyncVisemeMorphs(modelData) {
+		logVrmDebug("顎口パク補完スキップ: reason=viseme_exists")
+		return
+	}
+	resolvedAngles := DefaultJawLipSyncAngles()
+	if angles != nil {
+		resolvedAngles = *angles
+	}
+	vowels := resolvedAngles.jawLipSyncVowels()
+
+	if jawBoneIndex, exists := resolveHumanoidJawBoneIndex(modelData, nodeToBoneIndex); exists {
+		generated := 0
+		for _, vowel := range vowels {
+			if appendJawBoneLipSyncOffset(modelData, vowel, jawBoneIndex) {
+				generated++
+			}
+		}
+		logVrmInfo("顎口パク補完完了: source=jaw_bone bone=%d generated=%d", jawBoneIndex, generated)
+		return
+	}
+
+	region, ok := resolveJawLipSyncVertexRegion(modelData)
+	if !ok {
+		logVrmDebug("顎口パク補完スキップ: reason=jaw_region_not_found")
+		return
+	}
+	generated := 0
+	for _, vowel := range vowels {
+		offsets := buildJawLipSyncVertexOffsets(modelData, region, vowel.Angle)
+		if len(offsets) == 0 {
+			continue
+		}
+		if upsertTypedExpressionMorph(
+			modelData,
+			vowel.VertexMorphName,
+			model.MORPH_PANEL_SYSTEM,
+			model.MORPH_TYPE_VERTEX,
+			offsets,
+			false,
+		) != nil {
+			generated++
+		}
+	}
+	logVrmInfo(
+		"顎口パク補完完了: source=lower_face_region vertices=%d generated=%d",
+		len(region.VertexIndexes),
+		generated,
+	)
+}
+
+// hasLipSyncVisemeMorphs は母音の表情モーフまたは口形状ターゲットが既に存在するか判定する。
+func hasLipSyncVisemeMorphs(modelData *model.PmxModel) bool {
+	visemeNames := map[string]struct{}{}
+	for _, vowel := range DefaultJawLipSyncAngles().jawLipSyncVowels() {
+		visemeNames[vowel.VertexMorphName] = struct{}{}
+		visemeNames[strings.TrimSuffix(vowel.VertexMorphName, "頂点")] = struct{}{}
+	}
+	for _, morphData := range modelData.Morphs.Values() {
+		if morphData == nil || morphData.MorphType == model.MORPH_TYPE_BONE || len(morphData.Offsets) == 0 {
+			continue
+		}
+		morphName := strings.TrimSpace(morphData.Name())
+		if _, exists := visemeNames[morphName]; exists {
+			return true
+		}
+		canonicalName := resolveCanonicalExpressionName(stripPrimitiveTargetMorphPrefix(morphName))
+		if _, exists := visemeNames[canonicalName]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveHumanoidJawBoneIndex は humanoid の jaw ノードに対応する PMX ボーン index を node->bone 対応から返す。
+func resolveHumanoidJawBoneIndex(modelData *model.PmxModel, nodeToBoneIndex map[int]int) (int, bool) {
+	if modelData == nil || modelData.Bones == nil || modelData.VrmData == nil {
+		return -1, false
+	}
+	jawNodeIndex := -1
+	if modelData.VrmData.Vrm1 != nil && modelData.VrmData.Vrm1.Humanoid != nil {
+		if humanBone, exists := modelData.VrmData.Vrm1.Humanoid.HumanBones["jaw"]; exists {
+			jawNodeIndex = humanBone.Node
+		}
+	}
+	if jawNodeIndex < 0 && modelData.VrmData.Vrm0 != nil && modelData.VrmData.Vrm0.Humanoid != nil {
+		for _, humanBone := range modelData.VrmData.Vrm0.Humanoid.HumanBones {
+			if strings.EqualFold(strings.TrimSpace(humanBone.Bone), "jaw") {
+				jawNodeIndex = humanBone.Node
+				break
+			}
+		}
+	}
+	if jawNodeIndex < 0 {
+		return -1, false
+	}
+	jawBoneIndex, exists := nodeToBoneIndex[jawNodeIndex]
+	if !exists {
+		return -1, false
+	}
+	boneData, err := modelData.Bones.Get(jawBoneIndex)
+	if err != nil || boneData == nil {
+		return -1, false
+	}
+	return boneData.Index(), true
+}
+
+// appendJawBoneLipSyncOffset は母音ボーンモーフへ顎回転オフセットを追加する。既存の舌回転などは保持する。
+func appendJawBoneLipSyncOffset(modelData *model.PmxModel, vowel jawLipSyncVowel, jawBoneIndex int) bool {
+	if vowel.Angle == 0 {
+		return false
+	}
+	jawOffset := &model.BoneMorphOffset{
+		BoneIndex: jawBoneIndex,
+		Position:  mmath.ZERO_VEC3,
+		Rotation:  mmath.NewQuaternionFromDegrees(-vowel.Angle, 0, 0),
+	}
+	offsets := []model.IMorphOffset{jawOffset}
+	if existing, err := modelData.Morphs.GetByName(vowel.BoneMorphName); err == nil && existing != nil &&
+		existing.MorphType == model.MORPH_TYPE_BONE {
+		offsets = make([]model.IMorphOffset, 0, len(existing.Offsets)+1)
+		for _, rawOffset := range existing.Offsets {
+			if boneOffset, ok := rawOffset.(*model.BoneMorphOffset); ok && boneOffset != nil && boneOffset.BoneIndex == jawBoneIndex {
+				continue
+			}
+			offsets = append(offsets, rawOffset)
+		}
+		offsets = append(offsets, jawOffset)
+	}
+	return upsertTypedExpressionMorph(
+		modelData,
+		vowel.BoneMorphName,
+		model.MORPH_PANEL_SYSTEM,
+		model.MORPH_TYPE_BONE,
+		offsets,
+		false,
+	) != nil
+}
+
+// resolveJawLipSyncVertexRegion は顔面材質の下側領域から顎代替領域と回転軸位置を求める。
+// 回転軸は領域上端の最も奥側に置き、耳付近の顎関節を近似する。
+func resolveJawLipSyncVertexRegion(modelData *model.PmxModel) (jawLipSyncVertexRegion, bool) {
+	if modelData == nil || modelData.Vertices == nil || modelData.Vertices.Len() == 0 {
+		return jawLipSyncVertexRegion{}, false
+	}
+	allVertexSet := make(map[int]struct{}, modelData.Vertices.Len())
+	for _, vertexData := range modelData.Vertices.Values() {
+		if vertexData == nil {
+			continue
+		}
+		allVertexSet[vertexData.Index()] = struct{}{}
+	}
+	lowerFaceVertexSet := resolveMouthVertexSetByFaceLowerArea(modelData, allVertexSet)
+	if len(lowerFaceVertexSet) == 0 {
+		return jawLipSyncVertexRegion{}, false
+	}
+	region := jawLipSyncVertexRegion{
+		VertexIndexes: make([]int, 0, len(lowerFaceVertexSet)),
+		MinY:          math.Inf(1),
+	}
+	maxY := math.Inf(-1)
+	maxZ := math.Inf(-1)
+	sumX := 0.0
+	for _, vertexIndex := range sortedCreateVertexIndexes(lowerFaceVertexSet) {
+		vertexData, err := modelData.Vertices.Get(vertexIndex)
+		if err != nil || vertexData == nil {
+			continue
+		}
+		region.VertexIndexes = append(region.VertexIndexes, vertexIndex)
+		sumX += vertexData.Position.X
+		region.MinY = math.Min(region.MinY, vertexData.Position.Y)
+		maxY = math.Max(maxY, vertexData.Position.Y)
+		maxZ = math.Max(maxZ, vertexData.Position.Z)
+	}
+	if len(region.VertexIndexes) == 0 || maxY-region.MinY <= jawLipSyncOffsetEpsilon {
+		return jawLipSyncVertexRegion{}, false
+	}
+	region.Pivot = mmath.Vec3{Vec: r3.Vec{
+		X: sumX / float64(len(region.VertexIndexes)),
+		Y: maxY,
+		Z: maxZ,
+	}}
+	return region, true
+}
+
+// buildJawLipSyncVertexOffsets は顎代替領域を回転軸まわりに開口方向へ回した頂点オフセットを返す。
+// 回転量は回転軸の高さで0、領域下端で指定角度となるよう線形に減衰させる。
+func buildJawLipSyncVertexOffsets(
+	modelData *model.PmxModel,
+	region jawLipSyncVertexRegion,
+	angleDegrees float64,
+) []model.IMorphOffset {
+	if modelData == nil || modelData.Vertices == nil || angleDegrees == 0 {
+		return nil
+	}
+	height := region.Pivot.Y - region.MinY
+	if height <= jawLipSyncOffsetEpsilon {
+		return nil
+	}
+	offsets := make([]model.IMorphOffset, 0, len(region.VertexIndexes))
+	for _, vertexIndex := range region.VertexIndexes {
+		vertexData, err := modelData.Vertices.Get(vertexIndex)
+		if err != nil || vertexData == nil {
+			continue
+		}
+		dy := vertexData.Position.Y - region.Pivot.Y
+		dz := vertexData.Position.Z - region.Pivot.Z
+		falloff := math.Max(0.0, math.Min(1.0, -dy/height))
+		radians := angleDegrees * falloff * math.Pi / 180.0
+		// PMX は -Z が正面のため、正面側の頂点が下がる向きに X 軸回転させる。
+		rotatedY := dy*math.Cos(radians) + dz*math.Sin(radians)
+		rotatedZ := -dy*math.Sin(radians) + dz*math.Cos(radians)
+		deltaY := rotatedY - dy
+		deltaZ := rotatedZ - dz
+		if math.Abs(deltaY) <= jawLipSyncOffsetEpsilon && math.Abs(deltaZ) <= jawLipSyncOffsetEpsilon {
+			continue
+		}
+		offsets = append(offsets, &model.VertexMorphOffset{
+			VertexIndex: vertexIndex,
+			Position:    mmath.Vec3{Vec: r3.Vec{Y: deltaY, Z: deltaZ}},
+		})
+	}
+	return offsets
+}
@@ -0,0 +1,127 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"math"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestAppendJawLipSyncFallbackMorphsAddsJawRotationToBoneMorphs(t *testing.T) {
+	modelData := model.NewPmxModel()
+	headBoneIndex := modelData.Bones.AppendRaw(model.NewBoneByName("head"))
+	jawBone := model.NewBoneByName("jaw")
+	jawBone.ParentIndex = headBoneIndex
+	jawBoneIndex := modelData.Bones.AppendRaw(jawBone)
+	modelData.VrmData = vrm.NewVrmData()
+	modelData.VrmData.Vrm1 = vrm.NewVrm1Data()
+	modelData.VrmData.Vrm1.Humanoid = &vrm.Vrm1Humanoid{
+		HumanBones: map[string]vrm.Vrm1HumanBone{"jaw": {Node: 7}},
+	}
+	upsertTypedExpressionMorph(
+		modelData,
+		"あボーン",
+		model.MORPH_PANEL_SYSTEM,
+		model.MORPH_TYPE_BONE,
+		[]model.IMorphOffset{&model.BoneMorphOffset{BoneIndex: headBoneIndex, Rotation: mmath.NewQuaternionFromDegrees(-16, 0, 0)}},
+		false,
+	)
+
+	angles := DefaultJawLipSyncAngles()
+	angles.I = 0
+	// node index とボーン index が一致しない場合も node->bone 対応で jaw ボーンを解決する。
+	appendJawLipSyncFallbackMorphs(modelData, map[int]int{7: jawBoneIndex}, &angles)
+
+	mouthA, err := modelData.Morphs.GetByName("あボーン")
+	if err != nil || mouthA == nil || len(mouthA.Offsets) != 2 {
+		t.Fatalf("あボーン should keep existing offset and add jaw offset: morph=%v err=%v", mouthA, err)
+	}
+	jawOffset, ok := mouthA.Offsets[1].(*model.BoneMorphOffset)
+	if !ok || jawOffset.BoneIndex != jawBoneIndex {
+		t.Fatalf("jaw offset mismatch: %+v", mouthA.Offsets[1])
+	}
+	mouthO, err := modelData.Morphs.GetByName("おボーン")
+	if err != nil || mouthO == nil || len(mouthO.Offsets) != 1 || mouthO.MorphType != model.MORPH_TYPE_BONE {
+		t.Fatalf("おボーン should be generated on jaw bone: morph=%v err=%v", mouthO, err)
+	}
+	if _, err := modelData.Morphs.GetByName("いボーン"); err == nil {
+		t.Fatalf("いボーン should not be generated when angle is zero")
+	}
+}
+
+func TestAppendJawLipSyncFallbackMorphsRotatesLowerFaceRegion(t *testing.T) {
+	modelData := model.NewPmxModel()
+	faceMaterial := model.NewMaterial()
+	faceMaterial.SetName("Face_00_SKIN")
+	faceMaterial.EnglishName = "Face_00_SKIN"
+	faceMaterialIndex := modelData.Materials.AppendRaw(faceMaterial)
+	for step := 0; step < 10; step++ {
+		z := -0.1
+		if step == 4 {
+			z = 0.1
+		}
+		modelData.Vertices.AppendRaw(&model.Vertex{
+			Position:        mmath.Vec3{Vec: r3.Vec{Y: float64(step) * 0.1, Z: z}},
+			MaterialIndexes: []int{faceMaterialIndex},
+		})
+	}
+
+	appendJawLipSyncFallbackMorphs(modelData, nil, nil)
+
+	mouthA, err := modelData.Morphs.GetByName("あ頂点")
+	if err != nil || mouthA == nil || mouthA.MorphType != model.MORPH_TYPE_VERTEX {
+		t.Fatalf("あ頂点 should be generated from lower face region: err=%v", err)
+	}
+	offsets := collectVertexOffsetByIndex(mouthA.Offsets)
+	chinOffset := offsets[0]
+	if chinOffset == nil || chinOffset.Position.Y >= 0 || chinOffset.Position.Z <= 0 {
+		t.Fatalf("chin vertex should move down and back: %v", chinOffset)
+	}
+	if _, exists := offsets[4]; exists {
+		t.Fatalf("pivot height vertex should not move")
+	}
+	for vertexIndex := range offsets {
+		if vertexIndex > 4 {
+			t.Fatalf("upper face vertex should not move: vertex=%d", vertexIndex)
+		}
+	}
+	mouthI, _ := modelData.Morphs.GetByName("い頂点")
+	if mouthI == nil {
+		t.Fatalf("い頂点 should be generated")
+	}
+	if iOffset := collectVertexOffsetByIndex(mouthI.Offsets)[0]; iOffset == nil || math.Abs(iOffset.Position.Y) >= math.Abs(chinOffset.Position.Y) {
+		t.Fatalf("い頂点 should open less than あ頂点: %v", iOffset)
+	}
+
+	skipped := model.NewPmxModel()
+	appendArkitTestVertexMorph(skipped, "__vrm_target_m000_t000_aa", 0, -1.0)
+	appendJawLipSyncFallbackMorphs(skipped, nil, nil)
+	if _, err := skipped.Morphs.GetByName("あボーン"); err == nil {
+		t.Fatalf("fallback should be skipped when viseme target exists")
+	}
+}
+
+func TestAppendExpressionMorphsAddsJawLipSyncFallbackWithoutVrmExtension(t *testing.T) {
+	modelData := model.NewPmxModel()
+	modelData.VrmData = vrm.NewVrmData()
+	faceMaterial := model.NewMaterial()
+	faceMaterial.SetName("Face_00_SKIN")
+	faceMaterial.EnglishName = "Face_00_SKIN"
+	faceMaterialIndex := modelData.Materials.AppendRaw(faceMaterial)
+	for step := 0; step < 10; step++ {
+		modelData.Vertices.AppendRaw(&model.Vertex{
+			Position:        mmath.Vec3{Vec: r3.Vec{Y: float64(step) * 0.1, Z: -0.1}},
+			MaterialIndexes: []int{faceMaterialIndex},
+		})
+	}
+
+	appendExpressionMorphsFromVrmDefinition(modelData, &gltfDocument{}, newTargetMorphRegistry(), nil, vrmMeshOptions{})
+
+	if mouthA, err := modelData.Morphs.GetByName("あ頂点"); err != nil || mouthA == nil || len(mouthA.Offsets) == 0 {
+		t.Fatalf("あ頂点 should be generated for glTF without VRM extension: err=%v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+const (
+	vrmLookAtTypeBone       = "bone"
+	vrmLookAtTypeExpression = "expression"
+
+	vrmLookAtDefaultInputMaxValue       = 90.0
+	vrmLookAtDefaultBoneOutputScale     = 10.0
+	vrmLookAtDefaultExpressionOutputMax = 1.0
+)
+
+// vrmLookAtRange は lookAt range map の入力上限と出力倍率を表す。
+type vrmLookAtRange struct {
+	InputMaxValue float64 `json:"inputMaxValue"`
+	OutputScale   float64 `json:"outputScale"`
+}
+
+// vrmLookAtDefinition は VRM0/VRM1 lookAt を正規化した定義を表す。
+type vrmLookAtDefinition struct {
+	Type            string         `json:"type"`
+	HorizontalInner vrmLookAtRange `json:"horizontalInner"`
+	HorizontalOuter vrmLookAtRange `json:"horizontalOuter"`
+	VerticalDown    vrmLookAtRange `json:"verticalDown"`
+	VerticalUp      vrmLookAtRange `json:"verticalUp"`
+}
+
+// vrm1LookAtExtensionSource は VRMC_vrm.lookAt の最小構造を表す。
+type vrm1LookAtExtensionSource struct {
+	LookAt *struct {
+		Type                    string                 `json:"type"`
+		RangeMapHorizontalInner *vrm1LookAtRangeSource `json:"rangeMapHorizontalInner"`
+		RangeMapHorizontalOuter *vrm1LookAtRangeSource `json:"rangeMapHorizontalOuter"`
+		RangeMapVerticalDown    *vrm1LookAtRangeSource `json:"rangeMapVerticalDown"`
+		RangeMapVerticalUp      *vrm1LookAtRangeSource `json:"rangeMapVerticalUp"`
+	} `json:"lookAt"`
+}
+
+// vrm1LookAtRangeSource は VRM1 lookAt range map を表す。
+type vrm1LookAtRangeSource struct {
+	InputMaxValue *float64 `json:"inputMaxValue"`
+	OutputScale   *float64 `json:"outputScale"`
+}
+
+// vrm0LookAtExtensionSource は VRM.firstPerson の lookAt 関連要素を表す。
+type vrm0LookAtExtensionSource struct {
+	FirstPerson *struct {
+		LookAtTypeName        string                 `json:"lookAtTypeName"`
+		LookAtHorizontalInner *vrm0LookAtRangeSource `json:"lookAtHorizontalInner"`
+		LookAtHorizontalOuter *vrm0LookAtRangeSource `json:"lookAtHorizontalOuter"`
+		LookAtVerticalDown    *vrm0LookAtRangeSource `json:"lookAtVerticalDown"`
+		LookAtVerticalUp      *vrm0LookAtRangeSource `json:"lookAtVerticalUp"`
+	} `json:"firstPerson"`
+}
+
+// vrm0LookAtRangeSource は VRM0 lookAt degree map を表す。
+type vrm0LookAtRangeSource struct {
+	XRange *float64 `json:"xRange"`
+	YRange *float64 `json:"yRange"`
+}
+
+// storeVrmLookAtDefinition は lookAt 定義を解決してRawExtensionsへ保存する。
+func storeVrmLookAtDefinition(doc *gltfDocument, vrmData *vrm.VrmData) {
+	if doc == nil || vrmData == nil {
+		return
+	}
+	definition, ok := resolveVrmLookAtDefinition(doc.Extensions, vrmData.Version)
+	if !ok {
+		return
+	}
+	encoded, err := json.Marshal(definition)
+	if err != nil {
+		return
+	}
+	if vrmData.RawExtensions == nil {
+		vrmData.RawExtensions = map[string]json.RawMessage{}
+	}
+	vrmData.RawExtensions[warningid.VrmLookAtRawExtensionKey] = encoded
+	logVrmInfo(
+		"lookAt定義解析完了: type=%s horizontalOuter=%.2f/%.2f verticalUp=%.2f/%.2f",
+		definition.Type,
+		definition.HorizontalOuter.InputMaxValue,
+		definition.HorizontalOuter.OutputScale,
+		definition.VerticalUp.InputMaxValue,
+		definition.VerticalUp.OutputScale,
+	)
+}
+
+// resolveVrmLookAtDefinition はVRMバージョンに応じて lookAt 定義を解決する。
+func resolveVrmLookAtDefinition(extensions map[string]json.RawMessage, version vrm.VrmVersion) (vrmLookAtDefinition, bool) {
+	if extensions == nil {
+		return vrmLookAtDefinition{}, false
+	}
+	if version == vrm.VRM_VERSION_1 {
+		if raw, exists := extensions["VRMC_vrm"]; exists {
+			return parseVrm1LookAtDefinition(raw)
+		}
+		return vrmLookAtDefinition{}, false
+	}
+	if raw, exists := extensions["VRM"]; exists {
+		return parseVrm0LookAtDefinition(raw)
+	}
+	return vrmLookAtDefinition{}, false
+}
+
+// parseVrm1LookAtDefinition は VRMC_vrm.lookAt を正規化する。
+func parseVrm1LookAtDefinition(raw json.RawMessage) (vrmLookAtDefinition, bool) {
+	source := vrm1LookAtExtensionSource{}
+	if err := json.Unmarshal(raw, &source); err != nil || source.LookAt == nil {
+		return vrmLookAtDefinition{}, false
+	}
+	lookAtType := vrmLookAtTypeBone
+	if strings.EqualFold(strings.TrimSpace(source.LookAt.Type), vrmLookAtTypeExpression) {
+		lookAtType = vrmLookAtTypeExpression
+	}
+	toRange := func(rangeSource *vrm1LookAtRangeSource) vrmLookAtRange {
+		out := defaultVrmLookAtRange(lookAtType)
+		if rangeSource == nil {
+			return out
+		}
+		if rangeSource.InputMaxValue != nil {
+			out.InputMaxValue = *rangeSource.InputMaxValue
+		}
+		if rangeSource.OutputScale != nil {
+			out.OutputScale = *rangeSource.OutputScale
+		}
+		return out
+	}
+	return vrmLookAtDefinition{
+		Type:            lookAtType,
+		HorizontalInner: toRange(source.LookAt.RangeMapHorizontalInner),
+		HorizontalOuter: toRange(source.LookAt.RangeMapHorizontalOuter),
+		VerticalDown:    toRange(source.LookAt.RangeMapVerticalDown),
+		VerticalUp:      toRange(source.LookAt.RangeMapVerticalUp),
+	}, true
+}
+
+// parseVrm0LookAtDefinition は VRM.firstPerson の lookAt 設定を正規化する。
+func parseVrm0LookAtDefinition(raw json.RawMessage) (vrmLookAtDefinition, bool) {
+	source := vrm0LookAtExtensionSource{}
+	if err := json.Unmarshal(raw, &source); err != nil || source.FirstPerson == nil {
+		return vrmLookAtDefinition{}, false
+	}
+	lookAtType := vrmLookAtTypeBone
+	if strings.EqualFold(strings.TrimSpace(source.FirstPerson.LookAtTypeName), "BlendShape") {
+		lookAtType = vrmLookAtTypeExpression
+	}
+	toRange := func(rangeSource *vrm0LookAtRangeSource) vrmLookAtRange {
+		out := defaultVrmLookAtRange(lookAtType)
+		if rangeSource == nil {
+			return out
+		}
+		if rangeSource.XRange != nil {
+			out.InputMaxValue = *rangeSource.XRange
+		}
+		if rangeSource.YRange != nil {
+			out.OutputScale = *rangeSource.YRange
+		}
+		return out
+	}
+	return vrmLookAtDefinition{
+		Type:            lookAtType,
+		HorizontalInner: toRange(source.FirstPerson.LookAtHorizontalInner),
+		HorizontalOuter: toRange(source.FirstPerson.LookAtHorizontalOuter),
+		VerticalDown:    toRange(source.FirstPerson.LookAtVerticalDown),
+		VerticalUp:      toRange(source.FirstPerson.LookAtVerticalUp),
+	}, true
+}
+
+// defaultVrmLookAtRange は lookAt 種別ごとの既定 range map を返す。
+func defaultVrmLookAtRange(lookAtType string) vrmLookAtRange {
+	if lookAtType == vrmLookAtTypeExpression {
+		return vrmLookAtRange{
+			InputMaxValue: vrmLookAtDefaultInputMaxValue,
+			OutputScale:   vrmLookAtDefaultExpressionOutputMax,
+		}
+	}
+	return vrmLookAtRange{
+		InputMaxValue: vrmLookAtDefaultInputMaxValue,
+		OutputScale:   vrmLookAtDefaultBoneOutputScale,
+	}
+}
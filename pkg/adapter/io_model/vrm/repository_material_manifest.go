@@ -0,0 +1,238 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+const (
+	// materialSourceMemoKey は材質メモへ記録する glTF material index のキー。
+	materialSourceMemoKey = "gltfMaterial"
+	// outlineWidthTextureChannelVrm1 は VRM1 outlineWidthMultiplyTexture の参照チャンネル。
+	outlineWidthTextureChannelVrm1 = "g"
+	// outlineWidthTextureChannelVrm0 は VRM0 _OutlineWidthTexture の参照チャンネル。
+	outlineWidthTextureChannelVrm0 = "r"
+)
+
+// gltfNormalTextureRef は normalTexture 参照を表す。
+type gltfNormalTextureRef struct {
+	gltfTextureRef
+	Scale *float64 `json:"scale"`
+}
+
+// materialCompanionMToonSource は VRMC_materials_mtoon のうち補助テクスチャ一覧に必要な要素を表す。
+type materialCompanionMToonSource struct {
+	ShadeColorFactor            []float64       `json:"shadeColorFactor"`
+	ShadeMultiplyTexture        *gltfTextureRef `json:"shadeMultiplyTexture"`
+	ShadingShiftFactor          *float64        `json:"shadingShiftFactor"`
+	ShadingToonyFactor          *float64        `json:"shadingToonyFactor"`
+	ParametricRimColorFactor    []float64       `json:"parametricRimColorFactor"`
+	RimMultiplyTexture          *gltfTextureRef `json:"rimMultiplyTexture"`
+	RimLightingMixFactor        *float64        `json:"rimLightingMixFactor"`
+	OutlineWidthFactor          *float64        `json:"outlineWidthFactor"`
+	OutlineWidthMultiplyTexture *gltfTextureRef `json:"outlineWidthMultiplyTexture"`
+}
+
+// materialCompanionTexture は補助テクスチャの PMX テクスチャ参照を表す。
+type materialCompanionTexture struct {
+	TextureIndex int `json:"texture_index"`
+	TexCoord     int `json:"tex_coord"`
+}
+
+// materialCompanionSource は glTF material 単位の補助テクスチャと係数を表す。
+// ShadingShiftFactor/ShadingToonyFactor は VRM0 の値も MToon1.0 基準へ換算して保持する。
+type materialCompanionSource struct {
+	Name                        string                    `json:"name"`
+	NormalTexture               *materialCompanionTexture `json:"normal_texture,omitempty"`
+	NormalScale                 *float64                  `json:"normal_scale,omitempty"`
+	EmissiveTexture             *materialCompanionTexture `json:"emissive_texture,omitempty"`
+	EmissiveFactor              []float64                 `json:"emissive_factor,omitempty"`
+	ShadeMultiplyTexture        *materialCompanionTexture `json:"shade_multiply_texture,omitempty"`
+	ShadeColorFactor            []float64                 `json:"shade_color_factor,omitempty"`
+	ShadingShiftFactor          *float64                  `json:"shading_shift_factor,omitempty"`
+	ShadingToonyFactor          *float64                  `json:"shading_toony_factor,omitempty"`
+	RimMultiplyTexture          *materialCompanionTexture `json:"rim_multiply_texture,omitempty"`
+	RimColorFactor              []float64                 `json:"rim_color_factor,omitempty"`
+	RimLightingMixFactor        *float64                  `json:"rim_lighting_mix_factor,omitempty"`
+	OutlineWidthMultiplyTexture *materialCompanionTexture `json:"outline_width_multiply_texture,omitempty"`
+	OutlineWidthTextureChannel  string                    `json:"outline_width_texture_channel,omitempty"`
+	OutlineWidthFactor          *float64                  `json:"outline_width_factor,omitempty"`
+}
+
+// appendMaterialCompanionSources は glTF material ごとの補助テクスチャ情報を RawExtensions へ記録する。
+func appendMaterialCompanionSources(modelData *model.PmxModel, doc *gltfDocument, textureIndexesByImage []int) {
+	if modelData == nil || modelData.VrmData == nil || doc == nil || len(doc.Materials) == 0 {
+		return
+	}
+	sources := map[string]materialCompanionSource{}
+	for materialIndex, sourceMaterial := range doc.Materials {
+		sources[strconv.Itoa(materialIndex)] = resolveMaterialCompanionSource(
+			doc,
+			textureIndexesByImage,
+			sourceMaterial,
+			materialIndex,
+		)
+	}
+	encoded, err := json.Marshal(sources)
+	if err != nil {
+		logVrmWarn("材質補助テクスチャ情報の保存に失敗しました: err=%s", err.Error())
+		return
+	}
+	if modelData.VrmData.RawExtensions == nil {
+		modelData.VrmData.RawExtensions = map[string]json.RawMessage{}
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmMaterialCompanionRawExtensionKey] = encoded
+}
+
+// resolveMaterialCompanionSource は glTF/VRM1 MToon を優先し、未指定項目を VRM0 materialProperties で補う。
+func resolveMaterialCompanionSource(
+	doc *gltfDocument,
+	textureIndexesByImage []int,
+	sourceMaterial gltfMaterial,
+	materialIndex int,
+) materialCompanionSource {
+	source := materialCompanionSource{
+		Name:           strings.TrimSpace(sourceMaterial.Name),
+		EmissiveFactor: sourceMaterial.EmissiveFactor,
+	}
+	if sourceMaterial.NormalTexture != nil {
+		source.NormalTexture = resolveMaterialCompanionTexture(doc, textureIndexesByImage, &sourceMaterial.NormalTexture.gltfTextureRef)
+		source.NormalScale = sourceMaterial.NormalTexture.Scale
+	}
+	source.EmissiveTexture = resolveMaterialCompanionTexture(doc, textureIndexesByImage, sourceMaterial.EmissiveTexture)
+
+	if raw, exists := sourceMaterial.Extensions["VRMC_materials_mtoon"]; exists && len(raw) > 0 {
+		mtoon := materialCompanionMToonSource{}
+		if err := json.Unmarshal(raw, &mtoon); err == nil {
+			source.ShadeColorFactor = mtoon.ShadeColorFactor
+			source.ShadeMultiplyTexture = resolveMaterialCompanionTexture(doc, textureIndexesByImage, mtoon.ShadeMultiplyTexture)
+			source.ShadingShiftFactor = mtoon.ShadingShiftFactor
+			source.ShadingToonyFactor = mtoon.ShadingToonyFactor
+			source.RimColorFactor = mtoon.ParametricRimColorFactor
+			source.RimMultiplyTexture = resolveMaterialCompanionTexture(doc, textureIndexesByImage, mtoon.RimMultiplyTexture)
+			source.RimLightingMixFactor = mtoon.RimLightingMixFactor
+			source.OutlineWidthFactor = mtoon.OutlineWidthFactor
+			source.OutlineWidthMultiplyTexture = resolveMaterialCompanionTexture(
+				doc,
+				textureIndexesByImage,
+				mtoon.OutlineWidthMultiplyTexture,
+			)
+			if source.OutlineWidthMultiplyTexture != nil {
+				source.OutlineWidthTextureChannel = outlineWidthTextureChannelVrm1
+			}
+		}
+	}
+
+	property, ok := resolveVrm0MaterialProperty(doc, sourceMaterial, materialIndex)
+	if !ok {
+		return source
+	}
+	resolveVrm0Texture := func(keys ...string) *materialCompanionTexture {
+		gltfTextureIndex, exists := lookupVrm0MaterialTextureProperty(property, keys...)
+		if !exists {
+			return nil
+		}
+		return resolveMaterialCompanionTexture(doc, textureIndexesByImage, &gltfTextureRef{Index: gltfTextureIndex})
+	}
+	resolveVrm0Float := func(keys ...string) *float64 {
+		value, exists := lookupVrm0MaterialFloatProperty(property, keys...)
+		if !exists {
+			return nil
+		}
+		return &value
+	}
+	resolveVrm0Color := func(keys ...string) []float64 {
+		values, exists := lookupVrm0MaterialVectorProperty(property, keys...)
+		if !exists || len(values) < 3 {
+			return nil
+		}
+		return values[:3]
+	}
+	if source.NormalTexture == nil {
+		source.NormalTexture = resolveVrm0Texture("_BumpMap")
+		source.NormalScale = resolveVrm0Float("_BumpScale")
+	}
+	if source.EmissiveTexture == nil {
+		source.EmissiveTexture = resolveVrm0Texture("_EmissionMap")
+	}
+	if len(source.EmissiveFactor) == 0 {
+		source.EmissiveFactor = resolveVrm0Color("_EmissionColor")
+	}
+	if source.ShadeMultiplyTexture == nil {
+		source.ShadeMultiplyTexture = resolveVrm0Texture("_ShadeTexture")
+	}
+	if len(source.ShadeColorFactor) == 0 {
+		source.ShadeColorFactor = resolveVrm0Color("_ShadeColor")
+	}
+	if source.ShadingShiftFactor == nil && source.ShadingToonyFactor == nil {
+		vrm0Shift := resolveVrm0Float("_ShadeShift")
+		vrm0Toony := resolveVrm0Float("_ShadeToony")
+		if vrm0Shift != nil || vrm0Toony != nil {
+			shift := 0.0
+			if vrm0Shift != nil {
+				shift = *vrm0Shift
+			}
+			toony := vrm0MToonDefaultShadeToony
+			if vrm0Toony != nil {
+				toony = *vrm0Toony
+			}
+			shift, toony = convertVrm0MToonShadingToVrm1(shift, toony)
+			source.ShadingShiftFactor = &shift
+			source.ShadingToonyFactor = &toony
+		}
+	}
+	if source.RimMultiplyTexture == nil {
+		source.RimMultiplyTexture = resolveVrm0Texture("_RimTexture")
+	}
+	if len(source.RimColorFactor) == 0 {
+		source.RimColorFactor = resolveVrm0Color("_RimColor")
+	}
+	if source.RimLightingMixFactor == nil {
+		source.RimLightingMixFactor = resolveVrm0Float("_RimLightingMix")
+	}
+	if source.OutlineWidthMultiplyTexture == nil {
+		source.OutlineWidthMultiplyTexture = resolveVrm0Texture("_OutlineWidthTexture")
+		if source.OutlineWidthMultiplyTexture != nil {
+			source.OutlineWidthTextureChannel = outlineWidthTextureChannelVrm0
+		}
+	}
+	if source.OutlineWidthFactor == nil {
+		source.OutlineWidthFactor = resolveVrm0Float("_OutlineWidth")
+	}
+	return source
+}
+
+// convertVrm0MToonShadingToVrm1 は MToon0.x の _ShadeShift/_ShadeToony を MToon1.0 の shadingShift/shadingToony へ換算する。
+// MToon0.x の境界 [shift, shift+1-toony] と MToon1.0 の境界 [-1+toony-shift, 1-toony-shift] の幅と中心を一致させる。
+// MToon0.x の smoothstep は MToon1.0 の linearstep で近似する。
+func convertVrm0MToonShadingToVrm1(shift float64, toony float64) (float64, float64) {
+	toony = clampMToonUnit(toony)
+	return -(shift + (1.0-toony)*0.5), (1.0 + toony) * 0.5
+}
+
+// resolveMaterialCompanionTexture は textureRef を PMX テクスチャindex参照へ変換する。
+func resolveMaterialCompanionTexture(
+	doc *gltfDocument,
+	textureIndexesByImage []int,
+	textureRef *gltfTextureRef,
+) *materialCompanionTexture {
+	textureIndex, ok := resolvePmxTextureIndexByTextureRef(doc, textureIndexesByImage, textureRef)
+	if !ok {
+		return nil
+	}
+	return &materialCompanionTexture{TextureIndex: textureIndex, TexCoord: textureRef.TexCoord}
+}
+
+// appendPrimitiveMaterialSourceMemo は材質メモへ元 glTF material index を追記する。
+func appendPrimitiveMaterialSourceMemo(memo string, sourceMaterialIndex int) string {
+	if sourceMaterialIndex < 0 {
+		return memo
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s=%d", memo, materialSourceMemoKey, sourceMaterialIndex))
+}
@@ -0,0 +1,99 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestResolveMaterialCompanionSourceReadsVrm1AndVrm0Textures(t *testing.T) {
+	source0, source1, source2, source3 := 0, 1, 2, 3
+	mtoonRaw := json.RawMessage(`{
+		"shadeColorFactor": [0.2, 0.3, 0.4],
+		"shadeMultiplyTexture": {"index": 1},
+		"shadingShiftFactor": -0.2,
+		"shadingToonyFactor": 0.75,
+		"parametricRimColorFactor": [1.0, 0.5, 0.0],
+		"rimMultiplyTexture": {"index": 2, "texCoord": 1},
+		"rimLightingMixFactor": 0.25,
+		"outlineWidthFactor": 0.01,
+		"outlineWidthMultiplyTexture": {"index": 3}
+	}`)
+	scale := 0.8
+	doc := &gltfDocument{
+		Textures: []gltfTexture{{Source: &source0}, {Source: &source1}, {Source: &source2}, {Source: &source3}},
+		Materials: []gltfMaterial{
+			{
+				Name:           "Body",
+				EmissiveFactor: []float64{0.1, 0.2, 0.3},
+				NormalTexture:  &gltfNormalTextureRef{gltfTextureRef: gltfTextureRef{Index: 0}, Scale: &scale},
+				Extensions:     map[string]json.RawMessage{"VRMC_materials_mtoon": mtoonRaw},
+			},
+		},
+	}
+	textureIndexesByImage := []int{10, 11, 12, 13}
+
+	source := resolveMaterialCompanionSource(doc, textureIndexesByImage, doc.Materials[0], 0)
+	if source.NormalTexture == nil || source.NormalTexture.TextureIndex != 10 || source.NormalScale == nil || *source.NormalScale != 0.8 {
+		t.Fatalf("normal texture mismatch: %+v scale=%v", source.NormalTexture, source.NormalScale)
+	}
+	if source.ShadeMultiplyTexture == nil || source.ShadeMultiplyTexture.TextureIndex != 11 || source.ShadeColorFactor[2] != 0.4 {
+		t.Fatalf("shade texture mismatch: %+v factor=%v", source.ShadeMultiplyTexture, source.ShadeColorFactor)
+	}
+	if source.ShadingShiftFactor == nil || *source.ShadingShiftFactor != -0.2 || source.ShadingToonyFactor == nil || *source.ShadingToonyFactor != 0.75 {
+		t.Fatalf("shading factor mismatch: shift=%v toony=%v", source.ShadingShiftFactor, source.ShadingToonyFactor)
+	}
+	if source.RimMultiplyTexture == nil || source.RimMultiplyTexture.TextureIndex != 12 || source.RimMultiplyTexture.TexCoord != 1 {
+		t.Fatalf("rim texture mismatch: %+v", source.RimMultiplyTexture)
+	}
+	if source.OutlineWidthMultiplyTexture == nil || source.OutlineWidthMultiplyTexture.TextureIndex != 13 || *source.OutlineWidthFactor != 0.01 || source.OutlineWidthTextureChannel != "g" {
+		t.Fatalf("outline texture mismatch: %+v factor=%v", source.OutlineWidthMultiplyTexture, source.OutlineWidthFactor)
+	}
+	if source.EmissiveTexture != nil || source.EmissiveFactor[1] != 0.2 {
+		t.Fatalf("emissive mismatch: %+v factor=%v", source.EmissiveTexture, source.EmissiveFactor)
+	}
+
+	vrm0Doc := &gltfDocument{
+		Textures:  doc.Textures,
+		Materials: []gltfMaterial{{Name: "Hair"}},
+		Extensions: map[string]json.RawMessage{
+			"VRM": json.RawMessage(`{"materialProperties":[{
+				"name": "Hair",
+				"floatProperties": {"_BumpScale": 0.5, "_OutlineWidth": 0.2, "_ShadeToony": 0.7},
+				"vectorProperties": {"_ShadeColor": [0.5, 0.4, 0.3, 1.0], "_EmissionColor": [0, 0, 0, 1]},
+				"textureProperties": {"_BumpMap": 3, "_ShadeTexture": 2, "_EmissionMap": 1, "_OutlineWidthTexture": 0}
+			}]}`),
+		},
+	}
+	vrm0Source := resolveMaterialCompanionSource(vrm0Doc, textureIndexesByImage, vrm0Doc.Materials[0], 0)
+	if vrm0Source.NormalTexture == nil || vrm0Source.NormalTexture.TextureIndex != 13 || *vrm0Source.NormalScale != 0.5 {
+		t.Fatalf("vrm0 normal texture mismatch: %+v", vrm0Source.NormalTexture)
+	}
+	if vrm0Source.ShadeMultiplyTexture == nil || vrm0Source.ShadeMultiplyTexture.TextureIndex != 12 || len(vrm0Source.ShadeColorFactor) != 3 {
+		t.Fatalf("vrm0 shade texture mismatch: %+v factor=%v", vrm0Source.ShadeMultiplyTexture, vrm0Source.ShadeColorFactor)
+	}
+	// MToon0.x の境界 [0, 0.3] は MToon1.0 の shift=-0.15, toony=0.85 と一致する。
+	if vrm0Source.ShadingToonyFactor == nil || math.Abs(*vrm0Source.ShadingToonyFactor-0.85) > 1e-9 ||
+		vrm0Source.ShadingShiftFactor == nil || math.Abs(*vrm0Source.ShadingShiftFactor+0.15) > 1e-9 {
+		t.Fatalf("vrm0 shading factors should be converted to MToon1.0: shift=%v toony=%v", vrm0Source.ShadingShiftFactor, vrm0Source.ShadingToonyFactor)
+	}
+	if vrm0Source.EmissiveTexture == nil || vrm0Source.EmissiveTexture.TextureIndex != 11 {
+		t.Fatalf("vrm0 emissive texture mismatch: %+v", vrm0Source.EmissiveTexture)
+	}
+	if vrm0Source.OutlineWidthMultiplyTexture == nil || vrm0Source.OutlineWidthMultiplyTexture.TextureIndex != 10 || vrm0Source.OutlineWidthTextureChannel != "r" {
+		t.Fatalf("vrm0 outline texture mismatch: %+v channel=%s", vrm0Source.OutlineWidthMultiplyTexture, vrm0Source.OutlineWidthTextureChannel)
+	}
+	if vrm0Source.OutlineWidthFactor == nil || *vrm0Source.OutlineWidthFactor != 0.2 || vrm0Source.RimMultiplyTexture != nil {
+		t.Fatalf("vrm0 outline/rim mismatch: outline=%v rim=%+v", vrm0Source.OutlineWidthFactor, vrm0Source.RimMultiplyTexture)
+	}
+}
+
+func TestAppendPrimitiveMaterialSourceMemo(t *testing.T) {
+	if got := appendPrimitiveMaterialSourceMemo("VRM primitive alphaMode=OPAQUE", 3); got != "VRM primitive alphaMode=OPAQUE gltfMaterial=3" {
+		t.Fatalf("source memo mismatch: got=%s", got)
+	}
+	if got := appendPrimitiveMaterialSourceMemo("VRM primitive", -1); got != "VRM primitive" {
+		t.Fatalf("missing source should keep memo: got=%s", got)
+	}
+}
@@ -57,8 +57,22 @@ const (
 	// 互換レイヤーの観測期間中に、髪材質の新旧候補差分率がこの閾値を超えた場合はロールバック推奨警告を記録する。
 	legacySphereMigrationObservationMinSamples = 8
 	legacySphereMigrationRollbackThreshold     = 0.6
+
+	primitiveMaterialDefaultAlphaCutoff = 0.5
 )
 
+// vrmMeshOptions はメッシュ変換時の任意設定を表す。
+type vrmMeshOptions struct {
+	WeightReductionMode WeightReductionMode
+	VertexColorMode     VertexColorMode
+	ExtendedUvTexcoord1 bool
+	ExtendedUvTangent   bool
+	// MorphRules は表情補完に使うモーフ規則を表す。nil の場合は組み込み規則を使う。
+	MorphRules *morphRuleSet
+	// JawLipSyncAngles は顎フォールバック口パクの母音別開口角度を表す。nil の場合は既定値を使う。
+	JawLipSyncAngles *JawLipSyncAngles
+}
+
 // vrmConversion はVRM->PMX変換時の座標設定を表す。
 type vrmConversion struct {
 	Scale          float64
@@ -75,6 +89,12 @@ type accessorReadPlan struct {
 	BaseOffset    int
 	ViewStart     int
 	ViewEnd       int
+	// HasBase はbufferView由来の基底値を持つかを表す。false の場合は0埋めを基底とする。
+	HasBase bool
+	// SparseIndexes はsparse上書き対象の要素index一覧を表す。
+	SparseIndexes []int
+	// SparseValuesOffset はsparse上書き値の先頭オフセットを表す。
+	SparseValuesOffset int
 }
 
 // accessorValueCache はaccessor値の再読込みを抑止するキャッシュ。
@@ -114,6 +134,8 @@ type targetMorphRegistry struct {
 	ByMeshAndTarget map[meshTargetKey]int
 	ByGltfMaterial  map[int][]int
 	ByMaterialName  map[string][]int
+	// GeneratedToonRamps は材質変換で生成したtoonの陰影境界をtoonファイル名別に蓄積し、全 primitive 変換後にまとめて記録する。
+	GeneratedToonRamps map[string]generatedToonRamp
 }
 
 // vrm0MaterialPropertiesSource は VRM0 materialProperties の最小構造を表す。
@@ -135,8 +157,14 @@ type gltfMaterialMToonSource struct {
 	OutlineWidthFactor float64         `json:"outlineWidthFactor"`
 	OutlineColorFactor []float64       `json:"outlineColorFactor"`
 	ShadeColorFactor   []float64       `json:"shadeColorFactor"`
+	ShadingShiftFactor *float64        `json:"shadingShiftFactor"`
+	ShadingToonyFactor *float64        `json:"shadingToonyFactor"`
 	MatcapTexture      *gltfTextureRef `json:"matcapTexture"`
 	MatcapFactor       []float64       `json:"matcapFactor"`
+
+	ParametricRimColorFactor        []float64 `json:"parametricRimColorFactor"`
+	ParametricRimFresnelPowerFactor *float64  `json:"parametricRimFresnelPowerFactor"`
+	ParametricRimLiftFactor         *float64  `json:"parametricRimLiftFactor"`
 }
 
 // gltfMaterialEmissiveStrengthSource は KHR_materials_emissive_strength 拡張の最小構造を表す。
@@ -589,657 +617,6 @@ type createMorphStats struct {
 	SkippedNoOffset int
 }
 
-// createMorphFallbackRules は creates 対象を表す。
-var createMorphFallbackRules = []createMorphRule{
-	{
-		Name:    "下右",
-		Panel:   model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Type:    createMorphRuleTypeBrow,
-		Creates: []string{"FaceBrow"},
-	},
-	{
-		Name:    "下左",
-		Panel:   model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Type:    createMorphRuleTypeBrow,
-		Creates: []string{"FaceBrow"},
-	},
-	{
-		Name:    "上右",
-		Panel:   model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Type:    createMorphRuleTypeBrow,
-		Creates: []string{"FaceBrow"},
-	},
-	{
-		Name:    "上左",
-		Panel:   model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Type:    createMorphRuleTypeBrow,
-		Creates: []string{"FaceBrow"},
-	},
-	{
-		Name:    "右眉左",
-		Panel:   model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Type:    createMorphRuleTypeBrow,
-		Creates: []string{"FaceBrow"},
-	},
-	{
-		Name:    "左眉左",
-		Panel:   model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Type:    createMorphRuleTypeBrow,
-		Creates: []string{"FaceBrow"},
-	},
-	{
-		Name:    "右眉右",
-		Panel:   model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Type:    createMorphRuleTypeBrow,
-		Creates: []string{"FaceBrow"},
-	},
-	{
-		Name:    "左眉右",
-		Panel:   model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Type:    createMorphRuleTypeBrow,
-		Creates: []string{"FaceBrow"},
-	},
-	{
-		Name:    "右眉手前",
-		Panel:   model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Type:    createMorphRuleTypeBrow,
-		Creates: []string{"FaceBrow"},
-	},
-	{
-		Name:    "左眉手前",
-		Panel:   model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Type:    createMorphRuleTypeBrow,
-		Creates: []string{"FaceBrow"},
-	},
-	{
-		Name:    "瞳小右",
-		Panel:   model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Type:    createMorphRuleTypeEyeSmall,
-		Creates: []string{"EyeIris", "EyeHighlight"},
-	},
-	{
-		Name:    "瞳小左",
-		Panel:   model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Type:    createMorphRuleTypeEyeSmall,
-		Creates: []string{"EyeIris", "EyeHighlight"},
-	},
-	{
-		Name:    "瞳大右",
-		Panel:   model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Type:    createMorphRuleTypeEyeBig,
-		Creates: []string{"EyeIris", "EyeHighlight"},
-	},
-	{
-		Name:    "瞳大左",
-		Panel:   model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Type:    createMorphRuleTypeEyeBig,
-		Creates: []string{"EyeIris", "EyeHighlight"},
-	},
-	{
-		Name:    "目隠し頂点",
-		Panel:   model.MORPH_PANEL_SYSTEM,
-		Type:    createMorphRuleTypeEyeHideVertex,
-		Creates: []string{"EyeWhite"},
-		Hides:   []string{"Eyeline", "Eyelash"},
-	},
-}
-
-// expressionLinkRules は binds/split の表情連動規則を表す。
-var expressionLinkRules = []expressionLinkRule{
-	{
-		Name:  "にこり右",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "にこり",
-	},
-	{
-		Name:  "にこり左",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "にこり",
-	},
-	{
-		Name:  "にこり2右",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "にこり2",
-	},
-	{
-		Name:  "にこり2左",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "にこり2",
-	},
-	{
-		Name:  "困る右",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "困る",
-	},
-	{
-		Name:  "困る左",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "困る",
-	},
-	{
-		Name:  "怒り右",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "怒り",
-	},
-	{
-		Name:  "怒り左",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "怒り",
-	},
-	{
-		Name:  "驚き右",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "驚き",
-	},
-	{
-		Name:  "驚き左",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "驚き",
-	},
-	{
-		Name:  "下",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds: []string{"下右", "下左"},
-	},
-	{
-		Name:  "上",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds: []string{"上右", "上左"},
-	},
-	{
-		Name:  "眉左",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds: []string{"右眉左", "左眉左"},
-	},
-	{
-		Name:  "眉右",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds: []string{"右眉右", "左眉右"},
-	},
-	{
-		Name:  "眉手前",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds: []string{"右眉手前", "左眉手前"},
-	},
-	{
-		Name:   "真面目右",
-		Panel:  model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds:  []string{"怒り右", "下右"},
-		Ratios: []float64{0.25, 0.7},
-	},
-	{
-		Name:   "真面目左",
-		Panel:  model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds:  []string{"怒り左", "下左"},
-		Ratios: []float64{0.25, 0.7},
-	},
-	{
-		Name:   "真面目",
-		Panel:  model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds:  []string{"怒り右", "下右", "怒り左", "下左"},
-		Ratios: []float64{0.25, 0.7, 0.25, 0.7},
-	},
-	{
-		Name:   "ひそめ右",
-		Panel:  model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds:  []string{"怒り右", "困る右", "右眉右"},
-		Ratios: []float64{0.5, 0.5, 0.3},
-	},
-	{
-		Name:   "ひそめ左",
-		Panel:  model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds:  []string{"怒り左", "困る左", "左眉左"},
-		Ratios: []float64{0.5, 0.5, 0.3},
-	},
-	{
-		Name:   "ひそめ",
-		Panel:  model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds:  []string{"怒り右", "困る右", "右眉右", "怒り左", "困る左", "左眉左"},
-		Ratios: []float64{0.5, 0.5, 0.3, 0.5, 0.5, 0.3},
-	},
-	{
-		Name:  "ひそめる2右",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "ひそめる2",
-	},
-	{
-		Name:  "ひそめる2左",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Split: "ひそめる2",
-	},
-	{
-		Name:  "真面目2",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds: []string{"真面目2右", "真面目2左"},
-	},
-	{
-		Name:  "はんっ",
-		Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
-		Binds: []string{"はんっ右", "はんっ左"},
-	},
-	{
-		Name:  "びっくり右",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "びっくり",
-	},
-	{
-		Name:  "びっくり左",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "びっくり",
-	},
-	{
-		Name:  "瞳小",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"瞳小右", "瞳小左"},
-	},
-	{
-		Name:  "瞳大",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"瞳大右", "瞳大左"},
-	},
-	{
-		Name:   "ｳｨﾝｸ２右連動",
-		Panel:  model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds:  []string{"下右", "ｳｨﾝｸ２右", "瞳小右", "ｳｨﾝｸ２右ボーン", "右眉手前", "困る右"},
-		Ratios: []float64{0.2, 1.0, 0.3, 1.0, 0.1, 0.2},
-	},
-	{
-		Name:   "ウィンク２連動",
-		Panel:  model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds:  []string{"下左", "ウィンク２", "瞳小左", "ウィンク２ボーン", "左眉手前", "困る左"},
-		Ratios: []float64{0.2, 1.0, 0.3, 1.0, 0.1, 0.2},
-	},
-	{
-		Name:   "まばたき連動",
-		Panel:  model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds:  []string{"下右", "ｳｨﾝｸ２右", "瞳小右", "ｳｨﾝｸ２右ボーン", "右眉手前", "困る右", "下左", "ウィンク２", "瞳小左", "ウィンク２ボーン", "左眉手前", "困る左"},
-		Ratios: []float64{0.2, 1.0, 0.3, 1.0, 0.1, 0.2, 0.2, 1.0, 0.3, 1.0, 0.1, 0.2},
-	},
-	{
-		Name:   "ウィンク右連動",
-		Panel:  model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds:  []string{"下右", "ウィンク右", "瞳小右", "ウィンク右ボーン", "右眉手前", "にこり右"},
-		Ratios: []float64{0.5, 1.0, 0.3, 1.0, 0.1, 0.5},
-	},
-	{
-		Name:   "ウィンク連動",
-		Panel:  model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds:  []string{"下左", "ウィンク", "瞳小左", "ウィンクボーン", "左眉手前", "にこり左"},
-		Ratios: []float64{0.5, 1.0, 0.3, 1.0, 0.1, 0.5},
-	},
-	{
-		Name:   "笑い連動",
-		Panel:  model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds:  []string{"下右", "ウィンク右", "瞳小右", "ウィンク右ボーン", "右眉手前", "にこり右", "下左", "ウィンク", "瞳小左", "ウィンクボーン", "左眉手前", "にこり左"},
-		Ratios: []float64{0.5, 1.0, 0.3, 1.0, 0.1, 0.5, 0.5, 1.0, 0.3, 1.0, 0.1, 0.5},
-	},
-	{
-		Name:  "目を細める右",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "目を細める",
-	},
-	{
-		Name:  "目を細める左",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "目を細める",
-	},
-	{
-		Name:  "下瞼上げ右",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "目を細める右",
-	},
-	{
-		Name:  "下瞼上げ左",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "目を細める左",
-	},
-	{
-		Name:  "下瞼上げ",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"下瞼上げ右", "下瞼上げ左"},
-	},
-	{
-		Name:  "にんまり",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"にんまり右", "にんまり左"},
-	},
-	{
-		Name:  "ｷﾘｯ右",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "ｷﾘｯ",
-	},
-	{
-		Name:  "ｷﾘｯ左",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "ｷﾘｯ",
-	},
-	{
-		Name:  "ｷﾘｯ2",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"ｷﾘｯ2右", "ｷﾘｯ2左"},
-	},
-	{
-		Name:  "じと目右",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "じと目",
-	},
-	{
-		Name:  "じと目左",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "じと目",
-	},
-	{
-		Name:  "上瞼↑右",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "上瞼↑",
-	},
-	{
-		Name:  "上瞼↑左",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "上瞼↑",
-	},
-	{
-		Name:   "なぬ！右",
-		Panel:  model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds:  []string{"びっくり右", "ｷﾘｯ右"},
-		Ratios: []float64{1.0, 1.0},
-	},
-	{
-		Name:   "なぬ！左",
-		Panel:  model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds:  []string{"びっくり左", "ｷﾘｯ左"},
-		Ratios: []float64{1.0, 1.0},
-	},
-	{
-		Name:   "なぬ！",
-		Panel:  model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds:  []string{"びっくり右", "ｷﾘｯ右", "びっくり左", "ｷﾘｯ左"},
-		Ratios: []float64{1.0, 1.0, 1.0, 1.0},
-	},
-	{
-		Name:  "はぅ",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"はぅ材質", "目隠し頂点"},
-	},
-	{
-		Name:  "はちゅ目",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"はちゅ目材質", "目隠し頂点"},
-	},
-	{
-		Name:  "なごみ",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"なごみ材質", "目隠し頂点"},
-	},
-	{
-		Name:  "星目",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"目光なし", "星目材質"},
-	},
-	{
-		Name:  "はぁと",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"目光なし", "はぁと材質"},
-	},
-	{
-		Name:  "びっくり2",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"にんまり右", "にんまり左"},
-	},
-	{
-		Name:  "目上",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"目上右", "目上左"},
-	},
-	{
-		Name:  "目下",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"目下右", "目下左"},
-	},
-	{
-		Name:  "目頭広",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"目頭広右", "目頭広左"},
-	},
-	{
-		Name:  "目尻広",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"目尻広左", "目尻広右"},
-	},
-	{
-		Name:  "瞳小2",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"瞳小2右", "瞳小2左"},
-	},
-	{
-		Name:  "下瞼上げ2",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"下瞼上げ2右", "下瞼上げ2左"},
-	},
-	{
-		Name:  "白目右",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "白目",
-	},
-	{
-		Name:  "白目左",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "白目",
-	},
-	{
-		Name:  "目光なし右",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "目光なし",
-	},
-	{
-		Name:  "目光なし左",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Split: "目光なし",
-	},
-	{
-		Name:  "あ",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"あ頂点", "あボーン"},
-	},
-	{
-		Name:  "い",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"い頂点", "いボーン"},
-	},
-	{
-		Name:  "う",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"う頂点", "うボーン"},
-	},
-	{
-		Name:  "え",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"え頂点", "えボーン"},
-	},
-	{
-		Name:  "お",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"お頂点", "おボーン"},
-	},
-	{
-		Name:  "Λ右",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Split: "Λ",
-	},
-	{
-		Name:  "Λ左",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Split: "Λ",
-	},
-	{
-		Name:   "口角下げ右",
-		Panel:  model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds:  []string{"Λ右", "口横広げ"},
-		Ratios: []float64{1.0, 0.5},
-	},
-	{
-		Name:   "口角下げ左",
-		Panel:  model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds:  []string{"Λ左", "口横広げ"},
-		Ratios: []float64{1.0, 0.5},
-	},
-	{
-		Name:   "口角下げ",
-		Panel:  model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds:  []string{"Λ", "口横広げ"},
-		Ratios: []float64{1.0, 0.5},
-	},
-	{
-		Name:  "にっこり右",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Split: "にっこり",
-	},
-	{
-		Name:  "にっこり左",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Split: "にっこり",
-	},
-	{
-		Name:   "にこ右",
-		Panel:  model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds:  []string{"にっこり右", "口横広げ"},
-		Ratios: []float64{1.0, -0.3},
-	},
-	{
-		Name:   "にこ左",
-		Panel:  model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds:  []string{"にっこり左", "口横広げ"},
-		Ratios: []float64{1.0, -0.3},
-	},
-	{
-		Name:   "にこ",
-		Panel:  model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds:  []string{"にっこり右", "にっこり左", "口横広げ"},
-		Ratios: []float64{0.5, 0.5, -0.3},
-	},
-	{
-		Name:  "ワ",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"ワ頂点", "ワボーン"},
-	},
-	{
-		Name:  "▲",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"▲頂点", "▲ボーン"},
-	},
-	{
-		Name:  "わー",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"わー頂点", "わーボーン"},
-	},
-	{
-		Name:   "べー",
-		Panel:  model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds:  []string{"あ頂点", "い頂点", "べーボーン"},
-		Ratios: []float64{0.12, 0.56, 1.0},
-	},
-	{
-		Name:   "ぺろり",
-		Panel:  model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds:  []string{"あ頂点", "にっこり", "ぺろりボーン"},
-		Ratios: []float64{0.12, 0.54, 1.0},
-	},
-	{
-		Name:  "mouthRoll",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"上唇んむー", "下唇んむー"},
-	},
-	{
-		Name:  "むむ",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"上唇むむ", "下唇むむ"},
-	},
-	{
-		Name:  "口幅広",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"口幅広右", "口幅広左"},
-	},
-	{
-		Name:  "薄笑い",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"薄笑い右", "薄笑い左"},
-	},
-	{
-		Name:  "にやり2",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"にやり2右", "にやり2左"},
-	},
-	{
-		Name:  "にひ",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"にひ右", "口幅広左"},
-	},
-	{
-		Name:  "にひひ",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"にひひ右", "にひひ左"},
-	},
-	{
-		Name:  "ちっ",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"ちっ右", "ちっ左"},
-	},
-	{
-		Name:  "むっ",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"むっ右", "むっ左"},
-	},
-	{
-		Name:  "ぎりっ",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Binds: []string{"ぎりっ右", "ぎりっ左"},
-	},
-	{
-		Name:  "ぷくー右",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Split: "ぷくー",
-	},
-	{
-		Name:  "ぷくー左",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Split: "ぷくー",
-	},
-	{
-		Name:  "牙上右",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Split: "牙上",
-	},
-	{
-		Name:  "牙上左",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Split: "牙上",
-	},
-	{
-		Name:  "牙下右",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Split: "牙下",
-	},
-	{
-		Name:  "牙下左",
-		Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT,
-		Split: "牙下",
-	},
-}
-
-// expressionSidePairGroupFallbackRules は左右のみ存在する場合に親グループを補完する規則を表す。
-var expressionSidePairGroupFallbackRules = []expressionSidePairGroupFallbackRule{
-	{
-		Name:  "白目",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"白目右", "白目左"},
-	},
-	{
-		Name:  "目光なし",
-		Panel: model.MORPH_PANEL_EYE_UPPER_LEFT,
-		Binds: []string{"目光なし右", "目光なし左"},
-	},
-}
-
 // buildVrmConversion はVRMプロファイルに応じた座標変換設定を返す。
 func buildVrmConversion(vrmData *vrm.VrmData) vrmConversion {
 	conversion := vrmConversion{
@@ -1281,6 +658,7 @@ func appendMeshData(
 	binChunk []byte,
 	nodeToBoneIndex map[int]int,
 	conversion vrmConversion,
+	meshOptions vrmMeshOptions,
 	progressReporter func(LoadProgressEvent),
 ) (*targetMorphRegistry, error) {
 	if modelData == nil || doc == nil || len(doc.Meshes) == 0 {
@@ -1289,17 +667,21 @@ func appendMeshData(
 
 	textureIndexesByImage := appendImageTextures(modelData, doc.Images)
 	appendEmbeddedSpecialEyeTextures(modelData)
+	appendMaterialCompanionSources(modelData, doc, textureIndexesByImage)
 	totalPrimitives := countGltfPrimitives(doc.Meshes)
 	cache := newAccessorValueCache()
 	targetMorphRegistry := newTargetMorphRegistry()
 	primitiveStep := 0
 	meshUniquePrimitiveIndex := map[int]map[string]int{}
+	firstPersonAnnotations := resolveVrmFirstPersonAnnotations(doc)
+	vertexColorBakes := map[int]vertexColorBakeMetadata{}
 	logVrmInfo(
-		"VRMメッシュ変換開始: nodes=%d meshes=%d primitives=%d textures=%d",
+		"VRMメッシュ変換開始: nodes=%d meshes=%d primitives=%d textures=%d firstPersonAnnotations=%d",
 		len(doc.Nodes),
 		len(doc.Meshes),
 		totalPrimitives,
 		len(textureIndexesByImage),
+		firstPersonAnnotations.count(),
 	)
 
 	for nodeIndex, node := range doc.Nodes {
@@ -1370,11 +752,16 @@ func appendMeshData(
 				nodeToBoneIndex,
 				textureIndexesByImage,
 				conversion,
+				meshOptions,
 				cache,
 				targetMorphRegistry,
+				vertexColorBakes,
 			); err != nil {
 				return targetMorphRegistry, err
 			}
+			if annotationType, ok := firstPersonAnnotations.resolve(nodeIndex, meshIndex); ok {
+				appendFirstPersonMaterialMemo(modelData, beforeMaterialCount, annotationType)
+			}
 			logVrmDebug(
 				"VRMプリミティブ変換完了: step=%d/%d node=%d mesh=%d primitive=%d name=%s addVertices=%d addFaces=%d addMaterials=%d",
 				primitiveStep,
@@ -1396,6 +783,11 @@ func appendMeshData(
 			}
 		}
 	}
+	if meshOptions.hasExtendedUvOutput() {
+		padVertexExtendedUvs(modelData)
+	}
+	storeVertexColorBakeMetadata(modelData, vertexColorBakes)
+	storeGeneratedToonRamps(modelData, targetMorphRegistry.GeneratedToonRamps)
 	logVrmInfo(
 		"VRMメッシュ変換完了: vertices=%d faces=%d materials=%d textures=%d",
 		modelData.Vertices.Len(),
@@ -1611,8 +1003,10 @@ func appendPrimitiveMeshData(
 	nodeToBoneIndex map[int]int,
 	textureIndexesByImage []int,
 	conversion vrmConversion,
+	meshOptions vrmMeshOptions,
 	cache *accessorValueCache,
 	targetMorphRegistry *targetMorphRegistry,
+	vertexColorBakes map[int]vertexColorBakeMetadata,
 ) error {
 	positionAccessor, ok := primitive.Attributes["POSITION"]
 	if !ok {
@@ -1641,6 +1035,17 @@ func appendPrimitiveMeshData(
 	if err != nil {
 		return err
 	}
+	uvTransform := resolvePrimitiveUvTransform(modelData, doc, primitive, primitiveName)
+	uvs = resolvePrimitiveTransformSourceUvs(
+		modelData,
+		doc,
+		primitive,
+		primitiveName,
+		binChunk,
+		cache,
+		uvs,
+		&uvTransform,
+	)
 	joints, err := readOptionalIntAttribute(doc, primitive.Attributes, "JOINTS_0", binChunk, cache)
 	if err != nil {
 		return err
@@ -1649,6 +1054,28 @@ func appendPrimitiveMeshData(
 	if err != nil {
 		return err
 	}
+	extraJoints, err := readOptionalIntAttribute(doc, primitive.Attributes, "JOINTS_1", binChunk, cache)
+	if err != nil {
+		return err
+	}
+	extraWeights, err := readOptionalFloatAttribute(doc, primitive.Attributes, "WEIGHTS_1", binChunk, cache)
+	if err != nil {
+		return err
+	}
+	joints, weights = mergeVertexInfluenceSets(joints, weights, extraJoints, extraWeights)
+	var vertexColors [][4]float64
+	if meshOptions.VertexColorMode == VertexColorModeTexture || meshOptions.VertexColorMode == VertexColorModeExtendedUv {
+		vertexColors, err = readPrimitiveVertexColors(doc, primitive, binChunk, cache)
+		if err != nil {
+			logVrmWarn(
+				"VRM頂点カラーの読み取りに失敗したため頂点カラーなしで継続します: node=%d primitive=%s err=%s",
+				nodeIndex,
+				primitiveName,
+				err.Error(),
+			)
+			vertexColors = nil
+		}
+	}
 
 	indices, err := readPrimitiveIndices(doc, primitive, len(positions), binChunk, cache)
 	if err != nil {
@@ -1672,10 +1099,12 @@ func appendPrimitiveMeshData(
 		positions,
 		normals,
 		uvs,
+		uvTransform,
 		joints,
 		weights,
 		nodeToBoneIndex,
 		conversion,
+		meshOptions,
 		cache,
 	)
 	if appendedVertices == 0 {
@@ -1711,6 +1140,27 @@ func appendPrimitiveMeshData(
 		targetMorphRegistry,
 		buildLegacyHairAssignmentContext(modelData, doc, node, joints, weights, nodeToBoneIndex),
 	)
+	applyPrimitiveVertexColors(
+		modelData,
+		meshOptions,
+		vertexStart,
+		len(positions),
+		vertexColors,
+		materialIndex,
+		vertexColorBakes,
+	)
+	applyPrimitiveExtendedUvAttributes(
+		modelData,
+		doc,
+		primitive,
+		primitiveName,
+		binChunk,
+		cache,
+		conversion,
+		meshOptions,
+		vertexStart,
+		len(positions),
+	)
 	for _, tri := range triangles {
 		if tri[0] < 0 || tri[1] < 0 || tri[2] < 0 {
 			continue
@@ -1743,6 +1193,8 @@ func newTargetMorphRegistry() *targetMorphRegistry {
 		ByMeshAndTarget: map[meshTargetKey]int{},
 		ByGltfMaterial:  map[int][]int{},
 		ByMaterialName:  map[string][]int{},
+
+		GeneratedToonRamps: map[string]generatedToonRamp{},
 	}
 }
 
@@ -1885,35 +1337,59 @@ func isZeroMorphDelta(v mmath.Vec3) bool {
 }
 
 // appendExpressionMorphsFromVrmDefinition は VRM 定義表情を PMX モーフへ反映する。
+// VRM 拡張を持たない glTF でも、primitive の morph target からの補完と各種フォールバックは適用する。
 func appendExpressionMorphsFromVrmDefinition(
 	modelData *model.PmxModel,
 	doc *gltfDocument,
 	registry *targetMorphRegistry,
+	nodeToBoneIndex map[int]int,
+	meshOptions vrmMeshOptions,
 ) {
-	if modelData == nil || modelData.Morphs == nil || doc == nil || registry == nil || doc.Extensions == nil {
+	if modelData == nil || modelData.Morphs == nil || doc == nil || registry == nil {
 		return
 	}
-	loaded := false
+	ruleSet := meshOptions.MorphRules
+	if ruleSet == nil {
+		ruleSet = defaultMorphRuleSet
+	}
+	sourceTracker := newMorphSourceTracker()
+	sourceTracker.mark(modelData, MorphSourcePrimitiveTarget)
+	if applyVrmExpressionDefinitions(modelData, doc, registry) {
+		sourceTracker.mark(modelData, MorphSourceVrmExpression)
+	}
+	appendCanonicalMorphsFromPrimitiveTargets(modelData)
+	sourceTracker.mark(modelData, MorphSourcePrimitiveTarget)
+	appendArkitBlendShapeMorphs(modelData, ruleSet.Arkit)
+	sourceTracker.mark(modelData, MorphSourceArkitSynthesis)
+	appendSpecialEyeMaterialMorphsFromFallbackRules(modelData, doc, registry)
+	sourceTracker.mark(modelData, MorphSourceSpecialEye)
+	appendCreateMorphsFromFallbackRules(modelData, registry, ruleSet.Create)
+	sourceTracker.mark(modelData, MorphSourceCreateRule)
+	appendExpressionEdgeFallbackMorph(modelData)
+	sourceTracker.mark(modelData, MorphSourceEdgeFallback)
+	appendExpressionBoneFallbackMorphs(modelData, ruleSet.BoneFallback)
+	sourceTracker.mark(modelData, MorphSourceBoneFallback)
+	appendJawLipSyncFallbackMorphs(modelData, nodeToBoneIndex, meshOptions.JawLipSyncAngles)
+	sourceTracker.mark(modelData, MorphSourceJawFallback)
+	appendExpressionLinkRules(modelData, ruleSet)
+	sourceTracker.mark(modelData, MorphSourceLinkRule)
+	sourceTracker.store(modelData)
+}
+
+// applyVrmExpressionDefinitions は VRM1 expressions / VRM0 blendShapeMaster を反映し、定義を読み込んだかを返す。
+func applyVrmExpressionDefinitions(modelData *model.PmxModel, doc *gltfDocument, registry *targetMorphRegistry) bool {
+	if doc == nil || doc.Extensions == nil {
+		return false
+	}
 	if raw, exists := doc.Extensions["VRMC_vrm"]; exists {
 		applyVrm1ExpressionMorphs(modelData, raw, registry)
-		loaded = true
+		return true
 	}
-	if !loaded {
-		raw, exists := doc.Extensions["VRM"]
-		if !exists {
-			return
-		}
+	if raw, exists := doc.Extensions["VRM"]; exists {
 		applyVrm0BlendShapeMorphs(modelData, raw, registry)
-		loaded = true
-	}
-	if loaded {
-		appendCanonicalMorphsFromPrimitiveTargets(modelData)
-		appendSpecialEyeMaterialMorphsFromFallbackRules(modelData, doc, registry)
-		appendCreateMorphsFromFallbackRules(modelData, registry)
-		appendExpressionEdgeFallbackMorph(modelData)
-		appendExpressionBoneFallbackMorphs(modelData)
-		appendExpressionLinkRules(modelData)
+		return true
 	}
+	return false
 }
 
 // appendCanonicalMorphsFromPrimitiveTargets は内部ターゲット頂点モーフから正規名モーフを補完する。
@@ -2620,7 +2096,7 @@ func ensureVertexExtendedUv1(modelData *model.PmxModel, vertexIndex int) {
 	if err != nil || vertex == nil {
 		return
 	}
-	if len(vertex.ExtendedUvs) >= 1 {
+	if len(vertex.ExtendedUvs) > extendedUvSlotUvMorph {
 		return
 	}
 	vertex.ExtendedUvs = append(vertex.ExtendedUvs, mmath.ZERO_VEC4)
@@ -3884,117 +3360,6 @@ type expressionBoneMorphFallbackRule struct {
 	Offsets []expressionBoneOffsetRule
 }
 
-// expressionBoneFallbackRules は旧参考実装のボーンモーフ初期値を表す。
-var expressionBoneFallbackRules = []expressionBoneMorphFallbackRule{
-	{
-		Name: "ｳｨﾝｸ２右ボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticRightEyeLight, 0, 0, -0.015, -12, 0, 0),
-		},
-	},
-	{
-		Name: "ウィンク２ボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticLeftEyeLight, 0, 0, -0.015, -12, 0, 0),
-		},
-	},
-	{
-		Name: "ウィンク右ボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticRightEyeLight, 0, 0, 0.025, 8, 0, 0),
-		},
-	},
-	{
-		Name: "ウィンクボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticLeftEyeLight, 0, 0, 0.025, 8, 0, 0),
-		},
-	},
-	{
-		Name: "あボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue1, 0, 0, 0, -16, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue2, 0, 0, 0, -16, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue3, 0, 0, 0, -10, 0, 0),
-		},
-	},
-	{
-		Name: "いボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue1, 0, 0, 0, -6, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue2, 0, 0, 0, -6, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue3, 0, 0, 0, -3, 0, 0),
-		},
-	},
-	{
-		Name: "うボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue1, 0, 0, 0, -16, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue2, 0, 0, 0, -16, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue3, 0, 0, 0, -10, 0, 0),
-		},
-	},
-	{
-		Name: "えボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue1, 0, 0, 0, -6, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue2, 0, 0, 0, -6, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue3, 0, 0, 0, -3, 0, 0),
-		},
-	},
-	{
-		Name: "おボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue1, 0, 0, 0, -20, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue2, 0, 0, 0, -18, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue3, 0, 0, 0, -12, 0, 0),
-		},
-	},
-	{
-		Name: "ワボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue1, 0, 0, 0, -24, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue2, 0, 0, 0, -24, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue3, 0, 0, 0, 16, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue4, 0, 0, 0, 28, 0, 0),
-		},
-	},
-	{
-		Name: "▲ボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue1, 0, 0, 0, -6, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue2, 0, 0, 0, -6, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue3, 0, 0, 0, -3, 0, 0),
-		},
-	},
-	{
-		Name: "わーボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue1, 0, 0, 0, -24, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue2, 0, 0, 0, -24, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue3, 0, 0, 0, 16, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue4, 0, 0, 0, 28, 0, 0),
-		},
-	},
-	{
-		Name: "べーボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue1, 0, 0, 0, -9, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue2, 0, 0, -0.24, -13.2, 0, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue3, 0, 0, 0, -23.2, 0, 0),
-		},
-	},
-	{
-		Name: "ぺろりボーン",
-		Offsets: []expressionBoneOffsetRule{
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue1, 0, 0, 0, 0, -5, 0),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue2, 0, -0.03, -0.18, 33, -16, -4),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue3, 0, 0, 0, 15, 3.6, -1),
-			newExpressionBoneOffsetRule(expressionBoneSemanticTongue4, 0, 0, 0, 20, 0, 0),
-		},
-	},
-}
-
 // newExpressionBoneOffsetRule はボーンモーフ補完オフセット規則を生成する。
 func newExpressionBoneOffsetRule(
 	semantic string,
@@ -4013,14 +3378,14 @@ func newExpressionBoneOffsetRule(
 }
 
 // appendExpressionBoneFallbackMorphs は連動規則で参照するボーンモーフの不足分を実値で補完する。
-func appendExpressionBoneFallbackMorphs(modelData *model.PmxModel) {
+func appendExpressionBoneFallbackMorphs(modelData *model.PmxModel, rules []expressionBoneMorphFallbackRule) {
 	if modelData == nil || modelData.Morphs == nil || modelData.Bones == nil || modelData.Bones.Len() == 0 {
 		return
 	}
 	generated := 0
 	updated := 0
 	skippedNoBone := 0
-	for _, rule := range expressionBoneFallbackRules {
+	for _, rule := range rules {
 		if strings.TrimSpace(rule.Name) == "" {
 			continue
 		}
@@ -4363,11 +3728,15 @@ func normalizeMorphBindWeight(weight float64, isBinary bool) float64 {
 }
 
 // appendCreateMorphsFromFallbackRules は creates 規則に基づく頂点モーフを生成する。
-func appendCreateMorphsFromFallbackRules(modelData *model.PmxModel, registry *targetMorphRegistry) {
+func appendCreateMorphsFromFallbackRules(
+	modelData *model.PmxModel,
+	registry *targetMorphRegistry,
+	rules []createMorphRule,
+) {
 	if modelData == nil || modelData.Morphs == nil || modelData.Vertices == nil {
 		return
 	}
-	stats := createMorphStats{RuleCount: len(createMorphFallbackRules)}
+	stats := createMorphStats{RuleCount: len(rules)}
 	logVrmInfo("createsモーフ生成開始: rules=%d", stats.RuleCount)
 
 	materialVertexMap := buildMaterialVertexIndexMap(modelData)
@@ -4380,7 +3749,7 @@ func appendCreateMorphsFromFallbackRules(modelData *model.PmxModel, registry *ta
 		closeOffsets,
 	)
 
-	for _, rule := range createMorphFallbackRules {
+	for _, rule := range rules {
 		existing, err := modelData.Morphs.GetByName(rule.Name)
 		if err == nil && existing != nil && len(existing.Offsets) > 0 {
 			stats.SkippedExisting++
@@ -4435,17 +3804,17 @@ func appendCreateMorphsFromFallbackRules(modelData *model.PmxModel, registry *ta
 }
 
 // appendExpressionLinkRules は binds/split の表情連動規則を適用する。
-func appendExpressionLinkRules(modelData *model.PmxModel) {
-	if modelData == nil || modelData.Morphs == nil || modelData.Vertices == nil {
+func appendExpressionLinkRules(modelData *model.PmxModel, ruleSet *morphRuleSet) {
+	if modelData == nil || modelData.Morphs == nil || modelData.Vertices == nil || ruleSet == nil {
 		return
 	}
-	if len(expressionLinkRules) == 0 {
+	if len(ruleSet.Link) == 0 {
 		return
 	}
-	pairFallbackApplied := appendExpressionSidePairGroupFallbacks(modelData)
+	pairFallbackApplied := appendExpressionSidePairGroupFallbacks(modelData, ruleSet.SidePairGroup)
 	bindApplied := 0
 	splitApplied := 0
-	for _, rule := range expressionLinkRules {
+	for _, rule := range ruleSet.Link {
 		if len(rule.Binds) > 0 {
 			if applyExpressionBindRule(modelData, rule) {
 				bindApplied++
@@ -4461,7 +3830,7 @@ func appendExpressionLinkRules(modelData *model.PmxModel) {
 	}
 	logVrmInfo(
 		"表情連動規則適用完了: rules=%d pairFallbackApplied=%d bindsApplied=%d splitApplied=%d",
-		len(expressionLinkRules),
+		len(ruleSet.Link),
 		pairFallbackApplied,
 		bindApplied,
 		splitApplied,
@@ -4469,12 +3838,15 @@ func appendExpressionLinkRules(modelData *model.PmxModel) {
 }
 
 // appendExpressionSidePairGroupFallbacks は左右モーフから親グループを補完する。
-func appendExpressionSidePairGroupFallbacks(modelData *model.PmxModel) int {
+func appendExpressionSidePairGroupFallbacks(
+	modelData *model.PmxModel,
+	rules []expressionSidePairGroupFallbackRule,
+) int {
 	if modelData == nil || modelData.Morphs == nil {
 		return 0
 	}
 	applied := 0
-	for _, rule := range expressionSidePairGroupFallbackRules {
+	for _, rule := range rules {
 		if applyExpressionSidePairGroupFallbackRule(modelData, rule) {
 			applied++
 		}
@@ -6714,13 +6086,16 @@ func appendOrReusePrimitiveVertices(
 	positions [][]float64,
 	normals [][]float64,
 	uvs [][]float64,
+	uvTransform primitiveUvTransform,
 	joints [][]int,
 	weights [][]float64,
 	nodeToBoneIndex map[int]int,
 	conversion vrmConversion,
+	meshOptions vrmMeshOptions,
 	cache *accessorValueCache,
 ) (int, int) {
-	vertexKey := primitiveVertexKey(nodeIndex, primitive)
+	// UV変換が異なる材質は頂点を共有できないため、変換ごとに頂点範囲を分ける。
+	vertexKey := primitiveVertexKey(nodeIndex, primitive) + uvTransform.key()
 	if cachedStart, ok := cache.getVertexRange(vertexKey, len(positions)); ok {
 		return cachedStart, 0
 	}
@@ -6728,6 +6103,7 @@ func appendOrReusePrimitiveVertices(
 	defaultBoneIndex := resolveDefaultBoneIndex(modelData, nodeToBoneIndex, nodeIndex)
 	skinJoints := resolveSkinJoints(doc, node)
 	vertexStart := modelData.Vertices.Len()
+	truncatedCount := 0
 	for vertexIndex := 0; vertexIndex < len(positions); vertexIndex++ {
 		position := toVec3(positions[vertexIndex], mmath.ZERO_VEC3)
 		normal := mmath.Vec3{Vec: r3.Vec{X: 0, Y: 1, Z: 0}}
@@ -6736,7 +6112,7 @@ func appendOrReusePrimitiveVertices(
 		}
 		uv := mmath.ZERO_VEC2
 		if vertexIndex < len(uvs) {
-			uv = toVec2(uvs[vertexIndex], mmath.ZERO_VEC2)
+			uv = uvTransform.apply(toVec2(uvs[vertexIndex], mmath.ZERO_VEC2))
 		}
 
 		jointValues := []int{}
@@ -6747,7 +6123,18 @@ func appendOrReusePrimitiveVertices(
 		if vertexIndex < len(weights) {
 			weightValues = weights[vertexIndex]
 		}
-		deform := buildVertexDeform(defaultBoneIndex, jointValues, weightValues, skinJoints, nodeToBoneIndex)
+		deform, truncated := buildVertexDeform(
+			modelData,
+			defaultBoneIndex,
+			jointValues,
+			weightValues,
+			skinJoints,
+			nodeToBoneIndex,
+			meshOptions.WeightReductionMode,
+		)
+		if truncated {
+			truncatedCount++
+		}
 		vertex := &model.Vertex{
 			Position:        convertVrmPositionToPmx(position, conversion),
 			Normal:          convertVrmNormalToPmx(normal, conversion),
@@ -6761,6 +6148,16 @@ func appendOrReusePrimitiveVertices(
 		modelData.Vertices.AppendRaw(vertex)
 	}
 	cache.setVertexRange(vertexKey, vertexStart, len(positions))
+	if truncatedCount > 0 {
+		recordLegacyMaterialWarning(
+			modelData,
+			warningid.VrmWarningWeightsTruncated,
+			"node=%d vertices=%d mode=%s",
+			nodeIndex,
+			truncatedCount,
+			resolveWeightReductionMode(meshOptions.WeightReductionMode),
+		)
+	}
 	return vertexStart, len(positions)
 }
 
@@ -6799,14 +6196,60 @@ func resolveSkinJoints(doc *gltfDocument, node gltfNode) []int {
 	return doc.Skins[skinIndex].Joints
 }
 
+// mergeVertexInfluenceSets はJOINTS_0/WEIGHTS_0へJOINTS_1/WEIGHTS_1を頂点単位で連結する。
+func mergeVertexInfluenceSets(
+	joints [][]int,
+	weights [][]float64,
+	extraJoints [][]int,
+	extraWeights [][]float64,
+) ([][]int, [][]float64) {
+	if len(extraJoints) == 0 || len(extraWeights) == 0 {
+		return joints, weights
+	}
+	vertexCount := len(extraJoints)
+	if len(extraWeights) < vertexCount {
+		vertexCount = len(extraWeights)
+	}
+	mergedJoints := make([][]int, max(len(joints), vertexCount))
+	mergedWeights := make([][]float64, len(mergedJoints))
+	for vertexIndex := range mergedJoints {
+		jointValues := []int{}
+		weightValues := []float64{}
+		if vertexIndex < len(joints) && vertexIndex < len(weights) {
+			// 0系は joints/weights の短い方に揃え、1系の対応がずれないようにする。
+			count := min(len(joints[vertexIndex]), len(weights[vertexIndex]))
+			jointValues = append(jointValues, joints[vertexIndex][:count]...)
+			weightValues = append(weightValues, weights[vertexIndex][:count]...)
+		}
+		if vertexIndex < vertexCount {
+			jointValues = append(jointValues, extraJoints[vertexIndex]...)
+			weightValues = append(weightValues, extraWeights[vertexIndex]...)
+		}
+		mergedJoints[vertexIndex] = jointValues
+		mergedWeights[vertexIndex] = weightValues
+	}
+	return mergedJoints, mergedWeights
+}
+
+// resolveWeightReductionMode は未指定/不明な縮約方法を既定値へ寄せる。
+func resolveWeightReductionMode(mode WeightReductionMode) WeightReductionMode {
+	if mode == WeightReductionModeRedistributeToAncestor {
+		return mode
+	}
+	return WeightReductionModeDropSmallest
+}
+
 // buildVertexDeform はJOINTS/WEIGHTSからPMXデフォームを生成する。
+// 5本以上の影響ボーンを4本へ縮約した場合は true を返す。
 func buildVertexDeform(
+	modelData *model.PmxModel,
 	defaultBoneIndex int,
 	joints []int,
 	weights []float64,
 	skinJoints []int,
 	nodeToBoneIndex map[int]int,
-) model.IDeform {
+	reductionMode WeightReductionMode,
+) (model.IDeform, bool) {
 	weightByBone := map[int]float64{}
 	maxCount := len(joints)
 	if len(weights) < maxCount {
@@ -6837,7 +6280,7 @@ func buildVertexDeform(
 	}
 
 	if len(weightByBone) == 0 {
-		return model.NewBdef1(defaultBoneIndex)
+		return model.NewBdef1(defaultBoneIndex), false
 	}
 
 	weightedBones := make([]weightedBone, 0, len(weightByBone))
@@ -6853,7 +6296,7 @@ func buildVertexDeform(
 		totalWeight += weight
 	}
 	if totalWeight <= 0 || len(weightedBones) == 0 {
-		return model.NewBdef1(defaultBoneIndex)
+		return model.NewBdef1(defaultBoneIndex), false
 	}
 
 	sort.Slice(weightedBones, func(i int, j int) bool {
@@ -6864,23 +6307,25 @@ func buildVertexDeform(
 	})
 
 	if len(weightedBones) == 1 {
-		return model.NewBdef1(weightedBones[0].BoneIndex)
+		return model.NewBdef1(weightedBones[0].BoneIndex), false
 	}
 
 	if len(weightedBones) == 2 {
 		weight0 := weightedBones[0].Weight / (weightedBones[0].Weight + weightedBones[1].Weight)
-		return model.NewBdef2(weightedBones[0].BoneIndex, weightedBones[1].BoneIndex, weight0)
+		return model.NewBdef2(weightedBones[0].BoneIndex, weightedBones[1].BoneIndex, weight0), false
 	}
 
+	truncated := false
 	if len(weightedBones) > 4 {
-		weightedBones = weightedBones[:4]
+		truncated = true
+		weightedBones = reduceWeightedBones(modelData, weightedBones, 4, reductionMode)
 	}
 	totalTopWeight := 0.0
 	for _, wb := range weightedBones {
 		totalTopWeight += wb.Weight
 	}
 	if totalTopWeight <= 0 {
-		return model.NewBdef1(defaultBoneIndex)
+		return model.NewBdef1(defaultBoneIndex), false
 	}
 
 	indexes := [4]int{defaultBoneIndex, defaultBoneIndex, defaultBoneIndex, defaultBoneIndex}
@@ -6889,7 +6334,60 @@ func buildVertexDeform(
 		indexes[i] = weightedBones[i].BoneIndex
 		values[i] = weightedBones[i].Weight / totalTopWeight
 	}
-	return model.NewBdef4(indexes, values)
+	return model.NewBdef4(indexes, values), truncated
+}
+
+// reduceWeightedBones は降順整列済みの影響ボーンを上限本数へ縮約する。
+// 祖先再配分モードでは、切り捨てたウェイトを残存ボーンのうち最寄りの祖先へ加算する。
+func reduceWeightedBones(
+	modelData *model.PmxModel,
+	weightedBones []weightedBone,
+	limit int,
+	reductionMode WeightReductionMode,
+) []weightedBone {
+	if len(weightedBones) <= limit {
+		return weightedBones
+	}
+	kept := append([]weightedBone(nil), weightedBones[:limit]...)
+	if resolveWeightReductionMode(reductionMode) != WeightReductionModeRedistributeToAncestor {
+		return kept
+	}
+	if modelData == nil || modelData.Bones == nil {
+		return kept
+	}
+	keptPositions := make(map[int]int, len(kept))
+	for i, wb := range kept {
+		keptPositions[wb.BoneIndex] = i
+	}
+	for _, dropped := range weightedBones[limit:] {
+		ancestorIndex := findNearestKeptAncestorBone(modelData.Bones, dropped.BoneIndex, keptPositions)
+		if ancestorIndex < 0 {
+			continue
+		}
+		kept[keptPositions[ancestorIndex]].Weight += dropped.Weight
+	}
+	return kept
+}
+
+// findNearestKeptAncestorBone は残存ボーン集合に含まれる最寄りの祖先ボーンindexを返す。
+func findNearestKeptAncestorBone(bones *model.BoneCollection, boneIndex int, keptPositions map[int]int) int {
+	visited := map[int]struct{}{}
+	current := boneIndex
+	for current >= 0 {
+		if _, exists := visited[current]; exists {
+			return -1
+		}
+		visited[current] = struct{}{}
+		bone, err := bones.Get(current)
+		if err != nil || bone == nil {
+			return -1
+		}
+		current = bone.ParentIndex
+		if _, exists := keptPositions[current]; exists {
+			return current
+		}
+	}
+	return -1
 }
 
 // legacyHairAssignmentContext は髪判定に使うボーン割当情報を表す。
@@ -7113,6 +6611,10 @@ func appendPrimitiveMaterial(
 	}
 	edgeEnabled, edgeDecisionReason = shouldEnablePrimitiveMaterialEdge(edgeSourceState, material.EdgeSize)
 	material.Memo = buildPrimitiveMaterialMemo(alphaMode, memoOutline, edgeSourceState, edgeEnabled, edgeDecisionReason)
+	material.Memo = appendPrimitiveMaterialAlphaCutoffMemo(material.Memo, alphaMode, sourceMaterial.AlphaCutoff)
+	if hasSourceMaterial {
+		material.Memo = appendPrimitiveMaterialSourceMemo(material.Memo, sourceMaterialIndex)
+	}
 	if edgeEnabled {
 		material.DrawFlag |= model.DRAW_FLAG_DRAWING_EDGE
 	} else {
@@ -7146,6 +6648,17 @@ func appendPrimitiveMaterial(
 			material,
 			legacySphereContext,
 		)
+	} else {
+		applyStandardMToonMaterialConversion(
+			modelData,
+			doc,
+			sourceMaterial,
+			sourceMaterialIndex,
+			hasSourceMaterial,
+			textureIndexesByImage,
+			material,
+			registry,
+		)
 	}
 
 	materialIndex := modelData.Materials.AppendRaw(material)
@@ -7251,13 +6764,7 @@ func resolveLegacyVroidToonTexture(
 		return
 	}
 
-	normalizedMaterialIndex := normalizeLegacyGeneratedTextureMaterialIndex(sourceMaterialIndex)
-	toonFileName := fmt.Sprintf(
-		"toon%02d.bmp",
-		normalizedMaterialIndex+1,
-	)
-	toonTextureName := filepath.ToSlash(filepath.Join("tex", toonFileName))
-	toonTextureIndex, err := ensureGeneratedTextureIndex(modelData, toonTextureName, model.TEXTURE_TYPE_TOON)
+	toonTextureIndex, _, err := registerGeneratedToonTexture(modelData, sourceMaterialIndex, shadeColor)
 	if err != nil {
 		applyLegacySharedToonFallback(materialData)
 		recordLegacyMaterialWarning(
@@ -7269,12 +6776,31 @@ func resolveLegacyVroidToonTexture(
 		)
 		return
 	}
-	appendLegacyGeneratedToonShadeColor(modelData, toonFileName, shadeColor)
 
 	materialData.ToonSharingFlag = model.TOON_SHARING_INDIVIDUAL
 	materialData.ToonTextureIndex = toonTextureIndex
 }
 
+// registerGeneratedToonTexture は生成toonをテクスチャへ登録し、shade 色を RawExtensions へ記録する。
+func registerGeneratedToonTexture(
+	modelData *model.PmxModel,
+	sourceMaterialIndex int,
+	shadeColor [3]uint8,
+) (int, string, error) {
+	normalizedMaterialIndex := normalizeLegacyGeneratedTextureMaterialIndex(sourceMaterialIndex)
+	toonFileName := fmt.Sprintf(
+		"toon%02d.bmp",
+		normalizedMaterialIndex+1,
+	)
+	toonTextureName := filepath.ToSlash(filepath.Join("tex", toonFileName))
+	toonTextureIndex, err := ensureGeneratedTextureIndex(modelData, toonTextureName, model.TEXTURE_TYPE_TOON)
+	if err != nil {
+		return -1, "", err
+	}
+	appendLegacyGeneratedToonShadeColor(modelData, toonFileName, shadeColor)
+	return toonTextureIndex, toonFileName, nil
+}
+
 type legacySphereCandidateType int
 
 const (
@@ -7282,6 +6808,7 @@ const (
 	legacySphereCandidateHair
 	legacySphereCandidateMatcap
 	legacySphereCandidateEmissive
+	legacySphereCandidateRim
 )
 
 // legacySphereMaterialContext は旧 sphere 優先順位判定で使用する属性コンテキストを表す。
@@ -7308,13 +6835,17 @@ type legacySphereMigrationObservation struct {
 }
 
 type legacyGeneratedSphereMetadata struct {
-	SourceTextureIndex int        `json:"source_texture_index"`
-	MaterialIndex      int        `json:"material_index"`
-	SphereKind         string     `json:"sphere_kind"`
-	EmissiveFactor     [3]float64 `json:"emissive_factor"`
-	DiffuseFactor      [4]float64 `json:"diffuse_factor"`
-	HighlightTexture   string     `json:"highlight_texture_name,omitempty"`
-	BlendTexture       string     `json:"blend_texture_name,omitempty"`
+	SourceTextureIndex int         `json:"source_texture_index"`
+	MaterialIndex      int         `json:"material_index"`
+	SphereKind         string      `json:"sphere_kind"`
+	EmissiveFactor     [3]float64  `json:"emissive_factor"`
+	DiffuseFactor      [4]float64  `json:"diffuse_factor"`
+	HighlightTexture   string      `json:"highlight_texture_name,omitempty"`
+	BlendTexture       string      `json:"blend_texture_name,omitempty"`
+	MatcapFactor       *[3]float64 `json:"matcap_factor,omitempty"`
+	RimColor           [3]float64  `json:"rim_color,omitempty"`
+	RimFresnelPower    float64     `json:"rim_fresnel_power,omitempty"`
+	RimLift            float64     `json:"rim_lift,omitempty"`
 }
 
 // buildLegacySphereMaterialContext は旧 sphere 判定に必要な属性コンテキストを組み立てる。
@@ -7725,6 +7256,7 @@ func resolveLegacySphereTextureCandidate(
 				SourceTextureIndex: sourceTextureIndex,
 				MaterialIndex:      normalizedMaterialIndex,
 				SphereKind:         "matcap",
+				MatcapFactor:       resolveLegacyMatcapFactor(sourceMaterial),
 			},
 		)
 		return textureIndex, true, false
@@ -7752,6 +7284,32 @@ func resolveLegacySphereTextureCandidate(
 			},
 		)
 		return textureIndex, true, false
+	case legacySphereCandidateRim:
+		rimSource, ok := resolveMToonRimSource(doc, sourceMaterial, sourceMaterialIndex)
+		if !ok {
+			return -1, false, false
+		}
+		normalizedMaterialIndex := normalizeLegacyGeneratedTextureMaterialIndex(sourceMaterialIndex)
+		rimSphereTextureName := filepath.ToSlash(
+			filepath.Join("tex", legacyGeneratedSphereDirName, fmt.Sprintf("rim_sphere_%03d.png", normalizedMaterialIndex)),
+		)
+		textureIndex, err := ensureGeneratedTextureIndex(modelData, rimSphereTextureName, model.TEXTURE_TYPE_SPHERE)
+		if err != nil {
+			return -1, false, true
+		}
+		appendLegacyGeneratedSphereMetadata(
+			modelData,
+			rimSphereTextureName,
+			legacyGeneratedSphereMetadata{
+				SourceTextureIndex: -1,
+				MaterialIndex:      normalizedMaterialIndex,
+				SphereKind:         "rim",
+				RimColor:           rimSource.Color,
+				RimFresnelPower:    rimSource.FresnelPower,
+				RimLift:            rimSource.Lift,
+			},
+		)
+		return textureIndex, true, false
 	default:
 		return -1, false, false
 	}
@@ -8215,6 +7773,8 @@ func legacySphereCandidateLabel(candidate legacySphereCandidateType) string {
 		return "matcap"
 	case legacySphereCandidateEmissive:
 		return "emissive"
+	case legacySphereCandidateRim:
+		return "rim"
 	default:
 		return "unknown"
 	}
@@ -8759,6 +8319,18 @@ func buildPrimitiveMaterialMemo(
 	return strings.Join(tokens, " ")
 }
 
+// appendPrimitiveMaterialAlphaCutoffMemo は alphaMode=MASK の材質メモへ alphaCutoff を追記する。
+func appendPrimitiveMaterialAlphaCutoffMemo(memo string, alphaMode string, alphaCutoff *float64) string {
+	if !strings.EqualFold(strings.TrimSpace(alphaMode), "MASK") {
+		return memo
+	}
+	cutoff := primitiveMaterialDefaultAlphaCutoff
+	if alphaCutoff != nil && *alphaCutoff >= 0 {
+		cutoff = *alphaCutoff
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s alphaCutoff=%g", memo, cutoff))
+}
+
 func primitiveMaterialSourceEdgeStateLabel(sourceEdgeState primitiveMaterialSourceEdgeState) string {
 	switch sourceEdgeState {
 	case primitiveMaterialSourceEdgeStateEnabled:
@@ -8994,15 +8566,28 @@ func readAccessorFloatValues(doc *gltfDocument, accessorIndex int, binChunk []by
 	values := make([][]float64, plan.Accessor.Count)
 	for i := 0; i < plan.Accessor.Count; i++ {
 		row := make([]float64, plan.ComponentNum)
-		elementBase := plan.BaseOffset + i*plan.Stride
+		if plan.HasBase {
+			elementBase := plan.BaseOffset + i*plan.Stride
+			for c := 0; c < plan.ComponentNum; c++ {
+				value, readErr := readComponentAsFloat(plan.Accessor, binChunk, elementBase+c*plan.ComponentSize)
+				if readErr != nil {
+					return nil, readErr
+				}
+				row[c] = value
+			}
+		}
+		values[i] = row
+	}
+	elementSize := plan.ComponentNum * plan.ComponentSize
+	for sparseIndex, elementIndex := range plan.SparseIndexes {
+		elementBase := plan.SparseValuesOffset + sparseIndex*elementSize
 		for c := 0; c < plan.ComponentNum; c++ {
 			value, readErr := readComponentAsFloat(plan.Accessor, binChunk, elementBase+c*plan.ComponentSize)
 			if readErr != nil {
 				return nil, readErr
 			}
-			row[c] = value
+			values[elementIndex][c] = value
 		}
-		values[i] = row
 	}
 	return values, nil
 }
@@ -9016,15 +8601,28 @@ func readAccessorIntValues(doc *gltfDocument, accessorIndex int, binChunk []byte
 	values := make([][]int, plan.Accessor.Count)
 	for i := 0; i < plan.Accessor.Count; i++ {
 		row := make([]int, plan.ComponentNum)
-		elementBase := plan.BaseOffset + i*plan.Stride
+		if plan.HasBase {
+			elementBase := plan.BaseOffset + i*plan.Stride
+			for c := 0; c < plan.ComponentNum; c++ {
+				value, readErr := readComponentAsInt(plan.Accessor.ComponentType, binChunk, elementBase+c*plan.ComponentSize)
+				if readErr != nil {
+					return nil, readErr
+				}
+				row[c] = value
+			}
+		}
+		values[i] = row
+	}
+	elementSize := plan.ComponentNum * plan.ComponentSize
+	for sparseIndex, elementIndex := range plan.SparseIndexes {
+		elementBase := plan.SparseValuesOffset + sparseIndex*elementSize
 		for c := 0; c < plan.ComponentNum; c++ {
 			value, readErr := readComponentAsInt(plan.Accessor.ComponentType, binChunk, elementBase+c*plan.ComponentSize)
 			if readErr != nil {
 				return nil, readErr
 			}
-			row[c] = value
+			values[elementIndex][c] = value
 		}
-		values[i] = row
 	}
 	return values, nil
 }
@@ -9038,28 +8636,13 @@ func prepareAccessorRead(doc *gltfDocument, accessorIndex int, binChunk []byte)
 		return accessorReadPlan{}, io_common.NewIoParseFailed("accessor index が不正です: %d", nil, accessorIndex)
 	}
 	accessor := doc.Accessors[accessorIndex]
-	if accessor.BufferView == nil {
-		return accessorReadPlan{}, io_common.NewIoParseFailed("sparse accessor は未対応です", nil)
+	if accessor.BufferView == nil && accessor.Sparse == nil {
+		return accessorReadPlan{}, io_common.NewIoParseFailed("accessor.bufferView が未設定です", nil)
 	}
 	if accessor.Count < 0 {
 		return accessorReadPlan{}, io_common.NewIoParseFailed("accessor.count が不正です: %d", nil, accessor.Count)
 	}
 
-	viewIndex := *accessor.BufferView
-	if viewIndex < 0 || viewIndex >= len(doc.BufferViews) {
-		return accessorReadPlan{}, io_common.NewIoParseFailed("bufferView index が不正です: %d", nil, viewIndex)
-	}
-	view := doc.BufferViews[viewIndex]
-	if view.Buffer != 0 {
-		return accessorReadPlan{}, io_common.NewIoParseFailed("bufferView.buffer が未対応です: %d", nil, view.Buffer)
-	}
-	if view.ByteLength < 0 || view.ByteOffset < 0 {
-		return accessorReadPlan{}, io_common.NewIoParseFailed("bufferView の byteOffset/byteLength が不正です", nil)
-	}
-	if view.ByteOffset+view.ByteLength > len(binChunk) {
-		return accessorReadPlan{}, io_common.NewIoParseFailed("bufferView 範囲がBINチャンク外です", nil)
-	}
-
 	componentNum, err := accessorComponentNum(accessor.Type)
 	if err != nil {
 		return accessorReadPlan{}, err
@@ -9069,33 +8652,142 @@ func prepareAccessorRead(doc *gltfDocument, accessorIndex int, binChunk []byte)
 		return accessorReadPlan{}, err
 	}
 	elementSize := componentNum * componentSize
+
+	plan := accessorReadPlan{
+		Accessor:      accessor,
+		ComponentSize: componentSize,
+		ComponentNum:  componentNum,
+		Stride:        elementSize,
+	}
+	if accessor.BufferView != nil {
+		if err := prepareAccessorBaseRead(doc, accessor, binChunk, elementSize, &plan); err != nil {
+			return accessorReadPlan{}, err
+		}
+	}
+	if accessor.Sparse != nil {
+		if err := prepareAccessorSparseRead(doc, accessor, binChunk, elementSize, &plan); err != nil {
+			return accessorReadPlan{}, err
+		}
+	}
+	return plan, nil
+}
+
+// prepareAccessorBaseRead はbufferView由来の基底値読み取り情報を検証して plan へ設定する。
+func prepareAccessorBaseRead(
+	doc *gltfDocument,
+	accessor gltfAccessor,
+	binChunk []byte,
+	elementSize int,
+	plan *accessorReadPlan,
+) error {
+	view, err := resolveAccessorBufferView(doc, *accessor.BufferView, binChunk)
+	if err != nil {
+		return err
+	}
 	stride := view.ByteStride
 	if stride <= 0 {
 		stride = elementSize
 	}
 	if stride < elementSize {
-		return accessorReadPlan{}, io_common.NewIoParseFailed("bufferView.byteStride が要素サイズより小さいです", nil)
+		return io_common.NewIoParseFailed("bufferView.byteStride が要素サイズより小さいです", nil)
 	}
 	baseOffset := view.ByteOffset + accessor.ByteOffset
 	if baseOffset < view.ByteOffset || baseOffset > view.ByteOffset+view.ByteLength {
-		return accessorReadPlan{}, io_common.NewIoParseFailed("accessor.byteOffset が不正です", nil)
+		return io_common.NewIoParseFailed("accessor.byteOffset が不正です", nil)
 	}
 	if accessor.Count > 0 {
 		lastEnd := baseOffset + (accessor.Count-1)*stride + elementSize
 		if lastEnd > view.ByteOffset+view.ByteLength || lastEnd > len(binChunk) {
-			return accessorReadPlan{}, io_common.NewIoParseFailed("accessor 範囲がbufferViewを超えています", nil)
+			return io_common.NewIoParseFailed("accessor 範囲がbufferViewを超えています", nil)
 		}
 	}
 
-	return accessorReadPlan{
-		Accessor:      accessor,
-		ComponentSize: componentSize,
-		ComponentNum:  componentNum,
-		Stride:        stride,
-		BaseOffset:    baseOffset,
-		ViewStart:     view.ByteOffset,
-		ViewEnd:       view.ByteOffset + view.ByteLength,
-	}, nil
+	plan.HasBase = true
+	plan.Stride = stride
+	plan.BaseOffset = baseOffset
+	plan.ViewStart = view.ByteOffset
+	plan.ViewEnd = view.ByteOffset + view.ByteLength
+	return nil
+}
+
+// prepareAccessorSparseRead はsparseのindex一覧と上書き値位置を検証して plan へ設定する。
+func prepareAccessorSparseRead(
+	doc *gltfDocument,
+	accessor gltfAccessor,
+	binChunk []byte,
+	elementSize int,
+	plan *accessorReadPlan,
+) error {
+	sparse := accessor.Sparse
+	if sparse.Count <= 0 || sparse.Count > accessor.Count {
+		return io_common.NewIoParseFailed("accessor.sparse.count が不正です: %d", nil, sparse.Count)
+	}
+
+	indicesView, err := resolveAccessorBufferView(doc, sparse.Indices.BufferView, binChunk)
+	if err != nil {
+		return err
+	}
+	switch sparse.Indices.ComponentType {
+	case gltfComponentTypeUnsignedByte, gltfComponentTypeUnsignedShort, gltfComponentTypeUnsignedInt:
+	default:
+		return io_common.NewIoFormatNotSupported(
+			"accessor.sparse.indices.componentType が未対応です: %d",
+			nil,
+			sparse.Indices.ComponentType,
+		)
+	}
+	indexSize, err := accessorComponentSize(sparse.Indices.ComponentType)
+	if err != nil {
+		return err
+	}
+	indicesOffset := indicesView.ByteOffset + sparse.Indices.ByteOffset
+	indicesEnd := indicesOffset + sparse.Count*indexSize
+	if sparse.Indices.ByteOffset < 0 || indicesEnd > indicesView.ByteOffset+indicesView.ByteLength {
+		return io_common.NewIoParseFailed("accessor.sparse.indices 範囲がbufferViewを超えています", nil)
+	}
+	indexes := make([]int, sparse.Count)
+	for i := 0; i < sparse.Count; i++ {
+		elementIndex, readErr := readComponentAsInt(sparse.Indices.ComponentType, binChunk, indicesOffset+i*indexSize)
+		if readErr != nil {
+			return readErr
+		}
+		if elementIndex < 0 || elementIndex >= accessor.Count {
+			return io_common.NewIoParseFailed("accessor.sparse.indices の値が不正です: %d", nil, elementIndex)
+		}
+		indexes[i] = elementIndex
+	}
+
+	valuesView, err := resolveAccessorBufferView(doc, sparse.Values.BufferView, binChunk)
+	if err != nil {
+		return err
+	}
+	valuesOffset := valuesView.ByteOffset + sparse.Values.ByteOffset
+	valuesEnd := valuesOffset + sparse.Count*elementSize
+	if sparse.Values.ByteOffset < 0 || valuesEnd > valuesView.ByteOffset+valuesView.ByteLength {
+		return io_common.NewIoParseFailed("accessor.sparse.values 範囲がbufferViewを超えています", nil)
+	}
+
+	plan.SparseIndexes = indexes
+	plan.SparseValuesOffset = valuesOffset
+	return nil
+}
+
+// resolveAccessorBufferView はbufferViewを取得し、BINチャンク内に収まるか検証する。
+func resolveAccessorBufferView(doc *gltfDocument, viewIndex int, binChunk []byte) (gltfBufferView, error) {
+	if viewIndex < 0 || viewIndex >= len(doc.BufferViews) {
+		return gltfBufferView{}, io_common.NewIoParseFailed("bufferView index が不正です: %d", nil, viewIndex)
+	}
+	view := doc.BufferViews[viewIndex]
+	if view.Buffer != 0 {
+		return gltfBufferView{}, io_common.NewIoParseFailed("bufferView.buffer が未対応です: %d", nil, view.Buffer)
+	}
+	if view.ByteLength < 0 || view.ByteOffset < 0 {
+		return gltfBufferView{}, io_common.NewIoParseFailed("bufferView の byteOffset/byteLength が不正です", nil)
+	}
+	if view.ByteOffset+view.ByteLength > len(binChunk) {
+		return gltfBufferView{}, io_common.NewIoParseFailed("bufferView 範囲がBINチャンク外です", nil)
+	}
+	return view, nil
 }
 
 // accessorComponentNum はaccessor.typeから要素次元数を返す。
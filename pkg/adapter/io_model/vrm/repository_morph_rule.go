@@ -0,0 +1,401 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+)
+
+const (
+	// morphRuleFileSchemaVersion は読込可能なモーフ規則ファイルの最新スキーマ版数を表す。
+	morphRuleFileSchemaVersion = 1
+)
+
+// morphRuleEmbeddedFile は組み込みモーフ規則ファイルを保持する。
+//
+//go:embed assets/morph_rules.json
+var morphRuleEmbeddedFile []byte
+
+// morphRulePanelNames は規則ファイルで受け付けるパネル名を表す。
+var morphRulePanelNames = map[string]model.MorphPanel{
+	"system":  model.MORPH_PANEL_SYSTEM,
+	"eyebrow": model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
+	"eye":     model.MORPH_PANEL_EYE_UPPER_LEFT,
+	"lip":     model.MORPH_PANEL_LIP_UPPER_RIGHT,
+	"other":   model.MORPH_PANEL_OTHER_LOWER_RIGHT,
+}
+
+// morphRuleCreateTypeNames は規則ファイルで受け付ける creates 生成種別名を表す。
+var morphRuleCreateTypeNames = map[string]createMorphRuleType{
+	"brow":            createMorphRuleTypeBrow,
+	"eye_small":       createMorphRuleTypeEyeSmall,
+	"eye_big":         createMorphRuleTypeEyeBig,
+	"eye_hide_vertex": createMorphRuleTypeEyeHideVertex,
+}
+
+// morphRuleBoneSemantics は規則ファイルで受け付けるボーンモーフ対象セマンティクスを表す。
+var morphRuleBoneSemantics = map[string]struct{}{
+	expressionBoneSemanticRightEyeLight: {},
+	expressionBoneSemanticLeftEyeLight:  {},
+	expressionBoneSemanticTongue1:       {},
+	expressionBoneSemanticTongue2:       {},
+	expressionBoneSemanticTongue3:       {},
+	expressionBoneSemanticTongue4:       {},
+}
+
+// morphRuleSet は表情補完に使う creates/連動/左右統合/ボーンモーフ/ARKit合成規則一式を表す。
+type morphRuleSet struct {
+	Create        []createMorphRule
+	Link          []expressionLinkRule
+	SidePairGroup []expressionSidePairGroupFallbackRule
+	BoneFallback  []expressionBoneMorphFallbackRule
+	Arkit         []arkitBlendShapeMorphRule
+}
+
+// morphRuleFile はモーフ規則ファイルの構造を表す。
+type morphRuleFile struct {
+	Version       int                          `json:"version"`
+	Create        []morphRuleFileCreateRule    `json:"create"`
+	Link          []morphRuleFileLinkRule      `json:"link"`
+	SidePairGroup []morphRuleFileSidePairRule  `json:"side_pair_group"`
+	BoneFallback  []morphRuleFileBoneMorphRule `json:"bone_fallback"`
+	Arkit         []morphRuleFileArkitRule     `json:"arkit"`
+}
+
+// morphRuleFileCreateRule は規則ファイルの creates 規則1件を表す。
+type morphRuleFileCreateRule struct {
+	Name    string   `json:"name"`
+	Panel   string   `json:"panel"`
+	Type    string   `json:"type"`
+	Creates []string `json:"creates"`
+	Hides   []string `json:"hides,omitempty"`
+}
+
+// morphRuleFileLinkRule は規則ファイルの binds/split 連動規則1件を表す。
+type morphRuleFileLinkRule struct {
+	Name   string    `json:"name"`
+	Panel  string    `json:"panel"`
+	Binds  []string  `json:"binds,omitempty"`
+	Ratios []float64 `json:"ratios,omitempty"`
+	Split  string    `json:"split,omitempty"`
+}
+
+// morphRuleFileSidePairRule は規則ファイルの左右統合補完規則1件を表す。
+type morphRuleFileSidePairRule struct {
+	Name  string   `json:"name"`
+	Panel string   `json:"panel"`
+	Binds []string `json:"binds"`
+}
+
+// morphRuleFileBoneMorphRule は規則ファイルのボーンモーフ補完規則1件を表す。
+type morphRuleFileBoneMorphRule struct {
+	Name    string                        `json:"name"`
+	Offsets []morphRuleFileBoneOffsetRule `json:"offsets"`
+}
+
+// morphRuleFileBoneOffsetRule は規則ファイルのボーンモーフオフセット1件を表す。
+type morphRuleFileBoneOffsetRule struct {
+	Semantic string    `json:"semantic"`
+	Move     []float64 `json:"move"`
+	Rotate   []float64 `json:"rotate"`
+}
+
+// morphRuleFileArkitRule は規則ファイルの ARKit ブレンドシェイプ合成規則1件を表す。
+type morphRuleFileArkitRule struct {
+	Name   string    `json:"name"`
+	Panel  string    `json:"panel"`
+	Binds  []string  `json:"binds"`
+	Ratios []float64 `json:"ratios,omitempty"`
+}
+
+// defaultMorphRuleSet は組み込み規則ファイルから構築した既定のモーフ規則を表す。
+var defaultMorphRuleSet = mustLoadEmbeddedMorphRuleSet()
+
+// mustLoadEmbeddedMorphRuleSet は組み込み規則ファイルを読み込み、不正な場合は panic する。
+func mustLoadEmbeddedMorphRuleSet() *morphRuleSet {
+	ruleFile, err := parseMorphRuleFile(morphRuleEmbeddedFile)
+	if err != nil {
+		panic(fmt.Sprintf("組み込みモーフ規則ファイルが不正です: %v", err))
+	}
+	return buildMorphRuleSet(ruleFile)
+}
+
+// ValidateMorphRuleFile は組み込み規則へ追加・上書きする規則ファイルを読み込み、スキーマと意味定義を検証する。
+func ValidateMorphRuleFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("モーフ規則ファイルの読込に失敗しました: %w", err)
+	}
+	if _, err := parseMorphRuleFile(data); err != nil {
+		return fmt.Errorf("モーフ規則ファイルが不正です: %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadMorphRuleSetWithOverride は組み込み規則へ指定ファイルの規則を追加・上書きした規則一式を返す。
+func loadMorphRuleSetWithOverride(path string) (*morphRuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("モーフ規則ファイルの読込に失敗しました: %w", err)
+	}
+	ruleFile, err := parseMorphRuleFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("モーフ規則ファイルが不正です: %s: %w", path, err)
+	}
+	merged := mergeMorphRuleSet(defaultMorphRuleSet, buildMorphRuleSet(ruleFile))
+	logVrmInfo(
+		"モーフ規則ファイル読込: path=%s version=%d create=%d link=%d sidePair=%d bone=%d arkit=%d",
+		path,
+		ruleFile.Version,
+		len(ruleFile.Create),
+		len(ruleFile.Link),
+		len(ruleFile.SidePairGroup),
+		len(ruleFile.BoneFallback),
+		len(ruleFile.Arkit),
+	)
+	return merged, nil
+}
+
+// parseMorphRuleFile は規則ファイルを未知キーを拒否して解析し、スキーマ検証する。
+func parseMorphRuleFile(data []byte) (*morphRuleFile, error) {
+	decoder := json.NewDecoder(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	decoder.DisallowUnknownFields()
+	ruleFile := &morphRuleFile{}
+	if err := decoder.Decode(ruleFile); err != nil {
+		return nil, fmt.Errorf("JSONの解析に失敗しました: %w", err)
+	}
+	if err := validateMorphRuleFile(ruleFile); err != nil {
+		return nil, err
+	}
+	return ruleFile, nil
+}
+
+// validateMorphRuleFile は規則ファイルの版数・名称重複・未知セマンティクス・合成元指定を検証する。
+func validateMorphRuleFile(ruleFile *morphRuleFile) error {
+	if ruleFile == nil {
+		return fmt.Errorf("モーフ規則ファイルが空です")
+	}
+	errs := []error{}
+	if ruleFile.Version < 1 || ruleFile.Version > morphRuleFileSchemaVersion {
+		errs = append(errs, fmt.Errorf("未対応のモーフ規則ファイル版数です: version=%d supported=%d", ruleFile.Version, morphRuleFileSchemaVersion))
+	}
+
+	names := map[string]struct{}{}
+	for index, rule := range ruleFile.Create {
+		prefix := fmt.Sprintf("create[%d]", index)
+		errs = appendMorphRuleNameErrors(errs, prefix, rule.Name, names)
+		errs = appendMorphRulePanelErrors(errs, prefix, rule.Panel)
+		if _, exists := morphRuleCreateTypeNames[strings.TrimSpace(rule.Type)]; !exists {
+			errs = append(errs, fmt.Errorf("%s: 未知の生成種別です: type=%s", prefix, rule.Type))
+		}
+		if len(rule.Creates) == 0 {
+			errs = append(errs, fmt.Errorf("%s: creates が未指定です", prefix))
+		}
+		for _, createName := range rule.Creates {
+			if len(resolveCreateRuleSemantics([]string{createName})) == 0 {
+				errs = append(errs, fmt.Errorf("%s: 未知の Creates タグです: %s", prefix, createName))
+			}
+		}
+		for _, hideName := range rule.Hides {
+			if len(resolveCreateHideSemantics([]string{hideName})) == 0 {
+				errs = append(errs, fmt.Errorf("%s: 未知の Hides タグです: %s", prefix, hideName))
+			}
+		}
+	}
+
+	names = map[string]struct{}{}
+	for index, rule := range ruleFile.Link {
+		prefix := fmt.Sprintf("link[%d]", index)
+		errs = appendMorphRuleNameErrors(errs, prefix, rule.Name, names)
+		errs = appendMorphRulePanelErrors(errs, prefix, rule.Panel)
+		hasBinds := len(rule.Binds) > 0
+		hasSplit := strings.TrimSpace(rule.Split) != ""
+		switch {
+		case hasBinds && hasSplit:
+			errs = append(errs, fmt.Errorf("%s: binds と split は同時に指定できません", prefix))
+		case !hasBinds && !hasSplit:
+			errs = append(errs, fmt.Errorf("%s: binds または split が必要です", prefix))
+		}
+		if len(rule.Ratios) > 0 && len(rule.Ratios) != len(rule.Binds) {
+			errs = append(errs, fmt.Errorf("%s: ratios の件数が binds と一致しません: binds=%d ratios=%d", prefix, len(rule.Binds), len(rule.Ratios)))
+		}
+	}
+
+	names = map[string]struct{}{}
+	for index, rule := range ruleFile.SidePairGroup {
+		prefix := fmt.Sprintf("side_pair_group[%d]", index)
+		errs = appendMorphRuleNameErrors(errs, prefix, rule.Name, names)
+		errs = appendMorphRulePanelErrors(errs, prefix, rule.Panel)
+		if len(rule.Binds) == 0 {
+			errs = append(errs, fmt.Errorf("%s: binds が未指定です", prefix))
+		}
+	}
+
+	names = map[string]struct{}{}
+	for index, rule := range ruleFile.BoneFallback {
+		prefix := fmt.Sprintf("bone_fallback[%d]", index)
+		errs = appendMorphRuleNameErrors(errs, prefix, rule.Name, names)
+		if len(rule.Offsets) == 0 {
+			errs = append(errs, fmt.Errorf("%s: offsets が未指定です", prefix))
+		}
+		for offsetIndex, offset := range rule.Offsets {
+			if _, exists := morphRuleBoneSemantics[strings.TrimSpace(offset.Semantic)]; !exists {
+				errs = append(errs, fmt.Errorf("%s.offsets[%d]: 未知のボーンセマンティクスです: %s", prefix, offsetIndex, offset.Semantic))
+			}
+			if len(offset.Move) != 3 || len(offset.Rotate) != 3 {
+				errs = append(errs, fmt.Errorf("%s.offsets[%d]: move/rotate は3要素で指定してください", prefix, offsetIndex))
+			}
+		}
+	}
+
+	names = map[string]struct{}{}
+	for index, rule := range ruleFile.Arkit {
+		prefix := fmt.Sprintf("arkit[%d]", index)
+		errs = appendMorphRuleNameErrors(errs, prefix, rule.Name, names)
+		errs = appendMorphRulePanelErrors(errs, prefix, rule.Panel)
+		if len(rule.Binds) == 0 {
+			errs = append(errs, fmt.Errorf("%s: binds が未指定です", prefix))
+		}
+		for _, bindName := range rule.Binds {
+			if strings.TrimSpace(bindName) == "" {
+				errs = append(errs, fmt.Errorf("%s: binds に空のブレンドシェイプ名があります", prefix))
+			}
+		}
+		if len(rule.Ratios) > 0 && len(rule.Ratios) != len(rule.Binds) {
+			errs = append(errs, fmt.Errorf("%s: ratios の件数が binds と一致しません: binds=%d ratios=%d", prefix, len(rule.Binds), len(rule.Ratios)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// appendMorphRuleNameErrors は規則名の未指定と同一区分内の重複を検証する。
+func appendMorphRuleNameErrors(errs []error, prefix string, name string, names map[string]struct{}) []error {
+	trimmedName := strings.TrimSpace(name)
+	if trimmedName == "" {
+		return append(errs, fmt.Errorf("%s: name が未指定です", prefix))
+	}
+	if _, exists := names[trimmedName]; exists {
+		return append(errs, fmt.Errorf("%s: name が重複しています: %s", prefix, trimmedName))
+	}
+	names[trimmedName] = struct{}{}
+	return errs
+}
+
+// appendMorphRulePanelErrors は未知のパネル名を検証する。
+func appendMorphRulePanelErrors(errs []error, prefix string, panel string) []error {
+	if _, exists := morphRulePanelNames[strings.TrimSpace(panel)]; exists {
+		return errs
+	}
+	return append(errs, fmt.Errorf("%s: 未知のパネル名です: panel=%s", prefix, panel))
+}
+
+// buildMorphRuleSet は検証済み規則ファイルを実行用の規則一式へ変換する。
+func buildMorphRuleSet(ruleFile *morphRuleFile) *morphRuleSet {
+	ruleSet := &morphRuleSet{}
+	for _, rule := range ruleFile.Create {
+		ruleSet.Create = append(ruleSet.Create, createMorphRule{
+			Name:    strings.TrimSpace(rule.Name),
+			Panel:   morphRulePanelNames[strings.TrimSpace(rule.Panel)],
+			Type:    morphRuleCreateTypeNames[strings.TrimSpace(rule.Type)],
+			Creates: rule.Creates,
+			Hides:   rule.Hides,
+		})
+	}
+	for _, rule := range ruleFile.Link {
+		ruleSet.Link = append(ruleSet.Link, expressionLinkRule{
+			Name:   strings.TrimSpace(rule.Name),
+			Panel:  morphRulePanelNames[strings.TrimSpace(rule.Panel)],
+			Binds:  rule.Binds,
+			Ratios: rule.Ratios,
+			Split:  strings.TrimSpace(rule.Split),
+		})
+	}
+	for _, rule := range ruleFile.SidePairGroup {
+		ruleSet.SidePairGroup = append(ruleSet.SidePairGroup, expressionSidePairGroupFallbackRule{
+			Name:  strings.TrimSpace(rule.Name),
+			Panel: morphRulePanelNames[strings.TrimSpace(rule.Panel)],
+			Binds: rule.Binds,
+		})
+	}
+	for _, rule := range ruleFile.BoneFallback {
+		offsets := make([]expressionBoneOffsetRule, 0, len(rule.Offsets))
+		for _, offset := range rule.Offsets {
+			offsets = append(offsets, newExpressionBoneOffsetRule(
+				strings.TrimSpace(offset.Semantic),
+				offset.Move[0],
+				offset.Move[1],
+				offset.Move[2],
+				offset.Rotate[0],
+				offset.Rotate[1],
+				offset.Rotate[2],
+			))
+		}
+		ruleSet.BoneFallback = append(ruleSet.BoneFallback, expressionBoneMorphFallbackRule{
+			Name:    strings.TrimSpace(rule.Name),
+			Offsets: offsets,
+		})
+	}
+	for _, rule := range ruleFile.Arkit {
+		ruleSet.Arkit = append(ruleSet.Arkit, arkitBlendShapeMorphRule{
+			Name:   strings.TrimSpace(rule.Name),
+			Panel:  morphRulePanelNames[strings.TrimSpace(rule.Panel)],
+			Binds:  rule.Binds,
+			Ratios: rule.Ratios,
+		})
+	}
+	return ruleSet
+}
+
+// mergeMorphRuleSet は既定規則へ上書き規則を統合する。同名規則は元の位置で置き換え、新規規則は末尾へ追加する。
+func mergeMorphRuleSet(base *morphRuleSet, override *morphRuleSet) *morphRuleSet {
+	return &morphRuleSet{
+		Create: mergeMorphRulesByName(base.Create, override.Create, func(rule createMorphRule) string {
+			return rule.Name
+		}),
+		Link: mergeMorphRulesByName(base.Link, override.Link, func(rule expressionLinkRule) string {
+			return rule.Name
+		}),
+		SidePairGroup: mergeMorphRulesByName(base.SidePairGroup, override.SidePairGroup, func(rule expressionSidePairGroupFallbackRule) string {
+			return rule.Name
+		}),
+		BoneFallback: mergeMorphRulesByName(base.BoneFallback, override.BoneFallback, func(rule expressionBoneMorphFallbackRule) string {
+			return rule.Name
+		}),
+		Arkit: mergeMorphRulesByName(base.Arkit, override.Arkit, func(rule arkitBlendShapeMorphRule) string {
+			return rule.Name
+		}),
+	}
+}
+
+// mergeMorphRulesByName は名前をキーに規則配列を上書き統合する。
+func mergeMorphRulesByName[T any](baseRules []T, overrideRules []T, nameOf func(T) string) []T {
+	overrideByName := map[string]T{}
+	for _, rule := range overrideRules {
+		overrideByName[nameOf(rule)] = rule
+	}
+	merged := make([]T, 0, len(baseRules)+len(overrideRules))
+	replaced := map[string]struct{}{}
+	for _, rule := range baseRules {
+		name := nameOf(rule)
+		if override, exists := overrideByName[name]; exists {
+			merged = append(merged, override)
+			replaced[name] = struct{}{}
+			continue
+		}
+		merged = append(merged, rule)
+	}
+	for _, rule := range overrideRules {
+		if _, exists := replaced[nameOf(rule)]; exists {
+			continue
+		}
+		merged = append(merged, rule)
+	}
+	return merged
+}
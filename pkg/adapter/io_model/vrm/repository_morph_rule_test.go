@@ -0,0 +1,129 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+)
+
+func TestDefaultMorphRuleSetLoadsEmbeddedRules(t *testing.T) {
+	if defaultMorphRuleSet == nil {
+		t.Fatalf("default morph rule set should be loaded")
+	}
+	if len(defaultMorphRuleSet.Create) != 15 ||
+		len(defaultMorphRuleSet.Link) != 103 ||
+		len(defaultMorphRuleSet.SidePairGroup) != 2 ||
+		len(defaultMorphRuleSet.BoneFallback) != 14 ||
+		len(defaultMorphRuleSet.Arkit) != 23 {
+		t.Fatalf(
+			"default morph rule count mismatch: create=%d link=%d sidePair=%d bone=%d arkit=%d",
+			len(defaultMorphRuleSet.Create),
+			len(defaultMorphRuleSet.Link),
+			len(defaultMorphRuleSet.SidePairGroup),
+			len(defaultMorphRuleSet.BoneFallback),
+			len(defaultMorphRuleSet.Arkit),
+		)
+	}
+}
+
+func TestLoadMorphRuleSetWithOverrideReplacesAndAppendsRules(t *testing.T) {
+	baseLink := defaultMorphRuleSet.Link[0]
+	rulePath := filepath.Join(t.TempDir(), "morph_rules.json")
+	content := `{
+  "version": 1,
+  "link": [
+    {"name": "` + baseLink.Name + `", "panel": "other", "binds": ["custom_a"], "ratios": [0.5]},
+    {"name": "独自連動", "panel": "lip", "binds": ["あ", "い"]}
+  ],
+  "bone_fallback": [
+    {"name": "独自舌", "offsets": [{"semantic": "tongue_1", "move": [0, 0, 0], "rotate": [10, 0, 0]}]}
+  ],
+  "arkit": [
+    {"name": "まばたき", "panel": "eye", "binds": ["eyeBlinkLeft", "eyeBlinkRight"], "ratios": [0.8, 0.8]}
+  ]
+}`
+	if err := os.WriteFile(rulePath, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write rule file: %v", err)
+	}
+
+	ruleSet, err := loadMorphRuleSetWithOverride(rulePath)
+	if err != nil {
+		t.Fatalf("load rule file failed: %v", err)
+	}
+	if len(ruleSet.Create) != len(defaultMorphRuleSet.Create) {
+		t.Fatalf("create rules should be kept: got=%d", len(ruleSet.Create))
+	}
+	if len(ruleSet.Link) != len(defaultMorphRuleSet.Link)+1 {
+		t.Fatalf("link rule count mismatch: got=%d", len(ruleSet.Link))
+	}
+	replaced := ruleSet.Link[0]
+	if replaced.Name != baseLink.Name || replaced.Panel != model.MORPH_PANEL_OTHER_LOWER_RIGHT || len(replaced.Binds) != 1 {
+		t.Fatalf("link rule should be replaced in place: %+v", replaced)
+	}
+	if appended := ruleSet.Link[len(ruleSet.Link)-1]; appended.Name != "独自連動" || appended.Panel != model.MORPH_PANEL_LIP_UPPER_RIGHT {
+		t.Fatalf("link rule should be appended: %+v", appended)
+	}
+	if appended := ruleSet.BoneFallback[len(ruleSet.BoneFallback)-1]; appended.Name != "独自舌" || len(appended.Offsets) != 1 {
+		t.Fatalf("bone rule should be appended: %+v", appended)
+	}
+	if len(ruleSet.Arkit) != len(defaultMorphRuleSet.Arkit) || ruleSet.Arkit[0].Name != "まばたき" || ruleSet.Arkit[0].Ratios[0] != 0.8 {
+		t.Fatalf("arkit rule should be replaced in place: %+v", ruleSet.Arkit[0])
+	}
+	if defaultMorphRuleSet.Link[0].Panel != baseLink.Panel {
+		t.Fatalf("default rule set should not be modified")
+	}
+}
+
+func TestParseMorphRuleFileReportsSchemaErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown_creates",
+			content: `{"version":1,"create":[{"name":"眉","panel":"eyebrow","type":"brow","creates":["FaceMustache"]}]}`,
+			wantErr: "未知の Creates タグです: FaceMustache",
+		},
+		{
+			name:    "unknown_key",
+			content: `{"version":1,"links":[]}`,
+			wantErr: "links",
+		},
+		{
+			name:    "unsupported_version",
+			content: `{"version":2}`,
+			wantErr: "未対応のモーフ規則ファイル版数です",
+		},
+		{
+			name:    "binds_and_split",
+			content: `{"version":1,"link":[{"name":"笑い","panel":"eye","binds":["a"],"split":"b"}]}`,
+			wantErr: "binds と split は同時に指定できません",
+		},
+		{
+			name:    "duplicate_name",
+			content: `{"version":1,"side_pair_group":[{"name":"照れ","panel":"other","binds":["a"]},{"name":"照れ","panel":"other","binds":["b"]}]}`,
+			wantErr: "name が重複しています: 照れ",
+		},
+		{
+			name:    "unknown_semantic",
+			content: `{"version":1,"bone_fallback":[{"name":"舌","offsets":[{"semantic":"tail","move":[0,0,0],"rotate":[0,0,0]}]}]}`,
+			wantErr: "未知のボーンセマンティクスです: tail",
+		},
+		{
+			name:    "arkit_ratio_count",
+			content: `{"version":1,"arkit":[{"name":"まばたき","panel":"eye","binds":["eyeBlinkLeft","eyeBlinkRight"],"ratios":[1.0]}]}`,
+			wantErr: "arkit[0]: ratios の件数が binds と一致しません",
+		},
+	}
+	for _, testCase := range testCases {
+		_, err := parseMorphRuleFile([]byte(testCase.content))
+		if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+			t.Fatalf("%s: error mismatch: got=%v want=%s", testCase.name, err, testCase.wantErr)
+		}
+	}
+}
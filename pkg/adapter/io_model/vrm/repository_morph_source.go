@@ -0,0 +1,79 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+// モーフ生成元種別は RawExtensions の MU_VRM2PMX_morph_source へ記録し、モーフカバレッジ集計で参照する。
+const (
+	// MorphSourceVrmExpression は VRM 表情定義(preset/custom)から生成したモーフを表す。
+	MorphSourceVrmExpression = "vrm_expression"
+	// MorphSourcePrimitiveTarget は primitive の morph target から生成したモーフを表す。
+	MorphSourcePrimitiveTarget = "primitive_target"
+	// MorphSourceArkitSynthesis は ARKit ブレンドシェイプの合成で生成したモーフを表す。
+	MorphSourceArkitSynthesis = "arkit_synthesis"
+	// MorphSourceSpecialEye は特殊目材質の補完で生成したモーフを表す。
+	MorphSourceSpecialEye = "special_eye"
+	// MorphSourceCreateRule は creates 規則で生成したモーフを表す。
+	MorphSourceCreateRule = "create_rule"
+	// MorphSourceEdgeFallback はエッジ補完で生成したモーフを表す。
+	MorphSourceEdgeFallback = "edge_fallback"
+	// MorphSourceBoneFallback はボーンモーフ補完規則で生成したモーフを表す。
+	MorphSourceBoneFallback = "bone_fallback"
+	// MorphSourceJawFallback は顎口パク補完で生成したモーフを表す。
+	MorphSourceJawFallback = "jaw_fallback"
+	// MorphSourceLinkRule は表情連動規則で生成したモーフを表す。
+	MorphSourceLinkRule = "link_rule"
+)
+
+// morphSourceTracker は表情補完段階ごとにモーフの生成元種別を記録する。
+type morphSourceTracker struct {
+	sources map[int]string
+	filled  map[int]bool
+}
+
+// newMorphSourceTracker は morphSourceTracker を生成する。
+func newMorphSourceTracker() *morphSourceTracker {
+	return &morphSourceTracker{sources: map[int]string{}, filled: map[int]bool{}}
+}
+
+// mark は直前の補完段階で作成またはオフセットを書き込まれたモーフへ生成元種別を記録する。
+// 空のまま作成されたモーフは、後段で初めてオフセットが書き込まれた段階を生成元とする。
+func (t *morphSourceTracker) mark(modelData *model.PmxModel, source string) {
+	if t == nil || modelData == nil || modelData.Morphs == nil {
+		return
+	}
+	for index, morphData := range modelData.Morphs.Values() {
+		hasOffsets := morphData != nil && len(morphData.Offsets) > 0
+		if _, exists := t.sources[index]; exists && (t.filled[index] || !hasOffsets) {
+			continue
+		}
+		t.sources[index] = source
+		t.filled[index] = hasOffsets
+	}
+}
+
+// store はモーフ index 別の生成元種別を RawExtensions へ記録する。
+func (t *morphSourceTracker) store(modelData *model.PmxModel) {
+	if t == nil || modelData == nil || modelData.VrmData == nil || len(t.sources) == 0 {
+		return
+	}
+	sources := make(map[string]string, len(t.sources))
+	for index, source := range t.sources {
+		sources[strconv.Itoa(index)] = source
+	}
+	encoded, err := json.Marshal(sources)
+	if err != nil {
+		logVrmWarn("モーフ生成元情報の保存に失敗しました: err=%s", err.Error())
+		return
+	}
+	if modelData.VrmData.RawExtensions == nil {
+		modelData.VrmData.RawExtensions = map[string]json.RawMessage{}
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmMorphSourceRawExtensionKey] = encoded
+}
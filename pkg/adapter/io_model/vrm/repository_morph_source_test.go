@@ -0,0 +1,57 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestMorphSourceTrackerRecordsStageThatWritesOffsets(t *testing.T) {
+	modelData := model.NewPmxModel()
+	modelData.VrmData = vrm.NewVrmData()
+	tracker := newMorphSourceTracker()
+
+	appendArkitTestVertexMorph(modelData, "__vrm_target_m000_t000_aa", 0, -1.0)
+	tracker.mark(modelData, MorphSourcePrimitiveTarget)
+	emptyPreset := &model.Morph{Panel: model.MORPH_PANEL_EYE_UPPER_LEFT, MorphType: model.MORPH_TYPE_VERTEX}
+	emptyPreset.SetName("まばたき")
+	modelData.Morphs.AppendRaw(emptyPreset)
+	emptyMaterial := &model.Morph{Panel: model.MORPH_PANEL_OTHER_LOWER_RIGHT, MorphType: model.MORPH_TYPE_MATERIAL}
+	emptyMaterial.SetName("照れ")
+	modelData.Morphs.AppendRaw(emptyMaterial)
+	tracker.mark(modelData, MorphSourceVrmExpression)
+	appendArkitTestVertexMorph(modelData, "あ頂点", 0, -1.0)
+	tracker.mark(modelData, MorphSourceCreateRule)
+	// 空で作成された preset へ後段の連動規則がオフセットを書き込んだ場合は、書き込んだ段階を生成元とする。
+	emptyPreset.Offsets = []model.IMorphOffset{
+		&model.VertexMorphOffset{VertexIndex: 0, Position: mmath.Vec3{Vec: r3.Vec{Y: -0.1}}},
+	}
+	tracker.mark(modelData, MorphSourceLinkRule)
+	tracker.store(modelData)
+
+	sources := map[string]string{}
+	raw := modelData.VrmData.RawExtensions[warningid.VrmMorphSourceRawExtensionKey]
+	if err := json.Unmarshal(raw, &sources); err != nil {
+		t.Fatalf("morph source should be stored as json: %v", err)
+	}
+	want := map[string]string{
+		"0": MorphSourcePrimitiveTarget,
+		"1": MorphSourceLinkRule,
+		"2": MorphSourceVrmExpression,
+		"3": MorphSourceCreateRule,
+	}
+	if len(sources) != len(want) {
+		t.Fatalf("morph sources mismatch: %v", sources)
+	}
+	for index, source := range want {
+		if sources[index] != source {
+			t.Fatalf("morph source mismatch: index=%s got=%s want=%s", index, sources[index], source)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+const (
+	mtoonDefaultShadingToony     = 0.9
+	mtoonDefaultRimFresnelPower  = 5.0
+	vrm0MToonDefaultShadeToony   = 0.9
+	vrm0MToonDefaultRimFresnel   = 1.0
+	mtoonRimColorEpsilon         = 1e-6
+	mtoonMatcapFactorEpsilon     = 1e-6
+	generatedToonRampBoundsLimit = 4.0
+)
+
+// generatedToonRamp は生成toonの陰影境界を表す。
+// NdotL が LowerBound 以下で shade 色、UpperBound 以上で白となる。
+type generatedToonRamp struct {
+	LowerBound float64 `json:"lower_bound"`
+	UpperBound float64 `json:"upper_bound"`
+	Smooth     bool    `json:"smooth,omitempty"`
+}
+
+// mtoonRimSource は parametric rim の入力値を表す。
+type mtoonRimSource struct {
+	Color        [3]float64
+	FresnelPower float64
+	Lift         float64
+}
+
+// applyStandardMToonMaterialConversion は VRoid 以外のプロファイルで MToon 陰影を toon/sphere へ変換する。
+func applyStandardMToonMaterialConversion(
+	modelData *model.PmxModel,
+	doc *gltfDocument,
+	sourceMaterial gltfMaterial,
+	sourceMaterialIndex int,
+	hasSourceMaterial bool,
+	textureIndexesByImage []int,
+	materialData *model.Material,
+	registry *targetMorphRegistry,
+) {
+	if modelData == nil || materialData == nil || !hasSourceMaterial {
+		return
+	}
+	resolveStandardMToonToonTexture(modelData, doc, sourceMaterial, sourceMaterialIndex, materialData, registry)
+	resolveStandardMToonSphereTexture(
+		modelData,
+		doc,
+		sourceMaterial,
+		sourceMaterialIndex,
+		textureIndexesByImage,
+		materialData,
+	)
+}
+
+// resolveStandardMToonToonTexture は shade 色と shading shift/toony から個別toonを割り当てる。
+// 陰影境界は registry へ蓄積し、storeGeneratedToonRamps でまとめて記録する。
+func resolveStandardMToonToonTexture(
+	modelData *model.PmxModel,
+	doc *gltfDocument,
+	sourceMaterial gltfMaterial,
+	sourceMaterialIndex int,
+	materialData *model.Material,
+	registry *targetMorphRegistry,
+) {
+	shadeColor, hasShadeColor, shadeErr := resolveLegacyToonShadeColor(doc, sourceMaterial, sourceMaterialIndex)
+	if shadeErr != nil {
+		recordLegacyMaterialWarning(
+			modelData,
+			warningid.VrmWarningToonTextureGenerationFailed,
+			"toon生成スキップ: material=%s reason=%s",
+			strings.TrimSpace(materialData.Name()),
+			shadeErr.Error(),
+		)
+		return
+	}
+	if !hasShadeColor {
+		// MToon 以外の材質は既定の共有toonのまま扱う。
+		return
+	}
+	toonTextureIndex, toonFileName, err := registerGeneratedToonTexture(modelData, sourceMaterialIndex, shadeColor)
+	if err != nil {
+		recordLegacyMaterialWarning(
+			modelData,
+			warningid.VrmWarningToonTextureGenerationFailed,
+			"toon生成スキップ: material=%s reason=%s",
+			strings.TrimSpace(materialData.Name()),
+			err.Error(),
+		)
+		return
+	}
+	ramp := resolveMToonToonRamp(doc, sourceMaterial, sourceMaterialIndex)
+	if registry != nil && registry.GeneratedToonRamps != nil {
+		if normalizedToonFileName := normalizeGeneratedToonRampKey(toonFileName); normalizedToonFileName != "" {
+			registry.GeneratedToonRamps[normalizedToonFileName] = ramp
+		}
+	}
+
+	materialData.ToonSharingFlag = model.TOON_SHARING_INDIVIDUAL
+	materialData.ToonTextureIndex = toonTextureIndex
+	logVrmDebug(
+		"MToon toon生成: material=%s toon=%s shade=%v lower=%.3f upper=%.3f smooth=%t",
+		strings.TrimSpace(materialData.Name()),
+		toonFileName,
+		shadeColor,
+		ramp.LowerBound,
+		ramp.UpperBound,
+		ramp.Smooth,
+	)
+}
+
+// resolveStandardMToonSphereTexture は _SphereAdd/matcap/rim の順で sphere を割り当てる。
+func resolveStandardMToonSphereTexture(
+	modelData *model.PmxModel,
+	doc *gltfDocument,
+	sourceMaterial gltfMaterial,
+	sourceMaterialIndex int,
+	textureIndexesByImage []int,
+	materialData *model.Material,
+) {
+	for _, candidate := range standardMToonSphereCandidatesByPriority() {
+		if candidate == legacySphereCandidateMatcap && !hasEffectiveMatcapFactor(sourceMaterial) {
+			continue
+		}
+		sphereTextureIndex, resolved, generationFailed := resolveLegacySphereTextureCandidate(
+			modelData,
+			doc,
+			sourceMaterial,
+			sourceMaterialIndex,
+			textureIndexesByImage,
+			materialData,
+			candidate,
+		)
+		if !resolved {
+			// 標準プロファイルでは候補不在が通常のため、生成失敗のみ警告する。
+			if generationFailed {
+				recordLegacyMaterialWarning(
+					modelData,
+					warningid.VrmWarningSphereTextureGenerationFailed,
+					"sphere候補不採用: material=%s candidate=%s reason=%s",
+					strings.TrimSpace(materialData.Name()),
+					legacySphereCandidateLabel(candidate),
+					"texture generation failed",
+				)
+			}
+			continue
+		}
+		materialData.SphereTextureIndex = sphereTextureIndex
+		materialData.SphereMode = model.SPHERE_MODE_ADDITION
+		logLegacySphereCandidateSelection(materialData.Name(), false, candidate)
+		return
+	}
+}
+
+// standardMToonSphereCandidatesByPriority は標準プロファイルの sphere 候補優先順位を返す。
+func standardMToonSphereCandidatesByPriority() []legacySphereCandidateType {
+	return []legacySphereCandidateType{
+		legacySphereCandidateSphereAdd,
+		legacySphereCandidateMatcap,
+		legacySphereCandidateRim,
+	}
+}
+
+// resolveMToonToonRamp は VRM0/VRM1 MToon の shading shift/toony から toon 境界を求める。
+func resolveMToonToonRamp(
+	doc *gltfDocument,
+	sourceMaterial gltfMaterial,
+	sourceMaterialIndex int,
+) generatedToonRamp {
+	if property, ok := resolveVrm0MaterialProperty(doc, sourceMaterial, sourceMaterialIndex); ok {
+		if _, exists := lookupVrm0MaterialVectorProperty(property, "_ShadeColor", "ShadeColor"); exists {
+			shift, _ := lookupVrm0MaterialFloatProperty(property, "_ShadeShift", "ShadeShift")
+			toony, hasToony := lookupVrm0MaterialFloatProperty(property, "_ShadeToony", "ShadeToony")
+			if !hasToony {
+				toony = vrm0MToonDefaultShadeToony
+			}
+			toony = clampMToonUnit(toony)
+			// MToon0.x: smoothstep(shift, shift + (1 - toony), NdotL)
+			return normalizeGeneratedToonRamp(generatedToonRamp{
+				LowerBound: shift,
+				UpperBound: shift + (1.0 - toony),
+				Smooth:     true,
+			})
+		}
+	}
+
+	shift := 0.0
+	toony := mtoonDefaultShadingToony
+	if mtoonSource, hasMtoonSource, err := resolveMToonSource(sourceMaterial); err == nil && hasMtoonSource {
+		if mtoonSource.ShadingShiftFactor != nil {
+			shift = *mtoonSource.ShadingShiftFactor
+		}
+		if mtoonSource.ShadingToonyFactor != nil {
+			toony = *mtoonSource.ShadingToonyFactor
+		}
+	}
+	toony = clampMToonUnit(toony)
+	// MToon1.0: linearstep(-1 + toony, 1 - toony, NdotL + shift)
+	return normalizeGeneratedToonRamp(generatedToonRamp{
+		LowerBound: -1.0 + toony - shift,
+		UpperBound: 1.0 - toony - shift,
+	})
+}
+
+// normalizeGeneratedToonRamp は toon 境界を有限範囲へ丸め、上下を整える。
+func normalizeGeneratedToonRamp(ramp generatedToonRamp) generatedToonRamp {
+	ramp.LowerBound = math.Max(-generatedToonRampBoundsLimit, math.Min(generatedToonRampBoundsLimit, ramp.LowerBound))
+	ramp.UpperBound = math.Max(-generatedToonRampBoundsLimit, math.Min(generatedToonRampBoundsLimit, ramp.UpperBound))
+	if ramp.UpperBound < ramp.LowerBound {
+		ramp.LowerBound, ramp.UpperBound = ramp.UpperBound, ramp.LowerBound
+	}
+	return ramp
+}
+
+// resolveMToonRimSource は VRM0/VRM1 の parametric rim 入力を取得する。rim 色が黒の場合は false を返す。
+func resolveMToonRimSource(
+	doc *gltfDocument,
+	sourceMaterial gltfMaterial,
+	sourceMaterialIndex int,
+) (mtoonRimSource, bool) {
+	source := mtoonRimSource{}
+	hasColor := false
+	if property, ok := resolveVrm0MaterialProperty(doc, sourceMaterial, sourceMaterialIndex); ok {
+		if values, exists := lookupVrm0MaterialVectorProperty(property, "_RimColor", "RimColor"); exists && len(values) >= 3 {
+			source.Color = [3]float64{values[0], values[1], values[2]}
+			source.FresnelPower = vrm0MToonDefaultRimFresnel
+			if value, exists := lookupVrm0MaterialFloatProperty(property, "_RimFresnelPower", "RimFresnelPower"); exists {
+				source.FresnelPower = value
+			}
+			if value, exists := lookupVrm0MaterialFloatProperty(property, "_RimLift", "RimLift"); exists {
+				source.Lift = value
+			}
+			hasColor = true
+		}
+	}
+	if !hasColor {
+		mtoonSource, hasMtoonSource, err := resolveMToonSource(sourceMaterial)
+		if err != nil || !hasMtoonSource || len(mtoonSource.ParametricRimColorFactor) < 3 {
+			return mtoonRimSource{}, false
+		}
+		source.Color = [3]float64{
+			mtoonSource.ParametricRimColorFactor[0],
+			mtoonSource.ParametricRimColorFactor[1],
+			mtoonSource.ParametricRimColorFactor[2],
+		}
+		source.FresnelPower = mtoonDefaultRimFresnelPower
+		if mtoonSource.ParametricRimFresnelPowerFactor != nil {
+			source.FresnelPower = *mtoonSource.ParametricRimFresnelPowerFactor
+		}
+		if mtoonSource.ParametricRimLiftFactor != nil {
+			source.Lift = *mtoonSource.ParametricRimLiftFactor
+		}
+	}
+	for index := 0; index < 3; index++ {
+		source.Color[index] = clampMToonUnit(source.Color[index])
+	}
+	if source.Color[0] <= mtoonRimColorEpsilon &&
+		source.Color[1] <= mtoonRimColorEpsilon &&
+		source.Color[2] <= mtoonRimColorEpsilon {
+		return mtoonRimSource{}, false
+	}
+	if source.FresnelPower < 0 {
+		source.FresnelPower = 0
+	}
+	return source, true
+}
+
+// resolveLegacyMatcapFactor は matcapFactor を返す。未指定時は nil を返す。
+func resolveLegacyMatcapFactor(sourceMaterial gltfMaterial) *[3]float64 {
+	mtoonSource, hasMtoonSource, err := resolveMToonSource(sourceMaterial)
+	if err != nil || !hasMtoonSource || len(mtoonSource.MatcapFactor) < 3 {
+		return nil
+	}
+	factor := [3]float64{
+		clampMToonUnit(mtoonSource.MatcapFactor[0]),
+		clampMToonUnit(mtoonSource.MatcapFactor[1]),
+		clampMToonUnit(mtoonSource.MatcapFactor[2]),
+	}
+	return &factor
+}
+
+// hasEffectiveMatcapFactor は matcapFactor が黒以外(または未指定)かを判定する。
+func hasEffectiveMatcapFactor(sourceMaterial gltfMaterial) bool {
+	factor := resolveLegacyMatcapFactor(sourceMaterial)
+	if factor == nil {
+		return true
+	}
+	return factor[0] > mtoonMatcapFactorEpsilon ||
+		factor[1] > mtoonMatcapFactorEpsilon ||
+		factor[2] > mtoonMatcapFactorEpsilon
+}
+
+// clampMToonUnit は MToon 係数を 0..1 に丸める。
+func clampMToonUnit(value float64) float64 {
+	if value < 0 {
+		return 0
+	}
+	if value > 1 {
+		return 1
+	}
+	return value
+}
+
+// normalizeGeneratedToonRampKey は陰影境界の記録キーとなる小文字のtoonファイル名を返す。
+func normalizeGeneratedToonRampKey(toonFileName string) string {
+	return strings.ToLower(strings.TrimSpace(filepath.Base(toonFileName)))
+}
+
+// storeGeneratedToonRamps は生成toonごとの陰影境界を RawExtensions へ一括記録する。
+func storeGeneratedToonRamps(modelData *model.PmxModel, ramps map[string]generatedToonRamp) {
+	if modelData == nil || modelData.VrmData == nil || len(ramps) == 0 {
+		return
+	}
+	encodedRampMap, err := json.Marshal(ramps)
+	if err != nil {
+		logVrmWarn("toon陰影境界の保存に失敗しました: err=%s", err.Error())
+		return
+	}
+	if modelData.VrmData.RawExtensions == nil {
+		modelData.VrmData.RawExtensions = map[string]json.RawMessage{}
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmGeneratedToonRampMapRawExtensionKey] = encodedRampMap
+}
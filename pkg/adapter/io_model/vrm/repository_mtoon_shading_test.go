@@ -0,0 +1,120 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	modelvrm "github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+func TestAppendPrimitiveMaterialGeneratesToonRampAndRimSphereForStandardProfile(t *testing.T) {
+	modelData := model.NewPmxModel()
+	modelData.VrmData = &modelvrm.VrmData{
+		Profile:       modelvrm.VRM_PROFILE_STANDARD,
+		RawExtensions: map[string]json.RawMessage{},
+	}
+	mtoonRaw, err := json.Marshal(map[string]any{
+		"specVersion":                     "1.0",
+		"shadeColorFactor":                []any{0.2, 0.4, 0.6},
+		"shadingShiftFactor":              0.1,
+		"shadingToonyFactor":              0.5,
+		"parametricRimColorFactor":        []any{1.0, 0.5, 0.0},
+		"parametricRimFresnelPowerFactor": 3.0,
+		"parametricRimLiftFactor":         0.2,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal mtoon extension: %v", err)
+	}
+	doc := &gltfDocument{
+		Materials: []gltfMaterial{
+			{
+				Name:      "Outfit",
+				AlphaMode: "OPAQUE",
+				PbrMetallicRoughness: gltfPbrMetallicRoughness{
+					BaseColorFactor: []float64{1, 1, 1, 1},
+				},
+				Extensions: map[string]json.RawMessage{
+					"VRMC_materials_mtoon": mtoonRaw,
+				},
+			},
+			{
+				Name:      "Unlit",
+				AlphaMode: "OPAQUE",
+			},
+		},
+	}
+
+	outfitIndex := 0
+	registry := newTargetMorphRegistry()
+	appendedIndex := appendPrimitiveMaterial(
+		modelData,
+		doc,
+		gltfPrimitive{Material: &outfitIndex},
+		"Outfit",
+		nil,
+		3,
+		registry,
+	)
+	storeGeneratedToonRamps(modelData, registry.GeneratedToonRamps)
+	materialData, getErr := modelData.Materials.Get(appendedIndex)
+	if getErr != nil || materialData == nil {
+		t.Fatalf("appendPrimitiveMaterial failed: err=%v", getErr)
+	}
+	if materialData.ToonSharingFlag != model.TOON_SHARING_INDIVIDUAL {
+		t.Fatalf("toon sharing mismatch: got=%d want=%d", materialData.ToonSharingFlag, model.TOON_SHARING_INDIVIDUAL)
+	}
+	toonTexture, err := modelData.Textures.Get(materialData.ToonTextureIndex)
+	if err != nil || toonTexture == nil || toonTexture.Name() != "tex/toon01.bmp" {
+		t.Fatalf("toon texture mismatch: texture=%v err=%v", toonTexture, err)
+	}
+	rampMap := map[string]generatedToonRamp{}
+	if err := json.Unmarshal(modelData.VrmData.RawExtensions[warningid.VrmGeneratedToonRampMapRawExtensionKey], &rampMap); err != nil {
+		t.Fatalf("toon ramp map should be recorded: %v", err)
+	}
+	ramp, exists := rampMap["toon01.bmp"]
+	if !exists || math.Abs(ramp.LowerBound+0.6) > 1e-6 || math.Abs(ramp.UpperBound-0.4) > 1e-6 || ramp.Smooth {
+		t.Fatalf("toon ramp mismatch: exists=%t ramp=%+v", exists, ramp)
+	}
+
+	if materialData.SphereMode != model.SPHERE_MODE_ADDITION {
+		t.Fatalf("sphere mode mismatch: got=%d want=%d", materialData.SphereMode, model.SPHERE_MODE_ADDITION)
+	}
+	sphereTexture, err := modelData.Textures.Get(materialData.SphereTextureIndex)
+	if err != nil || sphereTexture == nil || sphereTexture.Name() != "tex/sphere/rim_sphere_000.png" {
+		t.Fatalf("rim sphere texture mismatch: texture=%v err=%v", sphereTexture, err)
+	}
+	metadataMap := map[string]legacyGeneratedSphereMetadata{}
+	if err := json.Unmarshal(modelData.VrmData.RawExtensions[legacyGeneratedSphereMetaKey], &metadataMap); err != nil {
+		t.Fatalf("rim sphere metadata should be recorded: %v", err)
+	}
+	metadata := metadataMap["tex/sphere/rim_sphere_000.png"]
+	if metadata.SphereKind != "rim" || metadata.RimFresnelPower != 3.0 || metadata.RimLift != 0.2 || metadata.RimColor[1] != 0.5 {
+		t.Fatalf("rim sphere metadata mismatch: %+v", metadata)
+	}
+
+	unlitIndex := 1
+	unlitAppendedIndex := appendPrimitiveMaterial(
+		modelData,
+		doc,
+		gltfPrimitive{Material: &unlitIndex},
+		"Unlit",
+		nil,
+		3,
+		newTargetMorphRegistry(),
+	)
+	unlitMaterial, getErr := modelData.Materials.Get(unlitAppendedIndex)
+	if getErr != nil || unlitMaterial == nil {
+		t.Fatalf("appendPrimitiveMaterial failed: err=%v", getErr)
+	}
+	defaultMaterial := model.NewMaterial()
+	if unlitMaterial.ToonTextureIndex != defaultMaterial.ToonTextureIndex || unlitMaterial.SphereMode != model.SPHERE_MODE_INVALID {
+		t.Fatalf("non-MToon material should keep default shading: toon=%d sphere=%d", unlitMaterial.ToonTextureIndex, unlitMaterial.SphereMode)
+	}
+	if hasWarningID(modelData, warningid.VrmWarningSphereTextureSourceMissing) {
+		t.Fatalf("standard profile should not warn missing sphere candidates")
+	}
+}
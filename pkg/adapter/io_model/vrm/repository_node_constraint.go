@@ -0,0 +1,409 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+const (
+	vrmNodeConstraintExtensionName = "VRMC_node_constraint"
+
+	nodeConstraintAimIkLoopCount = 20
+	nodeConstraintAimIkUnitDeg   = 57.29578
+
+	nodeConstraintAimTipBoneSuffix = "_aim先"
+	nodeConstraintAimIkBoneSuffix  = "_aimIK"
+)
+
+// vrmNodeConstraintExtensionSource は VRMC_node_constraint ノード拡張を表す。
+type vrmNodeConstraintExtensionSource struct {
+	Constraint *struct {
+		Roll     *vrmNodeConstraintSource `json:"roll"`
+		Aim      *vrmNodeConstraintSource `json:"aim"`
+		Rotation *vrmNodeConstraintSource `json:"rotation"`
+	} `json:"constraint"`
+}
+
+// vrmNodeConstraintSource は roll/aim/rotation 制約の共通要素を表す。
+type vrmNodeConstraintSource struct {
+	Source   *int     `json:"source"`
+	Weight   *float64 `json:"weight"`
+	RollAxis string   `json:"rollAxis"`
+	AimAxis  string   `json:"aimAxis"`
+}
+
+// applyNodeConstraints は VRMC_node_constraint を付与親/IK/固定軸へ変換する。
+func applyNodeConstraints(
+	modelData *model.PmxModel,
+	doc *gltfDocument,
+	parentIndexes []int,
+	nodeToBoneIndex map[int]int,
+	conversion vrmConversion,
+) {
+	if modelData == nil || modelData.Bones == nil || doc == nil {
+		return
+	}
+	convertedCount := 0
+	for nodeIndex, node := range doc.Nodes {
+		raw, exists := node.Extensions[vrmNodeConstraintExtensionName]
+		if !exists || len(raw) == 0 {
+			continue
+		}
+		source := vrmNodeConstraintExtensionSource{}
+		if err := json.Unmarshal(raw, &source); err != nil || source.Constraint == nil {
+			recordNodeConstraintWarning(
+				modelData,
+				"node constraint 解析失敗: node=%d",
+				nodeIndex,
+			)
+			continue
+		}
+		bone, ok := resolveNodeConstraintBone(modelData, nodeToBoneIndex, nodeIndex)
+		if !ok {
+			continue
+		}
+		switch {
+		case source.Constraint.Rotation != nil:
+			if applyNodeConstraintEffect(modelData, nodeToBoneIndex, bone, nodeIndex, "rotation", source.Constraint.Rotation) {
+				convertedCount++
+			}
+		case source.Constraint.Roll != nil:
+			// PMXの付与は回転全体を伝えるため、rollAxis 以外の成分も伝播する近似となる。
+			if applyNodeConstraintEffect(modelData, nodeToBoneIndex, bone, nodeIndex, "roll", source.Constraint.Roll) {
+				recordNodeConstraintApproximatedWarning(
+					modelData,
+					"node constraint roll 付与近似: node=%d bone=%s rollAxis=%s",
+					nodeIndex,
+					bone.Name(),
+					source.Constraint.Roll.RollAxis,
+				)
+				convertedCount++
+			}
+		case source.Constraint.Aim != nil:
+			aimDirection := resolveNodeConstraintAimDirection(doc, parentIndexes, nodeIndex, source.Constraint.Aim, conversion)
+			if applyNodeConstraintAim(modelData, nodeToBoneIndex, bone, nodeIndex, aimDirection, source.Constraint.Aim) {
+				convertedCount++
+			}
+		default:
+			recordNodeConstraintWarning(
+				modelData,
+				"node constraint 種別不明: node=%d",
+				nodeIndex,
+			)
+		}
+	}
+	if convertedCount > 0 {
+		logVrmInfo("node constraint変換完了: converted=%d", convertedCount)
+	}
+}
+
+// applyNodeConstraintEffect は rotation/roll 制約を回転付与へ変換する。
+func applyNodeConstraintEffect(
+	modelData *model.PmxModel,
+	nodeToBoneIndex map[int]int,
+	bone *model.Bone,
+	nodeIndex int,
+	constraintType string,
+	constraint *vrmNodeConstraintSource,
+) bool {
+	sourceBone, ok := resolveNodeConstraintSourceBone(modelData, nodeToBoneIndex, bone, nodeIndex, constraintType, constraint)
+	if !ok {
+		return false
+	}
+	weight := resolveNodeConstraintWeight(constraint)
+	if weight <= 0 {
+		return false
+	}
+	if hasNodeConstraintEffectCycle(modelData, bone.Index(), sourceBone.Index()) {
+		recordNodeConstraintWarning(
+			modelData,
+			"node constraint 付与循環: node=%d type=%s source=%s",
+			nodeIndex,
+			constraintType,
+			sourceBone.Name(),
+		)
+		return false
+	}
+	bone.EffectIndex = sourceBone.Index()
+	bone.EffectFactor = weight
+	bone.BoneFlag |= model.BONE_FLAG_IS_EXTERNAL_ROTATION
+	logVrmDebug(
+		"node constraint付与設定: bone=%s type=%s source=%s weight=%.3f",
+		bone.Name(),
+		constraintType,
+		sourceBone.Name(),
+		weight,
+	)
+	return true
+}
+
+// applyNodeConstraintAim は aim 制約を専用IKボーンによる単一リンクIK、または固定軸へ変換する。
+// aimDirection は aimAxis をPMX座標系のワールド方向へ変換した単位ベクトルとする。
+func applyNodeConstraintAim(
+	modelData *model.PmxModel,
+	nodeToBoneIndex map[int]int,
+	bone *model.Bone,
+	nodeIndex int,
+	aimDirection mmath.Vec3,
+	constraint *vrmNodeConstraintSource,
+) bool {
+	sourceBone, ok := resolveNodeConstraintSourceBone(modelData, nodeToBoneIndex, bone, nodeIndex, "aim", constraint)
+	if !ok {
+		return false
+	}
+	weight := resolveNodeConstraintWeight(constraint)
+	if weight <= 0 {
+		return false
+	}
+	direction := sourceBone.Position.Subed(bone.Position)
+	if direction.Length() <= 1e-8 || aimDirection.Length() <= 1e-8 {
+		recordNodeConstraintWarning(
+			modelData,
+			"node constraint aim 変換不可: node=%d source=%s",
+			nodeIndex,
+			sourceBone.Name(),
+		)
+		return false
+	}
+
+	// 制約ボーンの aimAxis 方向へ注視先ボーンを置き、注視先に追従する専用IKボーンで制約ボーンを向ける。
+	if !isBoneAncestorOf(modelData, bone.Index(), sourceBone.Index()) &&
+		!isBoneAncestorOf(modelData, sourceBone.Index(), bone.Index()) {
+		tipBone := &model.Bone{
+			Position:     bone.Position.Added(aimDirection.MuledScalar(direction.Length())),
+			ParentIndex:  bone.Index(),
+			TailIndex:    -1,
+			EffectIndex:  -1,
+			BoneFlag:     model.BONE_FLAG_CAN_ROTATE,
+			TailPosition: mmath.ZERO_VEC3,
+		}
+		tipBone.SetName(resolveNodeConstraintBoneName(modelData, bone.Name()+nodeConstraintAimTipBoneSuffix))
+		tipBoneIndex := modelData.Bones.AppendRaw(tipBone)
+		tipBone.Layer = boneLayer(modelData, tipBoneIndex)
+
+		unit := mmath.DegToRad(nodeConstraintAimIkUnitDeg)
+		ikBone := &model.Bone{
+			Position:    sourceBone.Position,
+			ParentIndex: sourceBone.Index(),
+			TailIndex:   -1,
+			EffectIndex: -1,
+			BoneFlag: model.BONE_FLAG_CAN_ROTATE | model.BONE_FLAG_CAN_TRANSLATE | model.BONE_FLAG_IS_VISIBLE |
+				model.BONE_FLAG_CAN_MANIPULATE | model.BONE_FLAG_IS_IK,
+			TailPosition: mmath.ZERO_VEC3,
+			Ik: &model.Ik{
+				BoneIndex:    tipBoneIndex,
+				LoopCount:    nodeConstraintAimIkLoopCount,
+				UnitRotation: mmath.Vec3{Vec: r3.Vec{X: unit, Y: unit, Z: unit}},
+				Links:        []model.IkLink{{BoneIndex: bone.Index()}},
+			},
+		}
+		ikBone.SetName(resolveNodeConstraintBoneName(modelData, bone.Name()+nodeConstraintAimIkBoneSuffix))
+		ikBoneIndex := modelData.Bones.AppendRaw(ikBone)
+		ikBone.Layer = boneLayer(modelData, ikBoneIndex)
+		// IKは weight による部分追従と aim 軸周りのロール拘束を表現できないため近似変換となる。
+		recordNodeConstraintApproximatedWarning(
+			modelData,
+			"node constraint aim IK近似: node=%d bone=%s source=%s ik=%s weight=%.3f",
+			nodeIndex,
+			bone.Name(),
+			sourceBone.Name(),
+			ikBone.Name(),
+			weight,
+		)
+		return true
+	}
+
+	// 親子関係にある場合はIKが循環するため、注視先方向の固定軸で近似する。
+	bone.FixedAxis = direction.Normalized()
+	bone.BoneFlag |= model.BONE_FLAG_HAS_FIXED_AXIS
+	recordNodeConstraintApproximatedWarning(
+		modelData,
+		"node constraint aim 固定軸近似: node=%d bone=%s source=%s",
+		nodeIndex,
+		bone.Name(),
+		sourceBone.Name(),
+	)
+	return true
+}
+
+// resolveNodeConstraintAimDirection は aimAxis をノードのワールド回転で回し、PMX座標系の単位ベクトルで返す。
+// matrix 指定ノードの回転は参照せず、回転なしとして扱う。
+func resolveNodeConstraintAimDirection(
+	doc *gltfDocument,
+	parentIndexes []int,
+	nodeIndex int,
+	constraint *vrmNodeConstraintSource,
+	conversion vrmConversion,
+) mmath.Vec3 {
+	axis := resolveNodeConstraintAimAxis(constraint)
+	rotation := mmath.NewQuaternion()
+	visited := map[int]struct{}{}
+	for current := nodeIndex; doc != nil && current >= 0 && current < len(doc.Nodes); {
+		if _, exists := visited[current]; exists {
+			break
+		}
+		visited[current] = struct{}{}
+		if localRotation, err := parseQuaternion(doc.Nodes[current].Rotation); err == nil {
+			rotation = localRotation.Muled(rotation)
+		}
+		if current >= len(parentIndexes) {
+			break
+		}
+		current = parentIndexes[current]
+	}
+	return convertVrmNormalToPmx(rotation.MulVec3(axis), conversion)
+}
+
+// resolveNodeConstraintAimAxis は aimAxis をノードローカルの単位ベクトルへ変換する。未指定時は仕様既定値の PositiveX とする。
+func resolveNodeConstraintAimAxis(constraint *vrmNodeConstraintSource) mmath.Vec3 {
+	axisName := ""
+	if constraint != nil {
+		axisName = strings.TrimSpace(constraint.AimAxis)
+	}
+	switch axisName {
+	case "NegativeX":
+		return mmath.Vec3{Vec: r3.Vec{X: -1}}
+	case "PositiveY":
+		return mmath.Vec3{Vec: r3.Vec{Y: 1}}
+	case "NegativeY":
+		return mmath.Vec3{Vec: r3.Vec{Y: -1}}
+	case "PositiveZ":
+		return mmath.Vec3{Vec: r3.Vec{Z: 1}}
+	case "NegativeZ":
+		return mmath.Vec3{Vec: r3.Vec{Z: -1}}
+	default:
+		return mmath.Vec3{Vec: r3.Vec{X: 1}}
+	}
+}
+
+// resolveNodeConstraintBoneName は追加ボーン名を既存ボーンと重複しない名前で返す。
+func resolveNodeConstraintBoneName(modelData *model.PmxModel, baseName string) string {
+	if _, err := modelData.Bones.GetByName(baseName); err != nil {
+		return baseName
+	}
+	for index := 1; ; index++ {
+		candidateName := fmt.Sprintf("%s_%d", baseName, index)
+		if _, err := modelData.Bones.GetByName(candidateName); err != nil {
+			return candidateName
+		}
+	}
+}
+
+// resolveNodeConstraintBone は制約対象ノードのボーンを返す。
+func resolveNodeConstraintBone(modelData *model.PmxModel, nodeToBoneIndex map[int]int, nodeIndex int) (*model.Bone, bool) {
+	boneIndex, exists := nodeToBoneIndex[nodeIndex]
+	if !exists {
+		return nil, false
+	}
+	bone, err := modelData.Bones.Get(boneIndex)
+	if err != nil || bone == nil {
+		return nil, false
+	}
+	return bone, true
+}
+
+// resolveNodeConstraintSourceBone は制約 source ノードのボーンを返し、不正時は警告を記録する。
+func resolveNodeConstraintSourceBone(
+	modelData *model.PmxModel,
+	nodeToBoneIndex map[int]int,
+	bone *model.Bone,
+	nodeIndex int,
+	constraintType string,
+	constraint *vrmNodeConstraintSource,
+) (*model.Bone, bool) {
+	if constraint == nil || constraint.Source == nil {
+		recordNodeConstraintWarning(
+			modelData,
+			"node constraint source 未指定: node=%d type=%s",
+			nodeIndex,
+			constraintType,
+		)
+		return nil, false
+	}
+	sourceBone, ok := resolveNodeConstraintBone(modelData, nodeToBoneIndex, *constraint.Source)
+	if !ok || sourceBone.Index() == bone.Index() {
+		recordNodeConstraintWarning(
+			modelData,
+			"node constraint source 不正: node=%d type=%s source=%d",
+			nodeIndex,
+			constraintType,
+			*constraint.Source,
+		)
+		return nil, false
+	}
+	return sourceBone, true
+}
+
+// resolveNodeConstraintWeight は制約 weight を 0..1 で返す。未指定時は仕様既定値の1.0とする。
+func resolveNodeConstraintWeight(constraint *vrmNodeConstraintSource) float64 {
+	if constraint == nil || constraint.Weight == nil {
+		return 1.0
+	}
+	weight := *constraint.Weight
+	if weight < 0 {
+		return 0
+	}
+	if weight > 1 {
+		return 1
+	}
+	return weight
+}
+
+// hasNodeConstraintEffectCycle は付与親連鎖が制約ボーンへ戻るかを判定する。
+func hasNodeConstraintEffectCycle(modelData *model.PmxModel, boneIndex int, sourceBoneIndex int) bool {
+	visited := map[int]struct{}{}
+	current := sourceBoneIndex
+	for current >= 0 {
+		if current == boneIndex {
+			return true
+		}
+		if _, exists := visited[current]; exists {
+			return true
+		}
+		visited[current] = struct{}{}
+		currentBone, err := modelData.Bones.Get(current)
+		if err != nil || currentBone == nil {
+			return false
+		}
+		current = currentBone.EffectIndex
+	}
+	return false
+}
+
+// isBoneAncestorOf は ancestorIndex が boneIndex の親系列に含まれるかを判定する。
+func isBoneAncestorOf(modelData *model.PmxModel, ancestorIndex int, boneIndex int) bool {
+	visited := map[int]struct{}{}
+	current := boneIndex
+	for current >= 0 {
+		currentBone, err := modelData.Bones.Get(current)
+		if err != nil || currentBone == nil {
+			return false
+		}
+		if currentBone.ParentIndex == ancestorIndex {
+			return true
+		}
+		if _, exists := visited[current]; exists {
+			return false
+		}
+		visited[current] = struct{}{}
+		current = currentBone.ParentIndex
+	}
+	return false
+}
+
+// recordNodeConstraintWarning は node constraint 変換不可警告を記録する。
+func recordNodeConstraintWarning(modelData *model.PmxModel, messageFormat string, params ...any) {
+	recordLegacyMaterialWarning(modelData, warningid.VrmWarningNodeConstraintNotConvertible, messageFormat, params...)
+}
+
+// recordNodeConstraintApproximatedWarning は node constraint 近似変換警告を記録する。
+func recordNodeConstraintApproximatedWarning(modelData *model.PmxModel, messageFormat string, params ...any) {
+	recordLegacyMaterialWarning(modelData, warningid.VrmWarningNodeConstraintApproximated, messageFormat, params...)
+}
@@ -0,0 +1,240 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+func TestVrmRepositoryLoadConvertsNodeConstraints(t *testing.T) {
+	repository := NewVrmRepository()
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "avatar.vrm")
+
+	doc := map[string]any{
+		"asset": map[string]any{
+			"version":   "2.0",
+			"generator": "UniGLTF-2.0",
+		},
+		"extensionsUsed": []string{"VRMC_vrm", "VRMC_node_constraint"},
+		"nodes": []any{
+			map[string]any{
+				"name":        "hips_node",
+				"translation": []float64{0, 0.9, 0},
+				"children":    []int{1, 2, 3, 5, 6},
+			},
+			map[string]any{
+				"name":        "arm_source",
+				"translation": []float64{0.2, 0.4, 0},
+			},
+			map[string]any{
+				"name":        "arm_twist",
+				"translation": []float64{0.3, 0.4, 0},
+				"extensions": map[string]any{
+					"VRMC_node_constraint": map[string]any{
+						"specVersion": "1.0",
+						"constraint": map[string]any{
+							"rotation": map[string]any{"source": 1, "weight": 0.5},
+						},
+					},
+				},
+			},
+			map[string]any{
+				"name":        "aim_bone",
+				"translation": []float64{0, 0.5, 0.1},
+				"children":    []int{4},
+				"extensions": map[string]any{
+					"VRMC_node_constraint": map[string]any{
+						"specVersion": "1.0",
+						"constraint": map[string]any{
+							"aim": map[string]any{"source": 5, "aimAxis": "PositiveZ"},
+						},
+					},
+				},
+			},
+			map[string]any{
+				"name":        "aim_tip",
+				"translation": []float64{0, 0, 0.1},
+			},
+			map[string]any{
+				"name":        "aim_target",
+				"translation": []float64{0, 0.5, 0.5},
+			},
+			map[string]any{
+				"name":        "broken_roll",
+				"translation": []float64{0, 0.2, 0},
+				"extensions": map[string]any{
+					"VRMC_node_constraint": map[string]any{
+						"specVersion": "1.0",
+						"constraint": map[string]any{
+							"roll": map[string]any{"source": 99, "rollAxis": "X"},
+						},
+					},
+				},
+			},
+		},
+		"extensions": map[string]any{
+			"VRMC_vrm": map[string]any{
+				"specVersion": "1.0",
+				"humanoid": map[string]any{
+					"humanBones": map[string]any{
+						"hips": map[string]any{"node": 0},
+					},
+				},
+			},
+		},
+	}
+	writeGLBFileForTest(t, path, doc)
+
+	hashableModel, err := repository.Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	pmxModel, ok := hashableModel.(*model.PmxModel)
+	if !ok {
+		t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+	}
+
+	sourceBone, err := pmxModel.Bones.GetByName("arm_source")
+	if err != nil || sourceBone == nil {
+		t.Fatalf("expected arm_source bone: %v", err)
+	}
+	twistBone, err := pmxModel.Bones.GetByName("arm_twist")
+	if err != nil || twistBone == nil {
+		t.Fatalf("expected arm_twist bone: %v", err)
+	}
+	if twistBone.EffectIndex != sourceBone.Index() || math.Abs(twistBone.EffectFactor-0.5) > 1e-6 {
+		t.Fatalf("rotation constraint should become effect: index=%d factor=%f", twistBone.EffectIndex, twistBone.EffectFactor)
+	}
+	if twistBone.BoneFlag&model.BONE_FLAG_IS_EXTERNAL_ROTATION == 0 {
+		t.Fatalf("rotation constraint should set external rotation flag")
+	}
+
+	aimTarget, err := pmxModel.Bones.GetByName("aim_target")
+	if err != nil || aimTarget == nil {
+		t.Fatalf("expected aim_target bone: %v", err)
+	}
+	aimBone, err := pmxModel.Bones.GetByName("aim_bone")
+	if err != nil || aimBone == nil {
+		t.Fatalf("expected aim_bone bone: %v", err)
+	}
+	aimTip, err := pmxModel.Bones.GetByName("aim_tip")
+	if err != nil || aimTip == nil {
+		t.Fatalf("expected aim_tip bone: %v", err)
+	}
+	if aimTarget.Ik != nil || aimTarget.BoneFlag&model.BONE_FLAG_IS_IK != 0 {
+		t.Fatalf("aim constraint should not take over source bone: %#v", aimTarget.Ik)
+	}
+	aimIk, err := pmxModel.Bones.GetByName("aim_bone_aimIK")
+	if err != nil || aimIk == nil || aimIk.Ik == nil {
+		t.Fatalf("expected dedicated aim IK bone: %v", err)
+	}
+	if aimIk.ParentIndex != aimTarget.Index() || aimIk.Position.Distance(aimTarget.Position) > 1e-6 {
+		t.Fatalf("aim IK bone should follow source: parent=%d position=%v", aimIk.ParentIndex, aimIk.Position)
+	}
+	if len(aimIk.Ik.Links) != 1 || aimIk.Ik.Links[0].BoneIndex != aimBone.Index() {
+		t.Fatalf("aim IK link mismatch: %#v", aimIk.Ik.Links)
+	}
+	aimIkTarget, err := pmxModel.Bones.Get(aimIk.Ik.BoneIndex)
+	if err != nil || aimIkTarget == nil || aimIkTarget.Index() == aimTip.Index() || aimIkTarget.ParentIndex != aimBone.Index() {
+		t.Fatalf("aim IK target should be dedicated child of aim_bone: %#v", aimIkTarget)
+	}
+	// aimAxis=PositiveZ は子 aim_tip と同じ +Z 方向へ注視先ボーンを置く。
+	aimAxisDirection := aimIkTarget.Position.Subed(aimBone.Position).Normalized()
+	tipDirection := aimTip.Position.Subed(aimBone.Position).Normalized()
+	if aimAxisDirection.Distance(tipDirection) > 1e-6 {
+		t.Fatalf("aim IK target should be placed along aimAxis: got=%v want=%v", aimAxisDirection, tipDirection)
+	}
+	if math.Abs(aimIkTarget.Position.Distance(aimBone.Position)-aimTarget.Position.Distance(aimBone.Position)) > 1e-6 {
+		t.Fatalf("aim IK target distance mismatch: %v", aimIkTarget.Position)
+	}
+	if !hasWarningID(pmxModel, warningid.VrmWarningNodeConstraintApproximated) {
+		t.Fatalf("expected warning id %s", warningid.VrmWarningNodeConstraintApproximated)
+	}
+
+	if !hasWarningID(pmxModel, warningid.VrmWarningNodeConstraintNotConvertible) {
+		t.Fatalf("expected warning id %s", warningid.VrmWarningNodeConstraintNotConvertible)
+	}
+}
+
+func TestVrmRepositoryLoadConvertsRollNodeConstraintWithApproximatedWarning(t *testing.T) {
+	repository := NewVrmRepository()
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "avatar.vrm")
+
+	doc := map[string]any{
+		"asset": map[string]any{
+			"version":   "2.0",
+			"generator": "UniGLTF-2.0",
+		},
+		"extensionsUsed": []string{"VRMC_vrm", "VRMC_node_constraint"},
+		"nodes": []any{
+			map[string]any{
+				"name":        "hips_node",
+				"translation": []float64{0, 0.9, 0},
+				"children":    []int{1, 2},
+			},
+			map[string]any{
+				"name":        "hand_source",
+				"translation": []float64{0.4, 0.4, 0},
+			},
+			map[string]any{
+				"name":        "forearm_roll",
+				"translation": []float64{0.3, 0.4, 0},
+				"extensions": map[string]any{
+					"VRMC_node_constraint": map[string]any{
+						"specVersion": "1.0",
+						"constraint": map[string]any{
+							"roll": map[string]any{"source": 1, "rollAxis": "X", "weight": 0.5},
+						},
+					},
+				},
+			},
+		},
+		"extensions": map[string]any{
+			"VRMC_vrm": map[string]any{
+				"specVersion": "1.0",
+				"humanoid": map[string]any{
+					"humanBones": map[string]any{
+						"hips": map[string]any{"node": 0},
+					},
+				},
+			},
+		},
+	}
+	writeGLBFileForTest(t, path, doc)
+
+	hashableModel, err := repository.Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	pmxModel, ok := hashableModel.(*model.PmxModel)
+	if !ok {
+		t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+	}
+
+	sourceBone, err := pmxModel.Bones.GetByName("hand_source")
+	if err != nil || sourceBone == nil {
+		t.Fatalf("expected hand_source bone: %v", err)
+	}
+	rollBone, err := pmxModel.Bones.GetByName("forearm_roll")
+	if err != nil || rollBone == nil {
+		t.Fatalf("expected forearm_roll bone: %v", err)
+	}
+	if rollBone.EffectIndex != sourceBone.Index() || math.Abs(rollBone.EffectFactor-0.5) > 1e-6 {
+		t.Fatalf("roll constraint should become effect: index=%d factor=%f", rollBone.EffectIndex, rollBone.EffectFactor)
+	}
+	if rollBone.BoneFlag&model.BONE_FLAG_IS_EXTERNAL_ROTATION == 0 {
+		t.Fatalf("roll constraint should set external rotation flag")
+	}
+	if !hasWarningID(pmxModel, warningid.VrmWarningNodeConstraintApproximated) {
+		t.Fatalf("expected warning id %s", warningid.VrmWarningNodeConstraintApproximated)
+	}
+	if hasWarningID(pmxModel, warningid.VrmWarningNodeConstraintNotConvertible) {
+		t.Fatalf("valid roll constraint should not record warning id %s", warningid.VrmWarningNodeConstraintNotConvertible)
+	}
+}
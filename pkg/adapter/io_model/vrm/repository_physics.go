@@ -0,0 +1,922 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+const (
+	// PMX衝突グループは 0..15 の16枠。体(予約)=0、コライダー=1..13、揺れ物=15 を使う。
+	physicsBodyCollisionGroup          = 0
+	physicsColliderCollisionGroupStart = 1
+	physicsColliderCollisionGroupCount = 13
+	physicsSpringCollisionGroup        = 15
+	physicsCollisionMaskAll            = 0xFFFF
+
+	springStiffnessMax    = 4.0
+	springGravityPowerMax = 2.0
+	springDragForceMax    = 1.0
+	springHitRadiusMax    = 0.5
+
+	springRigidBodyMinRadius        = 0.05
+	springRigidBodyMinLength        = 0.05
+	springRigidBodyBaseMass         = 1.0
+	springRigidBodyMinDamping       = 0.5
+	springRigidBodyMaxDamping       = 0.999
+	springRigidBodyRestitution      = 0.0
+	springRigidBodyFriction         = 0.5
+	springJointMaxRotationDegree    = 45.0
+	springJointMinRotationDegree    = 5.0
+	springJointRotationSpringFactor = 25.0
+	springGravityDirectionTolerance = 1e-3
+	springStaticRigidBodyMass       = 1.0
+	springStaticRigidBodyDamping    = 0.5
+	springColliderRigidBodyFriction = 0.0
+)
+
+// springJointParam は揺れ物1関節分の変換前パラメータを表す。
+type springJointParam struct {
+	Stiffness    float64
+	GravityPower float64
+	GravityDir   mmath.Vec3
+	DragForce    float64
+	HitRadius    float64
+}
+
+// springChainSource は揺れ物1グループ分の変換前情報を表す。
+type springChainSource struct {
+	Name            string
+	RootNodeIndexes []int
+	// JointNodeIndexes は関節列が明示される場合(VRM1)の node 順序。空なら root 以下の子孫全体を辿る。
+	JointNodeIndexes     []int
+	ParamsByNode         map[int]springJointParam
+	DefaultParam         springJointParam
+	ColliderGroupIndexes []int
+}
+
+// springColliderShape はコライダー形状を表す。
+type springColliderShape int
+
+const (
+	springColliderShapeSphere springColliderShape = iota
+	springColliderShapeCapsule
+)
+
+// springColliderSource はコライダー1件分の変換前情報を表す。
+type springColliderSource struct {
+	NodeIndex int
+	Shape     springColliderShape
+	Offset    mmath.Vec3
+	Tail      mmath.Vec3
+	Radius    float64
+}
+
+// springColliderGroupSource はコライダーグループ1件分の変換前情報を表す。
+type springColliderGroupSource struct {
+	Name      string
+	Colliders []springColliderSource
+}
+
+// springPhysicsSource は揺れ物変換の入力一式を表す。
+type springPhysicsSource struct {
+	Chains         []springChainSource
+	ColliderGroups []springColliderGroupSource
+}
+
+// springPhysicsStats は揺れ物変換の集計情報を表す。
+type springPhysicsStats struct {
+	Chains             int
+	DynamicRigidBodies int
+	StaticRigidBodies  int
+	ColliderBodies     int
+	Joints             int
+	ClampedParams      int
+	SkippedNodes       int
+}
+
+// vrm0SecondaryAnimationExtension は VRM0 拡張の secondaryAnimation 部分を表す。
+type vrm0SecondaryAnimationExtension struct {
+	SecondaryAnimation vrm0SecondaryAnimationSource `json:"secondaryAnimation"`
+}
+
+// vrm0SecondaryAnimationSource は VRM0 secondaryAnimation の最小構造を表す。
+type vrm0SecondaryAnimationSource struct {
+	BoneGroups     []vrm0SpringBoneGroupSource     `json:"boneGroups"`
+	ColliderGroups []vrm0SpringColliderGroupSource `json:"colliderGroups"`
+}
+
+// vrm0SpringBoneGroupSource は VRM0 boneGroups 要素を表す。
+type vrm0SpringBoneGroupSource struct {
+	Comment string `json:"comment"`
+	// Stiffiness は VRM0 仕様上の綴り(stiffiness)を受ける。
+	Stiffiness     *float64           `json:"stiffiness"`
+	Stiffness      *float64           `json:"stiffness"`
+	GravityPower   *float64           `json:"gravityPower"`
+	GravityDir     *vrm0Vector3Source `json:"gravityDir"`
+	DragForce      *float64           `json:"dragForce"`
+	Center         *int               `json:"center"`
+	HitRadius      *float64           `json:"hitRadius"`
+	Bones          []int              `json:"bones"`
+	ColliderGroups []int              `json:"colliderGroups"`
+}
+
+// vrm0SpringColliderGroupSource は VRM0 colliderGroups 要素を表す。
+type vrm0SpringColliderGroupSource struct {
+	Node      int                        `json:"node"`
+	Colliders []vrm0SpringColliderSource `json:"colliders"`
+}
+
+// vrm0SpringColliderSource は VRM0 colliders 要素を表す。
+type vrm0SpringColliderSource struct {
+	Offset vrm0Vector3Source `json:"offset"`
+	Radius float64           `json:"radius"`
+}
+
+// vrm0Vector3Source は VRM0 の {x,y,z} ベクトル表現を表す。
+type vrm0Vector3Source struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// vrm1SpringBoneExtension は VRMC_springBone 拡張の最小構造を表す。
+type vrm1SpringBoneExtension struct {
+	SpecVersion    string                          `json:"specVersion"`
+	Colliders      []vrm1SpringColliderSource      `json:"colliders"`
+	ColliderGroups []vrm1SpringColliderGroupSource `json:"colliderGroups"`
+	Springs        []vrm1SpringSource              `json:"springs"`
+}
+
+// vrm1SpringColliderSource は VRMC_springBone colliders 要素を表す。
+type vrm1SpringColliderSource struct {
+	Node  int `json:"node"`
+	Shape struct {
+		Sphere *struct {
+			Offset []float64 `json:"offset"`
+			Radius float64   `json:"radius"`
+		} `json:"sphere"`
+		Capsule *struct {
+			Offset []float64 `json:"offset"`
+			Radius float64   `json:"radius"`
+			Tail   []float64 `json:"tail"`
+		} `json:"capsule"`
+	} `json:"shape"`
+}
+
+// vrm1SpringColliderGroupSource は VRMC_springBone colliderGroups 要素を表す。
+type vrm1SpringColliderGroupSource struct {
+	Name      string `json:"name"`
+	Colliders []int  `json:"colliders"`
+}
+
+// vrm1SpringSource は VRMC_springBone springs 要素を表す。
+type vrm1SpringSource struct {
+	Name           string                  `json:"name"`
+	Joints         []vrm1SpringJointSource `json:"joints"`
+	ColliderGroups []int                   `json:"colliderGroups"`
+	Center         *int                    `json:"center"`
+}
+
+// vrm1SpringJointSource は VRMC_springBone joints 要素を表す。
+type vrm1SpringJointSource struct {
+	Node         int       `json:"node"`
+	HitRadius    *float64  `json:"hitRadius"`
+	Stiffness    *float64  `json:"stiffness"`
+	GravityPower *float64  `json:"gravityPower"`
+	GravityDir   []float64 `json:"gravityDir"`
+	DragForce    *float64  `json:"dragForce"`
+}
+
+// appendSpringBonePhysics は VRM の揺れ物定義から PMX 剛体/ジョイントを生成する。
+func appendSpringBonePhysics(
+	modelData *model.PmxModel,
+	doc *gltfDocument,
+	vrmData *vrm.VrmData,
+	nodeToBoneIndex map[int]int,
+	conversion vrmConversion,
+) {
+	if modelData == nil || modelData.RigidBodies == nil || modelData.Joints == nil || doc == nil || vrmData == nil {
+		return
+	}
+	source, ok := resolveSpringPhysicsSource(modelData, doc, vrmData)
+	if !ok || len(source.Chains) == 0 {
+		return
+	}
+	stats := buildSpringPhysics(modelData, doc, source, nodeToBoneIndex, conversion)
+	logVrmInfo(
+		"VRM揺れ物変換完了: chains=%d dynamic=%d static=%d colliders=%d joints=%d clamped=%d skipped=%d",
+		stats.Chains,
+		stats.DynamicRigidBodies,
+		stats.StaticRigidBodies,
+		stats.ColliderBodies,
+		stats.Joints,
+		stats.ClampedParams,
+		stats.SkippedNodes,
+	)
+}
+
+// resolveSpringPhysicsSource はVRMバージョンに応じた揺れ物定義を解決する。
+func resolveSpringPhysicsSource(
+	modelData *model.PmxModel,
+	doc *gltfDocument,
+	vrmData *vrm.VrmData,
+) (springPhysicsSource, bool) {
+	if doc == nil || doc.Extensions == nil || vrmData == nil {
+		return springPhysicsSource{}, false
+	}
+	if vrmData.Version == vrm.VRM_VERSION_1 {
+		raw, exists := doc.Extensions["VRMC_springBone"]
+		if !exists {
+			return springPhysicsSource{}, false
+		}
+		source, err := parseVrm1SpringPhysicsSource(modelData, raw)
+		if err != nil {
+			logVrmWarn("VRMC_springBone の解析に失敗したため揺れ物変換を省略します: err=%s", err.Error())
+			return springPhysicsSource{}, false
+		}
+		return source, true
+	}
+	if vrmData.Version != vrm.VRM_VERSION_0 {
+		return springPhysicsSource{}, false
+	}
+	raw, exists := doc.Extensions["VRM"]
+	if !exists {
+		return springPhysicsSource{}, false
+	}
+	source, err := parseVrm0SpringPhysicsSource(modelData, raw)
+	if err != nil {
+		logVrmWarn("VRM0 secondaryAnimation の解析に失敗したため揺れ物変換を省略します: err=%s", err.Error())
+		return springPhysicsSource{}, false
+	}
+	return source, true
+}
+
+// parseVrm0SpringPhysicsSource は VRM0 secondaryAnimation を揺れ物変換入力へ変換する。
+func parseVrm0SpringPhysicsSource(modelData *model.PmxModel, raw json.RawMessage) (springPhysicsSource, error) {
+	ext := vrm0SecondaryAnimationExtension{}
+	if err := json.Unmarshal(raw, &ext); err != nil {
+		return springPhysicsSource{}, err
+	}
+	source := springPhysicsSource{
+		Chains:         make([]springChainSource, 0, len(ext.SecondaryAnimation.BoneGroups)),
+		ColliderGroups: make([]springColliderGroupSource, 0, len(ext.SecondaryAnimation.ColliderGroups)),
+	}
+	for groupIndex, colliderGroup := range ext.SecondaryAnimation.ColliderGroups {
+		group := springColliderGroupSource{
+			Name:      fmt.Sprintf("collider_%03d", groupIndex),
+			Colliders: make([]springColliderSource, 0, len(colliderGroup.Colliders)),
+		}
+		for _, collider := range colliderGroup.Colliders {
+			group.Colliders = append(group.Colliders, springColliderSource{
+				NodeIndex: colliderGroup.Node,
+				Shape:     springColliderShapeSphere,
+				Offset: mmath.Vec3{Vec: r3.Vec{
+					X: collider.Offset.X,
+					Y: collider.Offset.Y,
+					Z: collider.Offset.Z,
+				}},
+				Radius: collider.Radius,
+			})
+		}
+		source.ColliderGroups = append(source.ColliderGroups, group)
+	}
+	for groupIndex, boneGroup := range ext.SecondaryAnimation.BoneGroups {
+		if len(boneGroup.Bones) == 0 {
+			continue
+		}
+		name := strings.TrimSpace(boneGroup.Comment)
+		if name == "" {
+			name = fmt.Sprintf("spring_%03d", groupIndex)
+		}
+		param := resolveVrm0SpringJointParam(boneGroup)
+		source.Chains = append(source.Chains, springChainSource{
+			Name:                 name,
+			RootNodeIndexes:      append([]int{}, boneGroup.Bones...),
+			DefaultParam:         clampSpringJointParam(modelData, name, param),
+			ColliderGroupIndexes: append([]int{}, boneGroup.ColliderGroups...),
+		})
+	}
+	return source, nil
+}
+
+// parseVrm1SpringPhysicsSource は VRMC_springBone を揺れ物変換入力へ変換する。
+func parseVrm1SpringPhysicsSource(modelData *model.PmxModel, raw json.RawMessage) (springPhysicsSource, error) {
+	ext := vrm1SpringBoneExtension{}
+	if err := json.Unmarshal(raw, &ext); err != nil {
+		return springPhysicsSource{}, err
+	}
+	source := springPhysicsSource{
+		Chains:         make([]springChainSource, 0, len(ext.Springs)),
+		ColliderGroups: make([]springColliderGroupSource, 0, len(ext.ColliderGroups)),
+	}
+	for groupIndex, colliderGroup := range ext.ColliderGroups {
+		name := strings.TrimSpace(colliderGroup.Name)
+		if name == "" {
+			name = fmt.Sprintf("collider_%03d", groupIndex)
+		}
+		group := springColliderGroupSource{
+			Name:      name,
+			Colliders: make([]springColliderSource, 0, len(colliderGroup.Colliders)),
+		}
+		for _, colliderIndex := range colliderGroup.Colliders {
+			if colliderIndex < 0 || colliderIndex >= len(ext.Colliders) {
+				continue
+			}
+			collider, ok := resolveVrm1SpringColliderSource(ext.Colliders[colliderIndex])
+			if !ok {
+				continue
+			}
+			group.Colliders = append(group.Colliders, collider)
+		}
+		source.ColliderGroups = append(source.ColliderGroups, group)
+	}
+	for springIndex, spring := range ext.Springs {
+		if len(spring.Joints) == 0 {
+			continue
+		}
+		name := strings.TrimSpace(spring.Name)
+		if name == "" {
+			name = fmt.Sprintf("spring_%03d", springIndex)
+		}
+		chain := springChainSource{
+			Name:                 name,
+			RootNodeIndexes:      []int{spring.Joints[0].Node},
+			JointNodeIndexes:     make([]int, 0, len(spring.Joints)),
+			ParamsByNode:         make(map[int]springJointParam, len(spring.Joints)),
+			ColliderGroupIndexes: append([]int{}, spring.ColliderGroups...),
+		}
+		for jointIndex, joint := range spring.Joints {
+			param := clampSpringJointParam(
+				modelData,
+				fmt.Sprintf("%s[%d]", name, jointIndex),
+				resolveVrm1SpringJointParam(joint),
+			)
+			if jointIndex == 0 {
+				chain.DefaultParam = param
+			}
+			chain.JointNodeIndexes = append(chain.JointNodeIndexes, joint.Node)
+			chain.ParamsByNode[joint.Node] = param
+		}
+		source.Chains = append(source.Chains, chain)
+	}
+	return source, nil
+}
+
+// resolveVrm1SpringColliderSource は VRMC_springBone collider を変換入力へ変換する。
+func resolveVrm1SpringColliderSource(collider vrm1SpringColliderSource) (springColliderSource, bool) {
+	if collider.Shape.Capsule != nil {
+		return springColliderSource{
+			NodeIndex: collider.Node,
+			Shape:     springColliderShapeCapsule,
+			Offset:    toSpringVec3(collider.Shape.Capsule.Offset),
+			Tail:      toSpringVec3(collider.Shape.Capsule.Tail),
+			Radius:    collider.Shape.Capsule.Radius,
+		}, true
+	}
+	if collider.Shape.Sphere != nil {
+		return springColliderSource{
+			NodeIndex: collider.Node,
+			Shape:     springColliderShapeSphere,
+			Offset:    toSpringVec3(collider.Shape.Sphere.Offset),
+			Radius:    collider.Shape.Sphere.Radius,
+		}, true
+	}
+	return springColliderSource{}, false
+}
+
+// resolveVrm1SpringJointParam は VRMC_springBone joint から関節パラメータを取り出す。
+func resolveVrm1SpringJointParam(joint vrm1SpringJointSource) springJointParam {
+	param := springJointParam{
+		Stiffness:    1.0,
+		GravityPower: 0.0,
+		GravityDir:   mmath.Vec3{Vec: r3.Vec{X: 0, Y: -1, Z: 0}},
+		DragForce:    0.5,
+		HitRadius:    0.0,
+	}
+	if joint.Stiffness != nil {
+		param.Stiffness = *joint.Stiffness
+	}
+	if joint.GravityPower != nil {
+		param.GravityPower = *joint.GravityPower
+	}
+	if len(joint.GravityDir) >= 3 {
+		param.GravityDir = toSpringVec3(joint.GravityDir)
+	}
+	if joint.DragForce != nil {
+		param.DragForce = *joint.DragForce
+	}
+	if joint.HitRadius != nil {
+		param.HitRadius = *joint.HitRadius
+	}
+	return param
+}
+
+// toSpringVec3 は配列表現のベクトルを Vec3 へ変換する。要素不足時は0で補う。
+func toSpringVec3(values []float64) mmath.Vec3 {
+	vec := mmath.Vec3{}
+	if len(values) > 0 {
+		vec.X = values[0]
+	}
+	if len(values) > 1 {
+		vec.Y = values[1]
+	}
+	if len(values) > 2 {
+		vec.Z = values[2]
+	}
+	return vec
+}
+
+// resolveVrm0SpringJointParam は VRM0 boneGroup から関節パラメータを取り出す。
+func resolveVrm0SpringJointParam(boneGroup vrm0SpringBoneGroupSource) springJointParam {
+	param := springJointParam{
+		Stiffness:    1.0,
+		GravityPower: 0.0,
+		GravityDir:   mmath.Vec3{Vec: r3.Vec{X: 0, Y: -1, Z: 0}},
+		DragForce:    0.4,
+		HitRadius:    0.02,
+	}
+	if boneGroup.Stiffiness != nil {
+		param.Stiffness = *boneGroup.Stiffiness
+	} else if boneGroup.Stiffness != nil {
+		param.Stiffness = *boneGroup.Stiffness
+	}
+	if boneGroup.GravityPower != nil {
+		param.GravityPower = *boneGroup.GravityPower
+	}
+	if boneGroup.GravityDir != nil {
+		param.GravityDir = mmath.Vec3{Vec: r3.Vec{
+			X: boneGroup.GravityDir.X,
+			Y: boneGroup.GravityDir.Y,
+			Z: boneGroup.GravityDir.Z,
+		}}
+	}
+	if boneGroup.DragForce != nil {
+		param.DragForce = *boneGroup.DragForce
+	}
+	if boneGroup.HitRadius != nil {
+		param.HitRadius = *boneGroup.HitRadius
+	}
+	return param
+}
+
+// clampSpringJointParam は関節パラメータを変換可能範囲へ丸め、必要に応じて警告を記録する。
+func clampSpringJointParam(modelData *model.PmxModel, chainName string, param springJointParam) springJointParam {
+	clamped := param
+	clamped.Stiffness = clampSpringValue(param.Stiffness, 0, springStiffnessMax)
+	clamped.GravityPower = clampSpringValue(param.GravityPower, 0, springGravityPowerMax)
+	clamped.DragForce = clampSpringValue(param.DragForce, 0, springDragForceMax)
+	clamped.HitRadius = clampSpringValue(param.HitRadius, 0, springHitRadiusMax)
+	if clamped.Stiffness != param.Stiffness ||
+		clamped.GravityPower != param.GravityPower ||
+		clamped.DragForce != param.DragForce ||
+		clamped.HitRadius != param.HitRadius {
+		recordVrmPhysicsWarning(
+			modelData,
+			warningid.VrmWarningSpringParamClamped,
+			"chain=%s stiffness=%.3f->%.3f gravityPower=%.3f->%.3f dragForce=%.3f->%.3f hitRadius=%.3f->%.3f",
+			chainName,
+			param.Stiffness,
+			clamped.Stiffness,
+			param.GravityPower,
+			clamped.GravityPower,
+			param.DragForce,
+			clamped.DragForce,
+			param.HitRadius,
+			clamped.HitRadius,
+		)
+	}
+	if clamped.GravityPower > 0 && !isDefaultSpringGravityDir(clamped.GravityDir) {
+		recordVrmPhysicsWarning(
+			modelData,
+			warningid.VrmWarningGravityDirectionUnsupported,
+			"chain=%s gravityDir=(%.3f,%.3f,%.3f)",
+			chainName,
+			clamped.GravityDir.X,
+			clamped.GravityDir.Y,
+			clamped.GravityDir.Z,
+		)
+	}
+	return clamped
+}
+
+// clampSpringValue は値を範囲内へ丸める。NaN は下限として扱う。
+func clampSpringValue(value float64, minValue float64, maxValue float64) float64 {
+	if math.IsNaN(value) || value < minValue {
+		return minValue
+	}
+	if value > maxValue {
+		return maxValue
+	}
+	return value
+}
+
+// isDefaultSpringGravityDir は重力方向がPMX物理の既定重力(下向き)と一致するか判定する。
+func isDefaultSpringGravityDir(direction mmath.Vec3) bool {
+	length := direction.Length()
+	if length <= springGravityDirectionTolerance {
+		return true
+	}
+	normalized := direction.MuledScalar(1.0 / length)
+	return math.Abs(normalized.X) <= springGravityDirectionTolerance &&
+		math.Abs(normalized.Y+1.0) <= springGravityDirectionTolerance &&
+		math.Abs(normalized.Z) <= springGravityDirectionTolerance
+}
+
+// buildSpringPhysics は揺れ物変換入力から剛体/ジョイントを生成する。
+func buildSpringPhysics(
+	modelData *model.PmxModel,
+	doc *gltfDocument,
+	source springPhysicsSource,
+	nodeToBoneIndex map[int]int,
+	conversion vrmConversion,
+) springPhysicsStats {
+	stats := springPhysicsStats{}
+	usedNames := map[string]int{}
+	colliderGroupNumbers := appendSpringColliderRigidBodies(modelData, source.ColliderGroups, nodeToBoneIndex, conversion, usedNames, &stats)
+
+	rigidBodyByNode := map[int]int{}
+	anchorByBone := map[int]int{}
+	parentIndexes, err := buildNodeParentIndexes(doc.Nodes)
+	if err != nil {
+		return stats
+	}
+	for _, chain := range source.Chains {
+		mask := resolveSpringCollisionMask(chain.ColliderGroupIndexes, colliderGroupNumbers)
+		appended := false
+		for _, rootNodeIndex := range chain.RootNodeIndexes {
+			if rootNodeIndex < 0 || rootNodeIndex >= len(doc.Nodes) {
+				stats.SkippedNodes++
+				continue
+			}
+			if _, exists := rigidBodyByNode[rootNodeIndex]; exists {
+				continue
+			}
+			anchorIndex := ensureSpringAnchorRigidBody(
+				modelData,
+				parentIndexes[rootNodeIndex],
+				nodeToBoneIndex,
+				anchorByBone,
+				usedNames,
+				&stats,
+			)
+			if appendSpringNodeRigidBodies(
+				modelData,
+				doc,
+				chain,
+				rootNodeIndex,
+				anchorIndex,
+				mask,
+				nodeToBoneIndex,
+				conversion,
+				rigidBodyByNode,
+				usedNames,
+				&stats,
+			) {
+				appended = true
+			}
+		}
+		if appended {
+			stats.Chains++
+		}
+	}
+	return stats
+}
+
+// appendSpringColliderRigidBodies はコライダーを静的剛体として追加し、グループごとの衝突グループ番号を返す。
+func appendSpringColliderRigidBodies(
+	modelData *model.PmxModel,
+	colliderGroups []springColliderGroupSource,
+	nodeToBoneIndex map[int]int,
+	conversion vrmConversion,
+	usedNames map[string]int,
+	stats *springPhysicsStats,
+) []int {
+	groupNumbers := make([]int, len(colliderGroups))
+	for groupIndex, colliderGroup := range colliderGroups {
+		groupNumber := physicsColliderCollisionGroupStart + groupIndex%physicsColliderCollisionGroupCount
+		groupNumbers[groupIndex] = groupNumber
+		for colliderIndex, collider := range colliderGroup.Colliders {
+			boneIndex, ok := nodeToBoneIndex[collider.NodeIndex]
+			if !ok || boneIndex < 0 {
+				continue
+			}
+			bone, err := modelData.Bones.Get(boneIndex)
+			if err != nil || bone == nil {
+				continue
+			}
+			radius := math.Max(collider.Radius*conversion.Scale, springRigidBodyMinRadius)
+			head := bone.Position.Added(convertVrmPositionToPmx(collider.Offset, conversion))
+			shape := model.SHAPE_SPHERE
+			size := mmath.Vec3{Vec: r3.Vec{X: radius}}
+			position := head
+			rotation := mmath.ZERO_VEC3
+			if collider.Shape == springColliderShapeCapsule {
+				tail := bone.Position.Added(convertVrmPositionToPmx(collider.Tail, conversion))
+				if length := tail.Subed(head).Length(); length > springRigidBodyMinLength {
+					shape = model.SHAPE_CAPSULE
+					size = mmath.Vec3{Vec: r3.Vec{X: radius, Y: length}}
+					position = head.Added(tail).MuledScalar(0.5)
+					rotation = ResolveCapsuleRotation(tail.Subed(head))
+				}
+			}
+			rigidBody := &model.RigidBody{
+				BoneIndex: boneIndex,
+				CollisionGroup: model.CollisionGroup{
+					Group: byte(groupNumber),
+					Mask:  physicsCollisionMaskAll &^ resolveCollisionGroupBit(groupNumber),
+				},
+				Shape:    shape,
+				Size:     size,
+				Position: position,
+				Rotation: rotation,
+				Param: model.RigidBodyParam{
+					Mass:           springStaticRigidBodyMass,
+					LinearDamping:  springStaticRigidBodyDamping,
+					AngularDamping: springStaticRigidBodyDamping,
+					Restitution:    springRigidBodyRestitution,
+					Friction:       springColliderRigidBodyFriction,
+				},
+				PhysicsType: model.PHYSICS_TYPE_STATIC,
+			}
+			name := ensureUniqueBoneName(fmt.Sprintf("%s_%s_%02d", bone.Name(), colliderGroup.Name, colliderIndex), usedNames)
+			rigidBody.SetName(name)
+			rigidBody.EnglishName = name
+			modelData.RigidBodies.AppendRaw(rigidBody)
+			stats.ColliderBodies++
+		}
+	}
+	return groupNumbers
+}
+
+// resolveSpringCollisionMask は揺れ物剛体が衝突する相手グループのマスクを返す。
+func resolveSpringCollisionMask(colliderGroupIndexes []int, colliderGroupNumbers []int) uint16 {
+	mask := resolveCollisionGroupBit(physicsBodyCollisionGroup)
+	for _, colliderGroupIndex := range colliderGroupIndexes {
+		if colliderGroupIndex < 0 || colliderGroupIndex >= len(colliderGroupNumbers) {
+			continue
+		}
+		mask |= resolveCollisionGroupBit(colliderGroupNumbers[colliderGroupIndex])
+	}
+	return mask
+}
+
+// resolveCollisionGroupBit は衝突グループ番号に対応するマスクビットを返す。
+func resolveCollisionGroupBit(groupNumber int) uint16 {
+	if groupNumber < 0 || groupNumber > 15 {
+		return 0
+	}
+	return uint16(1) << uint(groupNumber)
+}
+
+// ensureSpringAnchorRigidBody は揺れ物の根元を支えるボーン追従剛体を取得または作成する。
+func ensureSpringAnchorRigidBody(
+	modelData *model.PmxModel,
+	parentNodeIndex int,
+	nodeToBoneIndex map[int]int,
+	anchorByBone map[int]int,
+	usedNames map[string]int,
+	stats *springPhysicsStats,
+) int {
+	if parentNodeIndex < 0 {
+		return -1
+	}
+	boneIndex, ok := nodeToBoneIndex[parentNodeIndex]
+	if !ok || boneIndex < 0 {
+		return -1
+	}
+	if anchorIndex, exists := anchorByBone[boneIndex]; exists {
+		return anchorIndex
+	}
+	bone, err := modelData.Bones.Get(boneIndex)
+	if err != nil || bone == nil {
+		return -1
+	}
+	rigidBody := &model.RigidBody{
+		BoneIndex: boneIndex,
+		CollisionGroup: model.CollisionGroup{
+			Group: physicsSpringCollisionGroup,
+			Mask:  0,
+		},
+		Shape:    model.SHAPE_SPHERE,
+		Size:     mmath.Vec3{Vec: r3.Vec{X: springRigidBodyMinRadius}},
+		Position: bone.Position,
+		Rotation: mmath.ZERO_VEC3,
+		Param: model.RigidBodyParam{
+			Mass:           springStaticRigidBodyMass,
+			LinearDamping:  springStaticRigidBodyDamping,
+			AngularDamping: springStaticRigidBodyDamping,
+			Restitution:    springRigidBodyRestitution,
+			Friction:       springRigidBodyFriction,
+		},
+		PhysicsType: model.PHYSICS_TYPE_STATIC,
+	}
+	name := ensureUniqueBoneName(bone.Name()+"_anchor", usedNames)
+	rigidBody.SetName(name)
+	rigidBody.EnglishName = name
+	anchorIndex := modelData.RigidBodies.AppendRaw(rigidBody)
+	anchorByBone[boneIndex] = anchorIndex
+	stats.StaticRigidBodies++
+	return anchorIndex
+}
+
+// appendSpringNodeRigidBodies は root node 以下の子孫へ動的剛体とジョイントを追加する。
+func appendSpringNodeRigidBodies(
+	modelData *model.PmxModel,
+	doc *gltfDocument,
+	chain springChainSource,
+	rootNodeIndex int,
+	anchorIndex int,
+	mask uint16,
+	nodeToBoneIndex map[int]int,
+	conversion vrmConversion,
+	rigidBodyByNode map[int]int,
+	usedNames map[string]int,
+	stats *springPhysicsStats,
+) bool {
+	type springNodeVisit struct {
+		NodeIndex        int
+		ParentRigidIndex int
+	}
+	nextJointByNode := map[int]int{}
+	for jointIndex := 0; jointIndex+1 < len(chain.JointNodeIndexes); jointIndex++ {
+		nextJointByNode[chain.JointNodeIndexes[jointIndex]] = chain.JointNodeIndexes[jointIndex+1]
+	}
+	appended := false
+	stack := []springNodeVisit{{NodeIndex: rootNodeIndex, ParentRigidIndex: anchorIndex}}
+	for len(stack) > 0 {
+		visit := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visit.NodeIndex < 0 || visit.NodeIndex >= len(doc.Nodes) {
+			stats.SkippedNodes++
+			continue
+		}
+		if _, exists := rigidBodyByNode[visit.NodeIndex]; exists {
+			continue
+		}
+		boneIndex, ok := nodeToBoneIndex[visit.NodeIndex]
+		if !ok || boneIndex < 0 {
+			stats.SkippedNodes++
+			continue
+		}
+		bone, err := modelData.Bones.Get(boneIndex)
+		if err != nil || bone == nil {
+			stats.SkippedNodes++
+			continue
+		}
+		param, exists := chain.ParamsByNode[visit.NodeIndex]
+		if !exists {
+			param = chain.DefaultParam
+		}
+
+		rigidIndex := appendSpringDynamicRigidBody(modelData, bone, param, mask, conversion, usedNames)
+		rigidBodyByNode[visit.NodeIndex] = rigidIndex
+		stats.DynamicRigidBodies++
+		appended = true
+		if visit.ParentRigidIndex >= 0 {
+			appendSpringJoint(modelData, bone, visit.ParentRigidIndex, rigidIndex, param, usedNames)
+			stats.Joints++
+		}
+
+		if len(chain.JointNodeIndexes) > 0 {
+			if nextNodeIndex, exists := nextJointByNode[visit.NodeIndex]; exists {
+				stack = append(stack, springNodeVisit{
+					NodeIndex:        nextNodeIndex,
+					ParentRigidIndex: rigidIndex,
+				})
+			}
+			continue
+		}
+		children := doc.Nodes[visit.NodeIndex].Children
+		for childIndex := len(children) - 1; childIndex >= 0; childIndex-- {
+			stack = append(stack, springNodeVisit{
+				NodeIndex:        children[childIndex],
+				ParentRigidIndex: rigidIndex,
+			})
+		}
+	}
+	return appended
+}
+
+// appendSpringDynamicRigidBody はボーン区間に沿った物理演算カプセル剛体を追加する。
+func appendSpringDynamicRigidBody(
+	modelData *model.PmxModel,
+	bone *model.Bone,
+	param springJointParam,
+	mask uint16,
+	conversion vrmConversion,
+	usedNames map[string]int,
+) int {
+	head := bone.Position
+	tail := resolveSpringBoneTailPosition(modelData, bone)
+	direction := tail.Subed(head)
+	length := direction.Length()
+	radius := math.Max(param.HitRadius*conversion.Scale, springRigidBodyMinRadius)
+	shape := model.SHAPE_CAPSULE
+	size := mmath.Vec3{Vec: r3.Vec{X: radius, Y: length}}
+	position := head.Added(tail).MuledScalar(0.5)
+	rotation := ResolveCapsuleRotation(direction)
+	if length <= springRigidBodyMinLength {
+		shape = model.SHAPE_SPHERE
+		size = mmath.Vec3{Vec: r3.Vec{X: radius}}
+		position = head
+		rotation = mmath.ZERO_VEC3
+	}
+	damping := springRigidBodyMinDamping + (springRigidBodyMaxDamping-springRigidBodyMinDamping)*param.DragForce/springDragForceMax
+	rigidBody := &model.RigidBody{
+		BoneIndex: bone.Index(),
+		CollisionGroup: model.CollisionGroup{
+			Group: physicsSpringCollisionGroup,
+			Mask:  mask,
+		},
+		Shape:    shape,
+		Size:     size,
+		Position: position,
+		Rotation: rotation,
+		Param: model.RigidBodyParam{
+			// gravityPower が大きいほど重く、垂れ下がりやすくする。
+			Mass:           springRigidBodyBaseMass * (1.0 + param.GravityPower),
+			LinearDamping:  damping,
+			AngularDamping: damping,
+			Restitution:    springRigidBodyRestitution,
+			Friction:       springRigidBodyFriction,
+		},
+		PhysicsType: model.PHYSICS_TYPE_DYNAMIC,
+	}
+	name := ensureUniqueBoneName(bone.Name(), usedNames)
+	rigidBody.SetName(name)
+	rigidBody.EnglishName = name
+	return modelData.RigidBodies.AppendRaw(rigidBody)
+}
+
+// appendSpringJoint は親子剛体を結ぶバネ付き6DOFジョイントを追加する。
+func appendSpringJoint(
+	modelData *model.PmxModel,
+	bone *model.Bone,
+	rigidIndexA int,
+	rigidIndexB int,
+	param springJointParam,
+	usedNames map[string]int,
+) {
+	stiffnessRatio := param.Stiffness / springStiffnessMax
+	limitDegree := springJointMaxRotationDegree - (springJointMaxRotationDegree-springJointMinRotationDegree)*stiffnessRatio
+	limit := mmath.DegToRad(limitDegree)
+	springValue := param.Stiffness * springJointRotationSpringFactor
+	joint := &model.Joint{
+		RigidBodyIndexA: rigidIndexA,
+		RigidBodyIndexB: rigidIndexB,
+		Position:        bone.Position,
+		Rotation:        mmath.ZERO_VEC3,
+		Param: model.JointParam{
+			TranslationLimitMin:       mmath.ZERO_VEC3,
+			TranslationLimitMax:       mmath.ZERO_VEC3,
+			RotationLimitMin:          mmath.Vec3{Vec: r3.Vec{X: -limit, Y: -limit, Z: -limit}},
+			RotationLimitMax:          mmath.Vec3{Vec: r3.Vec{X: limit, Y: limit, Z: limit}},
+			SpringConstantTranslation: mmath.ZERO_VEC3,
+			SpringConstantRotation:    mmath.Vec3{Vec: r3.Vec{X: springValue, Y: springValue, Z: springValue}},
+		},
+	}
+	name := ensureUniqueBoneName("J_"+bone.Name(), usedNames)
+	joint.SetName(name)
+	joint.EnglishName = name
+	modelData.Joints.AppendRaw(joint)
+}
+
+// resolveSpringBoneTailPosition は剛体区間の終端位置を返す。
+func resolveSpringBoneTailPosition(modelData *model.PmxModel, bone *model.Bone) mmath.Vec3 {
+	if bone.TailIndex >= 0 {
+		if tailBone, err := modelData.Bones.Get(bone.TailIndex); err == nil && tailBone != nil {
+			return tailBone.Position
+		}
+	}
+	return bone.Position.Added(bone.TailPosition)
+}
+
+// ResolveCapsuleRotation はカプセルのY軸を指定方向へ向けるオイラー角(ラジアン)を返す。
+// 揺れ物剛体と体剛体で共有し、長さ0の方向はゼロ回転とする。
+func ResolveCapsuleRotation(direction mmath.Vec3) mmath.Vec3 {
+	length := direction.Length()
+	if length <= 0 {
+		return mmath.ZERO_VEC3
+	}
+	normalized := direction.MuledScalar(1.0 / length)
+	// PMX剛体は Y→X→Z 順の回転を持つため、Y=0 としてX/Zのみで軸を合わせる。
+	return mmath.Vec3{Vec: r3.Vec{
+		X: math.Atan2(normalized.Z, normalized.Y),
+		Y: 0,
+		Z: math.Asin(clampSpringValue(-normalized.X, -1, 1)),
+	}}
+}
+
+// recordVrmPhysicsWarning は揺れ物変換の warning ID を記録し、警告ログを出力する。
+func recordVrmPhysicsWarning(modelData *model.PmxModel, warningID string, messageFormat string, params ...any) {
+	recordLegacyMaterialWarning(modelData, warningID, messageFormat, params...)
+}
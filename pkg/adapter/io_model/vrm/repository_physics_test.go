@@ -0,0 +1,168 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+func TestVrmRepositoryLoadBuildsRigidBodiesFromVrm0SecondaryAnimation(t *testing.T) {
+	repository := NewVrmRepository()
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "avatar.vrm")
+
+	doc := map[string]any{
+		"asset": map[string]any{
+			"version":   "2.0",
+			"generator": "UniGLTF-1.28",
+		},
+		"extensionsUsed": []string{"VRM"},
+		"nodes": []any{
+			map[string]any{
+				"name":        "hips_node",
+				"translation": []float64{0, 0.9, 0},
+				"children":    []int{1},
+			},
+			map[string]any{
+				"name":        "head_node",
+				"translation": []float64{0, 0.6, 0},
+				"children":    []int{2},
+			},
+			map[string]any{
+				"name":        "hair_root",
+				"translation": []float64{0, 0.1, -0.05},
+				"children":    []int{3},
+			},
+			map[string]any{
+				"name":        "hair_1",
+				"translation": []float64{0, -0.1, 0},
+				"children":    []int{4},
+			},
+			map[string]any{
+				"name":        "hair_end",
+				"translation": []float64{0, -0.1, 0},
+			},
+		},
+		"extensions": map[string]any{
+			"VRM": map[string]any{
+				"exporterVersion": "UniVRM-0.51.0",
+				"humanoid": map[string]any{
+					"humanBones": []any{
+						map[string]any{"bone": "hips", "node": 0},
+						map[string]any{"bone": "head", "node": 1},
+					},
+				},
+				"secondaryAnimation": map[string]any{
+					"boneGroups": []any{
+						map[string]any{
+							"comment":        "hair",
+							"stiffiness":     5.0,
+							"gravityPower":   0.5,
+							"gravityDir":     map[string]any{"x": 1.0, "y": 0.0, "z": 0.0},
+							"dragForce":      0.4,
+							"center":         -1,
+							"hitRadius":      0.02,
+							"bones":          []int{2},
+							"colliderGroups": []int{0},
+						},
+					},
+					"colliderGroups": []any{
+						map[string]any{
+							"node": 1,
+							"colliders": []any{
+								map[string]any{
+									"offset": map[string]any{"x": 0.0, "y": 0.1, "z": 0.0},
+									"radius": 0.1,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	writeGLBFileForTest(t, path, doc)
+
+	hashableModel, err := repository.Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	pmxModel, ok := hashableModel.(*model.PmxModel)
+	if !ok {
+		t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+	}
+
+	if pmxModel.RigidBodies.Len() != 5 {
+		t.Fatalf("expected 5 rigid bodies (anchor/collider/3 dynamic), got %d", pmxModel.RigidBodies.Len())
+	}
+	if pmxModel.Joints.Len() != 3 {
+		t.Fatalf("expected 3 joints, got %d", pmxModel.Joints.Len())
+	}
+
+	dynamicCount := 0
+	staticCount := 0
+	for _, rigidBody := range pmxModel.RigidBodies.Values() {
+		switch rigidBody.PhysicsType {
+		case model.PHYSICS_TYPE_DYNAMIC:
+			dynamicCount++
+			if rigidBody.CollisionGroup.Group != physicsSpringCollisionGroup {
+				t.Fatalf("expected spring collision group: name=%s group=%d", rigidBody.Name(), rigidBody.CollisionGroup.Group)
+			}
+			if rigidBody.CollisionGroup.Mask&resolveCollisionGroupBit(physicsColliderCollisionGroupStart) == 0 {
+				t.Fatalf("expected spring body to collide with collider group: name=%s", rigidBody.Name())
+			}
+			if rigidBody.Param.Mass <= springRigidBodyBaseMass {
+				t.Fatalf("expected gravityPower to increase mass: name=%s mass=%f", rigidBody.Name(), rigidBody.Param.Mass)
+			}
+		case model.PHYSICS_TYPE_STATIC:
+			staticCount++
+		}
+	}
+	if dynamicCount != 3 || staticCount != 2 {
+		t.Fatalf("unexpected physics type counts: dynamic=%d static=%d", dynamicCount, staticCount)
+	}
+
+	hairRootBody, err := pmxModel.RigidBodies.GetByName("hair_root")
+	if err != nil || hairRootBody == nil {
+		t.Fatalf("expected hair_root rigid body: %v", err)
+	}
+	hairRootBone, err := pmxModel.Bones.GetByName("hair_root")
+	if err != nil || hairRootBone == nil {
+		t.Fatalf("expected hair_root bone: %v", err)
+	}
+	if hairRootBody.BoneIndex != hairRootBone.Index() {
+		t.Fatalf("expected hair_root rigid body to follow hair_root bone: got=%d want=%d", hairRootBody.BoneIndex, hairRootBone.Index())
+	}
+	if hairRootBody.Shape != model.SHAPE_CAPSULE {
+		t.Fatalf("expected hair_root rigid body to be capsule, got %v", hairRootBody.Shape)
+	}
+
+	if !hasWarningID(pmxModel, warningid.VrmWarningSpringParamClamped) {
+		t.Fatalf("expected warning id %s", warningid.VrmWarningSpringParamClamped)
+	}
+	if !hasWarningID(pmxModel, warningid.VrmWarningGravityDirectionUnsupported) {
+		t.Fatalf("expected warning id %s", warningid.VrmWarningGravityDirectionUnsupported)
+	}
+}
+
+func TestClampSpringJointParamKeepsValuesInRange(t *testing.T) {
+	modelData := newVroidProfileTestModelData()
+	param := resolveVrm0SpringJointParam(vrm0SpringBoneGroupSource{})
+	clamped := clampSpringJointParam(modelData, "default", param)
+	if clamped != param {
+		t.Fatalf("expected default params to be kept: got=%+v want=%+v", clamped, param)
+	}
+	if hasWarningID(modelData, warningid.VrmWarningSpringParamClamped) {
+		t.Fatalf("expected no clamp warning for default params")
+	}
+
+	outOfRange := param
+	outOfRange.DragForce = springDragForceMax + 1.0
+	clampSpringJointParam(modelData, "out_of_range", outOfRange)
+	if !hasWarningID(modelData, warningid.VrmWarningSpringParamClamped) {
+		t.Fatalf("expected clamp warning to be recorded for out of range params")
+	}
+}
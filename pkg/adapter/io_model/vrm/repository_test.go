@@ -34,6 +34,12 @@ func TestVrmRepositoryCanLoad(t *testing.T) {
 	if !repository.CanLoad("sample.VRM") {
 		t.Fatalf("expected sample.VRM to be loadable")
 	}
+	if !repository.CanLoad("sample.glb") {
+		t.Fatalf("expected sample.glb to be loadable")
+	}
+	if !repository.CanLoad("sample.GLTF") {
+		t.Fatalf("expected sample.GLTF to be loadable")
+	}
 	if repository.CanLoad("sample.pmx") {
 		t.Fatalf("expected sample.pmx to be not loadable")
 	}
@@ -684,6 +690,212 @@ func TestVrmRepositoryLoadCreatesMeshFromPrimitive(t *testing.T) {
 	}
 }
 
+func TestVrmRepositoryLoadGltfResolvesExternalBufferAndHeuristicHumanoid(t *testing.T) {
+	repository := NewVrmRepository()
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "mixamo.gltf")
+
+	positions := []float32{
+		0.0, 0.0, 0.0,
+		0.0, 1.0, 0.0,
+		1.0, 0.0, 0.0,
+	}
+	normals := []float32{
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+	}
+	uvs := []float32{
+		0.0, 0.0,
+		0.0, 1.0,
+		1.0, 0.0,
+	}
+	indices := []uint16{0, 1, 2}
+	binChunk := buildInterleavedBinForMeshTest(t, positions, normals, uvs, indices)
+	if err := os.WriteFile(filepath.Join(tempDir, "mixamo data.bin"), binChunk, 0o644); err != nil {
+		t.Fatalf("write bin failed: %v", err)
+	}
+
+	doc := map[string]any{
+		"asset": map[string]any{
+			"version":   "2.0",
+			"generator": "Blender glTF exporter",
+		},
+		"nodes": []any{
+			map[string]any{"name": "mixamorig:Hips", "children": []int{1, 3}},
+			map[string]any{"name": "mixamorig:Spine", "translation": []float64{0, 0.1, 0}, "children": []int{2}},
+			map[string]any{"name": "mixamorig:Spine1", "translation": []float64{0, 0.1, 0}},
+			map[string]any{"name": "mixamorig:LeftUpLeg", "translation": []float64{0.1, -0.05, 0}, "children": []int{4}},
+			map[string]any{"name": "mixamorig:LeftLeg", "translation": []float64{0, -0.4, 0}},
+			map[string]any{"name": "Body", "mesh": 0},
+		},
+		"meshes": []any{
+			map[string]any{
+				"name": "Body",
+				"primitives": []any{
+					map[string]any{
+						"attributes": map[string]any{
+							"POSITION":   0,
+							"NORMAL":     1,
+							"TEXCOORD_0": 2,
+						},
+						"indices": 3,
+						"mode":    4,
+					},
+				},
+			},
+		},
+		"buffers": []any{
+			map[string]any{
+				"uri":        "mixamo%20data.bin",
+				"byteLength": len(binChunk),
+			},
+		},
+		"bufferViews": []any{
+			map[string]any{
+				"buffer":     0,
+				"byteOffset": 0,
+				"byteLength": len(positions) * 4,
+			},
+			map[string]any{
+				"buffer":     0,
+				"byteOffset": len(positions) * 4,
+				"byteLength": len(normals) * 4,
+			},
+			map[string]any{
+				"buffer":     0,
+				"byteOffset": (len(positions) + len(normals)) * 4,
+				"byteLength": len(uvs) * 4,
+			},
+			map[string]any{
+				"buffer":     0,
+				"byteOffset": (len(positions) + len(normals) + len(uvs)) * 4,
+				"byteLength": len(indices) * 2,
+			},
+		},
+		"accessors": []any{
+			map[string]any{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]any{"bufferView": 1, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]any{"bufferView": 2, "componentType": 5126, "count": 3, "type": "VEC2"},
+			map[string]any{"bufferView": 3, "componentType": 5123, "count": 3, "type": "SCALAR"},
+		},
+	}
+	jsonBytes, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("json marshal failed: %v", err)
+	}
+	if err := os.WriteFile(path, jsonBytes, 0o644); err != nil {
+		t.Fatalf("write gltf failed: %v", err)
+	}
+
+	hashableModel, err := repository.Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	pmxModel, ok := hashableModel.(*model.PmxModel)
+	if !ok {
+		t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+	}
+	if pmxModel.Vertices.Len() != 3 || pmxModel.Faces.Len() != 1 {
+		t.Fatalf("unexpected mesh size: vertices=%d faces=%d", pmxModel.Vertices.Len(), pmxModel.Faces.Len())
+	}
+	if pmxModel.VrmData == nil || pmxModel.VrmData.Vrm1 == nil || pmxModel.VrmData.Vrm1.Humanoid == nil {
+		t.Fatalf("expected heuristic humanoid data")
+	}
+	expectedNodes := map[string]int{
+		"hips":         0,
+		"spine":        1,
+		"chest":        2,
+		"leftUpperLeg": 3,
+		"leftLowerLeg": 4,
+	}
+	for humanBoneName, nodeIndex := range expectedNodes {
+		humanBone, exists := pmxModel.VrmData.Vrm1.Humanoid.HumanBones[humanBoneName]
+		if !exists || humanBone.Node != nodeIndex {
+			t.Fatalf("humanoid mismatch: bone=%s exists=%t got=%d want=%d", humanBoneName, exists, humanBone.Node, nodeIndex)
+		}
+	}
+
+	result, err := ExportArtifacts(path, filepath.Join(tempDir, "out", "glTF"), filepath.Join(tempDir, "out", "tex"))
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	binBytes, err := os.ReadFile(result.BinPath)
+	if err != nil {
+		t.Fatalf("bin output not found: %v", err)
+	}
+	if !bytes.Equal(binBytes, binChunk) {
+		t.Fatalf("exported bin mismatch: got=%d bytes want=%d bytes", len(binBytes), len(binChunk))
+	}
+}
+
+func TestResolveHeuristicHumanoidBoneName(t *testing.T) {
+	cases := map[string]string{
+		"mixamorig:LeftArm":         "leftUpperArm",
+		"mixamorig:RightForeArm":    "rightLowerArm",
+		"mixamorig:LeftHandThumb1":  "leftThumbMetacarpal",
+		"mixamorig:RightHandIndex2": "rightIndexIntermediate",
+		"J_Bip_C_UpperChest":        "upperChest",
+		"J_Bip_L_Foot":              "leftFoot",
+		"thigh.R":                   "rightUpperLeg",
+		"Bip01 L Clavicle":          "leftShoulder",
+		"pinky_03_l":                "leftLittleDistal",
+	}
+	for nodeName, want := range cases {
+		got, ok := resolveHeuristicHumanoidBoneName(nodeName)
+		if !ok || got != want {
+			t.Fatalf("heuristic humanoid mismatch: node=%s got=%s ok=%t want=%s", nodeName, got, ok, want)
+		}
+	}
+	for _, nodeName := range []string{"mixamorig:HeadTop_End", "Arm", "hair_01", "mixamorig:LeftHandThumb4"} {
+		if got, ok := resolveHeuristicHumanoidBoneName(nodeName); ok {
+			t.Fatalf("unexpected heuristic humanoid: node=%s got=%s", nodeName, got)
+		}
+	}
+}
+
+func TestResolveVrmFirstPersonAnnotationsAppendsMaterialMemo(t *testing.T) {
+	doc := &gltfDocument{
+		Extensions: map[string]json.RawMessage{
+			"VRM": json.RawMessage(`{"firstPerson":{"meshAnnotations":[` +
+				`{"mesh":0,"firstPersonFlag":"ThirdPersonOnly"},` +
+				`{"mesh":1,"firstPersonFlag":"Both"},` +
+				`{"mesh":2,"firstPersonFlag":"Unknown"}]}}`),
+		},
+	}
+	annotations := resolveVrmFirstPersonAnnotations(doc)
+	if annotations.count() != 2 {
+		t.Fatalf("annotation count mismatch: got=%d", annotations.count())
+	}
+	annotationType, ok := annotations.resolve(3, 0)
+	if !ok || annotationType != vrmFirstPersonTypeThirdPersonOnly {
+		t.Fatalf("mesh annotation mismatch: got=%s ok=%t", annotationType, ok)
+	}
+	if _, ok := annotations.resolve(3, 2); ok {
+		t.Fatalf("unknown flag should be ignored")
+	}
+
+	modelData := model.NewPmxModel()
+	materialData := model.NewMaterial()
+	materialData.Memo = "VRM primitive alphaMode=OPAQUE"
+	modelData.Materials.AppendRaw(materialData)
+	appendFirstPersonMaterialMemo(modelData, 0, annotationType)
+	appendFirstPersonMaterialMemo(modelData, 0, vrmFirstPersonTypeBoth)
+	if materialData.Memo != "VRM primitive alphaMode=OPAQUE firstPerson=thirdPersonOnly" {
+		t.Fatalf("first person memo mismatch: got=%s", materialData.Memo)
+	}
+
+	vrm1Doc := &gltfDocument{
+		Extensions: map[string]json.RawMessage{
+			"VRMC_vrm": json.RawMessage(`{"firstPerson":{"meshAnnotations":[{"node":5,"type":"firstPersonOnly"}]}}`),
+		},
+	}
+	vrm1Annotations := resolveVrmFirstPersonAnnotations(vrm1Doc)
+	if annotationType, ok := vrm1Annotations.resolve(5, 0); !ok || annotationType != vrmFirstPersonTypeFirstPersonOnly {
+		t.Fatalf("node annotation mismatch: got=%s ok=%t", annotationType, ok)
+	}
+}
+
 func TestVrmRepositoryLoadBuildsExpressionMorphsFromVrm1Definitions(t *testing.T) {
 	repository := NewVrmRepository()
 	tempDir := t.TempDir()
@@ -3861,7 +4073,7 @@ func TestAppendExpressionLinkRulesBuildsSidePairFallbackAndHeartBindWithHighligh
 	appendVertexMorph("目光なし左")
 	heartMaterial := appendMaterialMorph("はぁと材質")
 
-	appendExpressionLinkRules(modelData)
+	appendExpressionLinkRules(modelData, defaultMorphRuleSet)
 
 	whiteGroup, err := modelData.Morphs.GetByName("白目")
 	if err != nil || whiteGroup == nil {
@@ -3935,7 +4147,7 @@ func TestAppendExpressionLinkRulesBuildsNamedHighlightHideGroupEvenWhenInternalC
 	appendVertexMorph("目光なし右")
 	appendVertexMorph("目光なし左")
 
-	appendExpressionLinkRules(modelData)
+	appendExpressionLinkRules(modelData, defaultMorphRuleSet)
 
 	highlightHideGroup, err := modelData.Morphs.GetByName("目光なし")
 	if err != nil || highlightHideGroup == nil {
@@ -4308,7 +4520,7 @@ func TestAppendExpressionBoneFallbackMorphsCreatesBoneMorphOffsets(t *testing.T)
 	sourceMorph.EnglishName = "ｳｨﾝｸ２右"
 	modelData.Morphs.AppendRaw(sourceMorph)
 
-	appendExpressionBoneFallbackMorphs(modelData)
+	appendExpressionBoneFallbackMorphs(modelData, defaultMorphRuleSet.BoneFallback)
 
 	boneMorph, err := modelData.Morphs.GetByName("ｳｨﾝｸ２右ボーン")
 	if err != nil || boneMorph == nil {
@@ -5844,6 +6056,512 @@ func TestVrmRepositoryLoadContinuesWhenNormalAccessorIsInvalid(t *testing.T) {
 	}
 }
 
+func TestVrmRepositoryLoadBuildsRigidBodiesFromVrm1SpringBone(t *testing.T) {
+	repository := NewVrmRepository()
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "avatar.vrm")
+
+	doc := map[string]any{
+		"asset": map[string]any{
+			"version":   "2.0",
+			"generator": "UniGLTF-2.0",
+		},
+		"extensionsUsed": []string{"VRMC_vrm", "VRMC_springBone"},
+		"nodes": []any{
+			map[string]any{
+				"name":        "hips_node",
+				"translation": []float64{0, 0.9, 0},
+				"children":    []int{1, 5},
+			},
+			map[string]any{
+				"name":        "head_node",
+				"translation": []float64{0, 0.6, 0},
+				"children":    []int{2},
+			},
+			map[string]any{
+				"name":        "hair_root",
+				"translation": []float64{0, 0.1, -0.05},
+				"children":    []int{3},
+			},
+			map[string]any{
+				"name":        "hair_1",
+				"translation": []float64{0, -0.1, 0},
+				"children":    []int{4},
+			},
+			map[string]any{
+				"name":        "hair_end",
+				"translation": []float64{0, -0.1, 0},
+			},
+			map[string]any{
+				"name":        "skirt_node",
+				"translation": []float64{0, -0.1, 0},
+			},
+		},
+		"extensions": map[string]any{
+			"VRMC_vrm": map[string]any{
+				"specVersion": "1.0",
+				"humanoid": map[string]any{
+					"humanBones": map[string]any{
+						"hips": map[string]any{"node": 0},
+						"head": map[string]any{"node": 1},
+					},
+				},
+			},
+			"VRMC_springBone": map[string]any{
+				"specVersion": "1.0",
+				"colliders": []any{
+					map[string]any{
+						"node": 1,
+						"shape": map[string]any{
+							"sphere": map[string]any{"offset": []float64{0, 0.1, 0}, "radius": 0.1},
+						},
+					},
+					map[string]any{
+						"node": 0,
+						"shape": map[string]any{
+							"capsule": map[string]any{
+								"offset": []float64{0, 0, 0},
+								"radius": 0.08,
+								"tail":   []float64{0, 0.3, 0},
+							},
+						},
+					},
+				},
+				"colliderGroups": []any{
+					map[string]any{"name": "head", "colliders": []int{0}},
+					map[string]any{"name": "body", "colliders": []int{1}},
+				},
+				"springs": []any{
+					map[string]any{
+						"name": "hair",
+						"joints": []any{
+							map[string]any{"node": 2, "hitRadius": 0.02, "stiffness": 1.0, "dragForce": 0.4},
+							map[string]any{"node": 3, "hitRadius": 0.02, "stiffness": 1.0, "dragForce": 0.4},
+							map[string]any{"node": 4},
+						},
+						"colliderGroups": []int{0, 1},
+					},
+				},
+			},
+		},
+	}
+	writeGLBFileForTest(t, path, doc)
+
+	hashableModel, err := repository.Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	pmxModel, ok := hashableModel.(*model.PmxModel)
+	if !ok {
+		t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+	}
+	if pmxModel.VrmData == nil || pmxModel.VrmData.Version != vrm.VRM_VERSION_1 {
+		t.Fatalf("expected VRM_VERSION_1 vrm data")
+	}
+
+	// anchor(head) + collider 2 + joint 3
+	if pmxModel.RigidBodies.Len() != 6 {
+		t.Fatalf("expected 6 rigid bodies, got %d", pmxModel.RigidBodies.Len())
+	}
+	if pmxModel.Joints.Len() != 3 {
+		t.Fatalf("expected 3 joints, got %d", pmxModel.Joints.Len())
+	}
+	if pmxModel.RigidBodies.ContainsByName("skirt_node") {
+		t.Fatalf("expected node outside spring joints to be skipped")
+	}
+
+	headBone, err := pmxModel.Bones.GetByName("head_node")
+	if err != nil || headBone == nil {
+		t.Fatalf("expected head_node bone: %v", err)
+	}
+	hipsBone, err := pmxModel.Bones.GetByName("hips_node")
+	if err != nil || hipsBone == nil {
+		t.Fatalf("expected hips_node bone: %v", err)
+	}
+	colliderGroups := map[int]byte{}
+	headColliderIsSphere := false
+	hipsColliderIsCapsule := false
+	for _, rigidBody := range pmxModel.RigidBodies.Values() {
+		if rigidBody.PhysicsType != model.PHYSICS_TYPE_STATIC {
+			if rigidBody.CollisionGroup.Group != physicsSpringCollisionGroup {
+				t.Fatalf("expected spring collision group: name=%s", rigidBody.Name())
+			}
+			for _, groupNumber := range []int{physicsColliderCollisionGroupStart, physicsColliderCollisionGroupStart + 1} {
+				if rigidBody.CollisionGroup.Mask&resolveCollisionGroupBit(groupNumber) == 0 {
+					t.Fatalf("expected spring body to collide with group %d: name=%s", groupNumber, rigidBody.Name())
+				}
+			}
+			continue
+		}
+		if rigidBody.CollisionGroup.Group == physicsSpringCollisionGroup {
+			continue
+		}
+		colliderGroups[rigidBody.BoneIndex] = rigidBody.CollisionGroup.Group
+		if rigidBody.BoneIndex == headBone.Index() && rigidBody.Shape == model.SHAPE_SPHERE {
+			headColliderIsSphere = true
+		}
+		if rigidBody.BoneIndex == hipsBone.Index() && rigidBody.Shape == model.SHAPE_CAPSULE {
+			hipsColliderIsCapsule = true
+		}
+	}
+	if !headColliderIsSphere {
+		t.Fatalf("expected head collider to be sphere")
+	}
+	if !hipsColliderIsCapsule {
+		t.Fatalf("expected hips collider to be capsule")
+	}
+	if colliderGroups[headBone.Index()] == colliderGroups[hipsBone.Index()] {
+		t.Fatalf("expected collider groups to be separated: head=%d hips=%d", colliderGroups[headBone.Index()], colliderGroups[hipsBone.Index()])
+	}
+}
+
+func TestVrmRepositoryLoadBuildsExpressionMorphsFromSparseTargets(t *testing.T) {
+	repository := NewVrmRepository()
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "mesh_sparse_expression.vrm")
+
+	positions := []float32{
+		0.0, 0.0, 0.0,
+		0.0, 1.0, 0.0,
+		1.0, 0.0, 0.0,
+	}
+	normals := []float32{
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+	}
+	uvs := []float32{
+		0.0, 0.0,
+		0.0, 1.0,
+		1.0, 0.0,
+	}
+	indices := []uint16{0, 1, 2}
+	sparseIndices := []uint16{1}
+	sparseValues := []float32{0.0, 0.1, 0.0}
+
+	var buf bytes.Buffer
+	for _, value := range positions {
+		if err := binary.Write(&buf, binary.LittleEndian, value); err != nil {
+			t.Fatalf("write position failed: %v", err)
+		}
+	}
+	for _, value := range normals {
+		if err := binary.Write(&buf, binary.LittleEndian, value); err != nil {
+			t.Fatalf("write normal failed: %v", err)
+		}
+	}
+	normalOffset := len(positions) * 4
+	for _, value := range uvs {
+		if err := binary.Write(&buf, binary.LittleEndian, value); err != nil {
+			t.Fatalf("write uv failed: %v", err)
+		}
+	}
+	uvOffset := normalOffset + len(normals)*4
+	indexOffset := uvOffset + len(uvs)*4
+	for _, value := range indices {
+		if err := binary.Write(&buf, binary.LittleEndian, value); err != nil {
+			t.Fatalf("write index failed: %v", err)
+		}
+	}
+	sparseIndexOffset := buf.Len()
+	for _, value := range sparseIndices {
+		if err := binary.Write(&buf, binary.LittleEndian, value); err != nil {
+			t.Fatalf("write sparse index failed: %v", err)
+		}
+	}
+	if padding := buf.Len() % 4; padding != 0 {
+		buf.Write(bytes.Repeat([]byte{0x00}, 4-padding))
+	}
+	sparseValueOffset := buf.Len()
+	for _, value := range sparseValues {
+		if err := binary.Write(&buf, binary.LittleEndian, value); err != nil {
+			t.Fatalf("write sparse value failed: %v", err)
+		}
+	}
+	binChunk := buf.Bytes()
+
+	doc := map[string]any{
+		"asset": map[string]any{
+			"version":   "2.0",
+			"generator": "VRM Test",
+		},
+		"extensionsUsed": []string{"VRMC_vrm"},
+		"nodes": []any{
+			map[string]any{
+				"name": "hips_node",
+			},
+			map[string]any{
+				"name": "mesh_node",
+				"mesh": 0,
+				"skin": 0,
+			},
+		},
+		"skins": []any{
+			map[string]any{
+				"joints": []int{0},
+			},
+		},
+		"meshes": []any{
+			map[string]any{
+				"name": "mesh0",
+				"primitives": []any{
+					map[string]any{
+						"attributes": map[string]any{
+							"POSITION":   0,
+							"NORMAL":     1,
+							"TEXCOORD_0": 2,
+						},
+						"indices":  3,
+						"material": 0,
+						"mode":     4,
+						"extras": map[string]any{
+							"targetNames": []string{"Fcl_ALL_Angry"},
+						},
+						"targets": []any{
+							map[string]any{
+								"POSITION": 4,
+							},
+						},
+					},
+				},
+			},
+		},
+		"materials": []any{
+			map[string]any{
+				"name": "body",
+				"pbrMetallicRoughness": map[string]any{
+					"baseColorFactor": []float64{1.0, 1.0, 1.0, 1.0},
+				},
+			},
+		},
+		"buffers": []any{
+			map[string]any{
+				"byteLength": len(binChunk),
+			},
+		},
+		"bufferViews": []any{
+			map[string]any{"buffer": 0, "byteOffset": 0, "byteLength": len(positions) * 4},
+			map[string]any{"buffer": 0, "byteOffset": normalOffset, "byteLength": len(normals) * 4},
+			map[string]any{"buffer": 0, "byteOffset": uvOffset, "byteLength": len(uvs) * 4},
+			map[string]any{"buffer": 0, "byteOffset": indexOffset, "byteLength": len(indices) * 2},
+			map[string]any{"buffer": 0, "byteOffset": sparseIndexOffset, "byteLength": len(sparseIndices) * 2},
+			map[string]any{"buffer": 0, "byteOffset": sparseValueOffset, "byteLength": len(sparseValues) * 4},
+		},
+		"accessors": []any{
+			map[string]any{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]any{"bufferView": 1, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]any{"bufferView": 2, "componentType": 5126, "count": 3, "type": "VEC2"},
+			map[string]any{"bufferView": 3, "componentType": 5123, "count": 3, "type": "SCALAR"},
+			map[string]any{
+				"componentType": 5126,
+				"count":         3,
+				"type":          "VEC3",
+				"sparse": map[string]any{
+					"count":   1,
+					"indices": map[string]any{"bufferView": 4, "componentType": 5123},
+					"values":  map[string]any{"bufferView": 5},
+				},
+			},
+		},
+		"extensions": map[string]any{
+			"VRMC_vrm": map[string]any{
+				"specVersion": "1.0",
+				"humanoid": map[string]any{
+					"humanBones": map[string]any{
+						"hips": map[string]any{"node": 0},
+					},
+				},
+				"expressions": map[string]any{
+					"custom": map[string]any{
+						"Fcl_ALL_Angry": map[string]any{
+							"morphTargetBinds": []any{
+								map[string]any{
+									"node":   1,
+									"index":  0,
+									"weight": 1.0,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	writeGLBFileForUsecaseMeshTest(t, path, doc, binChunk)
+
+	hashableModel, err := repository.Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	pmxModel, ok := hashableModel.(*model.PmxModel)
+	if !ok {
+		t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+	}
+	expressionMorph, err := pmxModel.Morphs.GetByName("怒")
+	if err != nil || expressionMorph == nil {
+		t.Fatalf("expression morph not found: err=%v", err)
+	}
+	if len(expressionMorph.Offsets) != 1 {
+		t.Fatalf("expression morph offset count mismatch: got=%d want=1", len(expressionMorph.Offsets))
+	}
+	vertexOffset, ok := expressionMorph.Offsets[0].(*model.VertexMorphOffset)
+	if !ok {
+		t.Fatalf("expression morph offset type mismatch: got=%T", expressionMorph.Offsets[0])
+	}
+	vertex, err := pmxModel.Vertices.Get(vertexOffset.VertexIndex)
+	if err != nil || vertex == nil {
+		t.Fatalf("expression vertex not found: index=%d err=%v", vertexOffset.VertexIndex, err)
+	}
+	if math.Abs(vertex.Position.Y) < 1e-6 {
+		t.Fatalf("sparse offset should be applied to vertex 1: position=%v", vertex.Position)
+	}
+	if math.Abs(math.Abs(vertexOffset.Position.Y)-0.1*vroidMeterScale) > 1e-4 {
+		t.Fatalf("sparse offset y mismatch: got=%f", vertexOffset.Position.Y)
+	}
+}
+
+func TestReadAccessorValuesOverlaysSparseValues(t *testing.T) {
+	var buf bytes.Buffer
+	baseValues := []float32{1.0, 2.0, 3.0, 4.0}
+	for _, value := range baseValues {
+		if err := binary.Write(&buf, binary.LittleEndian, value); err != nil {
+			t.Fatalf("write base value failed: %v", err)
+		}
+	}
+	sparseIndexOffset := buf.Len()
+	buf.Write([]byte{0x01, 0x03, 0x00, 0x00})
+	sparseValueOffset := buf.Len()
+	for _, value := range []float32{20.0, 40.0} {
+		if err := binary.Write(&buf, binary.LittleEndian, value); err != nil {
+			t.Fatalf("write sparse value failed: %v", err)
+		}
+	}
+	intValueOffset := buf.Len()
+	buf.Write([]byte{0x07, 0x09, 0x00, 0x00})
+	binChunk := buf.Bytes()
+
+	baseView := 0
+	sparse := func(count int, valueView int) *gltfAccessorSparse {
+		sparseAccessor := &gltfAccessorSparse{Count: count}
+		sparseAccessor.Indices.BufferView = 1
+		sparseAccessor.Indices.ComponentType = gltfComponentTypeUnsignedByte
+		sparseAccessor.Values.BufferView = valueView
+		return sparseAccessor
+	}
+	doc := &gltfDocument{
+		BufferViews: []gltfBufferView{
+			{Buffer: 0, ByteOffset: 0, ByteLength: len(baseValues) * 4},
+			{Buffer: 0, ByteOffset: sparseIndexOffset, ByteLength: 2},
+			{Buffer: 0, ByteOffset: sparseValueOffset, ByteLength: 8},
+			{Buffer: 0, ByteOffset: intValueOffset, ByteLength: 2},
+		},
+		Accessors: []gltfAccessor{
+			{BufferView: &baseView, ComponentType: gltfComponentTypeFloat, Count: 4, Type: "SCALAR", Sparse: sparse(2, 2)},
+			{ComponentType: gltfComponentTypeFloat, Count: 4, Type: "SCALAR", Sparse: sparse(2, 2)},
+			{ComponentType: gltfComponentTypeUnsignedByte, Count: 4, Type: "SCALAR", Sparse: sparse(2, 3)},
+		},
+	}
+
+	overlaid, err := readAccessorFloatValues(doc, 0, binChunk)
+	if err != nil {
+		t.Fatalf("read overlaid accessor failed: %v", err)
+	}
+	wantOverlaid := []float64{1.0, 20.0, 3.0, 40.0}
+	for i, want := range wantOverlaid {
+		if overlaid[i][0] != want {
+			t.Fatalf("overlaid value mismatch: index=%d got=%f want=%f", i, overlaid[i][0], want)
+		}
+	}
+
+	sparseOnly, err := readAccessorFloatValues(doc, 1, binChunk)
+	if err != nil {
+		t.Fatalf("read sparse-only accessor failed: %v", err)
+	}
+	wantSparseOnly := []float64{0.0, 20.0, 0.0, 40.0}
+	for i, want := range wantSparseOnly {
+		if sparseOnly[i][0] != want {
+			t.Fatalf("sparse-only value mismatch: index=%d got=%f want=%f", i, sparseOnly[i][0], want)
+		}
+	}
+
+	intValues, err := readAccessorIntValues(doc, 2, binChunk)
+	if err != nil {
+		t.Fatalf("read sparse int accessor failed: %v", err)
+	}
+	wantInt := []int{0, 7, 0, 9}
+	for i, want := range wantInt {
+		if intValues[i][0] != want {
+			t.Fatalf("sparse int value mismatch: index=%d got=%d want=%d", i, intValues[i][0], want)
+		}
+	}
+
+	doc.Accessors[1].Sparse.Count = 5
+	if _, err := readAccessorFloatValues(doc, 1, binChunk); err == nil {
+		t.Fatal("sparse count larger than accessor count should fail")
+	}
+}
+
+func TestBuildVertexDeformReducesInfluencesByMode(t *testing.T) {
+	modelData := model.NewPmxModel()
+	for i := 0; i < 6; i++ {
+		bone := model.NewBoneByName("bone_" + string(rune('a'+i)))
+		bone.ParentIndex = i - 1
+		modelData.Bones.AppendRaw(bone)
+	}
+	nodeToBoneIndex := map[int]int{0: 0, 1: 1, 2: 2, 3: 3, 4: 4, 5: 5}
+	joints, weights := mergeVertexInfluenceSets(
+		[][]int{{1, 2, 3, 4}},
+		[][]float64{{0.3, 0.25, 0.2, 0.15}},
+		[][]int{{5, 0, 0, 0}},
+		[][]float64{{0.06, 0.04, 0, 0}},
+	)
+	if len(joints[0]) != 8 || len(weights[0]) != 8 {
+		t.Fatalf("expected merged influence sets: joints=%v weights=%v", joints[0], weights[0])
+	}
+
+	dropDeform, dropTruncated := buildVertexDeform(
+		modelData, 0, joints[0], weights[0], nil, nodeToBoneIndex, WeightReductionModeDropSmallest,
+	)
+	if !dropTruncated {
+		t.Fatal("expected drop mode to report truncation")
+	}
+	if dropDeform.DeformType() != model.BDEF4 {
+		t.Fatalf("expected BDEF4, got %v", dropDeform.DeformType())
+	}
+	dropWeights := map[int]float64{}
+	for i, boneIndex := range dropDeform.Indexes() {
+		dropWeights[boneIndex] += dropDeform.Weights()[i]
+	}
+	if math.Abs(dropWeights[4]-0.15/0.9) > 1e-6 {
+		t.Fatalf("drop mode weight mismatch: got=%f want=%f", dropWeights[4], 0.15/0.9)
+	}
+
+	redistributeDeform, redistributeTruncated := buildVertexDeform(
+		modelData, 0, joints[0], weights[0], nil, nodeToBoneIndex, WeightReductionModeRedistributeToAncestor,
+	)
+	if !redistributeTruncated {
+		t.Fatal("expected redistribute mode to report truncation")
+	}
+	redistributeWeights := map[int]float64{}
+	for i, boneIndex := range redistributeDeform.Indexes() {
+		redistributeWeights[boneIndex] += redistributeDeform.Weights()[i]
+	}
+	// bone_f(5) は残存祖先 bone_e(4) へ寄せ、祖先を持たない bone_a(0) は切り捨てる。
+	if math.Abs(redistributeWeights[4]-0.21/0.96) > 1e-6 {
+		t.Fatalf("redistribute mode weight mismatch: got=%f want=%f", redistributeWeights[4], 0.21/0.96)
+	}
+	if math.Abs(redistributeWeights[1]-0.3/0.96) > 1e-6 {
+		t.Fatalf("redistribute mode top weight mismatch: got=%f want=%f", redistributeWeights[1], 0.3/0.96)
+	}
+
+	_, fourTruncated := buildVertexDeform(
+		modelData, 0, []int{1, 2, 3, 4}, []float64{0.4, 0.3, 0.2, 0.1}, nil, nodeToBoneIndex, WeightReductionModeDropSmallest,
+	)
+	if fourTruncated {
+		t.Fatal("four influences should not be reported as truncated")
+	}
+}
+
 func TestShouldSkipPrimitiveForUnsupportedTargets(t *testing.T) {
 	indices := 0
 	material := 0
@@ -0,0 +1,259 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+const (
+	khrTextureTransformExtensionName = "KHR_texture_transform"
+	uvTransformSourceKhr             = "KHR_texture_transform"
+	uvTransformSourceVrm0MainTex     = "_MainTex"
+	uvTransformEpsilon               = 1e-6
+	uvTransformMaxTexCoord           = 1
+)
+
+// gltfTextureTransformSource は KHR_texture_transform 拡張を表す。
+type gltfTextureTransformSource struct {
+	Offset   []float64 `json:"offset"`
+	Rotation float64   `json:"rotation"`
+	Scale    []float64 `json:"scale"`
+	TexCoord *int      `json:"texCoord"`
+}
+
+// primitiveUvTransform は頂点UVへ焼き込む 2x3 アフィン変換を表す。
+// u' = M00*u + M01*v + M02, v' = M10*u + M11*v + M12 とする。
+type primitiveUvTransform struct {
+	M00, M01, M02 float64
+	M10, M11, M12 float64
+	TexCoord      int
+	Source        string
+}
+
+// newIdentityPrimitiveUvTransform は恒等UV変換を返す。
+func newIdentityPrimitiveUvTransform() primitiveUvTransform {
+	return primitiveUvTransform{M00: 1, M11: 1}
+}
+
+// isIdentity は UV 座標を変更しない変換かを判定する。
+func (t primitiveUvTransform) isIdentity() bool {
+	return math.Abs(t.M00-1) <= uvTransformEpsilon &&
+		math.Abs(t.M01) <= uvTransformEpsilon &&
+		math.Abs(t.M02) <= uvTransformEpsilon &&
+		math.Abs(t.M10) <= uvTransformEpsilon &&
+		math.Abs(t.M11-1) <= uvTransformEpsilon &&
+		math.Abs(t.M12) <= uvTransformEpsilon
+}
+
+// key は頂点再利用キーへ付与する識別子を返す。恒等かつ TEXCOORD_0 の場合は空文字を返す。
+func (t primitiveUvTransform) key() string {
+	if t.isIdentity() && t.TexCoord == 0 {
+		return ""
+	}
+	return fmt.Sprintf(
+		"|uv=%d:%.6f,%.6f,%.6f,%.6f,%.6f,%.6f",
+		t.TexCoord,
+		t.M00,
+		t.M01,
+		t.M02,
+		t.M10,
+		t.M11,
+		t.M12,
+	)
+}
+
+// apply は UV へ変換を適用する。
+func (t primitiveUvTransform) apply(uv mmath.Vec2) mmath.Vec2 {
+	if t.isIdentity() {
+		return uv
+	}
+	return mmath.Vec2{
+		X: t.M00*uv.X + t.M01*uv.Y + t.M02,
+		Y: t.M10*uv.X + t.M11*uv.Y + t.M12,
+	}
+}
+
+// resolvePrimitiveUvTransform は primitive 材質の baseColorTexture に対応するUV変換を解決する。
+// KHR_texture_transform を優先し、未指定時は VRM0 MToon の _MainTex offset/scale を用いる。
+func resolvePrimitiveUvTransform(
+	modelData *model.PmxModel,
+	doc *gltfDocument,
+	primitive gltfPrimitive,
+	primitiveName string,
+) primitiveUvTransform {
+	transform := newIdentityPrimitiveUvTransform()
+	if doc == nil || primitive.Material == nil {
+		return transform
+	}
+	materialIndex := *primitive.Material
+	if materialIndex < 0 || materialIndex >= len(doc.Materials) {
+		return transform
+	}
+	sourceMaterial := doc.Materials[materialIndex]
+	baseTexture := sourceMaterial.PbrMetallicRoughness.BaseColorTexture
+	if baseTexture != nil {
+		transform.TexCoord = baseTexture.TexCoord
+		khrTransform, hasKhrTransform, err := resolveTextureRefTransform(baseTexture)
+		if err != nil {
+			recordTextureTransformApproxWarning(modelData, primitiveName, "KHR_texture_transform parse failed")
+		}
+		if hasKhrTransform {
+			transform = khrTransform
+		}
+	}
+	if transform.Source == "" {
+		if mainTexTransform, ok := resolveVrm0MainTexUvTransform(doc, sourceMaterial, materialIndex); ok {
+			mainTexTransform.TexCoord = transform.TexCoord
+			transform = mainTexTransform
+		}
+	}
+	if transform.TexCoord < 0 || transform.TexCoord > uvTransformMaxTexCoord {
+		recordTextureTransformApproxWarning(
+			modelData,
+			primitiveName,
+			fmt.Sprintf("texCoord=%d unsupported", transform.TexCoord),
+		)
+		transform.TexCoord = 0
+	}
+	if sourceMaterial.EmissiveTexture != nil && baseTexture != nil && transform.Source != uvTransformSourceVrm0MainTex {
+		// PMX は材質ごとに単一UVのため、baseColor 以外の変換は baseColor 側に揃える。
+		emissiveTransform, hasEmissiveTransform, _ := resolveTextureRefTransform(sourceMaterial.EmissiveTexture)
+		if !hasEmissiveTransform {
+			emissiveTransform = newIdentityPrimitiveUvTransform()
+		}
+		emissiveTransform.TexCoord = sourceMaterial.EmissiveTexture.TexCoord
+		if emissiveTransform.key() != transform.key() {
+			recordTextureTransformApproxWarning(modelData, primitiveName, "emissive transform differs from baseColor")
+		}
+	}
+	if !transform.isIdentity() {
+		logVrmDebug(
+			"UV変換焼き込み: primitive=%s source=%s texCoord=%d matrix=[%.4f %.4f %.4f; %.4f %.4f %.4f]",
+			primitiveName,
+			transform.Source,
+			transform.TexCoord,
+			transform.M00,
+			transform.M01,
+			transform.M02,
+			transform.M10,
+			transform.M11,
+			transform.M12,
+		)
+	}
+	return transform
+}
+
+// resolveTextureRefTransform は textureRef の KHR_texture_transform を変換行列へ変換する。
+func resolveTextureRefTransform(textureRef *gltfTextureRef) (primitiveUvTransform, bool, error) {
+	transform := newIdentityPrimitiveUvTransform()
+	if textureRef == nil || textureRef.Extensions == nil {
+		return transform, false, nil
+	}
+	raw, exists := textureRef.Extensions[khrTextureTransformExtensionName]
+	if !exists || len(raw) == 0 {
+		return transform, false, nil
+	}
+	source := gltfTextureTransformSource{}
+	if err := json.Unmarshal(raw, &source); err != nil {
+		return transform, false, err
+	}
+	offset := [2]float64{0, 0}
+	if len(source.Offset) >= 2 {
+		offset = [2]float64{source.Offset[0], source.Offset[1]}
+	}
+	scale := [2]float64{1, 1}
+	if len(source.Scale) >= 2 {
+		scale = [2]float64{source.Scale[0], source.Scale[1]}
+	}
+	// KHR_texture_transform: T * R * S (R は反時計回り、UV 原点は左上)
+	cosValue := math.Cos(source.Rotation)
+	sinValue := math.Sin(source.Rotation)
+	transform.M00 = cosValue * scale[0]
+	transform.M01 = sinValue * scale[1]
+	transform.M02 = offset[0]
+	transform.M10 = -sinValue * scale[0]
+	transform.M11 = cosValue * scale[1]
+	transform.M12 = offset[1]
+	transform.TexCoord = textureRef.TexCoord
+	if source.TexCoord != nil {
+		transform.TexCoord = *source.TexCoord
+	}
+	transform.Source = uvTransformSourceKhr
+	return transform, true, nil
+}
+
+// resolveVrm0MainTexUvTransform は VRM0 _MainTex の [offsetX, offsetY, scaleX, scaleY] を変換行列へ変換する。
+func resolveVrm0MainTexUvTransform(
+	doc *gltfDocument,
+	sourceMaterial gltfMaterial,
+	materialIndex int,
+) (primitiveUvTransform, bool) {
+	property, ok := resolveVrm0MaterialProperty(doc, sourceMaterial, materialIndex)
+	if !ok {
+		return primitiveUvTransform{}, false
+	}
+	values, exists := lookupVrm0MaterialVectorProperty(property, "_MainTex", "MainTex")
+	if !exists || len(values) < 4 {
+		return primitiveUvTransform{}, false
+	}
+	offsetX, offsetY, scaleX, scaleY := values[0], values[1], values[2], values[3]
+	// Unity の UV は左下原点のため、glTF の v を反転した空間で offset/scale を適用する。
+	transform := primitiveUvTransform{
+		M00:    scaleX,
+		M02:    offsetX,
+		M11:    scaleY,
+		M12:    1.0 - scaleY - offsetY,
+		Source: uvTransformSourceVrm0MainTex,
+	}
+	if transform.isIdentity() {
+		return primitiveUvTransform{}, false
+	}
+	return transform, true
+}
+
+// resolvePrimitiveTransformSourceUvs は UV 変換が参照する TEXCOORD を読み込む。
+// 読込できない場合は TEXCOORD_0 へフォールバックし、近似警告を記録する。
+func resolvePrimitiveTransformSourceUvs(
+	modelData *model.PmxModel,
+	doc *gltfDocument,
+	primitive gltfPrimitive,
+	primitiveName string,
+	binChunk []byte,
+	cache *accessorValueCache,
+	uvs [][]float64,
+	transform *primitiveUvTransform,
+) [][]float64 {
+	if transform == nil || transform.TexCoord == 0 {
+		return uvs
+	}
+	attributeName := fmt.Sprintf("TEXCOORD_%d", transform.TexCoord)
+	sourceUvs, err := readOptionalFloatAttribute(doc, primitive.Attributes, attributeName, binChunk, cache)
+	if err != nil || len(sourceUvs) == 0 {
+		recordTextureTransformApproxWarning(
+			modelData,
+			primitiveName,
+			fmt.Sprintf("%s missing", attributeName),
+		)
+		transform.TexCoord = 0
+		return uvs
+	}
+	return sourceUvs
+}
+
+// recordTextureTransformApproxWarning は textureTransform 近似警告を記録する。
+func recordTextureTransformApproxWarning(modelData *model.PmxModel, primitiveName string, reason string) {
+	recordLegacyMaterialWarning(
+		modelData,
+		warningid.VrmWarningTextureTransformApprox,
+		"textureTransform近似: primitive=%s reason=%s",
+		strings.TrimSpace(primitiveName),
+		reason,
+	)
+}
@@ -0,0 +1,225 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+func TestVrmRepositoryLoadBakesTextureTransformAndDuplicatesSharedVertices(t *testing.T) {
+	repository := NewVrmRepository()
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "texture_transform.vrm")
+
+	positions := []float32{
+		0.0, 0.0, 0.0,
+		0.0, 1.0, 0.0,
+		1.0, 0.0, 0.0,
+	}
+	normals := []float32{
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+	}
+	uvs := []float32{
+		0.0, 0.0,
+		0.0, 1.0,
+		1.0, 0.0,
+	}
+	indices := []uint16{0, 1, 2}
+
+	binChunk := buildInterleavedBinForMeshTest(t, positions, normals, uvs, indices)
+	primitiveAttributes := map[string]any{
+		"POSITION":   0,
+		"NORMAL":     1,
+		"TEXCOORD_0": 2,
+	}
+	doc := map[string]any{
+		"asset": map[string]any{
+			"version":   "2.0",
+			"generator": "VRM Test",
+		},
+		"extensionsUsed": []string{"VRMC_vrm", "KHR_texture_transform"},
+		"nodes": []any{
+			map[string]any{
+				"name": "hips_node",
+			},
+			map[string]any{
+				"name": "mesh_node",
+				"mesh": 0,
+				"skin": 0,
+			},
+		},
+		"skins": []any{
+			map[string]any{
+				"joints": []int{0},
+			},
+		},
+		"meshes": []any{
+			map[string]any{
+				"name": "mesh0",
+				"primitives": []any{
+					map[string]any{
+						"attributes": primitiveAttributes,
+						"indices":    3,
+						"material":   0,
+						"mode":       4,
+					},
+					map[string]any{
+						"attributes": primitiveAttributes,
+						"indices":    3,
+						"material":   1,
+						"mode":       4,
+					},
+					map[string]any{
+						"attributes": primitiveAttributes,
+						"indices":    3,
+						"material":   2,
+						"mode":       4,
+					},
+				},
+			},
+		},
+		"textures": []any{
+			map[string]any{},
+		},
+		"materials": []any{
+			map[string]any{
+				"name": "transformed",
+				"pbrMetallicRoughness": map[string]any{
+					"baseColorFactor": []float64{1.0, 1.0, 1.0, 1.0},
+					"baseColorTexture": map[string]any{
+						"index": 0,
+						"extensions": map[string]any{
+							"KHR_texture_transform": map[string]any{
+								"offset": []float64{0.5, 0.0},
+								"scale":  []float64{0.5, 0.5},
+							},
+						},
+					},
+				},
+			},
+			map[string]any{
+				"name": "plain",
+				"pbrMetallicRoughness": map[string]any{
+					"baseColorFactor": []float64{1.0, 1.0, 1.0, 1.0},
+				},
+			},
+			map[string]any{
+				"name": "missing_texcoord",
+				"pbrMetallicRoughness": map[string]any{
+					"baseColorFactor": []float64{1.0, 1.0, 1.0, 1.0},
+					"baseColorTexture": map[string]any{
+						"index": 0,
+						"extensions": map[string]any{
+							"KHR_texture_transform": map[string]any{
+								"texCoord": 1,
+							},
+						},
+					},
+				},
+			},
+		},
+		"buffers": []any{
+			map[string]any{
+				"byteLength": len(binChunk),
+			},
+		},
+		"bufferViews": []any{
+			map[string]any{
+				"buffer":     0,
+				"byteOffset": 0,
+				"byteLength": len(positions) * 4,
+			},
+			map[string]any{
+				"buffer":     0,
+				"byteOffset": len(positions) * 4,
+				"byteLength": len(normals) * 4,
+			},
+			map[string]any{
+				"buffer":     0,
+				"byteOffset": (len(positions) + len(normals)) * 4,
+				"byteLength": len(uvs) * 4,
+			},
+			map[string]any{
+				"buffer":     0,
+				"byteOffset": (len(positions) + len(normals) + len(uvs)) * 4,
+				"byteLength": len(indices) * 2,
+			},
+		},
+		"accessors": []any{
+			map[string]any{
+				"bufferView":    0,
+				"componentType": 5126,
+				"count":         3,
+				"type":          "VEC3",
+			},
+			map[string]any{
+				"bufferView":    1,
+				"componentType": 5126,
+				"count":         3,
+				"type":          "VEC3",
+			},
+			map[string]any{
+				"bufferView":    2,
+				"componentType": 5126,
+				"count":         3,
+				"type":          "VEC2",
+			},
+			map[string]any{
+				"bufferView":    3,
+				"componentType": 5123,
+				"count":         3,
+				"type":          "SCALAR",
+			},
+		},
+		"extensions": map[string]any{
+			"VRMC_vrm": map[string]any{
+				"specVersion": "1.0",
+				"humanoid": map[string]any{
+					"humanBones": map[string]any{
+						"hips": map[string]any{"node": 0},
+					},
+				},
+			},
+		},
+	}
+	writeGLBFileForTestWithBin(t, path, doc, binChunk)
+
+	hashableModel, err := repository.Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	pmxModel, ok := hashableModel.(*model.PmxModel)
+	if !ok {
+		t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+	}
+	// 変換あり材質は頂点を複製し、変換なし材質同士は頂点を共有する。
+	if pmxModel.Vertices.Len() != 6 {
+		t.Fatalf("expected 6 vertices, got %d", pmxModel.Vertices.Len())
+	}
+	assertUv := func(vertexIndex int, wantU float64, wantV float64) {
+		t.Helper()
+		vertex, getErr := pmxModel.Vertices.Get(vertexIndex)
+		if getErr != nil || vertex == nil {
+			t.Fatalf("vertex not found: index=%d err=%v", vertexIndex, getErr)
+		}
+		if math.Abs(vertex.Uv.X-wantU) > 1e-6 || math.Abs(vertex.Uv.Y-wantV) > 1e-6 {
+			t.Fatalf("uv mismatch: index=%d got=(%f,%f) want=(%f,%f)", vertexIndex, vertex.Uv.X, vertex.Uv.Y, wantU, wantV)
+		}
+	}
+	assertUv(0, 0.5, 0.0)
+	assertUv(1, 0.5, 0.5)
+	assertUv(2, 1.0, 0.0)
+	assertUv(3, 0.0, 0.0)
+	assertUv(4, 0.0, 1.0)
+	assertUv(5, 1.0, 0.0)
+
+	if !hasWarningID(pmxModel, warningid.VrmWarningTextureTransformApprox) {
+		t.Fatalf("expected warning id %s", warningid.VrmWarningTextureTransformApprox)
+	}
+}
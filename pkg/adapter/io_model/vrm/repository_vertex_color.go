@@ -0,0 +1,125 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+const (
+	vertexColorWhiteEpsilon = 1e-4
+)
+
+// vertexColorBakeMetadata は頂点カラー焼き込みテクスチャ生成に必要な材質単位の情報を表す。
+type vertexColorBakeMetadata struct {
+	SourceTextureIndex int          `json:"source_texture_index"`
+	VertexStart        int          `json:"vertex_start"`
+	Colors             [][4]float64 `json:"colors"`
+}
+
+// readPrimitiveVertexColors は COLOR_0 を RGBA として読み込む。未指定時は nil を返す。
+func readPrimitiveVertexColors(
+	doc *gltfDocument,
+	primitive gltfPrimitive,
+	binChunk []byte,
+	cache *accessorValueCache,
+) ([][4]float64, error) {
+	values, err := readOptionalFloatAttribute(doc, primitive.Attributes, "COLOR_0", binChunk, cache)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	colors := make([][4]float64, len(values))
+	for vertexIndex, value := range values {
+		color := [4]float64{1, 1, 1, 1}
+		for channel := 0; channel < len(value) && channel < len(color); channel++ {
+			color[channel] = clampMToonUnit(value[channel])
+		}
+		colors[vertexIndex] = color
+	}
+	return colors, nil
+}
+
+// applyPrimitiveVertexColors は変換方法に応じて COLOR_0 を拡張UVまたは焼き込み情報へ反映する。
+// 拡張UV方式では COLOR_0 を持たない primitive も白で埋め、シェーダー側の乗算結果を変えないようにする。
+// 焼き込み情報は vertexColorBakes へ材質index別に蓄積し、全 primitive 変換後にまとめて記録する。
+func applyPrimitiveVertexColors(
+	modelData *model.PmxModel,
+	meshOptions vrmMeshOptions,
+	vertexStart int,
+	vertexCount int,
+	colors [][4]float64,
+	materialIndex int,
+	vertexColorBakes map[int]vertexColorBakeMetadata,
+) {
+	if modelData == nil || modelData.Vertices == nil {
+		return
+	}
+	switch meshOptions.VertexColorMode {
+	case VertexColorModeExtendedUv:
+		for vertexOffset := 0; vertexOffset < vertexCount; vertexOffset++ {
+			vertex, err := modelData.Vertices.Get(vertexStart + vertexOffset)
+			if err != nil || vertex == nil {
+				continue
+			}
+			color := [4]float64{1, 1, 1, 1}
+			if vertexOffset < len(colors) {
+				color = colors[vertexOffset]
+			}
+			setVertexExtendedUvSlot(
+				vertex,
+				extendedUvSlotVertexColor,
+				mmath.Vec4{X: color[0], Y: color[1], Z: color[2], W: color[3]},
+			)
+		}
+	case VertexColorModeTexture:
+		if vertexColorBakes == nil || len(colors) == 0 || isWhiteVertexColors(colors) {
+			return
+		}
+		materialData, err := modelData.Materials.Get(materialIndex)
+		if err != nil || materialData == nil {
+			return
+		}
+		vertexColorBakes[materialIndex] = vertexColorBakeMetadata{
+			SourceTextureIndex: materialData.TextureIndex,
+			VertexStart:        vertexStart,
+			Colors:             colors,
+		}
+	}
+}
+
+// storeVertexColorBakeMetadata は材質indexをキーに頂点カラー焼き込み情報を RawExtensions へ一括記録する。
+func storeVertexColorBakeMetadata(modelData *model.PmxModel, vertexColorBakes map[int]vertexColorBakeMetadata) {
+	if modelData == nil || modelData.VrmData == nil || len(vertexColorBakes) == 0 {
+		return
+	}
+	metadataMap := make(map[string]vertexColorBakeMetadata, len(vertexColorBakes))
+	for materialIndex, metadata := range vertexColorBakes {
+		metadataMap[strconv.Itoa(materialIndex)] = metadata
+	}
+	encoded, err := json.Marshal(metadataMap)
+	if err != nil {
+		logVrmWarn("頂点カラー焼き込み情報の保存に失敗しました: err=%s", err.Error())
+		return
+	}
+	if modelData.VrmData.RawExtensions == nil {
+		modelData.VrmData.RawExtensions = map[string]json.RawMessage{}
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmVertexColorBakeRawExtensionKey] = encoded
+}
+
+// isWhiteVertexColors は全頂点カラーが白(乗算しても変化しない)か判定する。
+func isWhiteVertexColors(colors [][4]float64) bool {
+	for _, color := range colors {
+		for _, value := range color {
+			if math.Abs(value-1.0) > vertexColorWhiteEpsilon {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+// 指示: miu200521358
+package vrm
+
+import (
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+func TestVrmRepositoryLoadConvertsNormalizedVertexColors(t *testing.T) {
+	positions := []float32{
+		0.0, 0.0, 0.0,
+		0.0, 1.0, 0.0,
+		1.0, 0.0, 0.0,
+	}
+	normals := []float32{
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+		0.0, 0.0, 1.0,
+	}
+	uvs := []float32{
+		0.0, 0.0,
+		0.0, 1.0,
+		1.0, 0.0,
+	}
+	indices := []uint16{0, 1, 2}
+	colors := []byte{
+		255, 0, 0, 255,
+		0, 255, 0, 255,
+		0, 0, 255, 128,
+	}
+	binChunk := buildInterleavedBinForMeshTest(t, positions, normals, uvs, indices)
+	binChunk = append(binChunk, 0, 0)
+	colorOffset := len(binChunk)
+	binChunk = append(binChunk, colors...)
+
+	doc := map[string]any{
+		"asset": map[string]any{
+			"version":   "2.0",
+			"generator": "VRM Test",
+		},
+		"extensionsUsed": []string{"VRMC_vrm"},
+		"nodes": []any{
+			map[string]any{"name": "hips_node"},
+			map[string]any{"name": "mesh_node", "mesh": 0, "skin": 0},
+		},
+		"skins": []any{
+			map[string]any{"joints": []int{0}},
+		},
+		"meshes": []any{
+			map[string]any{
+				"name": "mesh0",
+				"primitives": []any{
+					map[string]any{
+						"attributes": map[string]any{
+							"POSITION":   0,
+							"NORMAL":     1,
+							"TEXCOORD_0": 2,
+							"COLOR_0":    4,
+						},
+						"indices":  3,
+						"material": 0,
+						"mode":     4,
+					},
+				},
+			},
+		},
+		"materials": []any{
+			map[string]any{
+				"name": "tinted",
+				"pbrMetallicRoughness": map[string]any{
+					"baseColorFactor": []float64{1.0, 1.0, 1.0, 1.0},
+				},
+			},
+		},
+		"buffers": []any{
+			map[string]any{"byteLength": len(binChunk)},
+		},
+		"bufferViews": []any{
+			map[string]any{"buffer": 0, "byteOffset": 0, "byteLength": len(positions) * 4},
+			map[string]any{"buffer": 0, "byteOffset": len(positions) * 4, "byteLength": len(normals) * 4},
+			map[string]any{"buffer": 0, "byteOffset": (len(positions) + len(normals)) * 4, "byteLength": len(uvs) * 4},
+			map[string]any{"buffer": 0, "byteOffset": (len(positions) + len(normals) + len(uvs)) * 4, "byteLength": len(indices) * 2},
+			map[string]any{"buffer": 0, "byteOffset": colorOffset, "byteLength": len(colors)},
+		},
+		"accessors": []any{
+			map[string]any{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]any{"bufferView": 1, "componentType": 5126, "count": 3, "type": "VEC3"},
+			map[string]any{"bufferView": 2, "componentType": 5126, "count": 3, "type": "VEC2"},
+			map[string]any{"bufferView": 3, "componentType": 5123, "count": 3, "type": "SCALAR"},
+			map[string]any{"bufferView": 4, "componentType": 5121, "normalized": true, "count": 3, "type": "VEC4"},
+		},
+		"extensions": map[string]any{
+			"VRMC_vrm": map[string]any{
+				"specVersion": "1.0",
+				"humanoid": map[string]any{
+					"humanBones": map[string]any{
+						"hips": map[string]any{"node": 0},
+					},
+				},
+			},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "vertex_color.vrm")
+	writeGLBFileForTestWithBin(t, path, doc, binChunk)
+
+	load := func(mode VertexColorMode) *model.PmxModel {
+		t.Helper()
+		repository := NewVrmRepository()
+		repository.SetVertexColorMode(mode)
+		hashableModel, err := repository.Load(path)
+		if err != nil {
+			t.Fatalf("load failed: mode=%s err=%v", mode, err)
+		}
+		pmxModel, ok := hashableModel.(*model.PmxModel)
+		if !ok {
+			t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+		}
+		return pmxModel
+	}
+
+	extendedUvModel := load(VertexColorModeExtendedUv)
+	blueVertex, err := extendedUvModel.Vertices.Get(2)
+	if err != nil || blueVertex == nil {
+		t.Fatalf("vertex not found: err=%v", err)
+	}
+	if len(blueVertex.ExtendedUvs) != 2 {
+		t.Fatalf("extended uv count mismatch: got=%d", len(blueVertex.ExtendedUvs))
+	}
+	blue := blueVertex.ExtendedUvs[extendedUvSlotVertexColor]
+	if blue.X != 0 || blue.Y != 0 || blue.Z != 1 || math.Abs(blue.W-128.0/255.0) > 1e-6 {
+		t.Fatalf("vertex color mismatch: got=%v", blue)
+	}
+	if _, exists := extendedUvModel.VrmData.RawExtensions[warningid.VrmVertexColorBakeRawExtensionKey]; exists {
+		t.Fatalf("extended uv mode should not record bake metadata")
+	}
+
+	textureModel := load(VertexColorModeTexture)
+	metadataMap := map[string]vertexColorBakeMetadata{}
+	raw := textureModel.VrmData.RawExtensions[warningid.VrmVertexColorBakeRawExtensionKey]
+	if err := json.Unmarshal(raw, &metadataMap); err != nil {
+		t.Fatalf("bake metadata should be recorded: %v", err)
+	}
+	metadata, exists := metadataMap["0"]
+	if !exists || metadata.VertexStart != 0 || len(metadata.Colors) != 3 || metadata.Colors[0] != [4]float64{1, 0, 0, 1} {
+		t.Fatalf("bake metadata mismatch: exists=%t metadata=%+v", exists, metadata)
+	}
+	redVertex, err := textureModel.Vertices.Get(0)
+	if err != nil || redVertex == nil || len(redVertex.ExtendedUvs) != 0 {
+		t.Fatalf("texture mode should not allocate extended uv: vertex=%v err=%v", redVertex, err)
+	}
+
+	noneModel := load(VertexColorModeNone)
+	if _, exists := noneModel.VrmData.RawExtensions[warningid.VrmVertexColorBakeRawExtensionKey]; exists {
+		t.Fatalf("none mode should ignore vertex colors")
+	}
+}
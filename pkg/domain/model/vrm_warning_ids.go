@@ -6,8 +6,18 @@ const (
 	VrmWarningRawExtensionKey = "MU_VRM2PMX_warnings"
 	// VrmLegacyGeneratedToonShadeMapRawExtensionKey は生成toonの shade 色マップを保持する RawExtensions のキー。
 	VrmLegacyGeneratedToonShadeMapRawExtensionKey = "MU_VRM2PMX_legacy_generated_toon_shade_map"
+	// VrmGeneratedToonRampMapRawExtensionKey は生成toonの MToon 陰影パラメータマップを保持する RawExtensions のキー。
+	VrmGeneratedToonRampMapRawExtensionKey = "MU_VRM2PMX_generated_toon_ramp_map"
 	// VrmLegacySpherePriorityMigrationRawExtensionKey は sphere 優先順位の移行観測統計を保持する RawExtensions のキー。
 	VrmLegacySpherePriorityMigrationRawExtensionKey = "MU_VRM2PMX_legacy_sphere_priority_migration"
+	// VrmLookAtRawExtensionKey は正規化済み lookAt 定義を保持する RawExtensions のキー。
+	VrmLookAtRawExtensionKey = "MU_VRM2PMX_look_at"
+	// VrmVertexColorBakeRawExtensionKey は COLOR_0 焼き込み対象の材質別頂点カラーを保持する RawExtensions のキー。
+	VrmVertexColorBakeRawExtensionKey = "MU_VRM2PMX_vertex_color_bake"
+	// VrmMaterialCompanionRawExtensionKey は glTF material 別の補助テクスチャ/係数を保持する RawExtensions のキー。
+	VrmMaterialCompanionRawExtensionKey = "MU_VRM2PMX_material_companion"
+	// VrmMorphSourceRawExtensionKey はモーフ index 別の生成元種別を保持する RawExtensions のキー。
+	VrmMorphSourceRawExtensionKey = "MU_VRM2PMX_morph_source"
 
 	// VrmWarningWeightsTruncated は頂点ウェイト切り捨て警告。
 	VrmWarningWeightsTruncated = "VrmWarningWeightsTruncated"
@@ -35,4 +45,8 @@ const (
 	VrmWarningGravityDirectionUnsupported = "VrmWarningGravityDirectionUnsupported"
 	// VrmWarningSpringParamClamped は spring パラメータ clamp 警告。
 	VrmWarningSpringParamClamped = "VrmWarningSpringParamClamped"
+	// VrmWarningNodeConstraintNotConvertible は node constraint 変換不可警告。
+	VrmWarningNodeConstraintNotConvertible = "VrmWarningNodeConstraintNotConvertible"
+	// VrmWarningNodeConstraintApproximated は node constraint 近似変換警告。
+	VrmWarningNodeConstraintApproximated = "VrmWarningNodeConstraintApproximated"
 )
@@ -13,6 +13,13 @@ func TestVrmWarningIDsAreNonEmptyAndUnique(t *testing.T) {
 			"MU_VRM2PMX_legacy_generated_toon_shade_map",
 		)
 	}
+	if VrmGeneratedToonRampMapRawExtensionKey != "MU_VRM2PMX_generated_toon_ramp_map" {
+		t.Fatalf(
+			"toon ramp map key mismatch: got=%s want=%s",
+			VrmGeneratedToonRampMapRawExtensionKey,
+			"MU_VRM2PMX_generated_toon_ramp_map",
+		)
+	}
 	if VrmLegacySpherePriorityMigrationRawExtensionKey != "MU_VRM2PMX_legacy_sphere_priority_migration" {
 		t.Fatalf(
 			"sphere migration key mismatch: got=%s want=%s",
@@ -20,6 +27,30 @@ func TestVrmWarningIDsAreNonEmptyAndUnique(t *testing.T) {
 			"MU_VRM2PMX_legacy_sphere_priority_migration",
 		)
 	}
+	if VrmLookAtRawExtensionKey != "MU_VRM2PMX_look_at" {
+		t.Fatalf("look at key mismatch: got=%s want=%s", VrmLookAtRawExtensionKey, "MU_VRM2PMX_look_at")
+	}
+	if VrmVertexColorBakeRawExtensionKey != "MU_VRM2PMX_vertex_color_bake" {
+		t.Fatalf(
+			"vertex color bake key mismatch: got=%s want=%s",
+			VrmVertexColorBakeRawExtensionKey,
+			"MU_VRM2PMX_vertex_color_bake",
+		)
+	}
+	if VrmMaterialCompanionRawExtensionKey != "MU_VRM2PMX_material_companion" {
+		t.Fatalf(
+			"material companion key mismatch: got=%s want=%s",
+			VrmMaterialCompanionRawExtensionKey,
+			"MU_VRM2PMX_material_companion",
+		)
+	}
+	if VrmMorphSourceRawExtensionKey != "MU_VRM2PMX_morph_source" {
+		t.Fatalf(
+			"morph source key mismatch: got=%s want=%s",
+			VrmMorphSourceRawExtensionKey,
+			"MU_VRM2PMX_morph_source",
+		)
+	}
 
 	warningIDs := []string{
 		VrmWarningWeightsTruncated,
@@ -35,6 +66,8 @@ func TestVrmWarningIDsAreNonEmptyAndUnique(t *testing.T) {
 		VrmWarningMaterialBindNotConvertible,
 		VrmWarningGravityDirectionUnsupported,
 		VrmWarningSpringParamClamped,
+		VrmWarningNodeConstraintNotConvertible,
+		VrmWarningNodeConstraintApproximated,
 	}
 
 	seen := map[string]struct{}{}
@@ -570,6 +570,7 @@ func NewTabPages(mWidgets *controller.MWidgets, baseServices base.IBaseServices,
 		},
 		[]widget.FileFilterExtension{
 			{Extension: "*.vrm", Description: "Vrm Files (*.vrm)"},
+			{Extension: "*.glb;*.gltf", Description: "glTF Files (*.glb;*.gltf)"},
 			{Extension: "*.*", Description: "All Files (*.*)"},
 		},
 		vrm.NewVrmRepository(),
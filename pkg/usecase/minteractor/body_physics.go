@@ -0,0 +1,333 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/shared/base/logging"
+	"github.com/miu200521358/mu_vrm2pmx/pkg/adapter/io_model/vrm"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+const (
+	// bodyRigidBodyCollisionGroup は揺れ物剛体側が衝突対象として予約している体用グループ。
+	bodyRigidBodyCollisionGroup = 0
+	bodyRigidBodyCollisionMask  = uint16(0xFFFF) &^ (uint16(1) << bodyRigidBodyCollisionGroup)
+	bodyRigidBodyMinRadius      = 0.2
+	bodyRigidBodyMinVertexCount = 4
+	bodyRigidBodyMass           = 1.0
+	bodyRigidBodyDamping        = 0.5
+	bodyRigidBodyRestitution    = 0.0
+	bodyRigidBodyFriction       = 0.0
+	bodyRigidBodyRadiusRatio    = 0.9
+	bodyRigidBodyAxisEpsilon    = 1e-6
+)
+
+// bodyRigidBodyTarget は体剛体生成対象の標準ボーンと軸方向の決定方法を表す。
+type bodyRigidBodyTarget struct {
+	BoneName       string
+	TailBoneNames  []string
+	FallbackAxis   mmath.Vec3
+	AliasBoneNames []string
+}
+
+// bodyRigidBodyStats は体剛体生成の集計情報を表す。
+type bodyRigidBodyStats struct {
+	Generated int
+	Skipped   int
+}
+
+// applyBodyRigidBodiesAfterBoneMapping は標準ボーンごとに体衝突用のカプセル剛体を追加する。
+func applyBodyRigidBodiesAfterBoneMapping(modelData *ModelData) error {
+	if modelData == nil || modelData.Bones == nil || modelData.Vertices == nil || modelData.RigidBodies == nil {
+		return nil
+	}
+	targets := buildBodyRigidBodyTargets()
+	targetByBoneIndex := resolveBodyRigidBodyTargetBoneIndexes(modelData.Bones, targets)
+	if len(targetByBoneIndex) == 0 {
+		return nil
+	}
+	positionsByTarget := collectBodyRigidBodyVertexPositions(modelData, targetByBoneIndex)
+
+	stats := bodyRigidBodyStats{}
+	for _, target := range targets {
+		bone, exists := getBoneByName(modelData.Bones, target.BoneName)
+		if !exists {
+			continue
+		}
+		positions := positionsByTarget[bone.Index()]
+		if len(positions) < bodyRigidBodyMinVertexCount {
+			stats.Skipped++
+			continue
+		}
+		rigidBody, ok := buildBodyRigidBody(modelData.Bones, bone, target, positions)
+		if !ok {
+			stats.Skipped++
+			continue
+		}
+		rigidBody.SetName(resolveUniqueRigidBodyName(modelData, bone.Name()))
+		rigidBody.EnglishName = bone.EnglishName
+		modelData.RigidBodies.AppendRaw(rigidBody)
+		stats.Generated++
+	}
+	if logger := logging.DefaultLogger(); logger != nil {
+		logger.Info("体剛体生成完了: generated=%d skipped=%d", stats.Generated, stats.Skipped)
+	}
+	return nil
+}
+
+// buildBodyRigidBodyTargets は体剛体生成対象の標準ボーン一覧を返す。
+func buildBodyRigidBodyTargets() []bodyRigidBodyTarget {
+	up := mmath.Vec3{Vec: r3.Vec{X: 0, Y: 1, Z: 0}}
+	down := mmath.Vec3{Vec: r3.Vec{X: 0, Y: -1, Z: 0}}
+	targets := []bodyRigidBodyTarget{
+		{BoneName: model.LOWER.String(), FallbackAxis: down},
+		{BoneName: model.UPPER.String(), TailBoneNames: []string{model.UPPER2.String(), model.NECK.String()}, FallbackAxis: up},
+		{BoneName: model.UPPER2.String(), TailBoneNames: []string{model.NECK.String()}, FallbackAxis: up},
+		{BoneName: model.NECK.String(), TailBoneNames: []string{model.HEAD.String()}, FallbackAxis: up},
+		{BoneName: model.HEAD.String(), FallbackAxis: up},
+	}
+	type sideBoneNames struct {
+		Shoulder   string
+		Arm        string
+		ArmTwist   string
+		Elbow      string
+		WristTwist string
+		Wrist      string
+		Leg        string
+		LegD       string
+		Knee       string
+		KneeD      string
+		Ankle      string
+	}
+	sides := []sideBoneNames{
+		{
+			Shoulder:   model.SHOULDER.Left(),
+			Arm:        model.ARM.Left(),
+			ArmTwist:   model.ARM_TWIST.Left(),
+			Elbow:      model.ELBOW.Left(),
+			WristTwist: model.WRIST_TWIST.Left(),
+			Wrist:      model.WRIST.Left(),
+			Leg:        model.LEG.Left(),
+			LegD:       model.LEG_D.Left(),
+			Knee:       model.KNEE.Left(),
+			KneeD:      model.KNEE_D.Left(),
+			Ankle:      model.ANKLE.Left(),
+		},
+		{
+			Shoulder:   model.SHOULDER.Right(),
+			Arm:        model.ARM.Right(),
+			ArmTwist:   model.ARM_TWIST.Right(),
+			Elbow:      model.ELBOW.Right(),
+			WristTwist: model.WRIST_TWIST.Right(),
+			Wrist:      model.WRIST.Right(),
+			Leg:        model.LEG.Right(),
+			LegD:       model.LEG_D.Right(),
+			Knee:       model.KNEE.Right(),
+			KneeD:      model.KNEE_D.Right(),
+			Ankle:      model.ANKLE.Right(),
+		},
+	}
+	for _, side := range sides {
+		targets = append(targets,
+			bodyRigidBodyTarget{BoneName: side.Shoulder, TailBoneNames: []string{side.Arm}},
+			bodyRigidBodyTarget{BoneName: side.Arm, TailBoneNames: []string{side.Elbow}, AliasBoneNames: []string{side.ArmTwist}},
+			bodyRigidBodyTarget{BoneName: side.Elbow, TailBoneNames: []string{side.Wrist}, AliasBoneNames: []string{side.WristTwist}},
+			bodyRigidBodyTarget{BoneName: side.Leg, TailBoneNames: []string{side.Knee}, AliasBoneNames: []string{side.LegD}},
+			bodyRigidBodyTarget{BoneName: side.Knee, TailBoneNames: []string{side.Ankle}, AliasBoneNames: []string{side.KneeD}},
+		)
+	}
+	return targets
+}
+
+// resolveBodyRigidBodyTargetBoneIndexes は対象ボーンと別名ボーンのindexから対象ボーンindexへの対応を返す。
+func resolveBodyRigidBodyTargetBoneIndexes(bones *model.BoneCollection, targets []bodyRigidBodyTarget) map[int]int {
+	targetByBoneIndex := map[int]int{}
+	for _, target := range targets {
+		bone, exists := getBoneByName(bones, target.BoneName)
+		if !exists {
+			continue
+		}
+		targetByBoneIndex[bone.Index()] = bone.Index()
+		for _, aliasName := range target.AliasBoneNames {
+			aliasBone, aliasExists := getBoneByName(bones, aliasName)
+			if !aliasExists {
+				continue
+			}
+			targetByBoneIndex[aliasBone.Index()] = bone.Index()
+		}
+	}
+	return targetByBoneIndex
+}
+
+// collectBodyRigidBodyVertexPositions は対象ボーンごとに主ウェイト頂点の位置を集める。
+func collectBodyRigidBodyVertexPositions(
+	modelData *ModelData,
+	targetByBoneIndex map[int]int,
+) map[int][]mmath.Vec3 {
+	physicsBones := collectDynamicRigidBodyBoneIndexes(modelData)
+	resolvedByBone := map[int]int{}
+	positionsByTarget := map[int][]mmath.Vec3{}
+	for _, vertex := range modelData.Vertices.Values() {
+		if vertex == nil || vertex.Deform == nil {
+			continue
+		}
+		dominantBoneIndex := resolveDominantDeformBoneIndex(vertex.Deform.Indexes(), vertex.Deform.Weights())
+		if dominantBoneIndex < 0 {
+			continue
+		}
+		targetIndex, exists := resolvedByBone[dominantBoneIndex]
+		if !exists {
+			targetIndex = resolveBodyRigidBodyTargetByAncestor(modelData.Bones, dominantBoneIndex, targetByBoneIndex, physicsBones)
+			resolvedByBone[dominantBoneIndex] = targetIndex
+		}
+		if targetIndex < 0 {
+			continue
+		}
+		positionsByTarget[targetIndex] = append(positionsByTarget[targetIndex], vertex.Position)
+	}
+	return positionsByTarget
+}
+
+// collectDynamicRigidBodyBoneIndexes は物理演算剛体が乗っているボーンindex集合を返す。
+func collectDynamicRigidBodyBoneIndexes(modelData *ModelData) map[int]struct{} {
+	boneIndexes := map[int]struct{}{}
+	if modelData == nil || modelData.RigidBodies == nil {
+		return boneIndexes
+	}
+	for _, rigidBody := range modelData.RigidBodies.Values() {
+		if rigidBody == nil || rigidBody.PhysicsType == model.PHYSICS_TYPE_STATIC {
+			continue
+		}
+		boneIndexes[rigidBody.BoneIndex] = struct{}{}
+	}
+	return boneIndexes
+}
+
+// resolveDominantDeformBoneIndex は最大ウェイトのボーンindexを返す。
+func resolveDominantDeformBoneIndex(indexes []int, weights []float64) int {
+	dominantIndex := -1
+	dominantWeight := 0.0
+	for i, boneIndex := range indexes {
+		if i >= len(weights) || boneIndex < 0 {
+			continue
+		}
+		if weights[i] > dominantWeight {
+			dominantWeight = weights[i]
+			dominantIndex = boneIndex
+		}
+	}
+	return dominantIndex
+}
+
+// resolveBodyRigidBodyTargetByAncestor は祖先を辿って対象ボーンindexを返す。物理ボーン配下は対象外とする。
+func resolveBodyRigidBodyTargetByAncestor(
+	bones *model.BoneCollection,
+	boneIndex int,
+	targetByBoneIndex map[int]int,
+	physicsBones map[int]struct{},
+) int {
+	visited := map[int]struct{}{}
+	current := boneIndex
+	for current >= 0 {
+		if _, exists := visited[current]; exists {
+			return -1
+		}
+		visited[current] = struct{}{}
+		if _, exists := physicsBones[current]; exists {
+			return -1
+		}
+		if targetIndex, exists := targetByBoneIndex[current]; exists {
+			return targetIndex
+		}
+		bone, err := bones.Get(current)
+		if err != nil || bone == nil {
+			return -1
+		}
+		current = bone.ParentIndex
+	}
+	return -1
+}
+
+// buildBodyRigidBody は頂点範囲からカプセル剛体を構築する。
+func buildBodyRigidBody(
+	bones *model.BoneCollection,
+	bone *model.Bone,
+	target bodyRigidBodyTarget,
+	positions []mmath.Vec3,
+) (*model.RigidBody, bool) {
+	axis, ok := resolveBodyRigidBodyAxis(bones, bone, target)
+	if !ok {
+		return nil, false
+	}
+	minT := math.Inf(1)
+	maxT := math.Inf(-1)
+	for _, position := range positions {
+		t := position.Subed(bone.Position).Dot(axis)
+		minT = math.Min(minT, t)
+		maxT = math.Max(maxT, t)
+	}
+	radialSum := 0.0
+	for _, position := range positions {
+		relative := position.Subed(bone.Position)
+		radial := relative.Subed(axis.MuledScalar(relative.Dot(axis)))
+		radialSum += radial.Length()
+	}
+	radius := math.Max(radialSum/float64(len(positions))*bodyRigidBodyRadiusRatio, bodyRigidBodyMinRadius)
+	height := math.Max(maxT-minT-radius*2, 0)
+	center := bone.Position.Added(axis.MuledScalar((minT + maxT) * 0.5))
+
+	return &model.RigidBody{
+		BoneIndex: bone.Index(),
+		CollisionGroup: model.CollisionGroup{
+			Group: bodyRigidBodyCollisionGroup,
+			Mask:  bodyRigidBodyCollisionMask,
+		},
+		Shape:    model.SHAPE_CAPSULE,
+		Size:     mmath.Vec3{Vec: r3.Vec{X: radius, Y: height}},
+		Position: center,
+		Rotation: vrm.ResolveCapsuleRotation(axis),
+		Param: model.RigidBodyParam{
+			Mass:           bodyRigidBodyMass,
+			LinearDamping:  bodyRigidBodyDamping,
+			AngularDamping: bodyRigidBodyDamping,
+			Restitution:    bodyRigidBodyRestitution,
+			Friction:       bodyRigidBodyFriction,
+		},
+		PhysicsType: model.PHYSICS_TYPE_STATIC,
+	}, true
+}
+
+// resolveBodyRigidBodyAxis はカプセル軸方向を返す。
+func resolveBodyRigidBodyAxis(bones *model.BoneCollection, bone *model.Bone, target bodyRigidBodyTarget) (mmath.Vec3, bool) {
+	for _, tailName := range target.TailBoneNames {
+		tailBone, exists := getBoneByName(bones, tailName)
+		if !exists {
+			continue
+		}
+		direction := tailBone.Position.Subed(bone.Position)
+		if direction.Length() > bodyRigidBodyAxisEpsilon {
+			return direction.Normalized(), true
+		}
+	}
+	if target.FallbackAxis.Length() > bodyRigidBodyAxisEpsilon {
+		return target.FallbackAxis.Normalized(), true
+	}
+	return mmath.ZERO_VEC3, false
+}
+
+// resolveUniqueRigidBodyName は既存剛体名と衝突しない名前を返す。
+func resolveUniqueRigidBodyName(modelData *ModelData, name string) string {
+	if modelData == nil || modelData.RigidBodies == nil || !modelData.RigidBodies.ContainsByName(name) {
+		return name
+	}
+	for suffix := 1; ; suffix++ {
+		candidate := fmt.Sprintf("%s_%d", name, suffix)
+		if !modelData.RigidBodies.ContainsByName(candidate) {
+			return candidate
+		}
+	}
+}
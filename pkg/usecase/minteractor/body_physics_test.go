@@ -0,0 +1,77 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestApplyBodyRigidBodiesAfterBoneMappingBuildsCapsulesFromVertices(t *testing.T) {
+	modelData := newBoneMappingTargetModel()
+	if err := applyHumanoidBoneMappingAfterReorder(modelData); err != nil {
+		t.Fatalf("bone mapping failed: %v", err)
+	}
+
+	upper, upperExists := getBoneByName(modelData.Bones, model.UPPER.String())
+	leftArm, leftArmExists := getBoneByName(modelData.Bones, model.ARM.Left())
+	leftElbow, leftElbowExists := getBoneByName(modelData.Bones, model.ELBOW.Left())
+	if !upperExists || !leftArmExists || !leftElbowExists {
+		t.Fatalf("required mapped bones are missing")
+	}
+
+	appendBodyRigidBodyTestRing(modelData, upper.Position.Added(mmath.Vec3{Vec: r3.Vec{Y: 0.5}}), 1.0, upper.Index())
+	appendBodyRigidBodyTestRing(modelData, upper.Position.Added(mmath.Vec3{Vec: r3.Vec{Y: 1.5}}), 1.0, upper.Index())
+	armCenter := meanPosition(leftArm.Position, leftElbow.Position)
+	appendBodyRigidBodyTestRing(modelData, armCenter, 0.3, leftArm.Index())
+
+	if err := applyBodyRigidBodiesAfterBoneMapping(modelData); err != nil {
+		t.Fatalf("apply body rigid bodies failed: %v", err)
+	}
+
+	upperBody, err := modelData.RigidBodies.GetByName(model.UPPER.String())
+	if err != nil || upperBody == nil {
+		t.Fatalf("expected upper body rigid body: %v", err)
+	}
+	if upperBody.BoneIndex != upper.Index() || upperBody.Shape != model.SHAPE_CAPSULE {
+		t.Fatalf("unexpected upper body rigid body: bone=%d shape=%v", upperBody.BoneIndex, upperBody.Shape)
+	}
+	if upperBody.PhysicsType != model.PHYSICS_TYPE_STATIC {
+		t.Fatalf("expected upper body rigid body to follow bone")
+	}
+	if math.Abs(upperBody.Size.X-1.0*bodyRigidBodyRadiusRatio) > 1e-6 {
+		t.Fatalf("expected radius from vertex extents: got=%f", upperBody.Size.X)
+	}
+
+	armBody, err := modelData.RigidBodies.GetByName(model.ARM.Left())
+	if err != nil || armBody == nil {
+		t.Fatalf("expected left arm rigid body: %v", err)
+	}
+	if !armBody.Position.NearEquals(armCenter, 1e-6) {
+		t.Fatalf("expected left arm rigid body at vertex center: got=%v want=%v", armBody.Position, armCenter)
+	}
+
+	for _, rigidBody := range modelData.RigidBodies.Values() {
+		if int(rigidBody.CollisionGroup.Group) != bodyRigidBodyCollisionGroup {
+			t.Fatalf("expected body collision group: name=%s group=%d", rigidBody.Name(), rigidBody.CollisionGroup.Group)
+		}
+		if rigidBody.CollisionGroup.Mask&(uint16(1)<<bodyRigidBodyCollisionGroup) != 0 {
+			t.Fatalf("body rigid body should not collide with body group: name=%s", rigidBody.Name())
+		}
+	}
+	if modelData.RigidBodies.ContainsByName(model.HEAD.String()) {
+		t.Fatalf("head rigid body should be skipped without weighted vertices")
+	}
+}
+
+// appendBodyRigidBodyTestRing は指定中心のXZ平面上へBDEF1頂点を8点追加する。
+func appendBodyRigidBodyTestRing(modelData *ModelData, center mmath.Vec3, radius float64, boneIndex int) {
+	for i := 0; i < 8; i++ {
+		angle := float64(i) * math.Pi / 4
+		position := center.Added(mmath.Vec3{Vec: r3.Vec{X: math.Cos(angle) * radius, Z: math.Sin(angle) * radius}})
+		appendAstanceTestVertex(modelData, position, boneIndex)
+	}
+}
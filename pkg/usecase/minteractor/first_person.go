@@ -0,0 +1,251 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/domain/model/collection"
+	"github.com/miu200521358/mlib_go/pkg/shared/base/logging"
+	"github.com/miu200521358/mu_vrm2pmx/pkg/usecase/port/moutput"
+)
+
+const (
+	firstPersonMaterialMemoKey      = "firstPerson"
+	firstPersonTypeThirdPersonOnly  = "thirdPersonOnly"
+	firstPersonTypeAuto             = "auto"
+	firstPersonOutputFileNameSuffix = "_first_person"
+)
+
+// SaveFirstPersonModel は thirdPersonOnly 材質と auto 材質の頭部面を除いた一人称視点用PMXを保存する。
+// 保存後は材質/面/材質モーフとモデルのパスを元の状態へ戻す。
+func (uc *Vrm2PmxUsecase) SaveFirstPersonModel(
+	rep moutput.IFileWriter,
+	path string,
+	modelData *ModelData,
+	opts SaveOptions,
+) error {
+	if modelData == nil {
+		return fmt.Errorf("保存対象モデルが未設定です")
+	}
+	restore, removedCount, err := applyFirstPersonMaterialFilter(modelData)
+	if err != nil {
+		return fmt.Errorf("一人称視点用材質の抽出に失敗しました: %w", err)
+	}
+	defer restore()
+	originalPath := modelData.Path()
+	defer modelData.SetPath(originalPath)
+	modelData.SetPath(path)
+	if logger := logging.DefaultLogger(); logger != nil {
+		logger.Info("一人称視点用PMX保存: removedMaterials=%d path=%s", removedCount, path)
+	}
+	return uc.SaveModel(rep, path, modelData, opts)
+}
+
+// buildFirstPersonOutputPath はPMX保存先から一人称視点用PMXの保存先を生成する。
+func buildFirstPersonOutputPath(outputPath string) string {
+	trimmed := strings.TrimSpace(outputPath)
+	if trimmed == "" {
+		return ""
+	}
+	ext := filepath.Ext(trimmed)
+	return strings.TrimSuffix(trimmed, ext) + firstPersonOutputFileNameSuffix + ext
+}
+
+// isThirdPersonOnlyMaterial は材質メモの firstPerson 注釈が thirdPersonOnly かを判定する。
+func isThirdPersonOnlyMaterial(materialData *model.Material) bool {
+	annotationType, ok := resolveMaterialMemoTokenValue(materialData, firstPersonMaterialMemoKey)
+	return ok && strings.EqualFold(annotationType, firstPersonTypeThirdPersonOnly)
+}
+
+// isAutoFirstPersonMaterial は材質メモの firstPerson 注釈が auto かを判定する。
+func isAutoFirstPersonMaterial(materialData *model.Material) bool {
+	annotationType, ok := resolveMaterialMemoTokenValue(materialData, firstPersonMaterialMemoKey)
+	return ok && strings.EqualFold(annotationType, firstPersonTypeAuto)
+}
+
+// applyFirstPersonMaterialFilter は thirdPersonOnly 材質と面、auto 材質の頭部ウェイト面を一時的に除外し、復元関数を返す。
+// auto 材質は VRM と同じく頭ボーン配下へウェイトを持つ頂点を含む面を除き、面が残らない場合は材質ごと除外する。
+func applyFirstPersonMaterialFilter(modelData *ModelData) (func(), int, error) {
+	noop := func() {}
+	if modelData == nil || modelData.Materials == nil || modelData.Faces == nil {
+		return noop, 0, nil
+	}
+	faceRanges, err := buildMaterialFaceRanges(modelData)
+	if err != nil {
+		return noop, 0, err
+	}
+
+	oldMaterials := append([]*model.Material(nil), modelData.Materials.Values()...)
+	oldFaces := append([]*model.Face(nil), modelData.Faces.Values()...)
+	oldToNew := make([]int, len(oldMaterials))
+	newMaterials := collection.NewNamedCollection[*model.Material](len(oldMaterials))
+	newFaces := collection.NewIndexedCollection[*model.Face](len(oldFaces))
+	removedCount := 0
+	removedFaceCount := 0
+	originalVerticesCounts := map[*model.Material]int{}
+	var headBoneIndexes map[int]struct{}
+	for oldIndex, materialData := range oldMaterials {
+		if isThirdPersonOnlyMaterial(materialData) {
+			oldToNew[oldIndex] = -1
+			removedCount++
+			continue
+		}
+		faceRange := faceRanges[oldIndex]
+		keptFaces := oldFaces[faceRange.start : faceRange.start+faceRange.count]
+		if isAutoFirstPersonMaterial(materialData) {
+			if headBoneIndexes == nil {
+				headBoneIndexes = collectFirstPersonHeadBoneIndexes(modelData)
+			}
+			keptFaces = filterFirstPersonHeadFaces(modelData, keptFaces, headBoneIndexes)
+			if len(keptFaces) == 0 {
+				oldToNew[oldIndex] = -1
+				removedCount++
+				continue
+			}
+			if len(keptFaces) != faceRange.count {
+				originalVerticesCounts[materialData] = materialData.VerticesCount
+				materialData.VerticesCount = len(keptFaces) * 3
+				removedFaceCount += faceRange.count - len(keptFaces)
+			}
+		}
+		oldToNew[oldIndex] = newMaterials.Len()
+		newMaterials.AppendRaw(materialData)
+		for _, face := range keptFaces {
+			newFaces.AppendRaw(face)
+		}
+	}
+	if removedCount == 0 && removedFaceCount == 0 {
+		return noop, 0, nil
+	}
+
+	originalOffsets := map[*model.Morph][]model.IMorphOffset{}
+	if modelData.Morphs != nil {
+		for _, morphData := range modelData.Morphs.Values() {
+			if morphData == nil || morphData.MorphType != model.MORPH_TYPE_MATERIAL {
+				continue
+			}
+			originalOffsets[morphData] = morphData.Offsets
+			morphData.Offsets = filterFirstPersonMaterialMorphOffsets(morphData.Offsets, oldToNew)
+		}
+	}
+	modelData.Materials = newMaterials
+	modelData.Faces = newFaces
+
+	restore := func() {
+		restoredMaterials := collection.NewNamedCollection[*model.Material](len(oldMaterials))
+		for _, materialData := range oldMaterials {
+			restoredMaterials.AppendRaw(materialData)
+		}
+		restoredFaces := collection.NewIndexedCollection[*model.Face](len(oldFaces))
+		for _, face := range oldFaces {
+			restoredFaces.AppendRaw(face)
+		}
+		modelData.Materials = restoredMaterials
+		modelData.Faces = restoredFaces
+		for materialData, verticesCount := range originalVerticesCounts {
+			materialData.VerticesCount = verticesCount
+		}
+		for morphData, offsets := range originalOffsets {
+			morphData.Offsets = offsets
+		}
+	}
+	return restore, removedCount, nil
+}
+
+// collectFirstPersonHeadBoneIndexes は頭ボーンと配下ボーンの index 集合を返す。
+func collectFirstPersonHeadBoneIndexes(modelData *ModelData) map[int]struct{} {
+	headBoneIndexes := map[int]struct{}{}
+	if modelData == nil || modelData.Bones == nil {
+		return headBoneIndexes
+	}
+	head, exists := getBoneByName(modelData.Bones, model.HEAD.String())
+	if !exists {
+		return headBoneIndexes
+	}
+	for _, bone := range modelData.Bones.Values() {
+		if bone == nil {
+			continue
+		}
+		visited := map[int]struct{}{}
+		for current := bone; current != nil; {
+			if current.Index() == head.Index() {
+				headBoneIndexes[bone.Index()] = struct{}{}
+				break
+			}
+			if _, seen := visited[current.Index()]; seen || current.ParentIndex < 0 {
+				break
+			}
+			visited[current.Index()] = struct{}{}
+			parent, err := modelData.Bones.Get(current.ParentIndex)
+			if err != nil {
+				break
+			}
+			current = parent
+		}
+	}
+	return headBoneIndexes
+}
+
+// filterFirstPersonHeadFaces は頭ボーン配下へウェイトを持つ頂点を含まない面だけを返す。
+func filterFirstPersonHeadFaces(modelData *ModelData, faces []*model.Face, headBoneIndexes map[int]struct{}) []*model.Face {
+	if len(headBoneIndexes) == 0 || modelData.Vertices == nil {
+		return faces
+	}
+	kept := make([]*model.Face, 0, len(faces))
+	for _, face := range faces {
+		if face != nil && isFirstPersonHeadFace(modelData, face, headBoneIndexes) {
+			continue
+		}
+		kept = append(kept, face)
+	}
+	return kept
+}
+
+// isFirstPersonHeadFace は面のいずれかの頂点が頭ボーン配下へウェイトを持つかを判定する。
+func isFirstPersonHeadFace(modelData *ModelData, face *model.Face, headBoneIndexes map[int]struct{}) bool {
+	for _, vertexIndex := range face.VertexIndexes {
+		vertex, err := modelData.Vertices.Get(vertexIndex)
+		if err != nil || vertex == nil || vertex.Deform == nil {
+			continue
+		}
+		indexes := vertex.Deform.Indexes()
+		weights := vertex.Deform.Weights()
+		for i := 0; i < len(indexes) && i < len(weights); i++ {
+			if weights[i] <= 0 {
+				continue
+			}
+			if _, exists := headBoneIndexes[indexes[i]]; exists {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterFirstPersonMaterialMorphOffsets は除外材質を参照しない材質モーフオフセットの複製を返す。
+func filterFirstPersonMaterialMorphOffsets(offsets []model.IMorphOffset, oldToNew []int) []model.IMorphOffset {
+	filtered := make([]model.IMorphOffset, 0, len(offsets))
+	for _, offset := range offsets {
+		materialOffset, ok := offset.(*model.MaterialMorphOffset)
+		if !ok || materialOffset == nil {
+			filtered = append(filtered, offset)
+			continue
+		}
+		if materialOffset.MaterialIndex < 0 || materialOffset.MaterialIndex >= len(oldToNew) {
+			// 全材質対象(-1)などの特殊indexはそのまま残す。
+			filtered = append(filtered, offset)
+			continue
+		}
+		newIndex := oldToNew[materialOffset.MaterialIndex]
+		if newIndex < 0 {
+			continue
+		}
+		copied := *materialOffset
+		copied.MaterialIndex = newIndex
+		filtered = append(filtered, &copied)
+	}
+	return filtered
+}
@@ -0,0 +1,191 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/adapter/io_model/pmx"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+)
+
+func TestApplyFirstPersonMaterialFilterRemovesThirdPersonOnlyAndRestores(t *testing.T) {
+	modelData := model.NewPmxModel()
+	body := newMaterial("Body", 1.0, 3)
+	body.Memo = "VRM primitive alphaMode=OPAQUE firstPerson=both"
+	hair := newMaterial("Hair", 1.0, 6)
+	hair.Memo = "VRM primitive alphaMode=OPAQUE firstPerson=thirdPersonOnly"
+	face := newMaterial("Face", 1.0, 3)
+	modelData.Materials.AppendRaw(body)
+	modelData.Materials.AppendRaw(hair)
+	modelData.Materials.AppendRaw(face)
+	for i := 0; i < 4; i++ {
+		modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{0, 1, 2}})
+	}
+	materialMorph := &model.Morph{
+		MorphType: model.MORPH_TYPE_MATERIAL,
+		Offsets: []model.IMorphOffset{
+			&model.MaterialMorphOffset{MaterialIndex: 1},
+			&model.MaterialMorphOffset{MaterialIndex: 2},
+		},
+	}
+	materialMorph.SetName("hide")
+	modelData.Morphs.AppendRaw(materialMorph)
+
+	restore, removedCount, err := applyFirstPersonMaterialFilter(modelData)
+	if err != nil {
+		t.Fatalf("filter failed: %v", err)
+	}
+	if removedCount != 1 {
+		t.Fatalf("removed count mismatch: got=%d", removedCount)
+	}
+	if got := materialNames(modelData); len(got) != 2 || got[0] != "Body" || got[1] != "Face" {
+		t.Fatalf("first person materials mismatch: got=%v", got)
+	}
+	if modelData.Faces.Len() != 2 {
+		t.Fatalf("first person faces mismatch: got=%d", modelData.Faces.Len())
+	}
+	if len(materialMorph.Offsets) != 1 {
+		t.Fatalf("removed material offset should be dropped: got=%d", len(materialMorph.Offsets))
+	}
+	if offset := materialMorph.Offsets[0].(*model.MaterialMorphOffset); offset.MaterialIndex != 1 {
+		t.Fatalf("material morph index should be remapped: got=%d", offset.MaterialIndex)
+	}
+
+	restore()
+	if got := materialNames(modelData); len(got) != 3 || got[1] != "Hair" {
+		t.Fatalf("materials should be restored: got=%v", got)
+	}
+	if modelData.Faces.Len() != 4 {
+		t.Fatalf("faces should be restored: got=%d", modelData.Faces.Len())
+	}
+	if len(materialMorph.Offsets) != 2 || materialMorph.Offsets[1].(*model.MaterialMorphOffset).MaterialIndex != 2 {
+		t.Fatalf("material morph offsets should be restored")
+	}
+}
+
+func TestApplyFirstPersonMaterialFilterRemovesHeadFacesOfAutoMaterials(t *testing.T) {
+	modelData := model.NewPmxModel()
+	center := model.NewBoneByName("センター")
+	centerIndex := modelData.Bones.AppendRaw(center)
+	head := model.NewBoneByName(model.HEAD.String())
+	head.ParentIndex = centerIndex
+	headIndex := modelData.Bones.AppendRaw(head)
+	hairBone := model.NewBoneByName("髪")
+	hairBone.ParentIndex = headIndex
+	hairBoneIndex := modelData.Bones.AppendRaw(hairBone)
+
+	faceMaterial := newMaterial("Face", 1.0, 6)
+	faceMaterial.Memo = "VRM primitive alphaMode=OPAQUE firstPerson=auto"
+	hat := newMaterial("Hat", 1.0, 3)
+	hat.Memo = "VRM primitive alphaMode=OPAQUE firstPerson=auto"
+	body := newMaterial("Body", 1.0, 3)
+	body.Memo = "VRM primitive alphaMode=OPAQUE firstPerson=both"
+	modelData.Materials.AppendRaw(faceMaterial)
+	modelData.Materials.AppendRaw(hat)
+	modelData.Materials.AppendRaw(body)
+	for i := 0; i < 3; i++ {
+		appendVertex(modelData, vec3(float64(i), 0, 0), centerIndex, []int{0, 2})
+	}
+	for i := 0; i < 3; i++ {
+		appendVertex(modelData, vec3(float64(i), 1, 0), hairBoneIndex, []int{0, 1})
+	}
+	bodyFace := &model.Face{VertexIndexes: [3]int{0, 1, 2}}
+	modelData.Faces.AppendRaw(bodyFace)
+	modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{3, 4, 5}})
+	modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{3, 5, 4}})
+	modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{0, 2, 1}})
+
+	restore, removedCount, err := applyFirstPersonMaterialFilter(modelData)
+	if err != nil {
+		t.Fatalf("filter failed: %v", err)
+	}
+	if removedCount != 1 {
+		t.Fatalf("removed count mismatch: got=%d", removedCount)
+	}
+	if got := materialNames(modelData); len(got) != 2 || got[0] != "Face" || got[1] != "Body" {
+		t.Fatalf("first person materials mismatch: got=%v", got)
+	}
+	if faceMaterial.VerticesCount != 3 {
+		t.Fatalf("auto material should keep only non-head faces: verticesCount=%d", faceMaterial.VerticesCount)
+	}
+	if modelData.Faces.Len() != 2 {
+		t.Fatalf("first person faces mismatch: got=%d", modelData.Faces.Len())
+	}
+	if keptFace, err := modelData.Faces.Get(0); err != nil || keptFace != bodyFace {
+		t.Fatalf("auto material should keep body weighted face: face=%v err=%v", keptFace, err)
+	}
+
+	restore()
+	if got := materialNames(modelData); len(got) != 3 || got[1] != "Hat" {
+		t.Fatalf("materials should be restored: got=%v", got)
+	}
+	if faceMaterial.VerticesCount != 6 {
+		t.Fatalf("auto material vertices count should be restored: got=%d", faceMaterial.VerticesCount)
+	}
+	if modelData.Faces.Len() != 4 {
+		t.Fatalf("faces should be restored: got=%d", modelData.Faces.Len())
+	}
+}
+
+func TestBuildFirstPersonOutputPathAddsSuffix(t *testing.T) {
+	got := buildFirstPersonOutputPath(filepath.Join("out", "model.pmx"))
+	want := filepath.Join("out", "model_first_person.pmx")
+	if got != want {
+		t.Fatalf("first person output path mismatch: got=%s want=%s", got, want)
+	}
+}
+
+func TestSaveConvertResultWritesFirstPersonVariant(t *testing.T) {
+	tempDir := t.TempDir()
+	modelData := model.NewPmxModel()
+	modelData.Bones.AppendRaw(model.NewBoneByName("センター"))
+	body := newMaterial("Body", 1.0, 3)
+	body.Memo = "VRM primitive alphaMode=OPAQUE firstPerson=both"
+	hair := newMaterial("Hair", 1.0, 3)
+	hair.Memo = "VRM primitive alphaMode=OPAQUE firstPerson=thirdPersonOnly"
+	modelData.Materials.AppendRaw(body)
+	modelData.Materials.AppendRaw(hair)
+	appendVertex(modelData, vec3(0, 0, 0), 0, []int{0, 1})
+	appendVertex(modelData, vec3(1, 0, 0), 0, []int{0, 1})
+	appendVertex(modelData, vec3(0, 1, 0), 0, []int{0, 1})
+	modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{0, 1, 2}})
+	modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{0, 2, 1}})
+	outputPath := filepath.Join(tempDir, "model.pmx")
+	modelData.SetPath(outputPath)
+	result := &ConvertResult{
+		Model:                 modelData,
+		OutputPath:            outputPath,
+		FirstPersonOutputPath: buildFirstPersonOutputPath(outputPath),
+	}
+
+	uc := NewVrm2PmxUsecase(Vrm2PmxUsecaseDeps{ModelWriter: pmx.NewPmxRepository()})
+	if err := uc.SaveConvertResult(nil, result, SaveOptions{}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if _, err := os.Stat(result.OutputPath); err != nil {
+		t.Fatalf("pmx output not found: %v", err)
+	}
+	if _, err := os.Stat(result.FirstPersonOutputPath); err != nil {
+		t.Fatalf("first person output not found: %v", err)
+	}
+	if got := materialNames(modelData); len(got) != 2 || modelData.Path() != outputPath {
+		t.Fatalf("model should be restored after first person save: materials=%v path=%s", got, modelData.Path())
+	}
+
+	hashableModel, err := pmx.NewPmxRepository().Load(result.FirstPersonOutputPath)
+	if err != nil {
+		t.Fatalf("first person load failed: %v", err)
+	}
+	firstPersonModel, ok := hashableModel.(*model.PmxModel)
+	if !ok {
+		t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+	}
+	if got := materialNames(firstPersonModel); len(got) != 1 || got[0] != "Body" {
+		t.Fatalf("thirdPersonOnly material should be excluded: got=%v", got)
+	}
+	if firstPersonModel.Faces.Len() != 1 {
+		t.Fatalf("first person faces mismatch: got=%d", firstPersonModel.Faces.Len())
+	}
+}
@@ -3,13 +3,47 @@ package minteractor
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/miu200521358/mlib_go/pkg/usecase"
+	"github.com/miu200521358/mu_vrm2pmx/pkg/adapter/io_model/vrm"
 	"github.com/miu200521358/mu_vrm2pmx/pkg/usecase/port/moutput"
 )
 
+// vrmLoadOptionsApplier は読込オプションを受け付ける読み込みリポジトリを表す。
+type vrmLoadOptionsApplier interface {
+	ApplyLoadOptions(options vrm.VrmLoadOptions) error
+}
+
 // LoadModel はVRMモデルを読み込む。
 func (uc *Vrm2PmxUsecase) LoadModel(rep moutput.IFileReader, path string) (*ModelData, error) {
+	repo, err := uc.resolveModelReader(rep)
+	if err != nil {
+		return nil, err
+	}
+	return usecase.LoadModel(repo, path)
+}
+
+// loadModelWithOptions は読込オプションをリポジトリへ設定してからVRMモデルを読み込む。
+func (uc *Vrm2PmxUsecase) loadModelWithOptions(
+	rep moutput.IFileReader,
+	path string,
+	options vrm.VrmLoadOptions,
+) (*ModelData, error) {
+	repo, err := uc.resolveModelReader(rep)
+	if err != nil {
+		return nil, err
+	}
+	if applier, ok := repo.(vrmLoadOptionsApplier); ok {
+		if err := applier.ApplyLoadOptions(options); err != nil {
+			return nil, err
+		}
+	}
+	return usecase.LoadModel(repo, path)
+}
+
+// resolveModelReader は指定リポジトリ、未指定時は既定の読み込みリポジトリを返す。
+func (uc *Vrm2PmxUsecase) resolveModelReader(rep moutput.IFileReader) (moutput.IFileReader, error) {
 	repo := rep
 	if repo == nil {
 		repo = uc.modelReader
@@ -17,5 +51,32 @@ func (uc *Vrm2PmxUsecase) LoadModel(rep moutput.IFileReader, path string) (*Mode
 	if repo == nil {
 		return nil, fmt.Errorf("モデル読み込みリポジトリが設定されていません")
 	}
-	return usecase.LoadModel(repo, path)
+	return repo, nil
+}
+
+// resolveVrmLoadOptions は変換要求から読込オプションを解決し、不正な値を変換前に検出する。
+func resolveVrmLoadOptions(request ConvertRequest) (vrm.VrmLoadOptions, error) {
+	weightReductionMode, err := vrm.ParseWeightReductionMode(request.WeightReductionMode)
+	if err != nil {
+		return vrm.VrmLoadOptions{}, err
+	}
+	vertexColorMode, err := vrm.ParseVertexColorMode(request.VertexColorMode)
+	if err != nil {
+		return vrm.VrmLoadOptions{}, err
+	}
+	morphRuleFilePath := strings.TrimSpace(request.MorphRuleFilePath)
+	if morphRuleFilePath != "" {
+		// 読込済みモデルを渡す経路でも変換前に規則ファイルの不正を報告する。
+		if err := vrm.ValidateMorphRuleFile(morphRuleFilePath); err != nil {
+			return vrm.VrmLoadOptions{}, err
+		}
+	}
+	return vrm.VrmLoadOptions{
+		WeightReductionMode: weightReductionMode,
+		VertexColorMode:     vertexColorMode,
+		ExtendedUvTexcoord1: request.ExtendedUvTexcoord1,
+		ExtendedUvTangent:   request.ExtendedUvTangent,
+		JawLipSyncAngles:    request.JawLipSyncAngles,
+		MorphRuleFilePath:   morphRuleFilePath,
+	}, nil
 }
@@ -0,0 +1,225 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+const (
+	lookAtTypeBone       = "bone"
+	lookAtTypeExpression = "expression"
+
+	lookAtMorphNameLeft  = "視線左"
+	lookAtMorphNameRight = "視線右"
+	lookAtMorphNameUp    = "視線上"
+	lookAtMorphNameDown  = "視線下"
+
+	lookAtEyeIkParentBoneName = "視線IK親"
+	lookAtEyeTipBoneSuffix    = "先"
+	lookAtEyeIkBoneSuffix     = "IK"
+	// lookAtEyeIkDistance は目IKを置く目の正面方向の距離を表す。目の左右位置による角度差を抑えるため遠方へ置く。
+	lookAtEyeIkDistance   = 10.0
+	lookAtEyeIkLoopCount  = 40
+	lookAtEyeIkUnitRadian = 1.0
+	// lookAtDefaultEyeDegrees は VRM1 既定の range map 出力角を表す。
+	lookAtDefaultEyeDegrees = 10.0
+)
+
+// lookAtRangeMetadata は lookAt range map の入力上限と出力倍率を表す。
+type lookAtRangeMetadata struct {
+	InputMaxValue float64 `json:"inputMaxValue"`
+	OutputScale   float64 `json:"outputScale"`
+}
+
+// lookAtMetadata は変換前に解決済みの lookAt 定義を表す。
+type lookAtMetadata struct {
+	Type            string              `json:"type"`
+	HorizontalInner lookAtRangeMetadata `json:"horizontalInner"`
+	HorizontalOuter lookAtRangeMetadata `json:"horizontalOuter"`
+	VerticalDown    lookAtRangeMetadata `json:"verticalDown"`
+	VerticalUp      lookAtRangeMetadata `json:"verticalUp"`
+}
+
+// applyLookAtEyeSettings は lookAt 定義を目ボーンの回転上限と視線モーフへ反映する。
+// 既存モーションの目の動きを変えないよう、ConvertRequest.ApplyLookAtEyeSettings 指定時のみ実行する。
+func applyLookAtEyeSettings(modelData *ModelData) {
+	metadata, ok := readLookAtMetadata(modelData)
+	if !ok || modelData.Bones == nil || modelData.Morphs == nil {
+		return
+	}
+	eyes, eyesExists := getBoneByName(modelData.Bones, model.EYES.String())
+	leftEye, leftExists := getBoneByName(modelData.Bones, model.EYE.Left())
+	rightEye, rightExists := getBoneByName(modelData.Bones, model.EYE.Right())
+	if !eyesExists || !leftExists || !rightExists {
+		return
+	}
+
+	// 左目は左向きで目尻側(outer)、右目は左向きで目頭側(inner)へ回る。
+	horizontalInner := resolveLookAtLimitDegrees(metadata, metadata.HorizontalInner)
+	horizontalOuter := resolveLookAtLimitDegrees(metadata, metadata.HorizontalOuter)
+	verticalUp := resolveLookAtLimitDegrees(metadata, metadata.VerticalUp)
+	verticalDown := resolveLookAtLimitDegrees(metadata, metadata.VerticalDown)
+	ikParentIndex := applyLookAtEyeIkParent(modelData, eyes, leftEye)
+	applyLookAtEyeRotationLimit(
+		modelData,
+		ikParentIndex,
+		leftEye,
+		newLookAtDegrees(-verticalDown, -horizontalOuter),
+		newLookAtDegrees(verticalUp, horizontalInner),
+	)
+	applyLookAtEyeRotationLimit(
+		modelData,
+		ikParentIndex,
+		rightEye,
+		newLookAtDegrees(-verticalDown, -horizontalInner),
+		newLookAtDegrees(verticalUp, horizontalOuter),
+	)
+
+	// 目は目IKで向きが決まるため、視線モーフは目IK親を回し、左右差は目ごとの回転上限で表す。
+	horizontal := math.Max(horizontalInner, horizontalOuter)
+	newOffsets := func(degrees mmath.Vec3) []model.IMorphOffset {
+		return []model.IMorphOffset{
+			&model.BoneMorphOffset{
+				BoneIndex: ikParentIndex,
+				Rotation:  mmath.NewQuaternionFromDegrees(degrees.X, degrees.Y, degrees.Z),
+			},
+		}
+	}
+	upsertLookAtMorph(modelData, lookAtMorphNameLeft, model.MORPH_TYPE_BONE, newOffsets(newLookAtDegrees(0, -horizontal)))
+	upsertLookAtMorph(modelData, lookAtMorphNameRight, model.MORPH_TYPE_BONE, newOffsets(newLookAtDegrees(0, horizontal)))
+	upsertLookAtMorph(modelData, lookAtMorphNameUp, model.MORPH_TYPE_BONE, newOffsets(newLookAtDegrees(verticalUp, 0)))
+	upsertLookAtMorph(modelData, lookAtMorphNameDown, model.MORPH_TYPE_BONE, newOffsets(newLookAtDegrees(-verticalDown, 0)))
+}
+
+// readLookAtMetadata は RawExtensions から lookAt 定義を読み取る。
+func readLookAtMetadata(modelData *ModelData) (lookAtMetadata, bool) {
+	if modelData == nil || modelData.VrmData == nil || modelData.VrmData.RawExtensions == nil {
+		return lookAtMetadata{}, false
+	}
+	raw, exists := modelData.VrmData.RawExtensions[warningid.VrmLookAtRawExtensionKey]
+	if !exists || len(raw) == 0 {
+		return lookAtMetadata{}, false
+	}
+	metadata := lookAtMetadata{}
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		return lookAtMetadata{}, false
+	}
+	metadata.Type = strings.ToLower(strings.TrimSpace(metadata.Type))
+	return metadata, true
+}
+
+// applyLookAtEyeIkParent は両目の回転を付与で受ける目IKの親ボーンを頭の子として追加し、index を返す。
+// 両目自体は頭へ追従しないため、目IKは頭に追従しつつ両目の回転で動くこの親の子とする。
+func applyLookAtEyeIkParent(modelData *ModelData, eyes *model.Bone, eye *model.Bone) int {
+	parentBone, parentBoneIndex := upsertLookAtBone(modelData, lookAtEyeIkParentBoneName)
+	parentBone.Position = eyes.Position
+	parentBone.ParentIndex = eye.ParentIndex
+	parentBone.TailIndex = -1
+	parentBone.EffectIndex = eyes.Index()
+	parentBone.EffectFactor = 1.0
+	parentBone.BoneFlag = model.BONE_FLAG_CAN_ROTATE | model.BONE_FLAG_IS_EXTERNAL_ROTATION
+	return parentBoneIndex
+}
+
+// applyLookAtEyeRotationLimit は目の正面に目IKを追加し、目ボーンの回転を range map の上限角へ制限する。
+// 目IKは両目に追従する親の子とするため、両目の回転がそのまま視線方向となり、目は上限角で止まる。
+func applyLookAtEyeRotationLimit(
+	modelData *ModelData,
+	ikParentIndex int,
+	eye *model.Bone,
+	minDegrees mmath.Vec3,
+	maxDegrees mmath.Vec3,
+) {
+	targetPosition := eye.Position.Added(mmath.Vec3{Vec: r3.Vec{Z: -lookAtEyeIkDistance}})
+	tipBone, tipBoneIndex := upsertLookAtBone(modelData, eye.Name()+lookAtEyeTipBoneSuffix)
+	tipBone.Position = targetPosition
+	tipBone.ParentIndex = eye.Index()
+	tipBone.TailIndex = -1
+	tipBone.EffectIndex = -1
+	tipBone.BoneFlag = model.BONE_FLAG_CAN_ROTATE
+
+	ikBone, _ := upsertLookAtBone(modelData, eye.Name()+lookAtEyeIkBoneSuffix)
+	unit := lookAtEyeIkUnitRadian
+	ikBone.Position = targetPosition
+	ikBone.ParentIndex = ikParentIndex
+	ikBone.TailIndex = -1
+	ikBone.EffectIndex = -1
+	ikBone.BoneFlag = model.BONE_FLAG_CAN_ROTATE | model.BONE_FLAG_CAN_TRANSLATE | model.BONE_FLAG_IS_IK
+	ikBone.Ik = &model.Ik{
+		BoneIndex:    tipBoneIndex,
+		LoopCount:    lookAtEyeIkLoopCount,
+		UnitRotation: mmath.Vec3{Vec: r3.Vec{X: unit, Y: unit, Z: unit}},
+		Links: []model.IkLink{
+			{
+				BoneIndex:  eye.Index(),
+				AngleLimit: true,
+				MinAngleLimit: mmath.Vec3{Vec: r3.Vec{
+					X: mmath.DegToRad(minDegrees.X),
+					Y: mmath.DegToRad(minDegrees.Y),
+				}},
+				MaxAngleLimit: mmath.Vec3{Vec: r3.Vec{
+					X: mmath.DegToRad(maxDegrees.X),
+					Y: mmath.DegToRad(maxDegrees.Y),
+				}},
+			},
+		},
+	}
+}
+
+// upsertLookAtBone は指定名のボーンを取得し、存在しない場合は末尾へ追加して index と共に返す。
+func upsertLookAtBone(modelData *ModelData, boneName string) (*model.Bone, int) {
+	if bone, exists := getBoneByName(modelData.Bones, boneName); exists {
+		return bone, bone.Index()
+	}
+	bone := model.NewBoneByName(boneName)
+	return bone, modelData.Bones.AppendRaw(bone)
+}
+
+// upsertLookAtMorph は視線モーフを目パネルへ追加または更新する。
+func upsertLookAtMorph(
+	modelData *ModelData,
+	morphName string,
+	morphType model.MorphType,
+	offsets []model.IMorphOffset,
+) {
+	if existing := findMorphByNames(modelData.Morphs, []string{morphName}); existing != nil {
+		existing.Panel = model.MORPH_PANEL_EYE_UPPER_LEFT
+		existing.MorphType = morphType
+		existing.Offsets = offsets
+		return
+	}
+	morphData := &model.Morph{
+		Panel:     model.MORPH_PANEL_EYE_UPPER_LEFT,
+		MorphType: morphType,
+		Offsets:   offsets,
+	}
+	morphData.SetName(morphName)
+	morphData.EnglishName = morphName
+	modelData.Morphs.AppendRaw(morphData)
+}
+
+// resolveLookAtLimitDegrees は range map から回転上限角度を返す。
+// 表情型の出力は表情ウェイトのため、VRM既定の視線角へ掛けて角度へ換算する。
+func resolveLookAtLimitDegrees(metadata lookAtMetadata, rangeMap lookAtRangeMetadata) float64 {
+	if metadata.Type == lookAtTypeExpression {
+		return lookAtDefaultEyeDegrees * clampLookAtFactor(rangeMap.OutputScale)
+	}
+	return math.Max(0, rangeMap.OutputScale)
+}
+
+// newLookAtDegrees は視線モーフ用の X/Y 回転角を返す。
+func newLookAtDegrees(x float64, y float64) mmath.Vec3 {
+	return mmath.Vec3{Vec: r3.Vec{X: x, Y: y}}
+}
+
+// clampLookAtFactor は係数を 0..1 に丸める。
+func clampLookAtFactor(value float64) float64 {
+	return math.Min(1.0, math.Max(0, value))
+}
@@ -0,0 +1,157 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+func TestApplyHumanoidBoneMappingAfterReorderKeepsEyeEffectFactorWithLookAt(t *testing.T) {
+	modelData := newBoneMappingTargetModel()
+	setLookAtMetadataForTest(t, modelData, lookAtMetadata{
+		Type:            lookAtTypeBone,
+		HorizontalInner: lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 8},
+		HorizontalOuter: lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 12},
+		VerticalDown:    lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 10},
+		VerticalUp:      lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 10},
+	})
+	if err := applyHumanoidBoneMappingAfterReorder(modelData); err != nil {
+		t.Fatalf("bone mapping failed: %v", err)
+	}
+
+	for _, eyeName := range []string{model.EYE.Left(), model.EYE.Right()} {
+		eye, exists := getBoneByName(modelData.Bones, eyeName)
+		if !exists {
+			t.Fatalf("eye bone should exist: %s", eyeName)
+		}
+		if math.Abs(eye.EffectFactor-0.3) > 1e-6 {
+			t.Fatalf("eye effect factor should be kept: name=%s got=%f", eyeName, eye.EffectFactor)
+		}
+	}
+	if _, exists := getBoneByName(modelData.Bones, lookAtEyeIkParentBoneName); exists {
+		t.Fatalf("look at eye ik should not be generated without option")
+	}
+	if findMorphByNames(modelData.Morphs, []string{lookAtMorphNameLeft}) != nil {
+		t.Fatalf("look at morph should not be generated without option")
+	}
+}
+
+func TestApplyLookAtEyeSettingsAppliesBoneRangeMapsAsEyeLimits(t *testing.T) {
+	modelData := newBoneMappingTargetModel()
+	setLookAtMetadataForTest(t, modelData, lookAtMetadata{
+		Type:            lookAtTypeBone,
+		HorizontalInner: lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 8},
+		HorizontalOuter: lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 12},
+		VerticalDown:    lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 10},
+		VerticalUp:      lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 6},
+	})
+	if err := applyHumanoidBoneMappingAfterReorder(modelData); err != nil {
+		t.Fatalf("bone mapping failed: %v", err)
+	}
+
+	applyLookAtEyeSettings(modelData)
+
+	eyes, eyesExists := getBoneByName(modelData.Bones, model.EYES.String())
+	ikParent, ikParentExists := getBoneByName(modelData.Bones, lookAtEyeIkParentBoneName)
+	if !eyesExists || !ikParentExists {
+		t.Fatalf("eyes and eye ik parent should exist: eyes=%t ikParent=%t", eyesExists, ikParentExists)
+	}
+	if ikParent.EffectIndex != eyes.Index() || math.Abs(ikParent.EffectFactor-1.0) > 1e-6 {
+		t.Fatalf("eye ik parent should follow eyes: effect=%d factor=%f", ikParent.EffectIndex, ikParent.EffectFactor)
+	}
+
+	limits := map[string][2][2]float64{
+		model.EYE.Left():  {{-10, -12}, {6, 8}},
+		model.EYE.Right(): {{-10, -8}, {6, 12}},
+	}
+	for eyeName, limit := range limits {
+		eye, exists := getBoneByName(modelData.Bones, eyeName)
+		if !exists {
+			t.Fatalf("eye bone should exist: %s", eyeName)
+		}
+		if math.Abs(eye.EffectFactor-0.3) > 1e-6 {
+			t.Fatalf("eye effect factor should be kept: name=%s got=%f", eyeName, eye.EffectFactor)
+		}
+		ikBone, exists := getBoneByName(modelData.Bones, eyeName+lookAtEyeIkBoneSuffix)
+		if !exists || ikBone.Ik == nil || len(ikBone.Ik.Links) != 1 {
+			t.Fatalf("eye ik should exist: name=%s", eyeName)
+		}
+		if ikBone.ParentIndex != ikParent.Index() {
+			t.Fatalf("eye ik parent mismatch: name=%s got=%d want=%d", eyeName, ikBone.ParentIndex, ikParent.Index())
+		}
+		link := ikBone.Ik.Links[0]
+		if link.BoneIndex != eye.Index() || !link.AngleLimit {
+			t.Fatalf("eye ik link should limit eye: name=%s link=%#v", eyeName, link)
+		}
+		if math.Abs(link.MinAngleLimit.X-mmath.DegToRad(limit[0][0])) > 1e-6 ||
+			math.Abs(link.MinAngleLimit.Y-mmath.DegToRad(limit[0][1])) > 1e-6 ||
+			math.Abs(link.MaxAngleLimit.X-mmath.DegToRad(limit[1][0])) > 1e-6 ||
+			math.Abs(link.MaxAngleLimit.Y-mmath.DegToRad(limit[1][1])) > 1e-6 {
+			t.Fatalf("eye ik limit mismatch: name=%s min=%v max=%v", eyeName, link.MinAngleLimit, link.MaxAngleLimit)
+		}
+	}
+
+	for _, morphName := range []string{lookAtMorphNameLeft, lookAtMorphNameRight, lookAtMorphNameUp, lookAtMorphNameDown} {
+		morphData := findMorphByNames(modelData.Morphs, []string{morphName})
+		if morphData == nil {
+			t.Fatalf("look at morph should exist: %s", morphName)
+		}
+		if morphData.MorphType != model.MORPH_TYPE_BONE || len(morphData.Offsets) != 1 {
+			t.Fatalf("look at morph should rotate eye ik parent: name=%s type=%v offsets=%d", morphName, morphData.MorphType, len(morphData.Offsets))
+		}
+		offset, ok := morphData.Offsets[0].(*model.BoneMorphOffset)
+		if !ok || offset.BoneIndex != ikParent.Index() {
+			t.Fatalf("look at morph offset mismatch: name=%s offset=%#v", morphName, morphData.Offsets[0])
+		}
+	}
+}
+
+func TestApplyLookAtEyeSettingsBuildsExpressionBoneMorphs(t *testing.T) {
+	modelData := newBoneMappingTargetModel()
+	setLookAtMetadataForTest(t, modelData, lookAtMetadata{
+		Type:            lookAtTypeExpression,
+		HorizontalInner: lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 1},
+		HorizontalOuter: lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 1},
+		VerticalDown:    lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 0.5},
+		VerticalUp:      lookAtRangeMetadata{InputMaxValue: 90, OutputScale: 1},
+	})
+	if err := applyHumanoidBoneMappingAfterReorder(modelData); err != nil {
+		t.Fatalf("bone mapping failed: %v", err)
+	}
+
+	applyLookAtEyeSettings(modelData)
+
+	morphData := findMorphByNames(modelData.Morphs, []string{lookAtMorphNameDown})
+	if morphData == nil {
+		t.Fatalf("look down bone morph should exist")
+	}
+	if morphData.MorphType != model.MORPH_TYPE_BONE || len(morphData.Offsets) != 1 {
+		t.Fatalf("look down morph should be bone: type=%v offsets=%d", morphData.MorphType, len(morphData.Offsets))
+	}
+	leftEyeIk, exists := getBoneByName(modelData.Bones, model.EYE.Left()+lookAtEyeIkBoneSuffix)
+	if !exists || leftEyeIk.Ik == nil || len(leftEyeIk.Ik.Links) != 1 {
+		t.Fatalf("left eye ik should exist")
+	}
+	wantDown := -mmath.DegToRad(lookAtDefaultEyeDegrees * 0.5)
+	if math.Abs(leftEyeIk.Ik.Links[0].MinAngleLimit.X-wantDown) > 1e-6 {
+		t.Fatalf("expression look down limit mismatch: got=%f want=%f", leftEyeIk.Ik.Links[0].MinAngleLimit.X, wantDown)
+	}
+}
+
+// setLookAtMetadataForTest は lookAt 定義をRawExtensionsへ保存する。
+func setLookAtMetadataForTest(t *testing.T, modelData *ModelData, metadata lookAtMetadata) {
+	t.Helper()
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		t.Fatalf("marshal look at metadata failed: %v", err)
+	}
+	if modelData.VrmData.RawExtensions == nil {
+		modelData.VrmData.RawExtensions = map[string]json.RawMessage{}
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmLookAtRawExtensionKey] = encoded
+}
@@ -0,0 +1,167 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+)
+
+const (
+	maskThresholdTextureDirName    = "mask"
+	maskThresholdDefaultAlphaCut   = 0.5
+	maskThresholdMemoToken         = "alphaThresholded=true"
+	maskThresholdMemoTokenKey      = "alphaThresholded"
+	maskThresholdAlphaCutoffMemoID = "alphaCutoff"
+)
+
+// maskThresholdTextureKey は閾値化テクスチャの再利用キーを表す。
+type maskThresholdTextureKey struct {
+	TextureIndex int
+	CutoffLevel  int
+}
+
+// exportMaskThresholdTextures は alphaMode=MASK 材質の基本テクスチャを alphaCutoff で二値化した複製を出力し、材質を差し替える。
+func exportMaskThresholdTextures(textureDir string, modelData *ModelData) {
+	if modelData == nil || modelData.Materials == nil || modelData.Textures == nil {
+		return
+	}
+	trimmedTextureDir := strings.TrimSpace(textureDir)
+	if trimmedTextureDir == "" {
+		return
+	}
+	convertedTextureIndexes := map[maskThresholdTextureKey]int{}
+	thresholdedCount := 0
+	for materialIndex, materialData := range modelData.Materials.Values() {
+		if materialData == nil || materialData.TextureIndex < 0 {
+			continue
+		}
+		if resolveMaterialAlphaModeFromMemo(materialData) != "MASK" || isAlphaThresholdedMaterial(materialData) {
+			continue
+		}
+		cutoffLevel := resolveMaskThresholdCutoffLevel(materialData)
+		key := maskThresholdTextureKey{TextureIndex: materialData.TextureIndex, CutoffLevel: cutoffLevel}
+		textureIndex, exists := convertedTextureIndexes[key]
+		if !exists {
+			createdIndex, err := exportMaskThresholdTexture(trimmedTextureDir, modelData, materialData.TextureIndex, cutoffLevel)
+			if err != nil {
+				logMaterialReorderWarn(
+					"MASK閾値テクスチャ生成失敗: material=%d name=%s texture=%d err=%v",
+					materialIndex,
+					materialData.Name(),
+					materialData.TextureIndex,
+					err,
+				)
+				continue
+			}
+			convertedTextureIndexes[key] = createdIndex
+			textureIndex = createdIndex
+		}
+		materialData.TextureIndex = textureIndex
+		materialData.Memo = strings.TrimSpace(materialData.Memo + " " + maskThresholdMemoToken)
+		thresholdedCount++
+	}
+	if thresholdedCount > 0 {
+		logMaterialReorderInfo(
+			"MASK閾値テクスチャ適用: materials=%d textures=%d",
+			thresholdedCount,
+			len(convertedTextureIndexes),
+		)
+	}
+}
+
+// exportMaskThresholdTexture は基本テクスチャを二値化したPNGを出力し、追加したテクスチャindexを返す。
+func exportMaskThresholdTexture(
+	textureDir string,
+	modelData *ModelData,
+	sourceTextureIndex int,
+	cutoffLevel int,
+) (int, error) {
+	sourceTexture, err := modelData.Textures.Get(sourceTextureIndex)
+	if err != nil || sourceTexture == nil {
+		return -1, fmt.Errorf("source texture not found: %d", sourceTextureIndex)
+	}
+	sourceImage, err := loadGeneratedSphereImageByTextureName(textureDir, sourceTexture.Name())
+	if err != nil {
+		return -1, err
+	}
+	thresholdImage := buildMaskThresholdImage(sourceImage, cutoffLevel)
+	var out bytes.Buffer
+	if err := png.Encode(&out, thresholdImage); err != nil {
+		return -1, err
+	}
+
+	sourceBaseName := path.Base(filepath.ToSlash(sourceTexture.Name()))
+	sourceBaseName = strings.TrimSuffix(sourceBaseName, path.Ext(sourceBaseName))
+	relativePath := path.Join(
+		maskThresholdTextureDirName,
+		fmt.Sprintf("%s_cutoff%03d.png", sourceBaseName, cutoffLevel),
+	)
+	outputPath := filepath.Join(textureDir, filepath.FromSlash(relativePath))
+	if err := os.MkdirAll(filepath.Dir(outputPath), outputDirFileMode); err != nil {
+		return -1, err
+	}
+	if err := os.WriteFile(outputPath, out.Bytes(), outputFileMode); err != nil {
+		return -1, err
+	}
+
+	texture := model.NewTexture()
+	texture.SetName(path.Join(defaultTextureDirName, relativePath))
+	texture.TextureType = model.TEXTURE_TYPE_TEXTURE
+	texture.SetValid(true)
+	return modelData.Textures.AppendRaw(texture), nil
+}
+
+// buildMaskThresholdImage は alpha >= cutoffLevel の画素を不透明、それ以外を完全透明へ二値化する。
+func buildMaskThresholdImage(sourceImage image.Image, cutoffLevel int) *image.NRGBA {
+	bounds := sourceImage.Bounds()
+	thresholdImage := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixel := thresholdImage.PixOffset(x-bounds.Min.X, y-bounds.Min.Y)
+			sourceColor := color.NRGBAModel.Convert(sourceImage.At(x, y)).(color.NRGBA)
+			thresholdImage.Pix[pixel] = sourceColor.R
+			thresholdImage.Pix[pixel+1] = sourceColor.G
+			thresholdImage.Pix[pixel+2] = sourceColor.B
+			if int(sourceColor.A) >= cutoffLevel {
+				thresholdImage.Pix[pixel+3] = 255
+			} else {
+				thresholdImage.Pix[pixel+3] = 0
+			}
+		}
+	}
+	return thresholdImage
+}
+
+// resolveMaskThresholdCutoffLevel は材質メモの alphaCutoff を 0..255 の閾値へ変換する。
+func resolveMaskThresholdCutoffLevel(materialData *model.Material) int {
+	cutoff := maskThresholdDefaultAlphaCut
+	if value, ok := resolveMaterialMemoTokenValue(materialData, maskThresholdAlphaCutoffMemoID); ok {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(parsed) {
+			cutoff = parsed
+		}
+	}
+	if cutoff < 0 {
+		cutoff = 0
+	}
+	if cutoff > 1 {
+		cutoff = 1
+	}
+	return int(math.Round(cutoff * 255))
+}
+
+// isAlphaThresholdedMaterial は材質が MASK 閾値テクスチャへ差し替え済みか判定する。
+func isAlphaThresholdedMaterial(materialData *model.Material) bool {
+	value, ok := resolveMaterialMemoTokenValue(materialData, maskThresholdMemoTokenKey)
+	return ok && strings.EqualFold(value, "true")
+}
@@ -0,0 +1,102 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+)
+
+func TestExportMaskThresholdTexturesWritesHardAlphaCopy(t *testing.T) {
+	texDir := t.TempDir()
+	sourceImage := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	for x, alpha := range []uint8{0x00, 0x40, 0x80, 0xff} {
+		sourceImage.SetNRGBA(x, 0, color.NRGBA{R: 0x20, G: 0x40, B: 0x60, A: alpha})
+	}
+	sourceFile, err := os.Create(filepath.Join(texDir, "hair.png"))
+	if err != nil {
+		t.Fatalf("failed to create source texture: %v", err)
+	}
+	if err := png.Encode(sourceFile, sourceImage); err != nil {
+		_ = sourceFile.Close()
+		t.Fatalf("failed to encode source texture: %v", err)
+	}
+	_ = sourceFile.Close()
+
+	modelData := model.NewPmxModel()
+	texture := model.NewTexture()
+	texture.SetName("tex/hair.png")
+	texture.TextureType = model.TEXTURE_TYPE_TEXTURE
+	texture.SetValid(true)
+	modelData.Textures.AppendRaw(texture)
+
+	maskMaterial := newMaterial("Hair_MASK", 1.0, 3)
+	maskMaterial.TextureIndex = 0
+	maskMaterial.Memo = "VRM primitive alphaMode=MASK alphaCutoff=0.5"
+	modelData.Materials.AppendRaw(maskMaterial)
+	blendMaterial := newMaterial("Hair_BLEND", 1.0, 3)
+	blendMaterial.TextureIndex = 0
+	blendMaterial.Memo = "VRM primitive alphaMode=BLEND"
+	modelData.Materials.AppendRaw(blendMaterial)
+
+	exportMaskThresholdTextures(texDir, modelData)
+
+	if maskMaterial.TextureIndex != 1 {
+		t.Fatalf("mask material should be repointed: got=%d", maskMaterial.TextureIndex)
+	}
+	if blendMaterial.TextureIndex != 0 {
+		t.Fatalf("blend material should keep source texture: got=%d", blendMaterial.TextureIndex)
+	}
+	if !isAlphaThresholdedMaterial(maskMaterial) || !strings.Contains(maskMaterial.Memo, "alphaCutoff=0.5") {
+		t.Fatalf("mask material memo mismatch: %s", maskMaterial.Memo)
+	}
+	thresholdTexture, err := modelData.Textures.Get(1)
+	if err != nil || thresholdTexture == nil || thresholdTexture.Name() != "tex/mask/hair_cutoff128.png" {
+		t.Fatalf("threshold texture mismatch: texture=%v err=%v", thresholdTexture, err)
+	}
+
+	outputFile, err := os.Open(filepath.Join(texDir, "mask", "hair_cutoff128.png"))
+	if err != nil {
+		t.Fatalf("threshold texture not written: %v", err)
+	}
+	defer outputFile.Close()
+	outputImage, err := png.Decode(outputFile)
+	if err != nil {
+		t.Fatalf("failed to decode threshold texture: %v", err)
+	}
+	for x, want := range []uint8{0x00, 0x00, 0xff, 0xff} {
+		got := color.NRGBAModel.Convert(outputImage.At(x, 0)).(color.NRGBA)
+		if got.A != want {
+			t.Fatalf("threshold alpha mismatch: x=%d got=%d want=%d", x, got.A, want)
+		}
+		if want == 0xff && (got.R != 0x20 || got.G != 0x40 || got.B != 0x60) {
+			t.Fatalf("threshold color mismatch: x=%d got=%v", x, got)
+		}
+	}
+}
+
+func TestCollectTransparentMaterialIndexesFromScoresSkipsAlphaThresholdedMaterials(t *testing.T) {
+	modelData := model.NewPmxModel()
+	thresholded := newMaterial("Hair_MASK", 1.0, 3)
+	thresholded.Memo = "VRM primitive alphaMode=MASK alphaCutoff=0.5 alphaThresholded=true"
+	modelData.Materials.AppendRaw(thresholded)
+	mask := newMaterial("Lash_MASK", 1.0, 3)
+	mask.Memo = "VRM primitive alphaMode=MASK alphaCutoff=0.5"
+	modelData.Materials.AppendRaw(mask)
+
+	scores := buildTransparentCandidateScores(modelData, []materialFaceRange{{}, {}}, map[int]textureImageCacheEntry{}, 1.0)
+	if scores[0] != 0 || scores[1] != 1.0 {
+		t.Fatalf("MASK candidate scores mismatch: got=%v", scores)
+	}
+	got := collectTransparentMaterialIndexesFromScores(modelData, map[int]float64{0: 1.0, 1: scores[1]})
+
+	if len(got) != 1 || got[0] != 1 {
+		t.Fatalf("thresholded MASK material should not be transparent: got=%v", got)
+	}
+}
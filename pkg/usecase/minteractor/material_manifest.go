@@ -0,0 +1,190 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+const (
+	materialManifestFileNameSuffix = "_materials.json"
+	materialManifestVersion        = 1
+	materialSourceMemoKey          = "gltfMaterial"
+)
+
+// materialCompanionTexture は adapter が記録した補助テクスチャ参照を表す。
+type materialCompanionTexture struct {
+	TextureIndex int `json:"texture_index"`
+	TexCoord     int `json:"tex_coord"`
+}
+
+// materialCompanionSource は adapter が記録した glTF material 単位の補助テクスチャと係数を表す。
+type materialCompanionSource struct {
+	Name                        string                    `json:"name"`
+	NormalTexture               *materialCompanionTexture `json:"normal_texture,omitempty"`
+	NormalScale                 *float64                  `json:"normal_scale,omitempty"`
+	EmissiveTexture             *materialCompanionTexture `json:"emissive_texture,omitempty"`
+	EmissiveFactor              []float64                 `json:"emissive_factor,omitempty"`
+	ShadeMultiplyTexture        *materialCompanionTexture `json:"shade_multiply_texture,omitempty"`
+	ShadeColorFactor            []float64                 `json:"shade_color_factor,omitempty"`
+	ShadingShiftFactor          *float64                  `json:"shading_shift_factor,omitempty"`
+	ShadingToonyFactor          *float64                  `json:"shading_toony_factor,omitempty"`
+	RimMultiplyTexture          *materialCompanionTexture `json:"rim_multiply_texture,omitempty"`
+	RimColorFactor              []float64                 `json:"rim_color_factor,omitempty"`
+	RimLightingMixFactor        *float64                  `json:"rim_lighting_mix_factor,omitempty"`
+	OutlineWidthMultiplyTexture *materialCompanionTexture `json:"outline_width_multiply_texture,omitempty"`
+	OutlineWidthTextureChannel  string                    `json:"outline_width_texture_channel,omitempty"`
+	OutlineWidthFactor          *float64                  `json:"outline_width_factor,omitempty"`
+}
+
+// materialManifest は PMX と同じ場所へ出力する材質別補助テクスチャ一覧を表す。
+type materialManifest struct {
+	Version   int                     `json:"version"`
+	Model     string                  `json:"model"`
+	Materials []materialManifestEntry `json:"materials"`
+}
+
+// materialManifestTexture はPMXからの相対パスで表した補助テクスチャを表す。
+type materialManifestTexture struct {
+	Path     string `json:"path"`
+	TexCoord int    `json:"tex_coord"`
+}
+
+// materialManifestEntry はPMX材質1件分の補助テクスチャと係数を表す。
+type materialManifestEntry struct {
+	Index                       int                      `json:"index"`
+	Name                        string                   `json:"name"`
+	EnglishName                 string                   `json:"english_name"`
+	SourceMaterialIndex         int                      `json:"source_material_index"`
+	SourceMaterialName          string                   `json:"source_material_name,omitempty"`
+	BaseColorTexture            string                   `json:"base_color_texture,omitempty"`
+	NormalTexture               *materialManifestTexture `json:"normal_texture,omitempty"`
+	NormalScale                 *float64                 `json:"normal_scale,omitempty"`
+	EmissiveTexture             *materialManifestTexture `json:"emissive_texture,omitempty"`
+	EmissiveFactor              []float64                `json:"emissive_factor,omitempty"`
+	ShadeMultiplyTexture        *materialManifestTexture `json:"shade_multiply_texture,omitempty"`
+	ShadeColorFactor            []float64                `json:"shade_color_factor,omitempty"`
+	RimMultiplyTexture          *materialManifestTexture `json:"rim_multiply_texture,omitempty"`
+	RimColorFactor              []float64                `json:"rim_color_factor,omitempty"`
+	RimLightingMixFactor        *float64                 `json:"rim_lighting_mix_factor,omitempty"`
+	OutlineWidthMultiplyTexture *materialManifestTexture `json:"outline_width_multiply_texture,omitempty"`
+	OutlineWidthFactor          *float64                 `json:"outline_width_factor,omitempty"`
+}
+
+// buildMaterialManifestOutputPath はPMX保存先から材質マニフェストの保存先を生成する。
+func buildMaterialManifestOutputPath(outputPath string) string {
+	trimmed := strings.TrimSpace(outputPath)
+	if trimmed == "" {
+		return ""
+	}
+	return strings.TrimSuffix(trimmed, filepath.Ext(trimmed)) + materialManifestFileNameSuffix
+}
+
+// exportMaterialManifest は最終材質順の補助テクスチャ一覧をPMXと同じ場所へJSON出力し、保存先を返す。
+func exportMaterialManifest(outputPath string, modelData *ModelData) (string, error) {
+	manifestPath := buildMaterialManifestOutputPath(outputPath)
+	if manifestPath == "" {
+		return "", fmt.Errorf("材質マニフェストの保存先が未指定です")
+	}
+	manifest := buildMaterialManifest(modelData)
+	manifest.Model = filepath.Base(outputPath)
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(manifestPath, encoded, outputFileMode); err != nil {
+		return "", err
+	}
+	logMaterialReorderInfo("材質マニフェスト出力: materials=%d path=%s", len(manifest.Materials), manifestPath)
+	return manifestPath, nil
+}
+
+// buildMaterialManifest は材質メモの glTF material index から補助テクスチャ一覧を組み立てる。
+func buildMaterialManifest(modelData *ModelData) materialManifest {
+	manifest := materialManifest{Version: materialManifestVersion, Materials: []materialManifestEntry{}}
+	if modelData == nil || modelData.Materials == nil {
+		return manifest
+	}
+	sources := resolveMaterialCompanionSources(modelData)
+	for materialIndex, materialData := range modelData.Materials.Values() {
+		if materialData == nil {
+			continue
+		}
+		entry := materialManifestEntry{
+			Index:               materialIndex,
+			Name:                materialData.Name(),
+			EnglishName:         materialData.EnglishName,
+			SourceMaterialIndex: -1,
+			BaseColorTexture:    resolveMaterialManifestTexturePath(modelData, materialData.TextureIndex),
+		}
+		sourceIndexText, ok := resolveMaterialMemoTokenValue(materialData, materialSourceMemoKey)
+		if ok {
+			if sourceIndex, err := strconv.Atoi(sourceIndexText); err == nil {
+				entry.SourceMaterialIndex = sourceIndex
+			}
+		}
+		if source, exists := sources[sourceIndexText]; ok && exists {
+			entry.SourceMaterialName = source.Name
+			entry.NormalTexture = resolveMaterialManifestTexture(modelData, source.NormalTexture)
+			entry.NormalScale = source.NormalScale
+			entry.EmissiveTexture = resolveMaterialManifestTexture(modelData, source.EmissiveTexture)
+			entry.EmissiveFactor = source.EmissiveFactor
+			entry.ShadeMultiplyTexture = resolveMaterialManifestTexture(modelData, source.ShadeMultiplyTexture)
+			entry.ShadeColorFactor = source.ShadeColorFactor
+			entry.RimMultiplyTexture = resolveMaterialManifestTexture(modelData, source.RimMultiplyTexture)
+			entry.RimColorFactor = source.RimColorFactor
+			entry.RimLightingMixFactor = source.RimLightingMixFactor
+			entry.OutlineWidthMultiplyTexture = resolveMaterialManifestTexture(modelData, source.OutlineWidthMultiplyTexture)
+			entry.OutlineWidthFactor = source.OutlineWidthFactor
+		}
+		manifest.Materials = append(manifest.Materials, entry)
+	}
+	return manifest
+}
+
+// resolveMaterialCompanionSources は RawExtensions から glTF material 別補助テクスチャ情報を返す。
+func resolveMaterialCompanionSources(modelData *ModelData) map[string]materialCompanionSource {
+	sources := map[string]materialCompanionSource{}
+	if modelData == nil || modelData.VrmData == nil || modelData.VrmData.RawExtensions == nil {
+		return sources
+	}
+	raw, exists := modelData.VrmData.RawExtensions[warningid.VrmMaterialCompanionRawExtensionKey]
+	if !exists || len(raw) == 0 {
+		return sources
+	}
+	if err := json.Unmarshal(raw, &sources); err != nil {
+		logMaterialReorderWarn("材質補助テクスチャ情報の解析に失敗しました: err=%v", err)
+		return map[string]materialCompanionSource{}
+	}
+	return sources
+}
+
+// resolveMaterialManifestTexture は補助テクスチャ参照を出力パスへ変換する。
+func resolveMaterialManifestTexture(modelData *ModelData, texture *materialCompanionTexture) *materialManifestTexture {
+	if texture == nil {
+		return nil
+	}
+	texturePath := resolveMaterialManifestTexturePath(modelData, texture.TextureIndex)
+	if texturePath == "" {
+		return nil
+	}
+	return &materialManifestTexture{Path: texturePath, TexCoord: texture.TexCoord}
+}
+
+// resolveMaterialManifestTexturePath はテクスチャindexをPMXからの相対パスへ変換する。
+func resolveMaterialManifestTexturePath(modelData *ModelData, textureIndex int) string {
+	if modelData == nil || modelData.Textures == nil || textureIndex < 0 {
+		return ""
+	}
+	textureData, err := modelData.Textures.Get(textureIndex)
+	if err != nil || textureData == nil || !textureData.IsValid() {
+		return ""
+	}
+	return filepath.ToSlash(strings.TrimSpace(textureData.Name()))
+}
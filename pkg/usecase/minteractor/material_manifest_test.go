@@ -0,0 +1,86 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+func TestExportMaterialManifestListsCompanionTexturesInMaterialOrder(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "avatar.pmx")
+	modelData := model.NewPmxModel()
+	modelData.VrmData = &vrm.VrmData{
+		RawExtensions: map[string]json.RawMessage{},
+	}
+	for _, name := range []string{"tex/body.png", "tex/body_normal.png", "tex/body_shade.png"} {
+		texture := model.NewTexture()
+		texture.SetName(name)
+		texture.SetValid(true)
+		modelData.Textures.AppendRaw(texture)
+	}
+	front := newMaterial("Body_表面", 1.0, 3)
+	front.TextureIndex = 0
+	front.Memo = "VRM primitive alphaMode=OPAQUE gltfMaterial=0"
+	modelData.Materials.AppendRaw(front)
+	unknown := newMaterial("Extra", 1.0, 3)
+	unknown.TextureIndex = -1
+	modelData.Materials.AppendRaw(unknown)
+
+	scale := 0.5
+	sourcesRaw, err := json.Marshal(map[string]materialCompanionSource{
+		"0": {
+			Name:                 "Body",
+			NormalTexture:        &materialCompanionTexture{TextureIndex: 1},
+			NormalScale:          &scale,
+			ShadeMultiplyTexture: &materialCompanionTexture{TextureIndex: 2, TexCoord: 1},
+			ShadeColorFactor:     []float64{0.2, 0.3, 0.4},
+			RimMultiplyTexture:   &materialCompanionTexture{TextureIndex: 99},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal companion sources: %v", err)
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmMaterialCompanionRawExtensionKey] = sourcesRaw
+
+	manifestPath, err := exportMaterialManifest(outputPath, modelData)
+	if err != nil {
+		t.Fatalf("export manifest failed: %v", err)
+	}
+	if manifestPath != filepath.Join(filepath.Dir(outputPath), "avatar_materials.json") {
+		t.Fatalf("manifest path mismatch: %s", manifestPath)
+	}
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	manifest := materialManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	if manifest.Model != "avatar.pmx" || len(manifest.Materials) != 2 {
+		t.Fatalf("manifest header mismatch: %+v", manifest)
+	}
+	body := manifest.Materials[0]
+	if body.Name != "Body_表面" || body.SourceMaterialIndex != 0 || body.SourceMaterialName != "Body" || body.BaseColorTexture != "tex/body.png" {
+		t.Fatalf("body entry mismatch: %+v", body)
+	}
+	if body.NormalTexture == nil || body.NormalTexture.Path != "tex/body_normal.png" || *body.NormalScale != 0.5 {
+		t.Fatalf("normal texture mismatch: %+v", body.NormalTexture)
+	}
+	if body.ShadeMultiplyTexture == nil || body.ShadeMultiplyTexture.Path != "tex/body_shade.png" || body.ShadeMultiplyTexture.TexCoord != 1 {
+		t.Fatalf("shade texture mismatch: %+v", body.ShadeMultiplyTexture)
+	}
+	if body.RimMultiplyTexture != nil {
+		t.Fatalf("unresolved texture should be omitted: %+v", body.RimMultiplyTexture)
+	}
+	extra := manifest.Materials[1]
+	if extra.SourceMaterialIndex != -1 || extra.BaseColorTexture != "" || extra.NormalTexture != nil {
+		t.Fatalf("material without source should be listed without companions: %+v", extra)
+	}
+}
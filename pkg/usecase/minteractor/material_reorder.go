@@ -2507,6 +2507,9 @@ func buildTransparentCandidateScores(
 		if materialData == nil || !hasVroidMaterialAlphaModeMaskOrBlend(materialData) {
 			continue
 		}
+		if isAlphaThresholdedMaterial(materialData) {
+			continue
+		}
 		if scores[materialIndex] > materialOrderScoreEpsilon {
 			continue
 		}
@@ -2532,6 +2535,10 @@ func collectTransparentMaterialIndexesFromScores(
 		if isSpecialEyeOverlayMaterialIndex(modelData, materialIndex) {
 			continue
 		}
+		// 閾値化済み MASK 材質は完全透明/不透明の二値となるため、半透明扱いしない。
+		if isAlphaThresholdedMaterial(materialData) {
+			continue
+		}
 		score := materialTransparencyScores[materialIndex]
 		if score <= materialOrderScoreEpsilon {
 			continue
@@ -2551,6 +2558,9 @@ func collectTransparentMaterialIndexesFromScores(
 		if isSpecialEyeOverlayMaterialIndex(modelData, materialIndex) {
 			continue
 		}
+		if isAlphaThresholdedMaterial(materialData) {
+			continue
+		}
 		groupKey, ok := resolveTransparentMaterialOrderGroupKey(modelData, materialIndex)
 		if !ok {
 			continue
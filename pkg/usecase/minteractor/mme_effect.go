@@ -0,0 +1,237 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	mmeEffectDirName            = "fx"
+	mmeEffectFileNameFormat     = "material_%03d.fx"
+	mmeEffectDefaultIncludePath = "MToon.fxsub"
+	mmeEffectAssignmentObject   = "Obj1"
+
+	mmeEffectDefaultShadingToony   = 0.9
+	mmeEffectDefaultRimLightingMix = 1.0
+	mmeEffectDefaultOutlineWidth   = 0.0
+)
+
+// mmeEffectDefaultIncludeContent は既定 include 先として .fx と同じ場所へ出力する共通シェーダーを保持する。
+//
+//go:embed assets/MToon.fxsub
+var mmeEffectDefaultIncludeContent []byte
+
+// mmeEffectOutput はMME用エフェクト出力結果を表す。
+type mmeEffectOutput struct {
+	EffectPaths []string
+	IncludePath string
+	EmdPath     string
+	EmmPath     string
+}
+
+// buildMmeEffectOutputPaths はPMX保存先から .emd/.emm の保存先を生成する。
+func buildMmeEffectOutputPaths(outputPath string) (string, string) {
+	trimmed := strings.TrimSpace(outputPath)
+	if trimmed == "" {
+		return "", ""
+	}
+	base := strings.TrimSuffix(trimmed, filepath.Ext(trimmed))
+	return base + ".emd", base + ".emm"
+}
+
+// exportMmeEffects は材質ごとの .fx と、材質へ割り当てる .emd/.emm をPMXと同じ場所へ出力する。
+func exportMmeEffects(outputPath string, modelData *ModelData, includePath string) (mmeEffectOutput, error) {
+	output := mmeEffectOutput{EffectPaths: []string{}}
+	emdPath, emmPath := buildMmeEffectOutputPaths(outputPath)
+	if emdPath == "" || emmPath == "" {
+		return output, fmt.Errorf("MMEエフェクトの保存先が未指定です")
+	}
+	if modelData == nil || modelData.Materials == nil {
+		return output, nil
+	}
+	effectDir := filepath.Join(filepath.Dir(outputPath), mmeEffectDirName)
+	if err := os.MkdirAll(effectDir, outputDirFileMode); err != nil {
+		return output, err
+	}
+	resolvedIncludePath := strings.TrimSpace(includePath)
+	if resolvedIncludePath == "" {
+		// 既定の共通シェーダーは .fx と同じ fx/ 配下へ同梱し、include 先の欠落を防ぐ。
+		resolvedIncludePath = mmeEffectDefaultIncludePath
+		defaultIncludePath := filepath.Join(effectDir, mmeEffectDefaultIncludePath)
+		includeContent := normalizeMmeLineEnding(string(mmeEffectDefaultIncludeContent))
+		if err := os.WriteFile(defaultIncludePath, []byte(includeContent), outputFileMode); err != nil {
+			return output, err
+		}
+		output.IncludePath = defaultIncludePath
+	}
+
+	sources := resolveMaterialCompanionSources(modelData)
+	effectRelativePaths := make([]string, 0, modelData.Materials.Len())
+	for materialIndex, materialData := range modelData.Materials.Values() {
+		if materialData == nil {
+			effectRelativePaths = append(effectRelativePaths, "")
+			continue
+		}
+		var source *materialCompanionSource
+		if sourceIndexText, ok := resolveMaterialMemoTokenValue(materialData, materialSourceMemoKey); ok {
+			if resolved, exists := sources[sourceIndexText]; exists {
+				source = &resolved
+			}
+		}
+		effectFileName := fmt.Sprintf(mmeEffectFileNameFormat, materialIndex)
+		effectPath := filepath.Join(effectDir, effectFileName)
+		content := buildMmeEffectContent(modelData, materialIndex, source, resolvedIncludePath)
+		if err := os.WriteFile(effectPath, []byte(content), outputFileMode); err != nil {
+			return output, err
+		}
+		output.EffectPaths = append(output.EffectPaths, effectPath)
+		effectRelativePaths = append(effectRelativePaths, path.Join(mmeEffectDirName, effectFileName))
+	}
+
+	if err := os.WriteFile(emdPath, []byte(buildMmeEmdContent(effectRelativePaths)), outputFileMode); err != nil {
+		return output, err
+	}
+	output.EmdPath = emdPath
+	emmContent := buildMmeEmmContent(filepath.Base(outputPath), effectRelativePaths)
+	if err := os.WriteFile(emmPath, []byte(emmContent), outputFileMode); err != nil {
+		return output, err
+	}
+	output.EmmPath = emmPath
+	logMaterialReorderInfo(
+		"MMEエフェクト出力: effects=%d include=%s emd=%s emm=%s",
+		len(output.EffectPaths),
+		resolvedIncludePath,
+		emdPath,
+		emmPath,
+	)
+	return output, nil
+}
+
+// buildMmeEffectContent は MToon 相当パラメータを #define した上で共通シェーダーを include する .fx を組み立てる。
+func buildMmeEffectContent(
+	modelData *ModelData,
+	materialIndex int,
+	source *materialCompanionSource,
+	includePath string,
+) string {
+	var builder strings.Builder
+	// MME は .fx を Shift-JIS として読むため、材質名は埋め込まず index のみを記録する。
+	builder.WriteString(fmt.Sprintf("// mu_vrm2pmx MToon material %03d\r\n", materialIndex))
+	if source == nil {
+		source = &materialCompanionSource{}
+	} else {
+		builder.WriteString(fmt.Sprintf("// source glTF material: %s\r\n", strconv.QuoteToASCII(source.Name)))
+	}
+
+	writeFloat3 := func(name string, values []float64, fallback float64) {
+		color := [3]float64{fallback, fallback, fallback}
+		for i := 0; i < len(values) && i < 3; i++ {
+			color[i] = values[i]
+		}
+		builder.WriteString(fmt.Sprintf(
+			"#define %s float3(%s, %s, %s)\r\n",
+			name,
+			formatMmeEffectFloat(color[0]),
+			formatMmeEffectFloat(color[1]),
+			formatMmeEffectFloat(color[2]),
+		))
+	}
+	writeFloat := func(name string, value *float64, fallback float64) {
+		resolved := fallback
+		if value != nil {
+			resolved = *value
+		}
+		builder.WriteString(fmt.Sprintf("#define %s (%s)\r\n", name, formatMmeEffectFloat(resolved)))
+	}
+	writeTexture := func(name string, texture *materialCompanionTexture) {
+		resolved := resolveMaterialManifestTexture(modelData, texture)
+		if resolved == nil {
+			return
+		}
+		// .fx は fx/ 配下へ出力するため、PMX基準の相対パスを1階層上へ補正する。
+		builder.WriteString(fmt.Sprintf("#define %s \"../%s\"\r\n", name, resolved.Path))
+	}
+
+	writeFloat3("MTOON_SHADE_COLOR", source.ShadeColorFactor, 0.0)
+	writeTexture("MTOON_SHADE_TEXTURE", source.ShadeMultiplyTexture)
+	writeFloat("MTOON_SHADING_SHIFT", source.ShadingShiftFactor, 0.0)
+	writeFloat("MTOON_SHADING_TOONY", source.ShadingToonyFactor, mmeEffectDefaultShadingToony)
+	writeFloat3("MTOON_RIM_COLOR", source.RimColorFactor, 0.0)
+	writeTexture("MTOON_RIM_TEXTURE", source.RimMultiplyTexture)
+	writeFloat("MTOON_RIM_LIGHTING_MIX", source.RimLightingMixFactor, mmeEffectDefaultRimLightingMix)
+	writeFloat("MTOON_OUTLINE_WIDTH", source.OutlineWidthFactor, mmeEffectDefaultOutlineWidth)
+	writeTexture("MTOON_OUTLINE_WIDTH_TEXTURE", source.OutlineWidthMultiplyTexture)
+	if resolveMaterialManifestTexture(modelData, source.OutlineWidthMultiplyTexture) != nil {
+		builder.WriteString(fmt.Sprintf(
+			"#define MTOON_OUTLINE_WIDTH_TEXTURE_CHANNEL %s\r\n",
+			resolveMmeOutlineWidthTextureChannel(source.OutlineWidthTextureChannel),
+		))
+	}
+	writeFloat3("MTOON_EMISSIVE_COLOR", source.EmissiveFactor, 0.0)
+	writeTexture("MTOON_EMISSIVE_TEXTURE", source.EmissiveTexture)
+	writeTexture("MTOON_NORMAL_TEXTURE", source.NormalTexture)
+	builder.WriteString(fmt.Sprintf("\r\n#include \"%s\"\r\n", filepath.ToSlash(includePath)))
+	return builder.String()
+}
+
+// buildMmeEmdContent はモデル読込時に材質へ .fx を割り当てる .emd を組み立てる。
+func buildMmeEmdContent(effectRelativePaths []string) string {
+	var builder strings.Builder
+	builder.WriteString("[Info]\r\nVersion = 1\r\n\r\n[Effect]\r\nObj = none\r\n")
+	writeMmeSubsetAssignments(&builder, "Obj", effectRelativePaths)
+	return builder.String()
+}
+
+// buildMmeEmmContent は MMEffect の「エフェクト割当」で読み込める .emm を組み立てる。
+func buildMmeEmmContent(modelFileName string, effectRelativePaths []string) string {
+	var builder strings.Builder
+	builder.WriteString("[Info]\r\nVersion = 3\r\n\r\n")
+	builder.WriteString(fmt.Sprintf("[Object]\r\n%s = %s\r\n\r\n", mmeEffectAssignmentObject, modelFileName))
+	builder.WriteString(fmt.Sprintf("[Effect]\r\n%s = none\r\n%s.show = true\r\n", mmeEffectAssignmentObject, mmeEffectAssignmentObject))
+	writeMmeSubsetAssignments(&builder, mmeEffectAssignmentObject, effectRelativePaths)
+	return builder.String()
+}
+
+// writeMmeSubsetAssignments は材質(サブセット)ごとの .fx 割当行を書き込む。
+func writeMmeSubsetAssignments(builder *strings.Builder, objectName string, effectRelativePaths []string) {
+	for materialIndex, effectPath := range effectRelativePaths {
+		if effectPath == "" {
+			continue
+		}
+		builder.WriteString(fmt.Sprintf(
+			"%s[%d] = %s\r\n",
+			objectName,
+			materialIndex,
+			strings.ReplaceAll(effectPath, "/", "\\"),
+		))
+	}
+}
+
+// resolveMmeOutlineWidthTextureChannel は outline 幅テクスチャの参照チャンネルを HLSL の swizzle で返す。
+// VRM0 は R、VRM1 と未指定時は G を参照する。
+func resolveMmeOutlineWidthTextureChannel(channel string) string {
+	if strings.EqualFold(strings.TrimSpace(channel), outlineWidthTextureChannelRed) {
+		return outlineWidthTextureChannelRed
+	}
+	return "g"
+}
+
+// normalizeMmeLineEnding は MME 出力ファイルの改行を .emd/.emm と同じ CRLF へ揃える。
+func normalizeMmeLineEnding(content string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+}
+
+// formatMmeEffectFloat はHLSLで解釈できる浮動小数リテラルを返す。
+func formatMmeEffectFloat(value float64) string {
+	formatted := strconv.FormatFloat(value, 'f', -1, 64)
+	if !strings.Contains(formatted, ".") {
+		formatted += ".0"
+	}
+	return formatted
+}
@@ -0,0 +1,184 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	vrmrepository "github.com/miu200521358/mu_vrm2pmx/pkg/adapter/io_model/vrm"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+func TestExportMmeEffectsWritesMaterialEffectsAndAssignments(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "avatar.pmx")
+	modelData := model.NewPmxModel()
+	modelData.VrmData = &vrm.VrmData{
+		RawExtensions: map[string]json.RawMessage{},
+	}
+	shadeTexture := model.NewTexture()
+	shadeTexture.SetName("tex/body_shade.png")
+	shadeTexture.SetValid(true)
+	modelData.Textures.AppendRaw(shadeTexture)
+	body := newMaterial("Body", 1.0, 3)
+	body.Memo = "VRM primitive alphaMode=OPAQUE gltfMaterial=0"
+	modelData.Materials.AppendRaw(body)
+	modelData.Materials.AppendRaw(newMaterial("Extra", 1.0, 3))
+
+	shift := -0.25
+	outlineWidth := 0.02
+	sourcesRaw, err := json.Marshal(map[string]materialCompanionSource{
+		"0": {
+			Name:                        "Body",
+			ShadeColorFactor:            []float64{0.5, 0.25, 1},
+			ShadeMultiplyTexture:        &materialCompanionTexture{TextureIndex: 0},
+			ShadingShiftFactor:          &shift,
+			RimColorFactor:              []float64{1, 0, 0},
+			OutlineWidthFactor:          &outlineWidth,
+			EmissiveFactor:              []float64{0.1, 0.2, 0.3},
+			OutlineWidthMultiplyTexture: &materialCompanionTexture{TextureIndex: 0},
+			OutlineWidthTextureChannel:  "r",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal companion sources: %v", err)
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmMaterialCompanionRawExtensionKey] = sourcesRaw
+
+	output, err := exportMmeEffects(outputPath, modelData, "")
+	if err != nil {
+		t.Fatalf("export mme effects failed: %v", err)
+	}
+	if len(output.EffectPaths) != 2 {
+		t.Fatalf("effect count mismatch: %v", output.EffectPaths)
+	}
+	bodyEffect, err := os.ReadFile(output.EffectPaths[0])
+	if err != nil {
+		t.Fatalf("body effect not written: %v", err)
+	}
+	for _, expected := range []string{
+		"#define MTOON_SHADE_COLOR float3(0.5, 0.25, 1.0)",
+		"#define MTOON_SHADE_TEXTURE \"../tex/body_shade.png\"",
+		"#define MTOON_SHADING_SHIFT (-0.25)",
+		"#define MTOON_SHADING_TOONY (0.9)",
+		"#define MTOON_RIM_COLOR float3(1.0, 0.0, 0.0)",
+		"#define MTOON_OUTLINE_WIDTH (0.02)",
+		"#define MTOON_OUTLINE_WIDTH_TEXTURE \"../tex/body_shade.png\"",
+		"#define MTOON_OUTLINE_WIDTH_TEXTURE_CHANNEL r",
+		"#define MTOON_EMISSIVE_COLOR float3(0.1, 0.2, 0.3)",
+		"#include \"MToon.fxsub\"",
+	} {
+		if !strings.Contains(string(bodyEffect), expected) {
+			t.Fatalf("body effect missing %q:\n%s", expected, bodyEffect)
+		}
+	}
+	includeContent, err := os.ReadFile(filepath.Join(filepath.Dir(output.EffectPaths[0]), "MToon.fxsub"))
+	if err != nil || output.IncludePath == "" {
+		t.Fatalf("default include shader should be written next to effects: path=%s err=%v", output.IncludePath, err)
+	}
+	if !strings.Contains(string(includeContent), "MTOON_SHADING_TOONY") {
+		t.Fatalf("default include shader content mismatch:\n%s", includeContent)
+	}
+	for _, content := range [][]byte{bodyEffect, includeContent} {
+		if strings.Count(string(content), "\n") != strings.Count(string(content), "\r\n") {
+			t.Fatalf("mme effect files should use CRLF line endings:\n%q", content)
+		}
+	}
+	extraEffect, err := os.ReadFile(output.EffectPaths[1])
+	if err != nil {
+		t.Fatalf("extra effect not written: %v", err)
+	}
+	if strings.Contains(string(extraEffect), "MTOON_SHADE_TEXTURE") || !strings.Contains(string(extraEffect), "#define MTOON_SHADE_COLOR float3(0.0, 0.0, 0.0)") {
+		t.Fatalf("material without source should use defaults:\n%s", extraEffect)
+	}
+
+	if output.EmmPath != filepath.Join(filepath.Dir(outputPath), "avatar.emm") {
+		t.Fatalf("emm path mismatch: %s", output.EmmPath)
+	}
+	emm, err := os.ReadFile(output.EmmPath)
+	if err != nil {
+		t.Fatalf("emm not written: %v", err)
+	}
+	for _, expected := range []string{"Obj1 = avatar.pmx", "Obj1[0] = fx\\material_000.fx", "Obj1[1] = fx\\material_001.fx"} {
+		if !strings.Contains(string(emm), expected) {
+			t.Fatalf("emm missing %q:\n%s", expected, emm)
+		}
+	}
+	emd, err := os.ReadFile(output.EmdPath)
+	if err != nil {
+		t.Fatalf("emd not written: %v", err)
+	}
+	if !strings.Contains(string(emd), "Obj[1] = fx\\material_001.fx") {
+		t.Fatalf("emd assignment missing:\n%s", emd)
+	}
+}
+
+func TestExportMmeEffectsWritesVrm1MToonShadingFactors(t *testing.T) {
+	tempDir := t.TempDir()
+	inputPath := filepath.Join(tempDir, "avatar.vrm")
+	writeGLBForUsecaseTest(t, inputPath, map[string]any{
+		"asset":          map[string]any{"version": "2.0"},
+		"extensionsUsed": []string{"VRMC_vrm", "VRMC_materials_mtoon"},
+		"nodes": []any{
+			map[string]any{"name": "hips_node", "translation": []float64{0, 0.8, 0}},
+		},
+		"meshes": []any{
+			map[string]any{"primitives": []any{}},
+		},
+		"materials": []any{
+			map[string]any{
+				"name": "Body",
+				"extensions": map[string]any{
+					"VRMC_materials_mtoon": map[string]any{
+						"specVersion":        "1.0",
+						"shadingShiftFactor": -0.3,
+						"shadingToonyFactor": 0.6,
+					},
+				},
+			},
+		},
+		"extensions": map[string]any{
+			"VRMC_vrm": map[string]any{
+				"specVersion": "1.0",
+				"humanoid": map[string]any{
+					"humanBones": map[string]any{"hips": map[string]any{"node": 0}},
+				},
+			},
+		},
+	}, nil)
+	hashableModel, err := vrmrepository.NewVrmRepository().Load(inputPath)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	modelData, ok := hashableModel.(*model.PmxModel)
+	if !ok {
+		t.Fatalf("expected *model.PmxModel, got %T", hashableModel)
+	}
+	body := newMaterial("Body", 1.0, 3)
+	body.Memo = "VRM primitive alphaMode=OPAQUE gltfMaterial=0"
+	modelData.Materials.AppendRaw(body)
+
+	output, err := exportMmeEffects(filepath.Join(tempDir, "avatar.pmx"), modelData, "")
+	if err != nil {
+		t.Fatalf("export mme effects failed: %v", err)
+	}
+	if len(output.EffectPaths) != 1 {
+		t.Fatalf("effect count mismatch: %v", output.EffectPaths)
+	}
+	bodyEffect, err := os.ReadFile(output.EffectPaths[0])
+	if err != nil {
+		t.Fatalf("body effect not written: %v", err)
+	}
+	for _, expected := range []string{
+		"#define MTOON_SHADING_SHIFT (-0.3)",
+		"#define MTOON_SHADING_TOONY (0.6)",
+	} {
+		if !strings.Contains(string(bodyEffect), expected) {
+			t.Fatalf("vrm1 effect missing %q:\n%s", expected, bodyEffect)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mu_vrm2pmx/pkg/adapter/io_model/vrm"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+const (
+	morphCoverageJsonFileNameSuffix     = "_morph_coverage.json"
+	morphCoverageMarkdownFileNameSuffix = "_morph_coverage.md"
+	morphCoverageReportVersion          = 1
+	// morphCoverageGroupDepthLimit はグループモーフの頂点数集計で辿る入れ子の上限を表す。
+	morphCoverageGroupDepthLimit = 8
+
+	morphCoverageSourceUnknown = "unknown"
+)
+
+// MorphCoverageStatus は標準モーフの充足状態を表す。
+type MorphCoverageStatus string
+
+const (
+	// MorphCoverageStatusPresent は VRM 定義または morph target 由来で存在することを表す。
+	MorphCoverageStatusPresent MorphCoverageStatus = "present"
+	// MorphCoverageStatusSynthesized は補完規則で合成されたことを表す。
+	MorphCoverageStatusSynthesized MorphCoverageStatus = "synthesized"
+	// MorphCoverageStatusEmpty はモーフは存在するがオフセットが空であることを表す。
+	MorphCoverageStatusEmpty MorphCoverageStatus = "empty"
+	// MorphCoverageStatusMissing はモーフが存在しないことを表す。
+	MorphCoverageStatusMissing MorphCoverageStatus = "missing"
+)
+
+// morphCoverageSynthesizedSources は adapter が記録する生成元のうち補完規則由来の種別を表す。
+var morphCoverageSynthesizedSources = map[string]struct{}{
+	vrm.MorphSourceArkitSynthesis: {},
+	vrm.MorphSourceSpecialEye:     {},
+	vrm.MorphSourceCreateRule:     {},
+	vrm.MorphSourceEdgeFallback:   {},
+	vrm.MorphSourceBoneFallback:   {},
+	vrm.MorphSourceJawFallback:    {},
+	vrm.MorphSourceLinkRule:       {},
+}
+
+// standardMorphCoverageTargets はカバレッジ集計対象とする MMD 標準表情モーフを表す。
+var standardMorphCoverageTargets = []struct {
+	Name  string
+	Panel model.MorphPanel
+}{
+	{Name: "真面目", Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT},
+	{Name: "困る", Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT},
+	{Name: "にこり", Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT},
+	{Name: "怒り", Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT},
+	{Name: "上", Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT},
+	{Name: "下", Panel: model.MORPH_PANEL_EYEBROW_LOWER_LEFT},
+	{Name: "まばたき", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "笑い", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "ウィンク", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "ウィンク右", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "ウィンク２", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "ｳｨﾝｸ２右", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "はぅ", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "なごみ", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "びっくり", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "じと目", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "はちゅ目", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "星目", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "はぁと", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "瞳小", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "瞳大", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "白目", Panel: model.MORPH_PANEL_EYE_UPPER_LEFT},
+	{Name: "あ", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "い", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "う", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "え", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "お", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "ワ", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "▲", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "ω", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "Λ", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "にやり", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "口角下げ", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "口横広げ", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "ぺろり", Panel: model.MORPH_PANEL_LIP_UPPER_RIGHT},
+	{Name: "照れ", Panel: model.MORPH_PANEL_OTHER_LOWER_RIGHT},
+}
+
+// MorphCoverageEntry は標準モーフ1件分の充足状況を表す。
+type MorphCoverageEntry struct {
+	Name          string              `json:"name"`
+	ExpectedPanel string              `json:"expected_panel"`
+	Status        MorphCoverageStatus `json:"status"`
+	// Source は adapter が記録した生成元種別を表す。未存在時は空文字、記録が無い場合は unknown。
+	Source      string `json:"source,omitempty"`
+	MorphIndex  int    `json:"morph_index"`
+	Panel       string `json:"panel,omitempty"`
+	MorphType   string `json:"morph_type,omitempty"`
+	OffsetCount int    `json:"offset_count"`
+	// VertexCount は頂点モーフの頂点数を表す。グループモーフは参照先頂点モーフの和集合で数える。
+	VertexCount int `json:"vertex_count"`
+}
+
+// MorphCoverageEmptyMorph は空のまま保持されたモーフを表す。
+type MorphCoverageEmptyMorph struct {
+	Name       string `json:"name"`
+	MorphIndex int    `json:"morph_index"`
+	Source     string `json:"source"`
+}
+
+// MorphCoverageReport は変換後の MMD 標準モーフ充足状況を表す。
+type MorphCoverageReport struct {
+	Version     int                       `json:"version"`
+	Model       string                    `json:"model"`
+	Present     int                       `json:"present"`
+	Synthesized int                       `json:"synthesized"`
+	Empty       int                       `json:"empty"`
+	Missing     int                       `json:"missing"`
+	Entries     []MorphCoverageEntry      `json:"entries"`
+	EmptyMorphs []MorphCoverageEmptyMorph `json:"empty_morphs"`
+}
+
+// buildMorphCoverageReport は最終モーフ一覧から標準モーフの充足状況を集計する。
+func buildMorphCoverageReport(modelData *ModelData) *MorphCoverageReport {
+	report := &MorphCoverageReport{
+		Version:     morphCoverageReportVersion,
+		Entries:     []MorphCoverageEntry{},
+		EmptyMorphs: []MorphCoverageEmptyMorph{},
+	}
+	if modelData == nil || modelData.Morphs == nil {
+		return report
+	}
+	sources := resolveMorphCoverageSources(modelData)
+	for _, target := range standardMorphCoverageTargets {
+		entry := MorphCoverageEntry{
+			Name:          target.Name,
+			ExpectedPanel: formatMorphRenameMappingPanel(target.Panel),
+			Status:        MorphCoverageStatusMissing,
+			MorphIndex:    -1,
+		}
+		morphData, err := modelData.Morphs.GetByName(target.Name)
+		if err != nil || morphData == nil {
+			report.Missing++
+			report.Entries = append(report.Entries, entry)
+			continue
+		}
+		entry.MorphIndex = morphData.Index()
+		entry.Source = resolveMorphCoverageSource(sources, morphData.Index())
+		entry.Panel = formatMorphRenameMappingPanel(morphData.Panel)
+		entry.MorphType = formatMorphCoverageType(morphData.MorphType)
+		entry.OffsetCount = len(morphData.Offsets)
+		entry.VertexCount = len(collectMorphCoverageVertexSet(modelData, morphData, 0))
+		switch {
+		case len(morphData.Offsets) == 0:
+			entry.Status = MorphCoverageStatusEmpty
+			report.Empty++
+		case isMorphCoverageSynthesizedSource(entry.Source):
+			entry.Status = MorphCoverageStatusSynthesized
+			report.Synthesized++
+		default:
+			entry.Status = MorphCoverageStatusPresent
+			report.Present++
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	for _, morphData := range modelData.Morphs.Values() {
+		if morphData == nil || len(morphData.Offsets) > 0 {
+			continue
+		}
+		report.EmptyMorphs = append(report.EmptyMorphs, MorphCoverageEmptyMorph{
+			Name:       morphData.Name(),
+			MorphIndex: morphData.Index(),
+			Source:     resolveMorphCoverageSource(sources, morphData.Index()),
+		})
+	}
+	return report
+}
+
+// resolveMorphCoverageSources は RawExtensions からモーフ index 別の生成元種別を返す。
+func resolveMorphCoverageSources(modelData *ModelData) map[string]string {
+	sources := map[string]string{}
+	if modelData == nil || modelData.VrmData == nil || modelData.VrmData.RawExtensions == nil {
+		return sources
+	}
+	raw, exists := modelData.VrmData.RawExtensions[warningid.VrmMorphSourceRawExtensionKey]
+	if !exists || len(raw) == 0 {
+		return sources
+	}
+	if err := json.Unmarshal(raw, &sources); err != nil {
+		logMaterialReorderWarn("モーフ生成元情報の解析に失敗しました: err=%v", err)
+		return map[string]string{}
+	}
+	return sources
+}
+
+// resolveMorphCoverageSource はモーフ index の生成元種別を返す。記録が無い場合は unknown を返す。
+func resolveMorphCoverageSource(sources map[string]string, morphIndex int) string {
+	if source, exists := sources[strconv.Itoa(morphIndex)]; exists && strings.TrimSpace(source) != "" {
+		return source
+	}
+	return morphCoverageSourceUnknown
+}
+
+// isMorphCoverageSynthesizedSource は生成元種別が補完規則由来か判定する。
+func isMorphCoverageSynthesizedSource(source string) bool {
+	_, exists := morphCoverageSynthesizedSources[source]
+	return exists
+}
+
+// collectMorphCoverageVertexSet はモーフが動かす頂点集合を返す。グループモーフは参照先を再帰的に辿る。
+func collectMorphCoverageVertexSet(modelData *ModelData, morphData *model.Morph, depth int) map[int]struct{} {
+	vertexSet := map[int]struct{}{}
+	if modelData == nil || modelData.Morphs == nil || morphData == nil || depth > morphCoverageGroupDepthLimit {
+		return vertexSet
+	}
+	for _, rawOffset := range morphData.Offsets {
+		switch offsetData := rawOffset.(type) {
+		case *model.VertexMorphOffset:
+			if offsetData != nil && offsetData.VertexIndex >= 0 {
+				vertexSet[offsetData.VertexIndex] = struct{}{}
+			}
+		case *model.GroupMorphOffset:
+			if offsetData == nil || offsetData.MorphIndex == morphData.Index() {
+				continue
+			}
+			childMorph, err := modelData.Morphs.Get(offsetData.MorphIndex)
+			if err != nil || childMorph == nil {
+				continue
+			}
+			for vertexIndex := range collectMorphCoverageVertexSet(modelData, childMorph, depth+1) {
+				vertexSet[vertexIndex] = struct{}{}
+			}
+		}
+	}
+	return vertexSet
+}
+
+// formatMorphCoverageType はモーフ種別を出力用の名前へ変換する。
+func formatMorphCoverageType(morphType model.MorphType) string {
+	switch morphType {
+	case model.MORPH_TYPE_GROUP:
+		return "group"
+	case model.MORPH_TYPE_VERTEX:
+		return "vertex"
+	case model.MORPH_TYPE_BONE:
+		return "bone"
+	case model.MORPH_TYPE_MATERIAL:
+		return "material"
+	case model.MORPH_TYPE_EXTENDED_UV:
+		return "extended_uv"
+	default:
+		return strconv.Itoa(int(morphType))
+	}
+}
+
+// buildMorphCoverageOutputPaths はPMX保存先からモーフカバレッジのJSON/Markdown保存先を生成する。
+func buildMorphCoverageOutputPaths(outputPath string) (string, string) {
+	trimmed := strings.TrimSpace(outputPath)
+	if trimmed == "" {
+		return "", ""
+	}
+	basePath := strings.TrimSuffix(trimmed, filepath.Ext(trimmed))
+	return basePath + morphCoverageJsonFileNameSuffix, basePath + morphCoverageMarkdownFileNameSuffix
+}
+
+// exportMorphCoverageReport はモーフカバレッジをPMXと同じ場所へJSON/Markdown出力し、保存先を返す。
+func exportMorphCoverageReport(outputPath string, report *MorphCoverageReport) (string, string, error) {
+	jsonPath, markdownPath := buildMorphCoverageOutputPaths(outputPath)
+	if jsonPath == "" || report == nil {
+		return "", "", fmt.Errorf("モーフカバレッジの保存先が未指定です")
+	}
+	report.Model = filepath.Base(outputPath)
+	encoded, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(jsonPath, encoded, outputFileMode); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(markdownPath, []byte(buildMorphCoverageMarkdown(report)), outputFileMode); err != nil {
+		return "", "", err
+	}
+	logMaterialReorderInfo(
+		"モーフカバレッジ出力: present=%d synthesized=%d empty=%d missing=%d path=%s",
+		report.Present,
+		report.Synthesized,
+		report.Empty,
+		report.Missing,
+		jsonPath,
+	)
+	return jsonPath, markdownPath, nil
+}
+
+// buildMorphCoverageMarkdown はモーフカバレッジを Markdown 表へ整形する。
+func buildMorphCoverageMarkdown(report *MorphCoverageReport) string {
+	builder := strings.Builder{}
+	builder.WriteString("# Morph coverage: " + report.Model + "\n\n")
+	builder.WriteString(fmt.Sprintf(
+		"present: %d / synthesized: %d / empty: %d / missing: %d\n\n",
+		report.Present,
+		report.Synthesized,
+		report.Empty,
+		report.Missing,
+	))
+	builder.WriteString("| name | status | source | panel | type | vertices |\n")
+	builder.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, entry := range report.Entries {
+		panel := entry.Panel
+		if panel == "" {
+			panel = entry.ExpectedPanel
+		}
+		builder.WriteString(fmt.Sprintf(
+			"| %s | %s | %s | %s | %s | %d |\n",
+			escapeMorphCoverageMarkdownCell(entry.Name),
+			entry.Status,
+			entry.Source,
+			panel,
+			entry.MorphType,
+			entry.VertexCount,
+		))
+	}
+	if len(report.EmptyMorphs) > 0 {
+		builder.WriteString("\n## Empty morphs\n\n")
+		for _, emptyMorph := range report.EmptyMorphs {
+			builder.WriteString(fmt.Sprintf(
+				"- %s (index=%d, source=%s)\n",
+				escapeMorphCoverageMarkdownCell(emptyMorph.Name),
+				emptyMorph.MorphIndex,
+				emptyMorph.Source,
+			))
+		}
+	}
+	return builder.String()
+}
+
+// escapeMorphCoverageMarkdownCell は Markdown 表のセル区切り文字をエスケープする。
+func escapeMorphCoverageMarkdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", "\\|")
+}
@@ -0,0 +1,88 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// TestBuildMorphCoverageReportClassifiesStandardMorphs は生成元・頂点数・空モーフ判定を検証する。
+func TestBuildMorphCoverageReportClassifiesStandardMorphs(t *testing.T) {
+	modelData := model.NewPmxModel()
+	modelData.VrmData = vrm.NewVrmData()
+	appendCoverageTestMorph(modelData, "まばたき", model.MORPH_PANEL_EYE_UPPER_LEFT, model.MORPH_TYPE_VERTEX, []model.IMorphOffset{
+		&model.VertexMorphOffset{VertexIndex: 0, Position: mmath.Vec3{Vec: r3.Vec{Y: -0.1}}},
+		&model.VertexMorphOffset{VertexIndex: 1, Position: mmath.Vec3{Vec: r3.Vec{Y: -0.1}}},
+	})
+	appendCoverageTestMorph(modelData, "あ頂点", model.MORPH_PANEL_SYSTEM, model.MORPH_TYPE_VERTEX, []model.IMorphOffset{
+		&model.VertexMorphOffset{VertexIndex: 2, Position: mmath.Vec3{Vec: r3.Vec{Y: -0.1}}},
+	})
+	appendCoverageTestMorph(modelData, "あ", model.MORPH_PANEL_LIP_UPPER_RIGHT, model.MORPH_TYPE_GROUP, []model.IMorphOffset{
+		&model.GroupMorphOffset{MorphIndex: 1, MorphFactor: 1.0},
+	})
+	appendCoverageTestMorph(modelData, "照れ", model.MORPH_PANEL_OTHER_LOWER_RIGHT, model.MORPH_TYPE_MATERIAL, []model.IMorphOffset{})
+	modelData.VrmData.RawExtensions = map[string]json.RawMessage{
+		warningid.VrmMorphSourceRawExtensionKey: json.RawMessage(`{"0":"vrm_expression","1":"primitive_target","2":"link_rule","3":"special_eye"}`),
+	}
+
+	report := buildMorphCoverageReport(modelData)
+	entries := map[string]MorphCoverageEntry{}
+	for _, entry := range report.Entries {
+		entries[entry.Name] = entry
+	}
+	if entry := entries["まばたき"]; entry.Status != MorphCoverageStatusPresent || entry.Source != "vrm_expression" || entry.VertexCount != 2 {
+		t.Fatalf("まばたき entry mismatch: %+v", entry)
+	}
+	if entry := entries["あ"]; entry.Status != MorphCoverageStatusSynthesized || entry.MorphType != "group" || entry.VertexCount != 1 || entry.Panel != "lip" {
+		t.Fatalf("あ entry mismatch: %+v", entry)
+	}
+	if entry := entries["照れ"]; entry.Status != MorphCoverageStatusEmpty || entry.Source != "special_eye" {
+		t.Fatalf("照れ entry mismatch: %+v", entry)
+	}
+	if entry := entries["い"]; entry.Status != MorphCoverageStatusMissing || entry.MorphIndex != -1 || entry.ExpectedPanel != "lip" {
+		t.Fatalf("い entry mismatch: %+v", entry)
+	}
+	if report.Present != 1 || report.Synthesized != 1 || report.Empty != 1 || report.Missing != len(standardMorphCoverageTargets)-3 {
+		t.Fatalf("summary mismatch: %+v", report)
+	}
+	if len(report.EmptyMorphs) != 1 || report.EmptyMorphs[0].Name != "照れ" {
+		t.Fatalf("empty morphs mismatch: %+v", report.EmptyMorphs)
+	}
+
+	jsonPath, markdownPath, err := exportMorphCoverageReport(filepath.Join(t.TempDir(), "model.pmx"), report)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if !strings.HasSuffix(jsonPath, "model_morph_coverage.json") || !strings.HasSuffix(markdownPath, "model_morph_coverage.md") {
+		t.Fatalf("output path mismatch: json=%s md=%s", jsonPath, markdownPath)
+	}
+	markdown, err := os.ReadFile(markdownPath)
+	if err != nil {
+		t.Fatalf("failed to read markdown: %v", err)
+	}
+	if !strings.Contains(string(markdown), "| あ | synthesized | link_rule | lip | group | 1 |") {
+		t.Fatalf("markdown row mismatch:\n%s", string(markdown))
+	}
+}
+
+// appendCoverageTestMorph はカバレッジ検証用のモーフを追加する。
+func appendCoverageTestMorph(
+	modelData *ModelData,
+	name string,
+	panel model.MorphPanel,
+	morphType model.MorphType,
+	offsets []model.IMorphOffset,
+) {
+	morphData := &model.Morph{Panel: panel, MorphType: morphType, Offsets: offsets}
+	morphData.SetName(name)
+	modelData.Morphs.AppendRaw(morphData)
+}
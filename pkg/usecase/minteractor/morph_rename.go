@@ -340,7 +340,16 @@ func buildMorphRenameSourceRules(mappings []morphRenameMappingRow) map[string]mo
 
 // applyMorphRenameOnlyBeforeViewer はrename-only対応表に基づきモーフ名・パネルを補正する。
 func applyMorphRenameOnlyBeforeViewer(modelData *ModelData, progressReporter IPrepareProgressReporter) morphRenameSummary {
-	summary := morphRenameSummary{Mappings: len(morphRenameSourceRules)}
+	return applyMorphRenameOnlyBeforeViewerWithRules(modelData, progressReporter, morphRenameSourceRules)
+}
+
+// applyMorphRenameOnlyBeforeViewerWithRules は指定した入力名lookupに基づきモーフ名・パネルを補正する。
+func applyMorphRenameOnlyBeforeViewerWithRules(
+	modelData *ModelData,
+	progressReporter IPrepareProgressReporter,
+	sourceRules map[string]morphRenameRule,
+) morphRenameSummary {
+	summary := morphRenameSummary{Mappings: len(sourceRules)}
 	summary.Targets = resolveMorphRenameTargetCount(modelData)
 
 	reportPrepareProgress(progressReporter, PrepareProgressEvent{
@@ -363,7 +372,7 @@ func applyMorphRenameOnlyBeforeViewer(modelData *ModelData, progressReporter IPr
 		return summary
 	}
 
-	operations, notFound := collectMorphRenameOperations(modelData.Morphs)
+	operations, notFound := collectMorphRenameOperations(modelData.Morphs, sourceRules)
 	summary.NotFound = notFound
 	renamePlanByIndex := buildMorphRenamePlannedFlags(modelData.Morphs, operations)
 	tempRenamed := applyMorphTemporaryRenames(modelData.Morphs, operations, renamePlanByIndex)
@@ -489,10 +498,13 @@ func resolveMorphRenameTargetCount(modelData *ModelData) int {
 }
 
 // collectMorphRenameOperations はモデル内モーフ名と対応表を照合し、操作一覧と未検出件数を返す。
-func collectMorphRenameOperations(morphs *collection.NamedCollection[*model.Morph]) (map[int]morphRenameOperation, int) {
+func collectMorphRenameOperations(
+	morphs *collection.NamedCollection[*model.Morph],
+	sourceRules map[string]morphRenameRule,
+) (map[int]morphRenameOperation, int) {
 	operations := map[int]morphRenameOperation{}
 	if morphs == nil {
-		return operations, len(morphRenameSourceRules)
+		return operations, len(sourceRules)
 	}
 
 	foundSources := map[string]struct{}{}
@@ -505,9 +517,9 @@ func collectMorphRenameOperations(morphs *collection.NamedCollection[*model.Morp
 		if sourceName == "" {
 			continue
 		}
-		rule, exists := morphRenameSourceRules[sourceName]
+		rule, exists := sourceRules[sourceName]
 		if !exists {
-			rule, exists = morphRenameSourceRules[strings.ToLower(sourceName)]
+			rule, exists = sourceRules[strings.ToLower(sourceName)]
 		}
 		if !exists {
 			continue
@@ -520,7 +532,7 @@ func collectMorphRenameOperations(morphs *collection.NamedCollection[*model.Morp
 		}
 		foundSources[sourceName] = struct{}{}
 	}
-	notFound := len(morphRenameSourceRules) - len(foundSources)
+	notFound := len(sourceRules) - len(foundSources)
 	if notFound < 0 {
 		notFound = 0
 	}
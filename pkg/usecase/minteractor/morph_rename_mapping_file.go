@@ -0,0 +1,326 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+)
+
+const (
+	morphRenameMappingDumpFileNameSuffix = "_morph_rename.csv"
+	morphRenameMappingSourceSeparator    = "|"
+)
+
+// morphRenameMappingCsvHeader はモーフ名称対応表CSVの見出し行を表す。
+var morphRenameMappingCsvHeader = []string{"name", "panel", "sources"}
+
+// morphRenameMappingPanelNames は対応表ファイルで受け付けるパネル名を表す。
+var morphRenameMappingPanelNames = map[string]model.MorphPanel{
+	"system":  model.MORPH_PANEL_SYSTEM,
+	"システム":    model.MORPH_PANEL_SYSTEM,
+	"eyebrow": model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
+	"眉":       model.MORPH_PANEL_EYEBROW_LOWER_LEFT,
+	"eye":     model.MORPH_PANEL_EYE_UPPER_LEFT,
+	"目":       model.MORPH_PANEL_EYE_UPPER_LEFT,
+	"lip":     model.MORPH_PANEL_LIP_UPPER_RIGHT,
+	"口":       model.MORPH_PANEL_LIP_UPPER_RIGHT,
+	"other":   model.MORPH_PANEL_OTHER_LOWER_RIGHT,
+	"その他":     model.MORPH_PANEL_OTHER_LOWER_RIGHT,
+}
+
+// morphRenameMappingFileRow は対応表JSONの1行を表す。
+type morphRenameMappingFileRow struct {
+	Name    string   `json:"name"`
+	Panel   string   `json:"panel"`
+	Sources []string `json:"sources"`
+}
+
+// resolveMorphRenameMappings は既定の対応表へ指定ファイルの行を上書き統合した対応表を返す。
+// ファイル未指定時は既定の対応表をそのまま返す。
+func resolveMorphRenameMappings(mappingPath string) ([]morphRenameMappingRow, error) {
+	if strings.TrimSpace(mappingPath) == "" {
+		return morphRenameMappings, nil
+	}
+	overrides, err := loadMorphRenameMappingFile(mappingPath)
+	if err != nil {
+		return nil, err
+	}
+	merged := mergeMorphRenameMappingRows(morphRenameMappings, overrides)
+	logMorphRenameInfo(
+		"モーフ名称対応表読込: path=%s rows=%d merged=%d",
+		mappingPath,
+		len(overrides),
+		len(merged),
+	)
+	return merged, nil
+}
+
+// loadMorphRenameMappingFile は拡張子に応じてJSONまたはCSVの対応表を読み込み、検証する。
+func loadMorphRenameMappingFile(mappingPath string) ([]morphRenameMappingRow, error) {
+	var parse func([]byte) ([]morphRenameMappingRow, error)
+	switch strings.ToLower(filepath.Ext(mappingPath)) {
+	case ".json":
+		parse = parseMorphRenameMappingJson
+	case ".csv":
+		parse = parseMorphRenameMappingCsv
+	default:
+		return nil, fmt.Errorf("未対応のモーフ名称対応表形式です: %s", mappingPath)
+	}
+	data, err := os.ReadFile(mappingPath)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMorphRenameMappingRows(rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// parseMorphRenameMappingJson は name/panel/sources を持つ配列JSONを対応表行へ変換する。
+func parseMorphRenameMappingJson(data []byte) ([]morphRenameMappingRow, error) {
+	fileRows := []morphRenameMappingFileRow{}
+	if err := json.Unmarshal(bytes.TrimPrefix(data, []byte("\ufeff")), &fileRows); err != nil {
+		return nil, fmt.Errorf("モーフ名称対応表JSONの解析に失敗しました: %w", err)
+	}
+	rows := make([]morphRenameMappingRow, 0, len(fileRows))
+	for index, fileRow := range fileRows {
+		panel, err := parseMorphRenameMappingPanel(fileRow.Panel)
+		if err != nil {
+			return nil, fmt.Errorf("モーフ名称対応表 %d 件目: %w", index+1, err)
+		}
+		rows = append(rows, morphRenameMappingRow{
+			Name:    strings.TrimSpace(fileRow.Name),
+			Panel:   panel,
+			Sources: normalizeMorphRenameMappingSources(fileRow.Sources),
+		})
+	}
+	return rows, nil
+}
+
+// parseMorphRenameMappingCsv は name,panel,sources 列のCSVを対応表行へ変換する。
+// sources は "|" 区切りで複数指定でき、先頭の見出し行は省略できる。
+func parseMorphRenameMappingCsv(data []byte) ([]morphRenameMappingRow, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows := []morphRenameMappingRow{}
+	for lineNumber := 1; ; lineNumber++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("モーフ名称対応表CSVの解析に失敗しました: %w", err)
+		}
+		if lineNumber == 1 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), morphRenameMappingCsvHeader[0]) {
+			continue
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("モーフ名称対応表 %d 行目: name,panel,sources の列が不足しています", lineNumber)
+		}
+		panel, err := parseMorphRenameMappingPanel(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("モーフ名称対応表 %d 行目: %w", lineNumber, err)
+		}
+		sources := []string{}
+		if len(record) > 2 {
+			sources = strings.Split(record[2], morphRenameMappingSourceSeparator)
+		}
+		rows = append(rows, morphRenameMappingRow{
+			Name:    strings.TrimSpace(record[0]),
+			Panel:   panel,
+			Sources: normalizeMorphRenameMappingSources(sources),
+		})
+	}
+	return rows, nil
+}
+
+// parseMorphRenameMappingPanel はパネル名または番号をモーフパネルへ変換する。
+func parseMorphRenameMappingPanel(value string) (model.MorphPanel, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+	if panel, exists := morphRenameMappingPanelNames[normalized]; exists {
+		return panel, nil
+	}
+	if number, err := strconv.Atoi(normalized); err == nil {
+		panel := model.MorphPanel(number)
+		if panel >= model.MORPH_PANEL_SYSTEM && panel <= model.MORPH_PANEL_OTHER_LOWER_RIGHT {
+			return panel, nil
+		}
+	}
+	return model.MORPH_PANEL_SYSTEM, fmt.Errorf("未対応のモーフパネル名です: %s", value)
+}
+
+// formatMorphRenameMappingPanel はモーフパネルを対応表ファイル用の名前へ変換する。
+func formatMorphRenameMappingPanel(panel model.MorphPanel) string {
+	switch panel {
+	case model.MORPH_PANEL_SYSTEM:
+		return "system"
+	case model.MORPH_PANEL_EYEBROW_LOWER_LEFT:
+		return "eyebrow"
+	case model.MORPH_PANEL_EYE_UPPER_LEFT:
+		return "eye"
+	case model.MORPH_PANEL_LIP_UPPER_RIGHT:
+		return "lip"
+	case model.MORPH_PANEL_OTHER_LOWER_RIGHT:
+		return "other"
+	default:
+		return strconv.Itoa(int(panel))
+	}
+}
+
+// normalizeMorphRenameMappingSources は入力名の前後空白と空要素を除去する。
+func normalizeMorphRenameMappingSources(sources []string) []string {
+	normalized := make([]string, 0, len(sources))
+	for _, sourceName := range sources {
+		trimmed := strings.TrimSpace(sourceName)
+		if trimmed == "" {
+			continue
+		}
+		normalized = append(normalized, trimmed)
+	}
+	return normalized
+}
+
+// validateMorphRenameMappingRows は対応表行の名称未指定・変換先重複・入力名重複を検証する。
+func validateMorphRenameMappingRows(rows []morphRenameMappingRow) error {
+	errs := []error{}
+	rowByName := map[string]int{}
+	rowBySource := map[string]int{}
+	for index, row := range rows {
+		if row.Name == "" {
+			errs = append(errs, fmt.Errorf("モーフ名称対応表 %d 件目: 変換先モーフ名が未指定です", index+1))
+			continue
+		}
+		if firstIndex, exists := rowByName[row.Name]; exists {
+			errs = append(errs, fmt.Errorf(
+				"モーフ名称対応表 %d 件目: 変換先モーフ名 %s が %d 件目と重複しています",
+				index+1,
+				row.Name,
+				firstIndex+1,
+			))
+			continue
+		}
+		rowByName[row.Name] = index
+		for _, sourceName := range row.Sources {
+			if firstIndex, exists := rowBySource[sourceName]; exists && firstIndex != index {
+				errs = append(errs, fmt.Errorf(
+					"モーフ名称対応表 %d 件目: 入力モーフ名 %s が %d 件目と重複しています",
+					index+1,
+					sourceName,
+					firstIndex+1,
+				))
+				continue
+			}
+			rowBySource[sourceName] = index
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// mergeMorphRenameMappingRows は既定の対応表へ追加・上書き行を統合する。
+// 変換先名が一致する行はパネルと入力名を置き換え、上書き行が持つ入力名は他の行から外す。
+func mergeMorphRenameMappingRows(
+	baseRows []morphRenameMappingRow,
+	overrideRows []morphRenameMappingRow,
+) []morphRenameMappingRow {
+	overrideByName := map[string]morphRenameMappingRow{}
+	claimedSources := map[string]struct{}{}
+	for _, row := range overrideRows {
+		overrideByName[row.Name] = row
+		for _, sourceName := range row.Sources {
+			claimedSources[sourceName] = struct{}{}
+		}
+	}
+
+	merged := make([]morphRenameMappingRow, 0, len(baseRows)+len(overrideRows))
+	appliedNames := map[string]struct{}{}
+	for _, row := range baseRows {
+		if override, exists := overrideByName[row.Name]; exists {
+			merged = append(merged, cloneMorphRenameMappingRow(override))
+			appliedNames[row.Name] = struct{}{}
+			continue
+		}
+		sources := make([]string, 0, len(row.Sources))
+		for _, sourceName := range row.Sources {
+			if _, claimed := claimedSources[strings.TrimSpace(sourceName)]; claimed {
+				continue
+			}
+			sources = append(sources, sourceName)
+		}
+		merged = append(merged, morphRenameMappingRow{Name: row.Name, Panel: row.Panel, Sources: sources})
+	}
+	for _, row := range overrideRows {
+		if _, applied := appliedNames[row.Name]; applied {
+			continue
+		}
+		merged = append(merged, cloneMorphRenameMappingRow(row))
+	}
+	return merged
+}
+
+// cloneMorphRenameMappingRow は入力名スライスを複製した対応表行を返す。
+func cloneMorphRenameMappingRow(row morphRenameMappingRow) morphRenameMappingRow {
+	return morphRenameMappingRow{
+		Name:    row.Name,
+		Panel:   row.Panel,
+		Sources: append([]string(nil), row.Sources...),
+	}
+}
+
+// buildMorphRenameMappingDumpOutputPath はPMX保存先からモーフ名称対応表ダンプの保存先を生成する。
+func buildMorphRenameMappingDumpOutputPath(outputPath string) string {
+	trimmed := strings.TrimSpace(outputPath)
+	if trimmed == "" {
+		return ""
+	}
+	return strings.TrimSuffix(trimmed, filepath.Ext(trimmed)) + morphRenameMappingDumpFileNameSuffix
+}
+
+// exportMorphRenameMappingDump は統合後の対応表を読込形式と同じCSVでPMXと同じ場所へ出力し、保存先を返す。
+func exportMorphRenameMappingDump(outputPath string, rows []morphRenameMappingRow) (string, error) {
+	dumpPath := buildMorphRenameMappingDumpOutputPath(outputPath)
+	if dumpPath == "" {
+		return "", fmt.Errorf("モーフ名称対応表ダンプの保存先が未指定です")
+	}
+	var out bytes.Buffer
+	writer := csv.NewWriter(&out)
+	if err := writer.Write(morphRenameMappingCsvHeader); err != nil {
+		return "", err
+	}
+	for _, row := range rows {
+		record := []string{
+			row.Name,
+			formatMorphRenameMappingPanel(row.Panel),
+			strings.Join(row.Sources, morphRenameMappingSourceSeparator),
+		}
+		if err := writer.Write(record); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(dumpPath, out.Bytes(), outputFileMode); err != nil {
+		return "", err
+	}
+	logMorphRenameInfo("モーフ名称対応表ダンプ出力: rows=%d path=%s", len(rows), dumpPath)
+	return dumpPath, nil
+}
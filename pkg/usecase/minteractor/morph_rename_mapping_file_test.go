@@ -0,0 +1,122 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+)
+
+// TestResolveMorphRenameMappingsMergesCsvOverrides はCSV行による上書き・追加と入力名の付け替えを検証する。
+func TestResolveMorphRenameMappingsMergesCsvOverrides(t *testing.T) {
+	mappingPath := filepath.Join(t.TempDir(), "vendor.csv")
+	content := "\ufeffname,panel,sources\n" +
+		"喜,lip,smile|Vendor_Smile\n" +
+		"悲しみ,other,sad\n"
+	if err := os.WriteFile(mappingPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write mapping file: %v", err)
+	}
+
+	merged, err := resolveMorphRenameMappings(mappingPath)
+	if err != nil {
+		t.Fatalf("resolve mappings failed: %v", err)
+	}
+	if len(merged) != len(morphRenameMappings)+1 {
+		t.Fatalf("merged row count mismatch: got=%d want=%d", len(merged), len(morphRenameMappings)+1)
+	}
+	rules := buildMorphRenameSourceRules(merged)
+	if rule := rules["smile"]; rule.Name != "喜" || rule.Panel != model.MORPH_PANEL_LIP_UPPER_RIGHT {
+		t.Fatalf("override rule mismatch: %+v", rule)
+	}
+	if _, exists := rules["happy"]; exists {
+		t.Fatalf("overridden row should replace default sources")
+	}
+	if rule := rules["sad"]; rule.Name != "悲しみ" {
+		t.Fatalf("claimed source should move to added row: %+v", rule)
+	}
+	if rule := rules["sorrow"]; rule.Name != "哀" {
+		t.Fatalf("unclaimed default source should stay: %+v", rule)
+	}
+	if morphRenameSourceRules["happy"].Name != "喜" {
+		t.Fatalf("default table should not be modified")
+	}
+
+	modelData := model.NewPmxModel()
+	appendMorphForRenameTest(modelData, "Vendor_Smile", model.MORPH_PANEL_SYSTEM, "Vendor_Smile")
+	appendMorphForRenameTest(modelData, "sad", model.MORPH_PANEL_SYSTEM, "sad")
+	summary := applyMorphRenameOnlyBeforeViewerWithRules(modelData, &morphRenameProgressCollector{}, rules)
+	if summary.Renamed != 2 || summary.Mappings != len(rules) {
+		t.Fatalf("summary mismatch: %+v", summary)
+	}
+	smileMorph, _ := modelData.Morphs.Get(0)
+	if smileMorph.Name() != "喜" || smileMorph.Panel != model.MORPH_PANEL_LIP_UPPER_RIGHT {
+		t.Fatalf("vendor morph mismatch: name=%s panel=%d", smileMorph.Name(), smileMorph.Panel)
+	}
+
+	dumpPath, err := exportMorphRenameMappingDump(filepath.Join(t.TempDir(), "model.pmx"), merged)
+	if err != nil {
+		t.Fatalf("dump failed: %v", err)
+	}
+	if !strings.HasSuffix(dumpPath, "model_morph_rename.csv") {
+		t.Fatalf("dump path mismatch: %s", dumpPath)
+	}
+	reloaded, err := loadMorphRenameMappingFile(dumpPath)
+	if err != nil {
+		t.Fatalf("dump should be reloadable: %v", err)
+	}
+	if len(reloaded) != len(merged) || reloaded[len(reloaded)-1].Name != "悲しみ" {
+		t.Fatalf("reloaded dump mismatch: rows=%d", len(reloaded))
+	}
+}
+
+// TestLoadMorphRenameMappingFileValidatesRows はJSON行の重複とパネル名を検証する。
+func TestLoadMorphRenameMappingFileValidatesRows(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			content: `[{"name":"まばたき改","panel":"目","sources":["blink_vendor"]},{"name":"眉上","panel":"1","sources":["browUp"]}]`,
+		},
+		{
+			name:    "duplicate_target",
+			content: `[{"name":"喜","panel":"other","sources":["a1"]},{"name":"喜","panel":"other","sources":["a2"]}]`,
+			wantErr: "変換先モーフ名 喜",
+		},
+		{
+			name:    "duplicate_source",
+			content: `[{"name":"喜","panel":"other","sources":["smile"]},{"name":"楽","panel":"other","sources":["smile"]}]`,
+			wantErr: "入力モーフ名 smile",
+		},
+		{
+			name:    "unknown_panel",
+			content: `[{"name":"喜","panel":"face","sources":["smile"]}]`,
+			wantErr: "未対応のモーフパネル名です: face",
+		},
+	}
+	for _, testCase := range testCases {
+		mappingPath := filepath.Join(dir, testCase.name+".json")
+		if err := os.WriteFile(mappingPath, []byte(testCase.content), 0o644); err != nil {
+			t.Fatalf("%s: failed to write mapping file: %v", testCase.name, err)
+		}
+		rows, err := loadMorphRenameMappingFile(mappingPath)
+		if testCase.wantErr == "" {
+			if err != nil || len(rows) != 2 || rows[1].Panel != model.MORPH_PANEL_EYEBROW_LOWER_LEFT {
+				t.Fatalf("%s: unexpected result: rows=%+v err=%v", testCase.name, rows, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), testCase.wantErr) {
+			t.Fatalf("%s: error mismatch: got=%v want=%s", testCase.name, err, testCase.wantErr)
+		}
+	}
+	if _, err := loadMorphRenameMappingFile(filepath.Join(dir, "mapping.txt")); err == nil {
+		t.Fatalf("unsupported extension should fail")
+	}
+}
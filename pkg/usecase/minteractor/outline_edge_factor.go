@@ -0,0 +1,155 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	outlineWidthTextureChannelRed = "r"
+)
+
+// applyOutlineWidthTextureEdgeFactors は outline 幅テクスチャを頂点UVで参照し、頂点のエッジ倍率へ書き込む。
+// 材質のエッジサイズは変更しないため、エッジ材質複製の押し出し量と受け入れ統計には影響しない。
+func applyOutlineWidthTextureEdgeFactors(textureDir string, modelData *ModelData) {
+	if modelData == nil || modelData.Materials == nil || modelData.Textures == nil || modelData.Vertices == nil {
+		return
+	}
+	sources := resolveMaterialCompanionSources(modelData)
+	if len(sources) == 0 {
+		return
+	}
+	faceRanges, err := buildMaterialFaceRanges(modelData)
+	if err != nil {
+		logMaterialReorderWarn("outline幅テクスチャの面範囲解決に失敗しました: err=%v", err)
+		return
+	}
+
+	imageCache := map[int]image.Image{}
+	assignedVertices := map[int]float64{}
+	appliedMaterialCount := 0
+	for materialIndex, materialData := range modelData.Materials.Values() {
+		if materialData == nil || materialIndex >= len(faceRanges) {
+			continue
+		}
+		sourceIndexText, ok := resolveMaterialMemoTokenValue(materialData, materialSourceMemoKey)
+		if !ok {
+			continue
+		}
+		source, exists := sources[sourceIndexText]
+		if !exists || source.OutlineWidthMultiplyTexture == nil {
+			continue
+		}
+		if source.OutlineWidthMultiplyTexture.TexCoord != 0 {
+			logMaterialReorderWarn(
+				"outline幅テクスチャのUV指定が未対応のため頂点エッジ倍率を更新しません: material=%d texCoord=%d",
+				materialIndex,
+				source.OutlineWidthMultiplyTexture.TexCoord,
+			)
+			continue
+		}
+		textureIndex := source.OutlineWidthMultiplyTexture.TextureIndex
+		widthImage, cached := imageCache[textureIndex]
+		if !cached {
+			widthImage = loadOutlineWidthTextureImage(textureDir, modelData, textureIndex)
+			imageCache[textureIndex] = widthImage
+		}
+		if widthImage == nil {
+			continue
+		}
+		if applyOutlineWidthTextureToMaterialVertices(
+			modelData,
+			faceRanges[materialIndex],
+			widthImage,
+			source.OutlineWidthTextureChannel,
+			assignedVertices,
+		) > 0 {
+			appliedMaterialCount++
+		}
+	}
+	if appliedMaterialCount > 0 {
+		logMaterialReorderInfo(
+			"outline幅テクスチャ適用: materials=%d vertices=%d",
+			appliedMaterialCount,
+			len(assignedVertices),
+		)
+	}
+}
+
+// loadOutlineWidthTextureImage は outline 幅テクスチャ画像を返す。読込失敗時は nil を返す。
+func loadOutlineWidthTextureImage(textureDir string, modelData *ModelData, textureIndex int) image.Image {
+	textureData, err := modelData.Textures.Get(textureIndex)
+	if err != nil || textureData == nil || !textureData.IsValid() {
+		logMaterialReorderWarn("outline幅テクスチャが見つかりません: texture=%d", textureIndex)
+		return nil
+	}
+	widthImage, err := loadGeneratedSphereImageByTextureName(textureDir, textureData.Name())
+	if err != nil {
+		logMaterialReorderWarn(
+			"outline幅テクスチャの読込に失敗しました: texture=%s err=%v",
+			strconv.Quote(textureData.Name()),
+			err,
+		)
+		return nil
+	}
+	return widthImage
+}
+
+// applyOutlineWidthTextureToMaterialVertices は材質面が参照する頂点へ outline 幅テクスチャの値を設定し、更新数を返す。
+// 複数材質で共有される頂点は材質の処理順に依らず、各材質で得た倍率の最小値を採用する。
+func applyOutlineWidthTextureToMaterialVertices(
+	modelData *ModelData,
+	faceRange materialFaceRange,
+	widthImage image.Image,
+	channel string,
+	assignedVertices map[int]float64,
+) int {
+	updatedCount := 0
+	for faceIndex := faceRange.start; faceIndex < faceRange.start+faceRange.count; faceIndex++ {
+		face, err := modelData.Faces.Get(faceIndex)
+		if err != nil || face == nil {
+			continue
+		}
+		for _, vertexIndex := range face.VertexIndexes {
+			vertex, getErr := modelData.Vertices.Get(vertexIndex)
+			if getErr != nil || vertex == nil {
+				continue
+			}
+			edgeFactor := sampleOutlineWidthTexture(widthImage, vertex.Uv.X, vertex.Uv.Y, channel)
+			if assignedFactor, assigned := assignedVertices[vertexIndex]; assigned {
+				if assignedFactor <= edgeFactor {
+					continue
+				}
+			}
+			vertex.EdgeFactor = edgeFactor
+			assignedVertices[vertexIndex] = edgeFactor
+			updatedCount++
+		}
+	}
+	return updatedCount
+}
+
+// sampleOutlineWidthTexture はUV位置の画素を最近傍で取得し、指定チャンネルを 0..1 の倍率で返す。
+// UVのタイル外は折り返す。
+func sampleOutlineWidthTexture(widthImage image.Image, u float64, v float64, channel string) float64 {
+	bounds := widthImage.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	if width <= 0 || height <= 0 {
+		return 1.0
+	}
+	x := int(math.Floor(u * float64(width)))
+	y := int(math.Floor(v * float64(height)))
+	x = ((x % width) + width) % width
+	y = ((y % height) + height) % height
+	pixel := color.NRGBAModel.Convert(widthImage.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+	if strings.EqualFold(strings.TrimSpace(channel), outlineWidthTextureChannelRed) {
+		return float64(pixel.R) / 255.0
+	}
+	// VRM1 の outlineWidthMultiplyTexture は G チャンネルを参照する。
+	return float64(pixel.G) / 255.0
+}
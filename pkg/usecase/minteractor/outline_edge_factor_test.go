@@ -0,0 +1,185 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+func TestApplyOutlineWidthTextureEdgeFactorsSamplesChannelPerVertex(t *testing.T) {
+	texDir := t.TempDir()
+	// 左半分は R=1/G=0、右半分は R=0/G=1 の幅テクスチャ。
+	widthImage := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	widthImage.SetNRGBA(0, 0, color.NRGBA{R: 0xff, G: 0x00, A: 0xff})
+	widthImage.SetNRGBA(1, 0, color.NRGBA{R: 0x00, G: 0xff, A: 0xff})
+	widthFile, err := os.Create(filepath.Join(texDir, "width.png"))
+	if err != nil {
+		t.Fatalf("failed to create width texture: %v", err)
+	}
+	if err := png.Encode(widthFile, widthImage); err != nil {
+		_ = widthFile.Close()
+		t.Fatalf("failed to encode width texture: %v", err)
+	}
+	_ = widthFile.Close()
+
+	modelData := model.NewPmxModel()
+	modelData.VrmData = &vrm.VrmData{
+		RawExtensions: map[string]json.RawMessage{},
+	}
+	texture := model.NewTexture()
+	texture.SetName("tex/width.png")
+	texture.TextureType = model.TEXTURE_TYPE_TEXTURE
+	texture.SetValid(true)
+	modelData.Textures.AppendRaw(texture)
+
+	uvs := []mmath.Vec2{{X: 0.25, Y: 0.5}, {X: 0.75, Y: 0.5}, {X: 1.25, Y: 0.5}}
+	for materialIndex := 0; materialIndex < 3; materialIndex++ {
+		vertexStart := modelData.Vertices.Len()
+		for _, uv := range uvs {
+			modelData.Vertices.AppendRaw(&model.Vertex{
+				Uv:              uv,
+				ExtendedUvs:     []mmath.Vec4{},
+				EdgeFactor:      1.0,
+				MaterialIndexes: []int{materialIndex},
+			})
+		}
+		modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{vertexStart, vertexStart + 1, vertexStart + 2}})
+	}
+	vrm1Material := newMaterial("Vrm1", 1.0, 3)
+	vrm1Material.EdgeSize = 0.5
+	vrm1Material.Memo = "VRM primitive gltfMaterial=0"
+	modelData.Materials.AppendRaw(vrm1Material)
+	vrm0Material := newMaterial("Vrm0", 1.0, 3)
+	vrm0Material.EdgeSize = 0.5
+	vrm0Material.Memo = "VRM primitive gltfMaterial=1"
+	modelData.Materials.AppendRaw(vrm0Material)
+	plainMaterial := newMaterial("Plain", 1.0, 3)
+	plainMaterial.EdgeSize = 0.5
+	plainMaterial.Memo = "VRM primitive gltfMaterial=2"
+	modelData.Materials.AppendRaw(plainMaterial)
+
+	sourcesRaw, err := json.Marshal(map[string]materialCompanionSource{
+		"0": {
+			Name:                        "Vrm1",
+			OutlineWidthMultiplyTexture: &materialCompanionTexture{TextureIndex: 0},
+			OutlineWidthTextureChannel:  "g",
+		},
+		"1": {
+			Name:                        "Vrm0",
+			OutlineWidthMultiplyTexture: &materialCompanionTexture{TextureIndex: 0},
+			OutlineWidthTextureChannel:  "r",
+		},
+		"2": {Name: "Plain"},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal companion sources: %v", err)
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmMaterialCompanionRawExtensionKey] = sourcesRaw
+
+	applyOutlineWidthTextureEdgeFactors(texDir, modelData)
+
+	expected := []float64{
+		0.0, 1.0, 0.0,
+		1.0, 0.0, 1.0,
+		1.0, 1.0, 1.0,
+	}
+	for vertexIndex, want := range expected {
+		vertex, getErr := modelData.Vertices.Get(vertexIndex)
+		if getErr != nil || vertex == nil {
+			t.Fatalf("vertex not found: index=%d err=%v", vertexIndex, getErr)
+		}
+		if math.Abs(vertex.EdgeFactor-want) > 1e-9 {
+			t.Fatalf("edge factor mismatch: index=%d got=%f want=%f", vertexIndex, vertex.EdgeFactor, want)
+		}
+	}
+	for materialIndex, materialData := range modelData.Materials.Values() {
+		if materialData.EdgeSize != 0.5 {
+			t.Fatalf("material edge size should stay unchanged: index=%d got=%f", materialIndex, materialData.EdgeSize)
+		}
+	}
+}
+
+func TestApplyOutlineWidthTextureEdgeFactorsUsesMinimumForSharedVertices(t *testing.T) {
+	texDir := t.TempDir()
+	// R=1/G=0 の単色幅テクスチャ。
+	widthImage := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	widthImage.SetNRGBA(0, 0, color.NRGBA{R: 0xff, G: 0x00, A: 0xff})
+	widthFile, err := os.Create(filepath.Join(texDir, "width.png"))
+	if err != nil {
+		t.Fatalf("failed to create width texture: %v", err)
+	}
+	if err := png.Encode(widthFile, widthImage); err != nil {
+		_ = widthFile.Close()
+		t.Fatalf("failed to encode width texture: %v", err)
+	}
+	_ = widthFile.Close()
+
+	modelData := model.NewPmxModel()
+	modelData.VrmData = &vrm.VrmData{
+		RawExtensions: map[string]json.RawMessage{},
+	}
+	texture := model.NewTexture()
+	texture.SetName("tex/width.png")
+	texture.TextureType = model.TEXTURE_TYPE_TEXTURE
+	texture.SetValid(true)
+	modelData.Textures.AppendRaw(texture)
+	for vertexIndex := 0; vertexIndex < 4; vertexIndex++ {
+		modelData.Vertices.AppendRaw(&model.Vertex{
+			Uv:              mmath.Vec2{X: 0.5, Y: 0.5},
+			ExtendedUvs:     []mmath.Vec4{},
+			EdgeFactor:      1.0,
+			MaterialIndexes: []int{0},
+		})
+	}
+	// 頂点1,2は両材質の面で共有される。
+	modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{0, 1, 2}})
+	modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{1, 2, 3}})
+	thickMaterial := newMaterial("Thick", 1.0, 3)
+	thickMaterial.Memo = "VRM primitive gltfMaterial=0"
+	modelData.Materials.AppendRaw(thickMaterial)
+	thinMaterial := newMaterial("Thin", 1.0, 3)
+	thinMaterial.Memo = "VRM primitive gltfMaterial=1"
+	modelData.Materials.AppendRaw(thinMaterial)
+
+	sourcesRaw, err := json.Marshal(map[string]materialCompanionSource{
+		"0": {
+			Name:                        "Thick",
+			OutlineWidthMultiplyTexture: &materialCompanionTexture{TextureIndex: 0},
+			OutlineWidthTextureChannel:  "r",
+		},
+		"1": {
+			Name:                        "Thin",
+			OutlineWidthMultiplyTexture: &materialCompanionTexture{TextureIndex: 0},
+			OutlineWidthTextureChannel:  "g",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal companion sources: %v", err)
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmMaterialCompanionRawExtensionKey] = sourcesRaw
+
+	applyOutlineWidthTextureEdgeFactors(texDir, modelData)
+
+	// 共有頂点は先に処理した材質ではなく、細い側の倍率を採用する。
+	expected := []float64{1.0, 0.0, 0.0, 0.0}
+	for vertexIndex, want := range expected {
+		vertex, getErr := modelData.Vertices.Get(vertexIndex)
+		if getErr != nil || vertex == nil {
+			t.Fatalf("vertex not found: index=%d err=%v", vertexIndex, getErr)
+		}
+		if math.Abs(vertex.EdgeFactor-want) > 1e-9 {
+			t.Fatalf("edge factor mismatch: index=%d got=%f want=%f", vertexIndex, vertex.EdgeFactor, want)
+		}
+	}
+}
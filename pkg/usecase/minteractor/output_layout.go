@@ -19,6 +19,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ftrvxmtrx/tga"
 	"github.com/miu200521358/mlib_go/pkg/domain/model"
 	"github.com/miu200521358/mu_vrm2pmx/pkg/adapter/io_model/vrm"
 	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
@@ -40,6 +41,7 @@ var (
 	generatedHairSphereName  = regexp.MustCompile(`^hair_sphere_[0-9]{2}\.png$`)
 	generatedMatcapSphere    = regexp.MustCompile(`^sphere/matcap_sphere_[0-9]{3}\.png$`)
 	generatedEmissiveSphere  = regexp.MustCompile(`^sphere/emissive_sphere_[0-9]{3}\.png$`)
+	generatedRimSphere       = regexp.MustCompile(`^sphere/rim_sphere_[0-9]{3}\.png$`)
 	generatedTextureNumber   = regexp.MustCompile(`_(\d+)`)
 )
 
@@ -54,16 +56,33 @@ const (
 	generatedSphereKindHair
 	generatedSphereKindMatcap
 	generatedSphereKindEmissive
+	generatedSphereKindRim
+)
+
+const (
+	generatedRimSphereSize = 128
+	generatedToonSize      = 32
 )
 
 type generatedSphereMetadata struct {
-	SourceTextureIndex int        `json:"source_texture_index"`
-	MaterialIndex      int        `json:"material_index"`
-	SphereKind         string     `json:"sphere_kind"`
-	EmissiveFactor     [3]float64 `json:"emissive_factor"`
-	DiffuseFactor      [4]float64 `json:"diffuse_factor"`
-	HighlightTexture   string     `json:"highlight_texture_name"`
-	BlendTexture       string     `json:"blend_texture_name"`
+	SourceTextureIndex int         `json:"source_texture_index"`
+	MaterialIndex      int         `json:"material_index"`
+	SphereKind         string      `json:"sphere_kind"`
+	EmissiveFactor     [3]float64  `json:"emissive_factor"`
+	DiffuseFactor      [4]float64  `json:"diffuse_factor"`
+	HighlightTexture   string      `json:"highlight_texture_name"`
+	BlendTexture       string      `json:"blend_texture_name"`
+	MatcapFactor       *[3]float64 `json:"matcap_factor"`
+	RimColor           [3]float64  `json:"rim_color"`
+	RimFresnelPower    float64     `json:"rim_fresnel_power"`
+	RimLift            float64     `json:"rim_lift"`
+}
+
+// generatedToonRamp は生成toonの陰影境界を表す。
+type generatedToonRamp struct {
+	LowerBound float64 `json:"lower_bound"`
+	UpperBound float64 `json:"upper_bound"`
+	Smooth     bool    `json:"smooth"`
 }
 
 // BuildDefaultOutputPath は入力VRMパスから既定のPMX出力パスを生成する。
@@ -84,14 +103,26 @@ func buildDefaultOutputPathAt(inputPath string, now time.Time) string {
 	return filepath.Join(outDir, base+".pmx")
 }
 
+// outputLayoutOptions は出力レイアウト準備時の任意設定を表す。
+type outputLayoutOptions struct {
+	ThresholdMaskTextures bool
+	TextureExport         vrm.TextureExportOptions
+}
+
 // prepareOutputLayout は出力先レイアウトを準備し、補助出力を生成する。
-func prepareOutputLayout(inputPath string, outputPath string, modelData *ModelData) error {
+// TextureExport は埋め込み画像の抽出にのみ適用し、以降で生成する補助テクスチャはPNGで出力する。
+func prepareOutputLayout(
+	inputPath string,
+	outputPath string,
+	modelData *ModelData,
+	options outputLayoutOptions,
+) error {
 	texDir, gltfDir, err := createOutputDirs(outputPath)
 	if err != nil {
 		return err
 	}
 
-	artifacts, err := vrm.ExportArtifacts(inputPath, gltfDir, texDir)
+	artifacts, err := vrm.ExportArtifactsWithOptions(inputPath, gltfDir, texDir, options.TextureExport)
 	if err != nil {
 		return err
 	}
@@ -104,6 +135,11 @@ func prepareOutputLayout(inputPath string, outputPath string, modelData *ModelDa
 	applyTextureOutputPaths(modelData, artifacts.TextureNames)
 	exportGeneratedToonTextures(texDir, modelData)
 	exportGeneratedSphereTextures(texDir, modelData)
+	applyOutlineWidthTextureEdgeFactors(texDir, modelData)
+	exportVertexColorTextures(texDir, modelData)
+	if options.ThresholdMaskTextures {
+		exportMaskThresholdTextures(texDir, modelData)
+	}
 	return nil
 }
 
@@ -159,6 +195,7 @@ func exportGeneratedToonTextures(textureDir string, modelData *ModelData) {
 	}
 
 	toonShadeColorMap := resolveGeneratedToonShadeColorMap(modelData)
+	toonRampMap := resolveGeneratedToonRampMap(modelData)
 	for _, textureData := range modelData.Textures.Values() {
 		if textureData == nil || textureData.TextureType != model.TEXTURE_TYPE_TOON {
 			continue
@@ -177,7 +214,13 @@ func exportGeneratedToonTextures(textureDir string, modelData *ModelData) {
 			B: mappedShadeColor[2],
 			A: 0xff,
 		}
-		toonBytes, err := buildGeneratedToonBmp32(shadeColor)
+		var toonBytes []byte
+		var err error
+		if ramp, hasRamp := toonRampMap[fileName]; hasRamp {
+			toonBytes, err = buildGeneratedToonRampBmp32(shadeColor, ramp)
+		} else {
+			toonBytes, err = buildGeneratedToonBmp32(shadeColor)
+		}
 		if err != nil {
 			continue
 		}
@@ -265,6 +308,8 @@ func buildGeneratedSphereTextureBytes(
 		return buildGeneratedHairSpherePng(textureDir, modelData, sphereMetadata)
 	case generatedSphereKindMatcap, generatedSphereKindEmissive:
 		return buildGeneratedSourceSpherePng(textureDir, modelData, sphereKind, sphereMetadata)
+	case generatedSphereKindRim:
+		return buildGeneratedRimSpherePng(sphereMetadata)
 	default:
 		return nil, errGeneratedSphereSourceMissing
 	}
@@ -611,6 +656,8 @@ func decodeGeneratedSphereSourceImage(sourceFile *os.File, sourceTexturePath str
 		return jpeg.Decode(sourceFile)
 	case ".gif":
 		return gif.Decode(sourceFile)
+	case ".tga":
+		return tga.Decode(sourceFile)
 	default:
 		return nil, fmt.Errorf("unsupported generated sphere source format: %s", filepath.Ext(sourceTexturePath))
 	}
@@ -704,6 +751,32 @@ func resolveGeneratedToonShadeColorMap(modelData *ModelData) map[string][3]uint8
 	return toonShadeColorMap
 }
 
+// resolveGeneratedToonRampMap は生成toonの陰影境界マップを RawExtensions から復元する。
+func resolveGeneratedToonRampMap(modelData *ModelData) map[string]generatedToonRamp {
+	toonRampMap := map[string]generatedToonRamp{}
+	if modelData == nil || modelData.VrmData == nil || modelData.VrmData.RawExtensions == nil {
+		return toonRampMap
+	}
+
+	rawRampMap, exists := modelData.VrmData.RawExtensions[warningid.VrmGeneratedToonRampMapRawExtensionKey]
+	if !exists || len(rawRampMap) == 0 {
+		return toonRampMap
+	}
+
+	decodedRampMap := map[string]generatedToonRamp{}
+	if err := json.Unmarshal(rawRampMap, &decodedRampMap); err != nil {
+		return toonRampMap
+	}
+	for rawFileName, ramp := range decodedRampMap {
+		fileName, ok := resolveGeneratedToonFileName(rawFileName)
+		if !ok {
+			continue
+		}
+		toonRampMap[fileName] = ramp
+	}
+	return toonRampMap
+}
+
 // resolveGeneratedToonFileName は生成toonの出力対象ファイル名を解決する。
 func resolveGeneratedToonFileName(textureName string) (string, bool) {
 	normalizedTextureName := strings.ToLower(filepath.ToSlash(strings.TrimSpace(textureName)))
@@ -736,6 +809,8 @@ func resolveGeneratedSphereRelativePath(textureName string) (string, generatedSp
 		return normalizedTextureName, generatedSphereKindMatcap, true
 	case generatedEmissiveSphere.MatchString(normalizedTextureName):
 		return normalizedTextureName, generatedSphereKindEmissive, true
+	case generatedRimSphere.MatchString(normalizedTextureName):
+		return normalizedTextureName, generatedSphereKindRim, true
 	default:
 		return "", generatedSphereKindUnknown, false
 	}
@@ -762,6 +837,79 @@ func buildGeneratedToonBmp32(lowerColor color.RGBA) ([]byte, error) {
 	return out.Bytes(), nil
 }
 
+// buildGeneratedToonRampBmp32 は MToon 陰影境界に沿った 32x32 toon BMP を生成する。
+// MMD は toon の上端を受光側、下端を影側として参照するため、行ごとに NdotL を 1→-1 へ割り当てる。
+func buildGeneratedToonRampBmp32(shadeColor color.RGBA, ramp generatedToonRamp) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, generatedToonSize, generatedToonSize))
+	for y := 0; y < generatedToonSize; y++ {
+		nDotL := 1.0 - 2.0*(float64(y)+0.5)/float64(generatedToonSize)
+		litRate := resolveGeneratedToonLitRate(nDotL, ramp)
+		lineColor := color.RGBA{
+			R: lerpGeneratedColor8(shadeColor.R, 0xff, litRate),
+			G: lerpGeneratedColor8(shadeColor.G, 0xff, litRate),
+			B: lerpGeneratedColor8(shadeColor.B, 0xff, litRate),
+			A: 0xff,
+		}
+		for x := 0; x < generatedToonSize; x++ {
+			img.SetRGBA(x, y, lineColor)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := bmp.Encode(&out, img); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// resolveGeneratedToonLitRate は NdotL に対する受光率(0..1)を返す。
+func resolveGeneratedToonLitRate(nDotL float64, ramp generatedToonRamp) float64 {
+	width := ramp.UpperBound - ramp.LowerBound
+	if width <= 1e-6 {
+		if nDotL >= ramp.LowerBound {
+			return 1.0
+		}
+		return 0.0
+	}
+	rate := math.Max(0.0, math.Min(1.0, (nDotL-ramp.LowerBound)/width))
+	if ramp.Smooth {
+		return rate * rate * (3.0 - 2.0*rate)
+	}
+	return rate
+}
+
+// lerpGeneratedColor8 は 8bit 色を線形補間する。
+func lerpGeneratedColor8(from uint8, to uint8, rate float64) uint8 {
+	value := float64(from) + (float64(to)-float64(from))*rate
+	return uint8(math.Max(0, math.Min(255, math.Round(value))))
+}
+
+// buildGeneratedRimSpherePng は parametric rim を加算 sphere PNG として生成する。
+func buildGeneratedRimSpherePng(sphereMetadata generatedSphereMetadata) ([]byte, error) {
+	fresnelPower := math.Max(sphereMetadata.RimFresnelPower, 1e-6)
+	sphereImage := image.NewRGBA(image.Rect(0, 0, generatedRimSphereSize, generatedRimSphereSize))
+	for y := 0; y < generatedRimSphereSize; y++ {
+		for x := 0; x < generatedRimSphereSize; x++ {
+			nx := (float64(x)+0.5)/float64(generatedRimSphereSize)*2.0 - 1.0
+			ny := 1.0 - (float64(y)+0.5)/float64(generatedRimSphereSize)*2.0
+			nz := math.Sqrt(math.Max(0.0, 1.0-nx*nx-ny*ny))
+			rim := math.Pow(math.Max(0.0, math.Min(1.0, 1.0-nz+sphereMetadata.RimLift)), fresnelPower)
+			sphereImage.SetRGBA(x, y, color.RGBA{
+				R: lerpGeneratedColor8(0, 0xff, sphereMetadata.RimColor[0]*rim),
+				G: lerpGeneratedColor8(0, 0xff, sphereMetadata.RimColor[1]*rim),
+				B: lerpGeneratedColor8(0, 0xff, sphereMetadata.RimColor[2]*rim),
+				A: 0xff,
+			})
+		}
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, sphereImage); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
 // buildGeneratedSourceSpherePng は source テクスチャ由来の sphere PNG を生成する。
 func buildGeneratedSourceSpherePng(
 	textureDir string,
@@ -807,6 +955,16 @@ func resolveGeneratedSphereColorFactor(
 			sphereMetadata.EmissiveFactor[2],
 			1.0,
 		}
+	case generatedSphereKindMatcap:
+		if sphereMetadata.MatcapFactor == nil {
+			return [4]float64{1.0, 1.0, 1.0, 1.0}
+		}
+		return [4]float64{
+			sphereMetadata.MatcapFactor[0],
+			sphereMetadata.MatcapFactor[1],
+			sphereMetadata.MatcapFactor[2],
+			1.0,
+		}
 	default:
 		return [4]float64{1.0, 1.0, 1.0, 1.0}
 	}
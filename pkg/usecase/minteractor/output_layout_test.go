@@ -104,6 +104,85 @@ func TestExportGeneratedToonTexturesUsesShadeColorMapPerTexture(t *testing.T) {
 	}
 }
 
+func TestExportGeneratedToonTexturesUsesToonRampAndRimSphere(t *testing.T) {
+	texDir := t.TempDir()
+	modelData := model.NewPmxModel()
+	modelData.VrmData = &modelvrm.VrmData{
+		RawExtensions: map[string]json.RawMessage{},
+	}
+
+	appendTexture := func(name string, textureType model.TextureType) {
+		texture := model.NewTexture()
+		texture.SetName(name)
+		texture.EnglishName = name
+		texture.TextureType = textureType
+		texture.SetValid(true)
+		modelData.Textures.AppendRaw(texture)
+	}
+	appendTexture("tex/toon01.bmp", model.TEXTURE_TYPE_TOON)
+	appendTexture("tex/sphere/rim_sphere_000.png", model.TEXTURE_TYPE_SPHERE)
+
+	shadeColorMapRaw, err := json.Marshal(map[string][3]uint8{"toon01.bmp": {0x00, 0x00, 0x00}})
+	if err != nil {
+		t.Fatalf("failed to marshal shade color map: %v", err)
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmLegacyGeneratedToonShadeMapRawExtensionKey] = shadeColorMapRaw
+	rampMapRaw, err := json.Marshal(map[string]generatedToonRamp{
+		"toon01.bmp": {LowerBound: -0.5, UpperBound: 0.5},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal toon ramp map: %v", err)
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmGeneratedToonRampMapRawExtensionKey] = rampMapRaw
+	appendGeneratedSphereMetadata(
+		t,
+		modelData,
+		map[string]generatedSphereMetadata{
+			"tex/sphere/rim_sphere_000.png": {
+				SourceTextureIndex: -1,
+				SphereKind:         "rim",
+				RimColor:           [3]float64{1.0, 0.5, 0.0},
+				RimFresnelPower:    1.0,
+			},
+		},
+	)
+
+	exportGeneratedToonTextures(texDir, modelData)
+	exportGeneratedSphereTextures(texDir, modelData)
+
+	toonData, err := os.ReadFile(filepath.Join(texDir, "toon01.bmp"))
+	if err != nil {
+		t.Fatalf("generated toon texture not found: %v", err)
+	}
+	toonImage, err := bmp.Decode(bytes.NewReader(toonData))
+	if err != nil {
+		t.Fatalf("failed to decode generated toon texture: %v", err)
+	}
+	top := color.RGBAModel.Convert(toonImage.At(0, 0)).(color.RGBA)
+	middle := color.RGBAModel.Convert(toonImage.At(0, 16)).(color.RGBA)
+	bottom := color.RGBAModel.Convert(toonImage.At(0, 31)).(color.RGBA)
+	if top.R != 0xff || bottom.R != 0x00 {
+		t.Fatalf("toon ramp ends mismatch: top=%v bottom=%v", top, bottom)
+	}
+	if middle.R <= 0x40 || middle.R >= 0xc0 {
+		t.Fatalf("toon ramp should be graded around NdotL=0: middle=%v", middle)
+	}
+
+	rimData, err := os.ReadFile(filepath.Join(texDir, "sphere", "rim_sphere_000.png"))
+	if err != nil {
+		t.Fatalf("generated rim sphere not found: %v", err)
+	}
+	rimImage, err := png.Decode(bytes.NewReader(rimData))
+	if err != nil {
+		t.Fatalf("failed to decode generated rim sphere: %v", err)
+	}
+	center := color.RGBAModel.Convert(rimImage.At(64, 64)).(color.RGBA)
+	edge := color.RGBAModel.Convert(rimImage.At(64, 0)).(color.RGBA)
+	if center.R > 0x08 || edge.R < 0xc0 || edge.B != 0x00 {
+		t.Fatalf("rim sphere gradient mismatch: center=%v edge=%v", center, edge)
+	}
+}
+
 func TestResolveGeneratedToonFileName(t *testing.T) {
 	testCases := []struct {
 		name      string
@@ -772,6 +851,98 @@ func TestPrepareModelWithExternalVrmPathGeneratesHairSphereAndBlendTextures(t *t
 	}
 }
 
+func TestPrepareOutputLayoutKeepsGeneratedTexturesPngWithTextureExportOptions(t *testing.T) {
+	tempDir := t.TempDir()
+	inPath := filepath.Join(tempDir, "sample.vrm")
+	sourceImage := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 2; y++ {
+			sourceImage.SetNRGBA(x, y, color.NRGBA{R: 0x20, G: 0x40, B: 0x60, A: 0xff})
+		}
+	}
+	var pngBuffer bytes.Buffer
+	if err := png.Encode(&pngBuffer, sourceImage); err != nil {
+		t.Fatalf("failed to encode source texture: %v", err)
+	}
+	pngData := pngBuffer.Bytes()
+	writeGLBForUsecaseTest(t, inPath, map[string]any{
+		"asset": map[string]any{
+			"version": "2.0",
+		},
+		"buffers": []any{
+			map[string]any{
+				"byteLength": len(pngData),
+			},
+		},
+		"bufferViews": []any{
+			map[string]any{
+				"buffer":     0,
+				"byteOffset": 0,
+				"byteLength": len(pngData),
+			},
+		},
+		"images": []any{
+			map[string]any{
+				"name":       "hair",
+				"bufferView": 0,
+				"mimeType":   "image/png",
+			},
+		},
+	}, pngData)
+
+	modelData := model.NewPmxModel()
+	texture := model.NewTexture()
+	texture.SetName("hair.png")
+	texture.TextureType = model.TEXTURE_TYPE_TEXTURE
+	modelData.Textures.AppendRaw(texture)
+	maskMaterial := newMaterial("Hair_MASK", 1.0, 3)
+	maskMaterial.TextureIndex = 0
+	maskMaterial.Memo = "VRM primitive alphaMode=MASK alphaCutoff=0.5"
+	modelData.Materials.AppendRaw(maskMaterial)
+
+	outputPath := filepath.Join(tempDir, "out", "sample.pmx")
+	if err := prepareOutputLayout(inPath, outputPath, modelData, outputLayoutOptions{
+		ThresholdMaskTextures: true,
+		TextureExport: vrm.TextureExportOptions{
+			MaxDimension: 2,
+			Format:       vrm.TextureExportFormatBmp,
+		},
+	}); err != nil {
+		t.Fatalf("prepare output layout failed: %v", err)
+	}
+
+	texDir := filepath.Join(tempDir, "out", "tex")
+	extractedFile, err := os.Open(filepath.Join(texDir, "hair.bmp"))
+	if err != nil {
+		t.Fatalf("extracted texture should follow export format: %v", err)
+	}
+	extractedImage, err := bmp.Decode(extractedFile)
+	_ = extractedFile.Close()
+	if err != nil {
+		t.Fatalf("failed to decode extracted texture: %v", err)
+	}
+	if bounds := extractedImage.Bounds(); bounds.Dx() != 2 || bounds.Dy() != 1 {
+		t.Fatalf("extracted texture should be resized: bounds=%v", bounds)
+	}
+
+	thresholdTexture, err := modelData.Textures.Get(maskMaterial.TextureIndex)
+	if err != nil || thresholdTexture == nil || thresholdTexture.Name() != "tex/mask/hair_cutoff128.png" {
+		t.Fatalf("generated threshold texture should stay png: texture=%v err=%v", thresholdTexture, err)
+	}
+	thresholdFile, err := os.Open(filepath.Join(texDir, "mask", "hair_cutoff128.png"))
+	if err != nil {
+		t.Fatalf("generated threshold texture not written: %v", err)
+	}
+	defer thresholdFile.Close()
+	thresholdImage, err := png.Decode(thresholdFile)
+	if err != nil {
+		t.Fatalf("generated threshold texture should be png: %v", err)
+	}
+	if bounds := thresholdImage.Bounds(); bounds.Dx() != 2 || bounds.Dy() != 1 {
+		t.Fatalf("generated threshold texture should inherit extracted size: bounds=%v", bounds)
+	}
+}
+
 func TestResolveGeneratedSphereRelativePath(t *testing.T) {
 	testCases := []struct {
 		name      string
@@ -6,12 +6,19 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/miu200521358/mu_vrm2pmx/pkg/adapter/io_model/vrm"
 	"github.com/miu200521358/mu_vrm2pmx/pkg/usecase/port/moutput"
 )
 
 // PrepareModel はVRM入力を読み込み、PMX出力用の補助ファイルを準備する。
 // PMX本体ファイルは保存しない。
 func (uc *Vrm2PmxUsecase) PrepareModel(request ConvertRequest) (*ConvertResult, error) {
+	return uc.prepareModel(request, false)
+}
+
+// prepareModel は PrepareModel の本体処理を行う。
+// modelLoadedWithOptions が false の場合、読込済みモデルへ既定以外の読込オプションを指定するとエラーを返す。
+func (uc *Vrm2PmxUsecase) prepareModel(request ConvertRequest, modelLoadedWithOptions bool) (*ConvertResult, error) {
 	if strings.TrimSpace(request.InputPath) == "" {
 		return nil, fmt.Errorf("入力VRMパスが未指定です")
 	}
@@ -27,14 +34,42 @@ func (uc *Vrm2PmxUsecase) PrepareModel(request ConvertRequest) (*ConvertResult,
 		Type: PrepareProgressEventTypeOutputPathResolved,
 	})
 
-	modelData, err := uc.resolveModelData(request.Reader, request.InputPath, request.ModelData)
+	loadOptions, err := resolveVrmLoadOptions(request)
+	if err != nil {
+		return nil, err
+	}
+	if request.ModelData != nil && !modelLoadedWithOptions && !loadOptions.IsDefault() {
+		// 読込オプションはVRM読込時にしか反映できないため、読込済みモデルへの指定は黙って無視せずエラーとする。
+		return nil, fmt.Errorf("読込オプションは読込済みモデルへ適用できません。LoadAndPrepareModelForViewer で読み込んでください")
+	}
+	modelData, err := uc.resolveModelData(request.Reader, request.InputPath, request.ModelData, loadOptions)
 	if err != nil {
 		return nil, err
 	}
 	reportPrepareProgress(request.ProgressReporter, PrepareProgressEvent{
 		Type: PrepareProgressEventTypeModelValidated,
 	})
-	if err := prepareOutputLayout(request.InputPath, outputPath, modelData); err != nil {
+	textureFormat, err := vrm.ParseTextureExportFormat(request.TextureFormat)
+	if err != nil {
+		return nil, err
+	}
+	renameMappings, err := resolveMorphRenameMappings(request.MorphRenameMappingPath)
+	if err != nil {
+		return nil, fmt.Errorf("モーフ名称対応表の読み込みに失敗しました: %w", err)
+	}
+	if err := prepareOutputLayout(
+		request.InputPath,
+		outputPath,
+		modelData,
+		outputLayoutOptions{
+			ThresholdMaskTextures: request.ThresholdMaskTextures,
+			TextureExport: vrm.TextureExportOptions{
+				MaxDimension:  request.TextureMaxDimension,
+				Format:        textureFormat,
+				FlattenOpaque: request.FlattenOpaqueTextures,
+			},
+		},
+	); err != nil {
 		return nil, err
 	}
 	reportPrepareProgress(request.ProgressReporter, PrepareProgressEvent{
@@ -57,9 +92,17 @@ func (uc *Vrm2PmxUsecase) PrepareModel(request ConvertRequest) (*ConvertResult,
 		return nil, fmt.Errorf("材質名略称処理に失敗しました: %w", err)
 	}
 	applyBodyDepthMaterialOrderWithProgress(modelData, request.ProgressReporter)
+	if request.AtlasOpaqueMaterials {
+		if err := applyOpaqueMaterialAtlas(modelData, request.AtlasMaxSize); err != nil {
+			return nil, fmt.Errorf("テクスチャアトラス生成に失敗しました: %w", err)
+		}
+	}
 	if err := applyHumanoidBoneMappingAfterReorder(modelData); err != nil {
 		return nil, fmt.Errorf("ボーンマッピング処理に失敗しました: %w", err)
 	}
+	if request.ApplyLookAtEyeSettings {
+		applyLookAtEyeSettings(modelData)
+	}
 	reportPrepareProgress(request.ProgressReporter, PrepareProgressEvent{
 		Type: PrepareProgressEventTypeBoneMappingCompleted,
 	})
@@ -69,9 +112,63 @@ func (uc *Vrm2PmxUsecase) PrepareModel(request ConvertRequest) (*ConvertResult,
 	reportPrepareProgress(request.ProgressReporter, PrepareProgressEvent{
 		Type: PrepareProgressEventTypeAstanceCompleted,
 	})
-	applyMorphRenameOnlyBeforeViewer(modelData, request.ProgressReporter)
+	if request.EnableSdef {
+		if err := applySdefDeformsAfterBoneMapping(modelData); err != nil {
+			return nil, fmt.Errorf("SDEF変換処理に失敗しました: %w", err)
+		}
+	}
+	if request.GenerateBodyRigidBodies {
+		if err := applyBodyRigidBodiesAfterBoneMapping(modelData); err != nil {
+			return nil, fmt.Errorf("体剛体生成処理に失敗しました: %w", err)
+		}
+	}
+	applyMorphRenameOnlyBeforeViewerWithRules(
+		modelData,
+		request.ProgressReporter,
+		buildMorphRenameSourceRules(renameMappings),
+	)
 
-	return &ConvertResult{Model: modelData, OutputPath: outputPath}, nil
+	result := &ConvertResult{
+		Model:         modelData,
+		OutputPath:    outputPath,
+		MorphCoverage: buildMorphCoverageReport(modelData),
+	}
+	if request.OutputFirstPersonVariant {
+		result.FirstPersonOutputPath = buildFirstPersonOutputPath(outputPath)
+	}
+	if request.OutputMaterialManifest {
+		manifestPath, err := exportMaterialManifest(outputPath, modelData)
+		if err != nil {
+			return nil, fmt.Errorf("材質マニフェスト出力に失敗しました: %w", err)
+		}
+		result.MaterialManifestPath = manifestPath
+	}
+	if request.OutputMmeEffects {
+		effectOutput, err := exportMmeEffects(outputPath, modelData, request.MmeEffectIncludePath)
+		if err != nil {
+			return nil, fmt.Errorf("MMEエフェクト出力に失敗しました: %w", err)
+		}
+		result.MmeEffectPaths = effectOutput.EffectPaths
+		result.MmeIncludePath = effectOutput.IncludePath
+		result.MmeEmdPath = effectOutput.EmdPath
+		result.MmeEmmPath = effectOutput.EmmPath
+	}
+	if request.OutputMorphRenameMappingDump {
+		dumpPath, err := exportMorphRenameMappingDump(outputPath, renameMappings)
+		if err != nil {
+			return nil, fmt.Errorf("モーフ名称対応表ダンプ出力に失敗しました: %w", err)
+		}
+		result.MorphRenameMappingDumpPath = dumpPath
+	}
+	if request.OutputMorphCoverageReport {
+		jsonPath, markdownPath, err := exportMorphCoverageReport(outputPath, result.MorphCoverage)
+		if err != nil {
+			return nil, fmt.Errorf("モーフカバレッジ出力に失敗しました: %w", err)
+		}
+		result.MorphCoverageReportPath = jsonPath
+		result.MorphCoverageMarkdownPath = markdownPath
+	}
+	return result, nil
 }
 
 // resolvePmxOutputPath はPMX保存先パスを解決し、拡張子を検証する。
@@ -90,10 +187,15 @@ func resolvePmxOutputPath(inputPath string, outputPath string) (string, error) {
 }
 
 // resolveModelData は変換対象モデルを解決し、VRMデータを検証する。
-func (uc *Vrm2PmxUsecase) resolveModelData(rep moutput.IFileReader, inputPath string, modelData *ModelData) (*ModelData, error) {
+func (uc *Vrm2PmxUsecase) resolveModelData(
+	rep moutput.IFileReader,
+	inputPath string,
+	modelData *ModelData,
+	loadOptions vrm.VrmLoadOptions,
+) (*ModelData, error) {
 	resolved := modelData
 	if resolved == nil {
-		loaded, err := uc.LoadModel(rep, inputPath)
+		loaded, err := uc.loadModelWithOptions(rep, inputPath, loadOptions)
 		if err != nil {
 			return nil, err
 		}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/miu200521358/mlib_go/pkg/adapter/io_model/pmx"
@@ -432,6 +433,196 @@ func writeGLBForUsecaseTest(t *testing.T, path string, doc map[string]any, binCh
 	}
 }
 
+func TestVrm2PmxUsecasePrepareModelAppliesLoadOptionsToReader(t *testing.T) {
+	tempDir := t.TempDir()
+	inPath := filepath.Join(tempDir, "sample.vrm")
+	outPath := filepath.Join(tempDir, "sample.pmx")
+	writeLoadOptionsTestGLB(t, inPath)
+	reader := &loadOptionsRecordingReader{VrmRepository: vrm.NewVrmRepository()}
+	uc := NewVrm2PmxUsecase(Vrm2PmxUsecaseDeps{ModelReader: reader})
+	jawAngles := vrm.DefaultJawLipSyncAngles()
+	jawAngles.A = 25
+
+	if _, err := uc.PrepareModel(ConvertRequest{
+		InputPath:           inPath,
+		OutputPath:          outPath,
+		WeightReductionMode: "Redistribute_To_Ancestor",
+		VertexColorMode:     "texture",
+		ExtendedUvTangent:   true,
+		JawLipSyncAngles:    &jawAngles,
+	}); err != nil {
+		t.Fatalf("prepare failed: %v", err)
+	}
+	if len(reader.applied) != 1 {
+		t.Fatalf("load options should be applied once: %d", len(reader.applied))
+	}
+	if reader.applied[0].WeightReductionMode != vrm.WeightReductionModeRedistributeToAncestor {
+		t.Fatalf("weight reduction mode mismatch: %+v", reader.applied[0])
+	}
+	if reader.applied[0].VertexColorMode != vrm.VertexColorModeTexture {
+		t.Fatalf("vertex color mode mismatch: %+v", reader.applied[0])
+	}
+	if reader.applied[0].ExtendedUvTexcoord1 || !reader.applied[0].ExtendedUvTangent {
+		t.Fatalf("extended uv sources mismatch: %+v", reader.applied[0])
+	}
+	if reader.applied[0].JawLipSyncAngles == nil || reader.applied[0].JawLipSyncAngles.A != 25 {
+		t.Fatalf("jaw lip sync angles mismatch: %+v", reader.applied[0])
+	}
+
+	reader.applied = nil
+	if _, err := uc.PrepareModel(ConvertRequest{
+		InputPath:           inPath,
+		OutputPath:          outPath,
+		WeightReductionMode: "unknown",
+	}); err == nil {
+		t.Fatalf("invalid weight reduction mode should fail")
+	}
+	if _, err := uc.PrepareModel(ConvertRequest{
+		InputPath:       inPath,
+		OutputPath:      outPath,
+		VertexColorMode: "unknown",
+	}); err == nil {
+		t.Fatalf("invalid vertex color mode should fail")
+	}
+	if len(reader.applied) != 0 {
+		t.Fatalf("invalid load options should be rejected before loading")
+	}
+
+	rulePath := filepath.Join(tempDir, "morph_rules.json")
+	if err := os.WriteFile(rulePath, []byte(`{"version":1,"link":[{"name":"笑い","panel":"eye"}]}`), 0o644); err != nil {
+		t.Fatalf("write rule file failed: %v", err)
+	}
+	_, err := uc.PrepareModel(ConvertRequest{
+		InputPath:         inPath,
+		OutputPath:        outPath,
+		MorphRuleFilePath: rulePath,
+	})
+	if err == nil || !strings.Contains(err.Error(), "binds または split が必要です") {
+		t.Fatalf("invalid morph rule file should fail before conversion: %v", err)
+	}
+	if len(reader.applied) != 0 {
+		t.Fatalf("invalid morph rule file should be rejected before loading: %+v", reader.applied)
+	}
+
+	validRulePath := filepath.Join(tempDir, "valid_morph_rules.json")
+	if err := os.WriteFile(validRulePath, []byte(`{"version":1}`), 0o644); err != nil {
+		t.Fatalf("write rule file failed: %v", err)
+	}
+	if _, err := uc.PrepareModel(ConvertRequest{
+		InputPath:         inPath,
+		OutputPath:        outPath,
+		MorphRuleFilePath: validRulePath,
+	}); err != nil {
+		t.Fatalf("valid morph rule file should be accepted: %v", err)
+	}
+	if len(reader.applied) != 1 || reader.applied[0].MorphRuleFilePath != validRulePath {
+		t.Fatalf("morph rule file path should be passed to reader: %+v", reader.applied)
+	}
+}
+
+func TestVrm2PmxUsecasePrepareModelRejectsLoadOptionsWithModelData(t *testing.T) {
+	tempDir := t.TempDir()
+	inPath := filepath.Join(tempDir, "sample.vrm")
+	outPath := filepath.Join(tempDir, "sample.pmx")
+	writeLoadOptionsTestGLB(t, inPath)
+	reader := &loadOptionsRecordingReader{VrmRepository: vrm.NewVrmRepository()}
+	uc := NewVrm2PmxUsecase(Vrm2PmxUsecaseDeps{ModelReader: reader})
+	loadedModel, err := uc.LoadModel(nil, inPath)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	_, err = uc.PrepareModel(ConvertRequest{
+		InputPath:           inPath,
+		OutputPath:          outPath,
+		ModelData:           loadedModel,
+		WeightReductionMode: "redistribute_to_ancestor",
+		ExtendedUvTangent:   true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "読込オプションは読込済みモデルへ適用できません") {
+		t.Fatalf("load options with ModelData should fail: %v", err)
+	}
+	if len(reader.applied) != 0 {
+		t.Fatalf("load options should not be applied to preloaded model: %+v", reader.applied)
+	}
+
+	rulePath := filepath.Join(tempDir, "morph_rules.json")
+	if err := os.WriteFile(rulePath, []byte(`{"version":1,"link":[{"name":"笑い","panel":"eye"}]}`), 0o644); err != nil {
+		t.Fatalf("write rule file failed: %v", err)
+	}
+	_, err = uc.PrepareModel(ConvertRequest{
+		InputPath:         inPath,
+		OutputPath:        outPath,
+		ModelData:         loadedModel,
+		MorphRuleFilePath: rulePath,
+	})
+	if err == nil || !strings.Contains(err.Error(), "binds または split が必要です") {
+		t.Fatalf("invalid morph rule file should be reported with ModelData: %v", err)
+	}
+
+	// 既定値の指定は読込結果へ影響しないため、読込済みモデルと併用できる。
+	if _, err := uc.PrepareModel(ConvertRequest{
+		InputPath:           inPath,
+		OutputPath:          outPath,
+		ModelData:           loadedModel,
+		WeightReductionMode: string(vrm.WeightReductionModeDropSmallest),
+		VertexColorMode:     string(vrm.VertexColorModeNone),
+	}); err != nil {
+		t.Fatalf("default load options with ModelData should succeed: %v", err)
+	}
+
+	// 読込から行う経路では同じ指定が読込時に適用される。
+	if _, err := uc.LoadAndPrepareModelForViewer(ConvertRequest{
+		InputPath:           inPath,
+		OutputPath:          outPath,
+		WeightReductionMode: "redistribute_to_ancestor",
+		ExtendedUvTangent:   true,
+	}); err != nil {
+		t.Fatalf("load and prepare with options failed: %v", err)
+	}
+	if len(reader.applied) != 1 || !reader.applied[0].ExtendedUvTangent {
+		t.Fatalf("load options should be applied while loading: %+v", reader.applied)
+	}
+}
+
+// writeLoadOptionsTestGLB は読込オプション検証用の最小VRMを書き出す。
+func writeLoadOptionsTestGLB(t *testing.T, path string) {
+	t.Helper()
+	writeGLBForUsecaseTest(t, path, map[string]any{
+		"asset": map[string]any{
+			"version": "2.0",
+		},
+		"extensionsUsed": []string{"VRMC_vrm"},
+		"nodes": []any{
+			map[string]any{
+				"name":        "hips_node",
+				"translation": []float64{0, 0.8, 0},
+			},
+		},
+		"extensions": map[string]any{
+			"VRMC_vrm": map[string]any{
+				"specVersion": "1.0",
+				"humanoid": map[string]any{
+					"humanBones": map[string]any{
+						"hips": map[string]any{"node": 0},
+					},
+				},
+			},
+		},
+	}, nil)
+}
+
+// loadOptionsRecordingReader は適用された読込オプションを記録するVRM読み込みリポジトリを表す。
+type loadOptionsRecordingReader struct {
+	*vrm.VrmRepository
+	applied []vrm.VrmLoadOptions
+}
+
+func (r *loadOptionsRecordingReader) ApplyLoadOptions(options vrm.VrmLoadOptions) error {
+	r.applied = append(r.applied, options)
+	return r.VrmRepository.ApplyLoadOptions(options)
+}
+
 type prepareProgressEventCollector struct {
 	events []PrepareProgressEventType
 }
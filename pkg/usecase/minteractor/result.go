@@ -3,6 +3,7 @@ package minteractor
 
 import (
 	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mu_vrm2pmx/pkg/adapter/io_model/vrm"
 	"github.com/miu200521358/mu_vrm2pmx/pkg/usecase/port/moutput"
 )
 
@@ -72,10 +73,82 @@ type ConvertRequest struct {
 	ModelData        *ModelData
 	Reader           moutput.IFileReader
 	ProgressReporter IPrepareProgressReporter
+	// GenerateBodyRigidBodies は標準ボーンへ体衝突用剛体を追加するかを表す。
+	GenerateBodyRigidBodies bool
+	// EnableSdef は腕/ひじ・足/ひざ系列のBDEF2頂点をSDEFへ変換するかを表す。
+	EnableSdef bool
+	// ApplyLookAtEyeSettings は VRM lookAt の range map を目IKの回転上限と視線モーフへ変換するかを表す。
+	// 有効時は両目の回転が目IK経由で左目/右目へ等倍で伝わり、目を直接回すモーションは目IKで上書きされる。
+	ApplyLookAtEyeSettings bool
+	// OutputFirstPersonVariant は thirdPersonOnly 材質と auto 材質の頭部面を除いた一人称視点用PMXの保存先を解決するかを表す。
+	OutputFirstPersonVariant bool
+	// ThresholdMaskTextures は alphaMode=MASK 材質の基本テクスチャを alphaCutoff で二値化した複製へ差し替えるかを表す。
+	ThresholdMaskTextures bool
+	// OutputMaterialManifest は材質別の補助テクスチャ一覧JSONをPMXと同じ場所へ出力するかを表す。
+	OutputMaterialManifest bool
+	// OutputMmeEffects は材質別のMME用 .fx と割当用 .emd/.emm をPMXと同じ場所へ出力するかを表す。
+	OutputMmeEffects bool
+	// MmeEffectIncludePath は材質別 .fx から include する共通シェーダーのパスを表す。空文字時は同梱の MToon.fxsub を fx/ 配下へ出力して参照する。
+	MmeEffectIncludePath string
+	// AtlasOpaqueMaterials は描画設定が一致する不透明材質をテクスチャアトラスで1材質へ統合するかを表す。
+	AtlasOpaqueMaterials bool
+	// AtlasMaxSize はテクスチャアトラスの最大辺長(px)を表す。0以下の場合は 4096。
+	AtlasMaxSize int
+	// TextureMaxDimension は抽出テクスチャの最大辺長(px)を表す。0以下の場合は縮小しない。
+	TextureMaxDimension int
+	// TextureFormat は抽出テクスチャの出力形式(png/tga/bmp)を表す。空文字の場合は埋め込み形式を維持する。
+	// TextureMaxDimension と同じく埋め込み画像にのみ適用し、変換時の生成テクスチャは常にPNGで出力する。
+	TextureFormat string
+	// FlattenOpaqueTextures はアルファを使わない抽出テクスチャをアルファなしで出力するかを表す。
+	FlattenOpaqueTextures bool
+	// MorphRenameMappingPath はモーフ名称対応表へ追加・上書きする行を記載したJSON/CSVのパスを表す。空文字時は既定の対応表のみ使う。
+	MorphRenameMappingPath string
+	// OutputMorphRenameMappingDump は統合後のモーフ名称対応表CSVをPMXと同じ場所へ出力するかを表す。
+	OutputMorphRenameMappingDump bool
+	// WeightReductionMode から MorphRuleFilePath までの読込オプションは読込時にのみ反映できるため、
+	// ModelData と既定値以外の指定を併用した場合は PrepareModel がエラーを返す。
+	// WeightReductionMode はBDEF4上限を超える頂点ウェイトの縮約方法(drop_smallest/redistribute_to_ancestor)を表す。
+	// Reader から読み込む場合に適用し、空文字時は drop_smallest。
+	WeightReductionMode string
+	// VertexColorMode は COLOR_0 頂点カラーの変換方法(none/texture/extended_uv)を表す。
+	// Reader から読み込む場合に適用し、空文字時は none。
+	VertexColorMode string
+	// ExtendedUvTexcoord1 は TEXCOORD_1 を拡張UV3へ出力するかを表す。Reader から読み込む場合に適用する。
+	ExtendedUvTexcoord1 bool
+	// ExtendedUvTangent は TANGENT を拡張UV4へ出力するかを表す。Reader から読み込む場合に適用する。
+	ExtendedUvTangent bool
+	// JawLipSyncAngles は口形状の表情が無いモデルへ補完する顎口パクの母音別開口角度(度)を表す。
+	// Reader から読み込む場合に適用し、nil の場合は既定値を使う。
+	JawLipSyncAngles *vrm.JawLipSyncAngles
+	// MorphRuleFilePath は組み込みモーフ規則へ追加・上書きする規則ファイル(JSON)のパスを表す。
+	// 読込元に依らず変換前に検証し、Reader から読み込む場合に適用する。空文字時は組み込み規則のみ使う。
+	MorphRuleFilePath string
+	// OutputMorphCoverageReport は標準モーフの充足状況をJSON/MarkdownでPMXと同じ場所へ出力するかを表す。
+	OutputMorphCoverageReport bool
 }
 
 // ConvertResult はVRM変換結果を表す。
 type ConvertResult struct {
 	Model      *ModelData
 	OutputPath string
+	// FirstPersonOutputPath は一人称視点用PMXの保存先を表す。未要求時は空文字。
+	FirstPersonOutputPath string
+	// MaterialManifestPath は材質別補助テクスチャ一覧JSONの保存先を表す。未要求時は空文字。
+	MaterialManifestPath string
+	// MmeEffectPaths は材質順に並べたMME用 .fx の保存先を表す。未要求時は空。
+	MmeEffectPaths []string
+	// MmeIncludePath は同梱して出力した共通シェーダーの保存先を表す。未要求時または include 先指定時は空文字。
+	MmeIncludePath string
+	// MmeEmdPath はMME用 .emd の保存先を表す。未要求時は空文字。
+	MmeEmdPath string
+	// MmeEmmPath はMME用 .emm の保存先を表す。未要求時は空文字。
+	MmeEmmPath string
+	// MorphRenameMappingDumpPath は統合後のモーフ名称対応表CSVの保存先を表す。未要求時は空文字。
+	MorphRenameMappingDumpPath string
+	// MorphCoverage は変換後の MMD 標準モーフの充足状況を表す。
+	MorphCoverage *MorphCoverageReport
+	// MorphCoverageReportPath はモーフカバレッジJSONの保存先を表す。未要求時は空文字。
+	MorphCoverageReportPath string
+	// MorphCoverageMarkdownPath はモーフカバレッジMarkdownの保存先を表す。未要求時は空文字。
+	MorphCoverageMarkdownPath string
 }
@@ -25,3 +25,20 @@ func (uc *Vrm2PmxUsecase) SaveModel(rep moutput.IFileWriter, path string, modelD
 	}
 	return writer.Save(path, modelData, opts)
 }
+
+// SaveConvertResult は変換結果のPMXを保存し、一人称視点用PMXが要求されている場合は同じ場所へ併せて保存する。
+func (uc *Vrm2PmxUsecase) SaveConvertResult(rep moutput.IFileWriter, result *ConvertResult, opts SaveOptions) error {
+	if result == nil {
+		return fmt.Errorf("変換結果が未設定です")
+	}
+	if err := uc.SaveModel(rep, result.OutputPath, result.Model, opts); err != nil {
+		return err
+	}
+	if strings.TrimSpace(result.FirstPersonOutputPath) == "" {
+		return nil
+	}
+	if err := uc.SaveFirstPersonModel(rep, result.FirstPersonOutputPath, result.Model, opts); err != nil {
+		return fmt.Errorf("一人称視点用PMXの保存に失敗しました: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/shared/base/logging"
+)
+
+const (
+	sdefMinimumWeight = 1e-3
+	sdefAxisEpsilon   = 1e-8
+)
+
+// sdefJointChain はSDEF化対象となる関節前後のボーン構成を表す。
+type sdefJointChain struct {
+	ParentBoneNames []string
+	ChildBoneNames  []string
+	StartBoneName   string
+	JointBoneName   string
+	EndBoneName     string
+}
+
+// sdefJointPlan はSDEF化対象関節の解決済み情報を表す。
+type sdefJointPlan struct {
+	Start mmath.Vec3
+	Joint mmath.Vec3
+	End   mmath.Vec3
+	// FirstIsParent はBDEF2の1本目が関節の親側ボーンかを表す。
+	FirstIsParent bool
+}
+
+// applySdefDeformsAfterBoneMapping は腕/ひじ・足/ひざ系列のBDEF2頂点をSDEFへ変換する。
+func applySdefDeformsAfterBoneMapping(modelData *ModelData) error {
+	if modelData == nil || modelData.Bones == nil || modelData.Vertices == nil {
+		return nil
+	}
+	plansByPair := buildSdefJointPlans(modelData.Bones, buildSdefJointChains())
+	if len(plansByPair) == 0 {
+		return nil
+	}
+
+	convertedCount := 0
+	for _, vertex := range modelData.Vertices.Values() {
+		if vertex == nil || vertex.Deform == nil || vertex.DeformType != model.BDEF2 {
+			continue
+		}
+		indexes := vertex.Deform.Indexes()
+		weights := vertex.Deform.Weights()
+		if len(indexes) < 2 || len(weights) < 2 {
+			continue
+		}
+		if weights[0] < sdefMinimumWeight || weights[1] < sdefMinimumWeight {
+			continue
+		}
+		plan, exists := plansByPair[[2]int{indexes[0], indexes[1]}]
+		if !exists {
+			continue
+		}
+		c, r0, r1 := resolveSdefParams(plan, vertex.Position)
+		vertex.Deform = model.NewSdef(indexes[0], indexes[1], weights[0], c, r0, r1)
+		vertex.DeformType = model.SDEF
+		convertedCount++
+	}
+	if logger := logging.DefaultLogger(); logger != nil {
+		logger.Info("SDEF変換完了: vertices=%d", convertedCount)
+	}
+	return nil
+}
+
+// buildSdefJointChains は左右の腕/ひじ・足/ひざ系列を返す。
+func buildSdefJointChains() []sdefJointChain {
+	chains := make([]sdefJointChain, 0, 4)
+	for _, direction := range []model.BoneDirection{model.BONE_DIRECTION_LEFT, model.BONE_DIRECTION_RIGHT} {
+		chains = append(chains,
+			sdefJointChain{
+				ParentBoneNames: []string{
+					model.ARM.StringFromDirection(direction),
+					model.ARM_TWIST.StringFromDirection(direction),
+					model.ARM_TWIST1.StringFromDirection(direction),
+					model.ARM_TWIST2.StringFromDirection(direction),
+					model.ARM_TWIST3.StringFromDirection(direction),
+				},
+				ChildBoneNames: []string{
+					model.ELBOW.StringFromDirection(direction),
+				},
+				StartBoneName: model.ARM.StringFromDirection(direction),
+				JointBoneName: model.ELBOW.StringFromDirection(direction),
+				EndBoneName:   model.WRIST.StringFromDirection(direction),
+			},
+			sdefJointChain{
+				ParentBoneNames: []string{
+					model.LEG.StringFromDirection(direction),
+					model.LEG_D.StringFromDirection(direction),
+				},
+				ChildBoneNames: []string{
+					model.KNEE.StringFromDirection(direction),
+					model.KNEE_D.StringFromDirection(direction),
+				},
+				StartBoneName: model.LEG.StringFromDirection(direction),
+				JointBoneName: model.KNEE.StringFromDirection(direction),
+				EndBoneName:   model.ANKLE.StringFromDirection(direction),
+			},
+		)
+	}
+	return chains
+}
+
+// buildSdefJointPlans は対象ボーンindex組から関節情報への対応を構築する。
+// BDEF2のボーン順は任意のため、両順序を登録する。
+func buildSdefJointPlans(bones *model.BoneCollection, chains []sdefJointChain) map[[2]int]sdefJointPlan {
+	plansByPair := map[[2]int]sdefJointPlan{}
+	for _, chain := range chains {
+		start, startOK := getBoneByName(bones, chain.StartBoneName)
+		joint, jointOK := getBoneByName(bones, chain.JointBoneName)
+		end, endOK := getBoneByName(bones, chain.EndBoneName)
+		if !startOK || !jointOK || !endOK {
+			continue
+		}
+		parentFirst := sdefJointPlan{
+			Start:         start.Position,
+			Joint:         joint.Position,
+			End:           end.Position,
+			FirstIsParent: true,
+		}
+		childFirst := parentFirst
+		childFirst.FirstIsParent = false
+		for _, parentName := range chain.ParentBoneNames {
+			parentBone, parentOK := getBoneByName(bones, parentName)
+			if !parentOK {
+				continue
+			}
+			for _, childName := range chain.ChildBoneNames {
+				childBone, childOK := getBoneByName(bones, childName)
+				if !childOK {
+					continue
+				}
+				plansByPair[[2]int{parentBone.Index(), childBone.Index()}] = parentFirst
+				plansByPair[[2]int{childBone.Index(), parentBone.Index()}] = childFirst
+			}
+		}
+	}
+	return plansByPair
+}
+
+// resolveSdefParams は関節位置をC、頂点から各ボーン軸への最近点をR0/R1としたSDEFパラメータを返す。
+func resolveSdefParams(plan sdefJointPlan, position mmath.Vec3) (mmath.Vec3, mmath.Vec3, mmath.Vec3) {
+	c := plan.Joint
+	parentPoint := projectSdefPointOnSegment(position, plan.Start, plan.Joint)
+	childPoint := projectSdefPointOnSegment(position, plan.Joint, plan.End)
+	if plan.FirstIsParent {
+		return c, parentPoint, childPoint
+	}
+	return c, childPoint, parentPoint
+}
+
+// projectSdefPointOnSegment は線分上の最近点を返す。
+func projectSdefPointOnSegment(position mmath.Vec3, start mmath.Vec3, end mmath.Vec3) mmath.Vec3 {
+	segment := end.Subed(start)
+	lengthSq := segment.Dot(segment)
+	if lengthSq <= sdefAxisEpsilon {
+		return start
+	}
+	ratio := position.Subed(start).Dot(segment) / lengthSq
+	if ratio < 0 {
+		ratio = 0
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	return start.Added(segment.MuledScalar(ratio))
+}
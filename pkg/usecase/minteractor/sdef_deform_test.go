@@ -0,0 +1,78 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+)
+
+func TestApplySdefDeformsAfterBoneMappingConvertsElbowBdef2(t *testing.T) {
+	modelData := newBoneMappingTargetModel()
+	if err := applyHumanoidBoneMappingAfterReorder(modelData); err != nil {
+		t.Fatalf("bone mapping failed: %v", err)
+	}
+
+	leftArm, leftArmExists := getBoneByName(modelData.Bones, model.ARM.Left())
+	leftElbow, leftElbowExists := getBoneByName(modelData.Bones, model.ELBOW.Left())
+	leftWrist, leftWristExists := getBoneByName(modelData.Bones, model.WRIST.Left())
+	upper, upperExists := getBoneByName(modelData.Bones, model.UPPER.String())
+	if !leftArmExists || !leftElbowExists || !leftWristExists || !upperExists {
+		t.Fatalf("required mapped bones are missing")
+	}
+
+	elbowVertexIndex := modelData.Vertices.AppendRaw(&model.Vertex{
+		Position:   leftElbow.Position,
+		Normal:     mmath.UNIT_Y_VEC3,
+		Uv:         mmath.ZERO_VEC2,
+		DeformType: model.BDEF2,
+		Deform:     model.NewBdef2(leftElbow.Index(), leftArm.Index(), 0.5),
+		EdgeFactor: 1.0,
+	})
+	bodyVertexIndex := modelData.Vertices.AppendRaw(&model.Vertex{
+		Position:   upper.Position,
+		Normal:     mmath.UNIT_Y_VEC3,
+		Uv:         mmath.ZERO_VEC2,
+		DeformType: model.BDEF2,
+		Deform:     model.NewBdef2(upper.Index(), leftArm.Index(), 0.5),
+		EdgeFactor: 1.0,
+	})
+
+	if err := applySdefDeformsAfterBoneMapping(modelData); err != nil {
+		t.Fatalf("apply sdef failed: %v", err)
+	}
+
+	elbowVertex := mustGetVertex(t, modelData, elbowVertexIndex)
+	if elbowVertex.DeformType != model.SDEF {
+		t.Fatalf("elbow vertex should be SDEF: got=%v", elbowVertex.DeformType)
+	}
+	if indexes := elbowVertex.Deform.Indexes(); indexes[0] != leftElbow.Index() || indexes[1] != leftArm.Index() {
+		t.Fatalf("sdef bone order should be kept: got=%v", indexes)
+	}
+
+	bodyVertex := mustGetVertex(t, modelData, bodyVertexIndex)
+	if bodyVertex.DeformType != model.BDEF2 {
+		t.Fatalf("non elbow pair should keep BDEF2: got=%v", bodyVertex.DeformType)
+	}
+}
+
+func TestResolveSdefParamsFollowsBoneOrder(t *testing.T) {
+	plan := sdefJointPlan{
+		Start:         mmath.Vec3{},
+		Joint:         mmath.UNIT_X_VEC3,
+		End:           mmath.UNIT_X_VEC3.MuledScalar(2),
+		FirstIsParent: true,
+	}
+	position := mmath.UNIT_X_VEC3.MuledScalar(0.5)
+	_, r0, r1 := resolveSdefParams(plan, position)
+	if !r0.NearEquals(position, 1e-6) || !r1.NearEquals(mmath.UNIT_X_VEC3, 1e-6) {
+		t.Fatalf("parent-first params mismatch: r0=%v r1=%v", r0, r1)
+	}
+
+	plan.FirstIsParent = false
+	_, r0, r1 = resolveSdefParams(plan, position)
+	if !r0.NearEquals(mmath.UNIT_X_VEC3, 1e-6) || !r1.NearEquals(position, 1e-6) {
+		t.Fatalf("child-first params mismatch: r0=%v r1=%v", r0, r1)
+	}
+}
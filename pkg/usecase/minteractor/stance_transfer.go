@@ -8,6 +8,8 @@ import (
 
 	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
 	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mu_vrm2pmx/pkg/adapter/io_model/vrm"
+	"gonum.org/v1/gonum/spatial/r3"
 )
 
 const (
@@ -48,6 +50,7 @@ func applyAstanceBeforeViewer(modelData *ModelData) error {
 	applyAstanceBonePositions(modelData.Bones, transformedBones)
 	updateAstanceBoneLocalAxes(modelData.Bones, transformedBones)
 	applyAstanceVertices(modelData, originalPositions, transformedBones)
+	applyAstanceRigidBodies(modelData, originalPositions, transformedBones)
 
 	return nil
 }
@@ -461,9 +464,140 @@ func applyAstanceVertices(
 		case model.BDEF4:
 			applyAstanceBdef4Vertex(vertex, originalVertexPos, originalVertexNormal, originalPositions, transformedBones)
 		}
+		applyAstanceVertexTangent(vertex, transformedBones)
 	}
 }
 
+// applyAstanceVertexTangent は拡張UV4へ格納した接線を法線と同じボーン回転で変換する。
+// 従接線の向き(w)は回転で変わらないため維持する。
+func applyAstanceVertexTangent(vertex *model.Vertex, transformedBones map[int]astanceBoneTransform) {
+	if vertex == nil || vertex.Deform == nil || len(vertex.ExtendedUvs) <= vrm.ExtendedUvSlotTangent {
+		return
+	}
+	tangent := vertex.ExtendedUvs[vrm.ExtendedUvSlotTangent]
+	direction := mmath.Vec3{Vec: r3.Vec{X: tangent.X, Y: tangent.Y, Z: tangent.Z}}
+	if direction.Length() <= astanceAxisEpsilon {
+		return
+	}
+	indexes := vertex.Deform.Indexes()
+	weights := vertex.Deform.Weights()
+	transformedDirection := mmath.ZERO_VEC3
+	appliedWeight := 0.0
+	for idx := 0; idx < len(indexes) && idx < len(weights); idx++ {
+		if weights[idx] <= 0 {
+			continue
+		}
+		boneTransform, exists := transformedBones[indexes[idx]]
+		if !exists {
+			continue
+		}
+		transformedDirection = transformedDirection.Added(
+			transformAstanceNormalByBone(boneTransform, direction).MuledScalar(weights[idx]),
+		)
+		appliedWeight += weights[idx]
+	}
+	if appliedWeight <= 0 || transformedDirection.Length() <= astanceAxisEpsilon {
+		return
+	}
+	transformedDirection = transformedDirection.Normalized()
+	vertex.ExtendedUvs[vrm.ExtendedUvSlotTangent] = mmath.Vec4{
+		X: transformedDirection.X,
+		Y: transformedDirection.Y,
+		Z: transformedDirection.Z,
+		W: tangent.W,
+	}
+}
+
+// applyAstanceRigidBodies はAスタンス補正後の剛体とジョイントの位置・回転を再計算する。
+func applyAstanceRigidBodies(
+	modelData *ModelData,
+	originalPositions map[int]mmath.Vec3,
+	transformedBones map[int]astanceBoneTransform,
+) {
+	if modelData == nil || modelData.RigidBodies == nil {
+		return
+	}
+	if len(originalPositions) == 0 || len(transformedBones) == 0 {
+		return
+	}
+
+	for _, rigidBody := range modelData.RigidBodies.Values() {
+		if rigidBody == nil {
+			continue
+		}
+		boneTransform, transformed := transformedBones[rigidBody.BoneIndex]
+		originalBonePos, exists := originalPositions[rigidBody.BoneIndex]
+		if !transformed || !exists {
+			continue
+		}
+		rigidBody.Position = transformAstancePositionByBone(boneTransform, originalBonePos, rigidBody.Position)
+		rigidBody.Rotation = transformAstanceEulerByBone(boneTransform, rigidBody.Rotation)
+	}
+	if modelData.Joints == nil {
+		return
+	}
+	for _, joint := range modelData.Joints.Values() {
+		if joint == nil {
+			continue
+		}
+		rigidBody, err := modelData.RigidBodies.Get(joint.RigidBodyIndexB)
+		if err != nil || rigidBody == nil {
+			continue
+		}
+		boneTransform, transformed := transformedBones[rigidBody.BoneIndex]
+		originalBonePos, exists := originalPositions[rigidBody.BoneIndex]
+		if !transformed || !exists {
+			continue
+		}
+		joint.Position = transformAstancePositionByBone(boneTransform, originalBonePos, joint.Position)
+		joint.Rotation = transformAstanceEulerByBone(boneTransform, joint.Rotation)
+	}
+}
+
+// transformAstanceEulerByBone は剛体/ジョイントのオイラー角(ラジアン)へボーン回転を合成する。
+// PMX 剛体と同じ Y→X→Z 順(R = Ry・Rx・Rz)で基底ベクトルを復元し、回転後の基底から角度を再構成する。
+func transformAstanceEulerByBone(boneTransform astanceBoneTransform, rotation mmath.Vec3) mmath.Vec3 {
+	axisX, axisY, axisZ := resolveAstanceEulerBasis(rotation)
+	axisX = boneTransform.Rotation.MulVec3(axisX)
+	axisY = boneTransform.Rotation.MulVec3(axisY)
+	axisZ = boneTransform.Rotation.MulVec3(axisZ)
+
+	sinX := math.Max(-1.0, math.Min(1.0, -axisZ.Y))
+	x := math.Asin(sinX)
+	if math.Abs(math.Cos(x)) < 1e-6 {
+		// ジンバルロック時は Z を 0 として Y へ集約する。
+		return mmath.Vec3{Vec: r3.Vec{X: x, Y: math.Atan2(-axisX.Z, axisX.X), Z: 0}}
+	}
+	return mmath.Vec3{Vec: r3.Vec{
+		X: x,
+		Y: math.Atan2(axisZ.X, axisZ.Z),
+		Z: math.Atan2(axisX.Y, axisY.Y),
+	}}
+}
+
+// resolveAstanceEulerBasis は Y→X→Z 順オイラー角の回転後基底ベクトル(X/Y/Z軸)を返す。
+func resolveAstanceEulerBasis(rotation mmath.Vec3) (mmath.Vec3, mmath.Vec3, mmath.Vec3) {
+	sinX, cosX := math.Sincos(rotation.X)
+	sinY, cosY := math.Sincos(rotation.Y)
+	sinZ, cosZ := math.Sincos(rotation.Z)
+	axisX := mmath.Vec3{Vec: r3.Vec{
+		X: cosY*cosZ + sinY*sinX*sinZ,
+		Y: cosX * sinZ,
+		Z: -sinY*cosZ + cosY*sinX*sinZ,
+	}}
+	axisY := mmath.Vec3{Vec: r3.Vec{
+		X: -cosY*sinZ + sinY*sinX*cosZ,
+		Y: cosX * cosZ,
+		Z: sinY*sinZ + cosY*sinX*cosZ,
+	}}
+	axisZ := mmath.Vec3{Vec: r3.Vec{
+		X: sinY * cosX,
+		Y: -sinX,
+		Z: cosY * cosX,
+	}}
+	return axisX, axisY, axisZ
+}
+
 // applyAstanceBdef1Vertex はBDEF1頂点へAスタンス補正を適用する。
 func applyAstanceBdef1Vertex(
 	vertex *model.Vertex,
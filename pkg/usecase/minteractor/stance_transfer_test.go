@@ -8,6 +8,7 @@ import (
 	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
 	"github.com/miu200521358/mlib_go/pkg/domain/model"
 	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	vrmrepository "github.com/miu200521358/mu_vrm2pmx/pkg/adapter/io_model/vrm"
 	"gonum.org/v1/gonum/spatial/r3"
 )
 
@@ -91,6 +92,46 @@ func TestApplyAstanceBeforeViewerTransformsArmAndThumbForTstance(t *testing.T) {
 	}
 }
 
+func TestApplyAstanceBeforeViewerRotatesExtendedUvTangent(t *testing.T) {
+	modelData := newBoneMappingTargetModel()
+	modelData.VrmData.Profile = vrm.VRM_PROFILE_STANDARD
+
+	if err := applyHumanoidBoneMappingAfterReorder(modelData); err != nil {
+		t.Fatalf("bone mapping failed: %v", err)
+	}
+	setAstanceTestTstanceArms(t, modelData)
+
+	rightArm, rightArmExists := getBoneByName(modelData.Bones, model.ARM.Right())
+	if !rightArmExists {
+		t.Fatalf("right arm bone is missing")
+	}
+	vertexIndex := appendAstanceTestVertex(modelData, mmath.Vec3{Vec: r3.Vec{X: -1.3, Y: 14.3, Z: 0.0}}, rightArm.Index())
+	vertex := mustGetVertex(t, modelData, vertexIndex)
+	vertex.ExtendedUvs = make([]mmath.Vec4, vrmrepository.ExtendedUvSlotTangent+1)
+	vertex.ExtendedUvs[vrmrepository.ExtendedUvSlotTangent] = mmath.Vec4{X: -1.0, Y: 0.0, Z: 0.0, W: -1.0}
+	tangentBefore := vertex.ExtendedUvs[vrmrepository.ExtendedUvSlotTangent]
+
+	if err := applyAstanceBeforeViewer(modelData); err != nil {
+		t.Fatalf("apply astance failed: %v", err)
+	}
+
+	vertexAfter := mustGetVertex(t, modelData, vertexIndex)
+	tangentAfter := vertexAfter.ExtendedUvs[vrmrepository.ExtendedUvSlotTangent]
+	tangentDirection := mmath.Vec3{Vec: r3.Vec{X: tangentAfter.X, Y: tangentAfter.Y, Z: tangentAfter.Z}}
+	if math.Abs(tangentAfter.X-tangentBefore.X) <= 1e-6 && math.Abs(tangentAfter.Y-tangentBefore.Y) <= 1e-6 {
+		t.Fatalf("tangent should be rotated: before=%v after=%v", tangentBefore, tangentAfter)
+	}
+	if math.Abs(tangentDirection.Length()-1.0) > 1e-6 {
+		t.Fatalf("tangent should be normalized: tangent=%v length=%f", tangentDirection, tangentDirection.Length())
+	}
+	if math.Abs(tangentDirection.Dot(vertexAfter.Normal)) > 1e-6 {
+		t.Fatalf("tangent should stay perpendicular to normal: tangent=%v normal=%v", tangentDirection, vertexAfter.Normal)
+	}
+	if tangentAfter.W != tangentBefore.W {
+		t.Fatalf("tangent handedness should be kept: before=%f after=%f", tangentBefore.W, tangentAfter.W)
+	}
+}
+
 func TestApplyAstanceBeforeViewerTransformsBdef4VertexWhenTinyWeightBoneIsOutOfScope(t *testing.T) {
 	modelData := newBoneMappingTargetModel()
 	modelData.VrmData.Profile = vrm.VRM_PROFILE_STANDARD
@@ -250,3 +291,53 @@ func mustGetVertex(t *testing.T, modelData *ModelData, index int) *model.Vertex
 	}
 	return vertex
 }
+
+func TestApplyAstanceRigidBodiesRotatesRigidBodyAndJoint(t *testing.T) {
+	modelData := model.NewPmxModel()
+	bone := model.NewBoneByName("arm")
+	bone.Position = vec3(1, 0, 0)
+	boneIndex := modelData.Bones.AppendRaw(bone)
+	rigidBody := &model.RigidBody{
+		BoneIndex: boneIndex,
+		Shape:     model.SHAPE_CAPSULE,
+		Position:  vec3(2, 0, 0),
+		Rotation:  vrmrepository.ResolveCapsuleRotation(vec3(1, 0, 0)),
+	}
+	rigidBodyIndex := modelData.RigidBodies.AppendRaw(rigidBody)
+	joint := &model.Joint{
+		RigidBodyIndexA: rigidBodyIndex,
+		RigidBodyIndexB: rigidBodyIndex,
+		Position:        vec3(1, 0, 0),
+		Rotation:        mmath.ZERO_VEC3,
+	}
+	modelData.Joints.AppendRaw(joint)
+
+	_, originalAxis, _ := resolveAstanceEulerBasis(rigidBody.Rotation)
+	if math.Abs(originalAxis.X-1.0) > 1e-6 {
+		t.Fatalf("capsule axis should follow +X before transform: %v", originalAxis)
+	}
+	boneTransform := astanceBoneTransform{
+		Position: vec3(1, 0, 0),
+		Rotation: mmath.NewQuaternionFromDegrees(0, 0, -35),
+	}
+	applyAstanceRigidBodies(
+		modelData,
+		map[int]mmath.Vec3{boneIndex: vec3(1, 0, 0)},
+		map[int]astanceBoneTransform{boneIndex: boneTransform},
+	)
+
+	expectedAxis := boneTransform.Rotation.MulVec3(originalAxis)
+	_, rotatedAxis, _ := resolveAstanceEulerBasis(rigidBody.Rotation)
+	if rotatedAxis.Subed(expectedAxis).Length() > 1e-6 {
+		t.Fatalf("rigid body axis should follow bone rotation: got=%v want=%v", rotatedAxis, expectedAxis)
+	}
+	expectedPosition := vec3(1, 0, 0).Added(expectedAxis)
+	if rigidBody.Position.Subed(expectedPosition).Length() > 1e-6 {
+		t.Fatalf("rigid body position mismatch: got=%v want=%v", rigidBody.Position, expectedPosition)
+	}
+	jointAxisX, jointAxisY, _ := resolveAstanceEulerBasis(joint.Rotation)
+	if jointAxisX.Subed(boneTransform.Rotation.MulVec3(vec3(1, 0, 0))).Length() > 1e-6 ||
+		jointAxisY.Subed(boneTransform.Rotation.MulVec3(vec3(0, 1, 0))).Length() > 1e-6 {
+		t.Fatalf("joint rotation should follow bone rotation: %v", joint.Rotation)
+	}
+}
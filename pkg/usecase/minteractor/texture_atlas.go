@@ -0,0 +1,512 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/domain/model/collection"
+)
+
+const (
+	textureAtlasDirName        = "atlas"
+	textureAtlasDefaultMaxSize = 4096
+	textureAtlasPadding        = 2
+	textureAtlasMinScale       = 1.0 / 16.0
+	textureAtlasUvTolerance    = 1e-4
+)
+
+// textureAtlasPlacement はアトラス内へ配置した1材質分のテクスチャ位置を表す。
+type textureAtlasPlacement struct {
+	materialIndex int
+	sourceImage   *image.NRGBA
+	x             int
+	y             int
+	width         int
+	height        int
+}
+
+// applyOpaqueMaterialAtlas は描画設定が一致する不透明材質を1材質へ統合し、テクスチャを1枚へ詰めてUVを付け替える。
+// 半透明材質は対象外とし、材質並べ替えで確定した半透明材質同士の順序を維持する。
+func applyOpaqueMaterialAtlas(modelData *ModelData, maxAtlasSize int) error {
+	if modelData == nil || modelData.Materials == nil || modelData.Faces == nil || modelData.Textures == nil {
+		return nil
+	}
+	if maxAtlasSize <= 0 {
+		maxAtlasSize = textureAtlasDefaultMaxSize
+	}
+	modelPath := strings.TrimSpace(modelData.Path())
+	if modelPath == "" {
+		return fmt.Errorf("アトラス出力先のモデルパスが未設定です")
+	}
+	faceRanges, err := buildMaterialFaceRanges(modelData)
+	if err != nil {
+		return err
+	}
+
+	groups := collectOpaqueMaterialAtlasGroups(modelData, faceRanges)
+	if len(groups) == 0 {
+		return nil
+	}
+	textureDir := filepath.Join(filepath.Dir(modelPath), defaultTextureDirName)
+	imageCache := map[int]*image.NRGBA{}
+	leaderByMaterial := map[int]int{}
+	mergedMaterialCount := 0
+	for groupIndex, members := range groups {
+		placements := make([]textureAtlasPlacement, 0, len(members))
+		for _, materialIndex := range members {
+			materialData, _ := modelData.Materials.Get(materialIndex)
+			sourceImage := loadTextureAtlasSourceImage(modelData, materialData.TextureIndex, imageCache)
+			if sourceImage == nil {
+				continue
+			}
+			placements = append(placements, textureAtlasPlacement{materialIndex: materialIndex, sourceImage: sourceImage})
+		}
+		if len(placements) < 2 {
+			continue
+		}
+		atlasImage, atlasPlacements, ok := packTextureAtlas(placements, maxAtlasSize)
+		if !ok {
+			logMaterialReorderWarn(
+				"テクスチャアトラスを最大サイズへ収められないためスキップします: group=%d materials=%d maxSize=%d",
+				groupIndex,
+				len(placements),
+				maxAtlasSize,
+			)
+			continue
+		}
+		textureIndex, writeErr := writeTextureAtlasImage(textureDir, modelData, groupIndex, atlasImage)
+		if writeErr != nil {
+			return writeErr
+		}
+		remapTextureAtlasUvs(modelData, faceRanges, atlasPlacements, atlasImage.Rect.Dx(), atlasImage.Rect.Dy())
+		leaderIndex := atlasPlacements[0].materialIndex
+		for _, placement := range atlasPlacements {
+			if placement.materialIndex < leaderIndex {
+				leaderIndex = placement.materialIndex
+			}
+		}
+		leaderMaterial, _ := modelData.Materials.Get(leaderIndex)
+		leaderMaterial.TextureIndex = textureIndex
+		for _, placement := range atlasPlacements {
+			leaderByMaterial[placement.materialIndex] = leaderIndex
+		}
+		mergedMaterialCount += len(atlasPlacements)
+		logMaterialReorderInfo(
+			"テクスチャアトラス生成: group=%d leader=%s materials=%d size=%dx%d",
+			groupIndex,
+			leaderMaterial.Name(),
+			len(atlasPlacements),
+			atlasImage.Rect.Dx(),
+			atlasImage.Rect.Dy(),
+		)
+	}
+	if len(leaderByMaterial) == 0 {
+		return nil
+	}
+	if err := mergeAtlasMaterials(modelData, faceRanges, leaderByMaterial); err != nil {
+		return err
+	}
+	logMaterialReorderInfo(
+		"テクスチャアトラス適用: mergedMaterials=%d materials=%d",
+		mergedMaterialCount,
+		modelData.Materials.Len(),
+	)
+	return nil
+}
+
+// collectOpaqueMaterialAtlasGroups は描画設定ごとにアトラス統合可能な不透明材質indexをまとめる。
+func collectOpaqueMaterialAtlasGroups(modelData *ModelData, faceRanges []materialFaceRange) [][]int {
+	morphMaterialIndexes := collectMaterialMorphTargetIndexes(modelData)
+	vertexMaterialCounts := collectVertexMaterialUsageCounts(modelData, faceRanges)
+	textureAlphaCache := map[int]textureAlphaCacheEntry{}
+	companionSources := resolveMaterialCompanionSources(modelData)
+
+	groupKeys := []string{}
+	groupsByKey := map[string][]int{}
+	for materialIndex, materialData := range modelData.Materials.Values() {
+		if materialData == nil || materialIndex >= len(faceRanges) || faceRanges[materialIndex].count == 0 {
+			continue
+		}
+		if _, exists := morphMaterialIndexes[materialIndex]; exists {
+			continue
+		}
+		if !isOpaqueAtlasCandidateMaterial(modelData, materialData, textureAlphaCache) {
+			continue
+		}
+		if !hasAtlasCompatibleMaterialUvs(modelData, faceRanges[materialIndex], vertexMaterialCounts) {
+			continue
+		}
+		companionKey, ok := buildMaterialAtlasCompanionKey(materialData, companionSources)
+		if !ok {
+			continue
+		}
+		key := buildMaterialAtlasShadingKey(materialData) + " " + companionKey
+		if _, exists := groupsByKey[key]; !exists {
+			groupKeys = append(groupKeys, key)
+		}
+		groupsByKey[key] = append(groupsByKey[key], materialIndex)
+	}
+
+	groups := make([][]int, 0, len(groupKeys))
+	for _, key := range groupKeys {
+		if len(groupsByKey[key]) < 2 {
+			continue
+		}
+		groups = append(groups, groupsByKey[key])
+	}
+	return groups
+}
+
+// isOpaqueAtlasCandidateMaterial は材質が不透明でテクスチャを持つかを判定する。
+func isOpaqueAtlasCandidateMaterial(
+	modelData *ModelData,
+	materialData *model.Material,
+	textureAlphaCache map[int]textureAlphaCacheEntry,
+) bool {
+	if materialData.TextureIndex < 0 || materialData.Diffuse.W < 1.0 {
+		return false
+	}
+	alphaMode := resolveMaterialAlphaModeFromMemo(materialData)
+	if alphaMode != "" && alphaMode != "OPAQUE" {
+		return false
+	}
+	if isSpecialEyeOverlayMaterialName(materialData.Name(), materialData.EnglishName) {
+		return false
+	}
+	return !isTransparentMaterial(modelData, materialData, textureAlphaCache)
+}
+
+// hasAtlasCompatibleMaterialUvs は材質の頂点UVがタイル内に収まり、他材質と頂点を共有しないかを判定する。
+func hasAtlasCompatibleMaterialUvs(
+	modelData *ModelData,
+	faceRange materialFaceRange,
+	vertexMaterialCounts map[int]int,
+) bool {
+	for faceIndex := faceRange.start; faceIndex < faceRange.start+faceRange.count; faceIndex++ {
+		face, err := modelData.Faces.Get(faceIndex)
+		if err != nil || face == nil {
+			return false
+		}
+		for _, vertexIndex := range face.VertexIndexes {
+			if vertexMaterialCounts[vertexIndex] > 1 {
+				return false
+			}
+			vertex, getErr := modelData.Vertices.Get(vertexIndex)
+			if getErr != nil || vertex == nil {
+				return false
+			}
+			if vertex.Uv.X < -textureAtlasUvTolerance || vertex.Uv.X > 1.0+textureAtlasUvTolerance ||
+				vertex.Uv.Y < -textureAtlasUvTolerance || vertex.Uv.Y > 1.0+textureAtlasUvTolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// collectVertexMaterialUsageCounts は頂点ごとに参照元材質数を数える。
+func collectVertexMaterialUsageCounts(modelData *ModelData, faceRanges []materialFaceRange) map[int]int {
+	counts := map[int]int{}
+	for _, faceRange := range faceRanges {
+		seen := map[int]struct{}{}
+		for faceIndex := faceRange.start; faceIndex < faceRange.start+faceRange.count; faceIndex++ {
+			face, err := modelData.Faces.Get(faceIndex)
+			if err != nil || face == nil {
+				continue
+			}
+			for _, vertexIndex := range face.VertexIndexes {
+				if _, exists := seen[vertexIndex]; exists {
+					continue
+				}
+				seen[vertexIndex] = struct{}{}
+				counts[vertexIndex]++
+			}
+		}
+	}
+	return counts
+}
+
+// collectMaterialMorphTargetIndexes は材質モーフが参照する材質indexを返す。
+func collectMaterialMorphTargetIndexes(modelData *ModelData) map[int]struct{} {
+	indexes := map[int]struct{}{}
+	if modelData == nil || modelData.Morphs == nil {
+		return indexes
+	}
+	for _, morphData := range modelData.Morphs.Values() {
+		if morphData == nil || morphData.MorphType != model.MORPH_TYPE_MATERIAL {
+			continue
+		}
+		for _, offset := range morphData.Offsets {
+			materialOffset, ok := offset.(*model.MaterialMorphOffset)
+			if !ok || materialOffset == nil {
+				continue
+			}
+			indexes[materialOffset.MaterialIndex] = struct{}{}
+		}
+	}
+	return indexes
+}
+
+// buildMaterialAtlasShadingKey はテクスチャ以外の描画設定が一致するかを判定するキーを返す。
+func buildMaterialAtlasShadingKey(materialData *model.Material) string {
+	return fmt.Sprintf(
+		"diffuse=%v specular=%v ambient=%v edge=%v edgeSize=%v drawFlag=%v sphere=%d sphereMode=%v toonShare=%v toon=%d thirdPersonOnly=%t",
+		materialData.Diffuse,
+		materialData.Specular,
+		materialData.Ambient,
+		materialData.Edge,
+		materialData.EdgeSize,
+		materialData.DrawFlag,
+		materialData.SphereTextureIndex,
+		materialData.SphereMode,
+		materialData.ToonSharingFlag,
+		materialData.ToonTextureIndex,
+		isThirdPersonOnlyMaterial(materialData),
+	)
+}
+
+// buildMaterialAtlasCompanionKey は統合後も先頭材質の gltfMaterial メモで表せるよう、MToon 係数の一致判定キーを返す。
+// 補助テクスチャはアトラスのUV付け替えに追従できないため、補助テクスチャを持つ材質は統合対象外として false を返す。
+func buildMaterialAtlasCompanionKey(
+	materialData *model.Material,
+	companionSources map[string]materialCompanionSource,
+) (string, bool) {
+	sourceIndexText, ok := resolveMaterialMemoTokenValue(materialData, materialSourceMemoKey)
+	if !ok {
+		return "", true
+	}
+	source, exists := companionSources[sourceIndexText]
+	if !exists {
+		return "", true
+	}
+	if source.NormalTexture != nil || source.EmissiveTexture != nil || source.ShadeMultiplyTexture != nil ||
+		source.RimMultiplyTexture != nil || source.OutlineWidthMultiplyTexture != nil {
+		return "", false
+	}
+	source.Name = ""
+	encoded, err := json.Marshal(source)
+	if err != nil {
+		return "", false
+	}
+	return "companion=" + string(encoded), true
+}
+
+// loadTextureAtlasSourceImage はアトラスへ詰める元テクスチャを読み込む。失敗時は nil を返す。
+func loadTextureAtlasSourceImage(modelData *ModelData, textureIndex int, imageCache map[int]*image.NRGBA) *image.NRGBA {
+	if cached, exists := imageCache[textureIndex]; exists {
+		return cached
+	}
+	imageCache[textureIndex] = nil
+	textureData, err := modelData.Textures.Get(textureIndex)
+	if err != nil || textureData == nil || !textureData.IsValid() {
+		return nil
+	}
+	texturePath := filepath.Join(filepath.Dir(modelData.Path()), normalizeTextureRelativePath(textureData.Name()))
+	sourceImage, _, err := decodeTextureImageFile(texturePath)
+	if err != nil {
+		logMaterialReorderWarn("テクスチャアトラス元画像の読込に失敗しました: path=%s err=%v", texturePath, err)
+		return nil
+	}
+	bounds := sourceImage.Bounds()
+	converted := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			converted.SetNRGBA(
+				x-bounds.Min.X,
+				y-bounds.Min.Y,
+				color.NRGBAModel.Convert(sourceImage.At(x, y)).(color.NRGBA),
+			)
+		}
+	}
+	imageCache[textureIndex] = converted
+	return converted
+}
+
+// packTextureAtlas は元テクスチャを棚詰めで配置する。最大サイズへ収まらない場合は全体を縮小して再配置する。
+func packTextureAtlas(placements []textureAtlasPlacement, maxAtlasSize int) (*image.NRGBA, []textureAtlasPlacement, bool) {
+	order := make([]int, len(placements))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return placements[order[i]].sourceImage.Rect.Dy() > placements[order[j]].sourceImage.Rect.Dy()
+	})
+
+	for scale := 1.0; scale >= textureAtlasMinScale; scale /= 2.0 {
+		packed := append([]textureAtlasPlacement(nil), placements...)
+		cursorX, cursorY, shelfHeight, atlasWidth := 0, 0, 0, 0
+		fits := true
+		for _, placementIndex := range order {
+			placement := &packed[placementIndex]
+			placement.width = int(math.Max(1, math.Round(float64(placement.sourceImage.Rect.Dx())*scale)))
+			placement.height = int(math.Max(1, math.Round(float64(placement.sourceImage.Rect.Dy())*scale)))
+			cellWidth := placement.width + textureAtlasPadding*2
+			cellHeight := placement.height + textureAtlasPadding*2
+			if cellWidth > maxAtlasSize {
+				fits = false
+				break
+			}
+			if cursorX+cellWidth > maxAtlasSize {
+				cursorX = 0
+				cursorY += shelfHeight
+				shelfHeight = 0
+			}
+			placement.x = cursorX + textureAtlasPadding
+			placement.y = cursorY + textureAtlasPadding
+			cursorX += cellWidth
+			if cellHeight > shelfHeight {
+				shelfHeight = cellHeight
+			}
+			if cursorX > atlasWidth {
+				atlasWidth = cursorX
+			}
+		}
+		atlasHeight := cursorY + shelfHeight
+		if !fits || atlasHeight > maxAtlasSize {
+			continue
+		}
+		atlasImage := image.NewNRGBA(image.Rect(0, 0, atlasWidth, atlasHeight))
+		for _, placement := range packed {
+			drawTextureAtlasPlacement(atlasImage, placement)
+		}
+		return atlasImage, packed, true
+	}
+	return nil, nil, false
+}
+
+// drawTextureAtlasPlacement は元テクスチャを最近傍で拡縮して描き込み、余白へ端の画素を延長する。
+func drawTextureAtlasPlacement(atlasImage *image.NRGBA, placement textureAtlasPlacement) {
+	sourceWidth := placement.sourceImage.Rect.Dx()
+	sourceHeight := placement.sourceImage.Rect.Dy()
+	for y := -textureAtlasPadding; y < placement.height+textureAtlasPadding; y++ {
+		sourceY := clampTextureAtlasIndex(int(float64(y)*float64(sourceHeight)/float64(placement.height)), sourceHeight)
+		for x := -textureAtlasPadding; x < placement.width+textureAtlasPadding; x++ {
+			sourceX := clampTextureAtlasIndex(int(float64(x)*float64(sourceWidth)/float64(placement.width)), sourceWidth)
+			atlasImage.SetNRGBA(placement.x+x, placement.y+y, placement.sourceImage.NRGBAAt(sourceX, sourceY))
+		}
+	}
+}
+
+// clampTextureAtlasIndex は画素indexを画像範囲へ丸める。
+func clampTextureAtlasIndex(index int, size int) int {
+	if index < 0 {
+		return 0
+	}
+	if index >= size {
+		return size - 1
+	}
+	return index
+}
+
+// writeTextureAtlasImage はアトラス画像をPNG出力し、追加したテクスチャindexを返す。
+func writeTextureAtlasImage(textureDir string, modelData *ModelData, groupIndex int, atlasImage *image.NRGBA) (int, error) {
+	var out bytes.Buffer
+	if err := png.Encode(&out, atlasImage); err != nil {
+		return -1, err
+	}
+	relativePath := path.Join(textureAtlasDirName, fmt.Sprintf("atlas_%03d.png", groupIndex))
+	outputPath := filepath.Join(textureDir, filepath.FromSlash(relativePath))
+	if err := os.MkdirAll(filepath.Dir(outputPath), outputDirFileMode); err != nil {
+		return -1, err
+	}
+	if err := os.WriteFile(outputPath, out.Bytes(), outputFileMode); err != nil {
+		return -1, err
+	}
+
+	texture := model.NewTexture()
+	texture.SetName(path.Join(defaultTextureDirName, relativePath))
+	texture.TextureType = model.TEXTURE_TYPE_TEXTURE
+	texture.SetValid(true)
+	return modelData.Textures.AppendRaw(texture), nil
+}
+
+// remapTextureAtlasUvs は統合対象材質の頂点UVをアトラス内の配置矩形へ写像する。
+func remapTextureAtlasUvs(
+	modelData *ModelData,
+	faceRanges []materialFaceRange,
+	placements []textureAtlasPlacement,
+	atlasWidth int,
+	atlasHeight int,
+) {
+	for _, placement := range placements {
+		faceRange := faceRanges[placement.materialIndex]
+		remapped := map[int]struct{}{}
+		for faceIndex := faceRange.start; faceIndex < faceRange.start+faceRange.count; faceIndex++ {
+			face, err := modelData.Faces.Get(faceIndex)
+			if err != nil || face == nil {
+				continue
+			}
+			for _, vertexIndex := range face.VertexIndexes {
+				if _, exists := remapped[vertexIndex]; exists {
+					continue
+				}
+				remapped[vertexIndex] = struct{}{}
+				vertex, getErr := modelData.Vertices.Get(vertexIndex)
+				if getErr != nil || vertex == nil {
+					continue
+				}
+				vertex.Uv.X = (float64(placement.x) + vertex.Uv.X*float64(placement.width)) / float64(atlasWidth)
+				vertex.Uv.Y = (float64(placement.y) + vertex.Uv.Y*float64(placement.height)) / float64(atlasHeight)
+			}
+		}
+	}
+}
+
+// mergeAtlasMaterials はアトラス統合した材質の面を代表材質(最小index)の位置へ集め、他の材質を削除する。
+func mergeAtlasMaterials(modelData *ModelData, faceRanges []materialFaceRange, leaderByMaterial map[int]int) error {
+	oldMaterials := append([]*model.Material(nil), modelData.Materials.Values()...)
+	oldFaces := append([]*model.Face(nil), modelData.Faces.Values()...)
+	membersByLeader := map[int][]int{}
+	for materialIndex := range oldMaterials {
+		if leaderIndex, exists := leaderByMaterial[materialIndex]; exists {
+			membersByLeader[leaderIndex] = append(membersByLeader[leaderIndex], materialIndex)
+		}
+	}
+
+	newMaterials := collection.NewNamedCollection[*model.Material](len(oldMaterials))
+	newFaces := collection.NewIndexedCollection[*model.Face](len(oldFaces))
+	oldToNew := make([]int, len(oldMaterials))
+	for oldIndex, materialData := range oldMaterials {
+		leaderIndex, merged := leaderByMaterial[oldIndex]
+		if merged && leaderIndex != oldIndex {
+			continue
+		}
+		newIndex := newMaterials.Len()
+		newMaterials.AppendRaw(materialData)
+		members := []int{oldIndex}
+		if merged {
+			members = membersByLeader[oldIndex]
+		}
+		verticesCount := 0
+		for _, memberIndex := range members {
+			oldToNew[memberIndex] = newIndex
+			faceRange := faceRanges[memberIndex]
+			for i := 0; i < faceRange.count; i++ {
+				newFaces.AppendRaw(oldFaces[faceRange.start+i])
+			}
+			verticesCount += faceRange.count * 3
+		}
+		materialData.VerticesCount = verticesCount
+	}
+	if newFaces.Len() != len(oldFaces) {
+		return fmt.Errorf("アトラス統合後の面数が一致しません: before=%d after=%d", len(oldFaces), newFaces.Len())
+	}
+
+	modelData.Materials = newMaterials
+	modelData.Faces = newFaces
+	remapVertexMaterialIndexes(modelData, oldToNew)
+	remapMaterialMorphOffsets(modelData, oldToNew)
+	return nil
+}
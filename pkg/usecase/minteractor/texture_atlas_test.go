@@ -0,0 +1,201 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+func TestApplyOpaqueMaterialAtlasMergesOpaqueMaterialsOnly(t *testing.T) {
+	outputDir := t.TempDir()
+	texDir := filepath.Join(outputDir, "tex")
+	if err := os.MkdirAll(texDir, 0o755); err != nil {
+		t.Fatalf("failed to create tex dir: %v", err)
+	}
+	textureAlphas := map[string]uint8{"body.png": 255, "hair.png": 0, "cloth.png": 255}
+	modelData := model.NewPmxModel()
+	modelData.SetPath(filepath.Join(outputDir, "model.pmx"))
+	for _, name := range []string{"body.png", "hair.png", "cloth.png"} {
+		if err := writeAlphaTexture(filepath.Join(texDir, name), textureAlphas[name]); err != nil {
+			t.Fatalf("failed to write texture: %v", err)
+		}
+		texture := model.NewTexture()
+		texture.SetName("tex/" + name)
+		texture.SetValid(true)
+		modelData.Textures.AppendRaw(texture)
+	}
+
+	materialSpecs := []struct {
+		name         string
+		textureIndex int
+		uvScale      float64
+	}{
+		{name: "Body", textureIndex: 0, uvScale: 1.0},
+		{name: "Hair", textureIndex: 1, uvScale: 1.0},
+		{name: "Cloth", textureIndex: 2, uvScale: 1.0},
+		{name: "Tile", textureIndex: 0, uvScale: 1.5},
+	}
+	for materialIndex, spec := range materialSpecs {
+		vertexStart := modelData.Vertices.Len()
+		for _, uv := range []mmath.Vec2{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}} {
+			modelData.Vertices.AppendRaw(&model.Vertex{
+				Position:        vec3(float64(materialIndex), uv.Y, uv.X),
+				Uv:              mmath.Vec2{X: uv.X * spec.uvScale, Y: uv.Y * spec.uvScale},
+				ExtendedUvs:     []mmath.Vec4{},
+				MaterialIndexes: []int{materialIndex},
+			})
+		}
+		modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{vertexStart, vertexStart + 1, vertexStart + 2}})
+		materialData := newMaterial(spec.name, 1.0, 3)
+		materialData.TextureIndex = spec.textureIndex
+		modelData.Materials.AppendRaw(materialData)
+	}
+
+	if err := applyOpaqueMaterialAtlas(modelData, 0); err != nil {
+		t.Fatalf("apply atlas failed: %v", err)
+	}
+
+	names := materialNames(modelData)
+	if len(names) != 3 || names[0] != "Body" || names[1] != "Hair" || names[2] != "Tile" {
+		t.Fatalf("material order mismatch: %v", names)
+	}
+	body, _ := modelData.Materials.Get(0)
+	if body.VerticesCount != 6 || body.TextureIndex != 3 {
+		t.Fatalf("merged material mismatch: vertices=%d texture=%d", body.VerticesCount, body.TextureIndex)
+	}
+	atlasTexture, err := modelData.Textures.Get(3)
+	if err != nil || atlasTexture.Name() != "tex/atlas/atlas_000.png" {
+		t.Fatalf("atlas texture mismatch: %v err=%v", atlasTexture, err)
+	}
+	if _, err := os.Stat(filepath.Join(texDir, "atlas", "atlas_000.png")); err != nil {
+		t.Fatalf("atlas image not written: %v", err)
+	}
+	hair, _ := modelData.Materials.Get(1)
+	if hair.TextureIndex != 1 {
+		t.Fatalf("transparent material should keep its texture: %d", hair.TextureIndex)
+	}
+
+	// 先頭面は Body、続いて Cloth の面が統合材質へ入り、半透明材質の面はその後ろに残る。
+	expectedFaceStarts := []int{0, 6, 3, 9}
+	for faceIndex, vertexStart := range expectedFaceStarts {
+		face, _ := modelData.Faces.Get(faceIndex)
+		if face.VertexIndexes[0] != vertexStart {
+			t.Fatalf("face order mismatch: face=%d got=%d want=%d", faceIndex, face.VertexIndexes[0], vertexStart)
+		}
+	}
+
+	// 1x1 テクスチャ2枚を余白2pxで横並びにした 10x5 のアトラスへ写像する。
+	clothVertex, _ := modelData.Vertices.Get(8)
+	if math.Abs(clothVertex.Uv.X-0.8) > 1e-9 || math.Abs(clothVertex.Uv.Y-0.6) > 1e-9 {
+		t.Fatalf("cloth uv mismatch: %v", clothVertex.Uv)
+	}
+	if len(clothVertex.MaterialIndexes) != 1 || clothVertex.MaterialIndexes[0] != 0 {
+		t.Fatalf("cloth vertex material index mismatch: %v", clothVertex.MaterialIndexes)
+	}
+	bodyVertex, _ := modelData.Vertices.Get(0)
+	if math.Abs(bodyVertex.Uv.X-0.2) > 1e-9 || math.Abs(bodyVertex.Uv.Y-0.4) > 1e-9 {
+		t.Fatalf("body uv mismatch: %v", bodyVertex.Uv)
+	}
+	tileVertex, _ := modelData.Vertices.Get(11)
+	if tileVertex.Uv.X != 1.5 || tileVertex.MaterialIndexes[0] != 2 {
+		t.Fatalf("tiled material should be left untouched: uv=%v materials=%v", tileVertex.Uv, tileVertex.MaterialIndexes)
+	}
+}
+
+func TestCollectOpaqueMaterialAtlasGroupsSplitsByCompanionSource(t *testing.T) {
+	outputDir := t.TempDir()
+	texDir := filepath.Join(outputDir, "tex")
+	if err := os.MkdirAll(texDir, 0o755); err != nil {
+		t.Fatalf("failed to create tex dir: %v", err)
+	}
+	modelData := model.NewPmxModel()
+	modelData.SetPath(filepath.Join(outputDir, "model.pmx"))
+	modelData.VrmData = &vrm.VrmData{RawExtensions: map[string]json.RawMessage{}}
+	if err := writeAlphaTexture(filepath.Join(texDir, "body.png"), 255); err != nil {
+		t.Fatalf("failed to write texture: %v", err)
+	}
+	texture := model.NewTexture()
+	texture.SetName("tex/body.png")
+	texture.SetValid(true)
+	modelData.Textures.AppendRaw(texture)
+	for materialIndex, name := range []string{"Skin", "Arm", "Cloth", "Shaded"} {
+		vertexStart := modelData.Vertices.Len()
+		for _, uv := range []mmath.Vec2{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}} {
+			modelData.Vertices.AppendRaw(&model.Vertex{
+				Position:        vec3(float64(materialIndex), uv.Y, uv.X),
+				Uv:              uv,
+				ExtendedUvs:     []mmath.Vec4{},
+				MaterialIndexes: []int{materialIndex},
+			})
+		}
+		modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{vertexStart, vertexStart + 1, vertexStart + 2}})
+		materialData := newMaterial(name, 1.0, 3)
+		materialData.TextureIndex = 0
+		materialData.Memo = fmt.Sprintf("VRM primitive alphaMode=OPAQUE gltfMaterial=%d", materialIndex)
+		modelData.Materials.AppendRaw(materialData)
+	}
+	skinShift := -0.2
+	clothShift := 0.5
+	sourcesRaw, err := json.Marshal(map[string]materialCompanionSource{
+		"0": {Name: "Skin", ShadingShiftFactor: &skinShift},
+		"1": {Name: "Arm", ShadingShiftFactor: &skinShift},
+		"2": {Name: "Cloth", ShadingShiftFactor: &clothShift},
+		"3": {Name: "Shaded", ShadingShiftFactor: &skinShift, ShadeMultiplyTexture: &materialCompanionTexture{TextureIndex: 0}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal companion sources: %v", err)
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmMaterialCompanionRawExtensionKey] = sourcesRaw
+
+	faceRanges, err := buildMaterialFaceRanges(modelData)
+	if err != nil {
+		t.Fatalf("face ranges failed: %v", err)
+	}
+	groups := collectOpaqueMaterialAtlasGroups(modelData, faceRanges)
+	// MToon 係数が一致し補助テクスチャを持たない材質だけを統合する。
+	if len(groups) != 1 || len(groups[0]) != 2 || groups[0][0] != 0 || groups[0][1] != 1 {
+		t.Fatalf("atlas groups mismatch: %v", groups)
+	}
+}
+
+func TestPackTextureAtlasScalesDownToMaxSize(t *testing.T) {
+	placements := []textureAtlasPlacement{
+		{materialIndex: 0, sourceImage: newTextureAtlasTestImage(4, 4)},
+		{materialIndex: 1, sourceImage: newTextureAtlasTestImage(2, 2)},
+	}
+	atlasImage, packed, ok := packTextureAtlas(placements, 12)
+	if !ok {
+		t.Fatalf("expected atlas to fit after scaling")
+	}
+	if atlasImage.Rect.Dx() != 11 || atlasImage.Rect.Dy() != 6 {
+		t.Fatalf("atlas size mismatch: %v", atlasImage.Rect)
+	}
+	if packed[0].width != 2 || packed[1].width != 1 || packed[1].x != 8 {
+		t.Fatalf("placement mismatch: %+v", packed)
+	}
+	if _, _, ok := packTextureAtlas(placements, 4); ok {
+		t.Fatalf("atlas smaller than padding should not fit")
+	}
+}
+
+// newTextureAtlasTestImage は指定サイズの不透明画像を返す。
+func newTextureAtlasTestImage(width int, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	return img
+}
@@ -0,0 +1,280 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+const (
+	vertexColorTextureDirName      = "vcolor"
+	vertexColorDefaultTextureSize  = 256
+	vertexColorTextureDilationStep = 2
+)
+
+// vertexColorBakeMetadata は頂点カラー焼き込み対象材質の情報を表す。
+type vertexColorBakeMetadata struct {
+	SourceTextureIndex int          `json:"source_texture_index"`
+	VertexStart        int          `json:"vertex_start"`
+	Colors             [][4]float64 `json:"colors"`
+}
+
+// exportVertexColorTextures は COLOR_0 を材質ごとの基本テクスチャへ乗算した複製を出力し、材質を差し替える。
+func exportVertexColorTextures(textureDir string, modelData *ModelData) {
+	if modelData == nil || modelData.VrmData == nil || modelData.Materials == nil || modelData.Textures == nil {
+		return
+	}
+	raw, exists := modelData.VrmData.RawExtensions[warningid.VrmVertexColorBakeRawExtensionKey]
+	if !exists || len(raw) == 0 {
+		return
+	}
+	metadataMap := map[string]vertexColorBakeMetadata{}
+	if err := json.Unmarshal(raw, &metadataMap); err != nil {
+		logMaterialReorderWarn("頂点カラー焼き込み情報の解析に失敗しました: err=%v", err)
+		return
+	}
+	faceRanges, err := buildMaterialFaceRanges(modelData)
+	if err != nil {
+		logMaterialReorderWarn("頂点カラー焼き込みの面範囲解決に失敗しました: err=%v", err)
+		return
+	}
+
+	materialIndexes := make([]int, 0, len(metadataMap))
+	for key := range metadataMap {
+		materialIndex, parseErr := strconv.Atoi(key)
+		if parseErr != nil || materialIndex < 0 || materialIndex >= len(faceRanges) {
+			continue
+		}
+		materialIndexes = append(materialIndexes, materialIndex)
+	}
+	sort.Ints(materialIndexes)
+
+	bakedCount := 0
+	for _, materialIndex := range materialIndexes {
+		metadata := metadataMap[strconv.Itoa(materialIndex)]
+		textureIndex, bakeErr := exportVertexColorTexture(
+			textureDir,
+			modelData,
+			materialIndex,
+			faceRanges[materialIndex],
+			metadata,
+		)
+		if bakeErr != nil {
+			logMaterialReorderWarn(
+				"頂点カラーテクスチャ生成失敗: material=%d texture=%d err=%v",
+				materialIndex,
+				metadata.SourceTextureIndex,
+				bakeErr,
+			)
+			continue
+		}
+		materialData, getErr := modelData.Materials.Get(materialIndex)
+		if getErr != nil || materialData == nil {
+			continue
+		}
+		materialData.TextureIndex = textureIndex
+		bakedCount++
+	}
+	if bakedCount > 0 {
+		logMaterialReorderInfo("頂点カラーテクスチャ適用: materials=%d", bakedCount)
+	}
+}
+
+// exportVertexColorTexture は1材質分の頂点カラー乗算テクスチャを出力し、追加したテクスチャindexを返す。
+func exportVertexColorTexture(
+	textureDir string,
+	modelData *ModelData,
+	materialIndex int,
+	faceRange materialFaceRange,
+	metadata vertexColorBakeMetadata,
+) (int, error) {
+	baseImage, err := loadVertexColorBaseImage(textureDir, modelData, metadata.SourceTextureIndex)
+	if err != nil {
+		return -1, err
+	}
+	bakedImage := bakeVertexColorImage(modelData, faceRange, metadata, baseImage)
+	var out bytes.Buffer
+	if err := png.Encode(&out, bakedImage); err != nil {
+		return -1, err
+	}
+
+	relativePath := path.Join(vertexColorTextureDirName, fmt.Sprintf("vertex_color_%03d.png", materialIndex))
+	outputPath := filepath.Join(strings.TrimSpace(textureDir), filepath.FromSlash(relativePath))
+	if err := os.MkdirAll(filepath.Dir(outputPath), outputDirFileMode); err != nil {
+		return -1, err
+	}
+	if err := os.WriteFile(outputPath, out.Bytes(), outputFileMode); err != nil {
+		return -1, err
+	}
+
+	texture := model.NewTexture()
+	texture.SetName(path.Join(defaultTextureDirName, relativePath))
+	texture.TextureType = model.TEXTURE_TYPE_TEXTURE
+	texture.SetValid(true)
+	return modelData.Textures.AppendRaw(texture), nil
+}
+
+// loadVertexColorBaseImage は焼き込み元の基本テクスチャを返す。未設定時は白画像を返す。
+func loadVertexColorBaseImage(textureDir string, modelData *ModelData, textureIndex int) (*image.NRGBA, error) {
+	if textureIndex < 0 {
+		whiteImage := image.NewNRGBA(image.Rect(0, 0, vertexColorDefaultTextureSize, vertexColorDefaultTextureSize))
+		for index := range whiteImage.Pix {
+			whiteImage.Pix[index] = 0xff
+		}
+		return whiteImage, nil
+	}
+	textureData, err := modelData.Textures.Get(textureIndex)
+	if err != nil || textureData == nil {
+		return nil, fmt.Errorf("source texture not found: %d", textureIndex)
+	}
+	sourceImage, err := loadGeneratedSphereImageByTextureName(textureDir, textureData.Name())
+	if err != nil {
+		return nil, err
+	}
+	bounds := sourceImage.Bounds()
+	baseImage := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			baseImage.SetNRGBA(
+				x-bounds.Min.X,
+				y-bounds.Min.Y,
+				color.NRGBAModel.Convert(sourceImage.At(x, y)).(color.NRGBA),
+			)
+		}
+	}
+	return baseImage, nil
+}
+
+// bakeVertexColorImage は材質の各面をUV空間へラスタライズし、補間した頂点カラーを基本テクスチャへ乗算する。
+// 面に覆われない画素は基本テクスチャのまま残し、UV境界のにじみ対策として被覆画素を数画素分だけ外側へ広げる。
+func bakeVertexColorImage(
+	modelData *ModelData,
+	faceRange materialFaceRange,
+	metadata vertexColorBakeMetadata,
+	baseImage *image.NRGBA,
+) *image.NRGBA {
+	width := baseImage.Rect.Dx()
+	height := baseImage.Rect.Dy()
+	bakedImage := image.NewNRGBA(baseImage.Rect)
+	copy(bakedImage.Pix, baseImage.Pix)
+	if width == 0 || height == 0 {
+		return bakedImage
+	}
+	covered := make([]bool, width*height)
+
+	for faceIndex := faceRange.start; faceIndex < faceRange.start+faceRange.count; faceIndex++ {
+		face, err := modelData.Faces.Get(faceIndex)
+		if err != nil || face == nil {
+			continue
+		}
+		var pixelPositions [3][2]float64
+		var vertexColors [3][4]float64
+		valid := true
+		for corner, vertexIndex := range face.VertexIndexes {
+			vertex, getErr := modelData.Vertices.Get(vertexIndex)
+			if getErr != nil || vertex == nil {
+				valid = false
+				break
+			}
+			pixelPositions[corner] = [2]float64{vertex.Uv.X*float64(width) - 0.5, vertex.Uv.Y*float64(height) - 0.5}
+			vertexColors[corner] = [4]float64{1, 1, 1, 1}
+			colorIndex := vertexIndex - metadata.VertexStart
+			if colorIndex >= 0 && colorIndex < len(metadata.Colors) {
+				vertexColors[corner] = metadata.Colors[colorIndex]
+			}
+		}
+		if !valid {
+			continue
+		}
+		rasterizeVertexColorTriangle(baseImage, bakedImage, covered, pixelPositions, vertexColors)
+	}
+	dilateVertexColorImage(bakedImage, covered, vertexColorTextureDilationStep)
+	return bakedImage
+}
+
+// rasterizeVertexColorTriangle は1面分の画素へ重心補間した頂点カラーを乗算する。UVのタイル外は折り返す。
+func rasterizeVertexColorTriangle(
+	baseImage *image.NRGBA,
+	bakedImage *image.NRGBA,
+	covered []bool,
+	positions [3][2]float64,
+	colors [3][4]float64,
+) {
+	width := baseImage.Rect.Dx()
+	height := baseImage.Rect.Dy()
+	area := (positions[1][0]-positions[0][0])*(positions[2][1]-positions[0][1]) -
+		(positions[2][0]-positions[0][0])*(positions[1][1]-positions[0][1])
+	if math.Abs(area) <= 1e-12 {
+		return
+	}
+	minX := int(math.Floor(math.Min(positions[0][0], math.Min(positions[1][0], positions[2][0]))))
+	maxX := int(math.Ceil(math.Max(positions[0][0], math.Max(positions[1][0], positions[2][0]))))
+	minY := int(math.Floor(math.Min(positions[0][1], math.Min(positions[1][1], positions[2][1]))))
+	maxY := int(math.Ceil(math.Max(positions[0][1], math.Max(positions[1][1], positions[2][1]))))
+	const edgeEpsilon = -1e-6
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			px := float64(x)
+			py := float64(y)
+			w0 := ((positions[1][0]-px)*(positions[2][1]-py) - (positions[2][0]-px)*(positions[1][1]-py)) / area
+			w1 := ((positions[2][0]-px)*(positions[0][1]-py) - (positions[0][0]-px)*(positions[2][1]-py)) / area
+			w2 := 1.0 - w0 - w1
+			if w0 < edgeEpsilon || w1 < edgeEpsilon || w2 < edgeEpsilon {
+				continue
+			}
+			wrappedX := ((x % width) + width) % width
+			wrappedY := ((y % height) + height) % height
+			offset := baseImage.PixOffset(wrappedX, wrappedY)
+			for channel := 0; channel < 4; channel++ {
+				factor := w0*colors[0][channel] + w1*colors[1][channel] + w2*colors[2][channel]
+				value := float64(baseImage.Pix[offset+channel]) * factor
+				bakedImage.Pix[offset+channel] = uint8(math.Round(math.Max(0, math.Min(255, value))))
+			}
+			covered[wrappedY*width+wrappedX] = true
+		}
+	}
+}
+
+// dilateVertexColorImage は被覆画素の値を未被覆の隣接画素へ指定回数だけ広げる。
+func dilateVertexColorImage(bakedImage *image.NRGBA, covered []bool, steps int) {
+	width := bakedImage.Rect.Dx()
+	height := bakedImage.Rect.Dy()
+	neighbors := [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	for step := 0; step < steps; step++ {
+		nextCovered := append([]bool(nil), covered...)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if covered[y*width+x] {
+					continue
+				}
+				for _, neighbor := range neighbors {
+					nx := x + neighbor[0]
+					ny := y + neighbor[1]
+					if nx < 0 || ny < 0 || nx >= width || ny >= height || !covered[ny*width+nx] {
+						continue
+					}
+					targetOffset := bakedImage.PixOffset(x, y)
+					sourceOffset := bakedImage.PixOffset(nx, ny)
+					copy(bakedImage.Pix[targetOffset:targetOffset+4], bakedImage.Pix[sourceOffset:sourceOffset+4])
+					nextCovered[y*width+x] = true
+					break
+				}
+			}
+		}
+		copy(covered, nextCovered)
+	}
+}
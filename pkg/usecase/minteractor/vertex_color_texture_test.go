@@ -0,0 +1,94 @@
+// 指示: miu200521358
+package minteractor
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miu200521358/mlib_go/pkg/domain/mmath"
+	"github.com/miu200521358/mlib_go/pkg/domain/model"
+	"github.com/miu200521358/mlib_go/pkg/domain/model/vrm"
+	warningid "github.com/miu200521358/mu_vrm2pmx/pkg/domain/model"
+)
+
+func TestExportVertexColorTexturesMultipliesBaseTexture(t *testing.T) {
+	texDir := t.TempDir()
+	baseImage := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			baseImage.SetNRGBA(x, y, color.NRGBA{R: 0x80, G: 0xff, B: 0xff, A: 0xff})
+		}
+	}
+	baseFile, err := os.Create(filepath.Join(texDir, "body.png"))
+	if err != nil {
+		t.Fatalf("failed to create base texture: %v", err)
+	}
+	if err := png.Encode(baseFile, baseImage); err != nil {
+		_ = baseFile.Close()
+		t.Fatalf("failed to encode base texture: %v", err)
+	}
+	_ = baseFile.Close()
+
+	modelData := model.NewPmxModel()
+	modelData.VrmData = &vrm.VrmData{
+		RawExtensions: map[string]json.RawMessage{},
+	}
+	texture := model.NewTexture()
+	texture.SetName("tex/body.png")
+	texture.TextureType = model.TEXTURE_TYPE_TEXTURE
+	texture.SetValid(true)
+	modelData.Textures.AppendRaw(texture)
+
+	// UV全体を覆う2面へ、赤(1,0,0)を割り当てる。
+	for _, uv := range []mmath.Vec2{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}} {
+		modelData.Vertices.AppendRaw(&model.Vertex{Uv: uv, ExtendedUvs: []mmath.Vec4{}, MaterialIndexes: []int{0}})
+	}
+	modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{0, 1, 2}})
+	modelData.Faces.AppendRaw(&model.Face{VertexIndexes: [3]int{1, 3, 2}})
+	materialData := newMaterial("Body", 1.0, 6)
+	materialData.TextureIndex = 0
+	modelData.Materials.AppendRaw(materialData)
+
+	red := [4]float64{1, 0, 0, 1}
+	metadataRaw, err := json.Marshal(map[string]vertexColorBakeMetadata{
+		"0": {SourceTextureIndex: 0, VertexStart: 0, Colors: [][4]float64{red, red, red, red}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal bake metadata: %v", err)
+	}
+	modelData.VrmData.RawExtensions[warningid.VrmVertexColorBakeRawExtensionKey] = metadataRaw
+
+	exportVertexColorTextures(texDir, modelData)
+
+	if materialData.TextureIndex != 1 {
+		t.Fatalf("material should be repointed to baked texture: got=%d", materialData.TextureIndex)
+	}
+	bakedTexture, err := modelData.Textures.Get(1)
+	if err != nil || bakedTexture == nil || bakedTexture.Name() != "tex/vcolor/vertex_color_000.png" {
+		t.Fatalf("baked texture mismatch: texture=%v err=%v", bakedTexture, err)
+	}
+	bakedFile, err := os.Open(filepath.Join(texDir, "vcolor", "vertex_color_000.png"))
+	if err != nil {
+		t.Fatalf("baked texture not written: %v", err)
+	}
+	defer bakedFile.Close()
+	bakedImage, err := png.Decode(bakedFile)
+	if err != nil {
+		t.Fatalf("failed to decode baked texture: %v", err)
+	}
+	for _, point := range [][2]int{{0, 0}, {4, 4}, {7, 7}} {
+		got := color.NRGBAModel.Convert(bakedImage.At(point[0], point[1])).(color.NRGBA)
+		if got.R != 0x80 || got.G != 0x00 || got.B != 0x00 || got.A != 0xff {
+			t.Fatalf("baked color mismatch: point=%v got=%v", point, got)
+		}
+	}
+	sourceTexture, _ := modelData.Textures.Get(0)
+	if sourceTexture.Name() != "tex/body.png" {
+		t.Fatalf("source texture should be kept: %s", sourceTexture.Name())
+	}
+}
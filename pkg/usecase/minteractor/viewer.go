@@ -25,11 +25,15 @@ func NewVrm2PmxUsecase(deps Vrm2PmxUsecaseDeps) *Vrm2PmxUsecase {
 
 // LoadAndPrepareModelForViewer はUIプレビュー表示用と同一経路でモデルを読み込み、表示前処理済みモデルを返す。
 func (uc *Vrm2PmxUsecase) LoadAndPrepareModelForViewer(request ConvertRequest) (*ConvertResult, error) {
-	loadedModel, err := uc.LoadModel(request.Reader, request.InputPath)
+	loadOptions, err := resolveVrmLoadOptions(request)
+	if err != nil {
+		return nil, err
+	}
+	loadedModel, err := uc.loadModelWithOptions(request.Reader, request.InputPath, loadOptions)
 	if err != nil {
 		return nil, err
 	}
 	request.ModelData = loadedModel
 	request.Reader = nil
-	return uc.PrepareModel(request)
+	return uc.prepareModel(request, true)
 }